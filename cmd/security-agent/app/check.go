@@ -12,4 +12,5 @@ import "github.com/DataDog/datadog-agent/cmd/security-agent/common"
 
 func init() {
 	SecurityAgentCmd.AddCommand(common.CheckCmd(&confPath))
+	complianceCmd.AddCommand(common.CheckCmd(&confPath))
 }
@@ -8,12 +8,15 @@
 package app
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
 
+	"github.com/DataDog/datadog-agent/cmd/agent/common"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
 	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/logs/auditor"
@@ -22,6 +25,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/logs/pipeline"
 	"github.com/DataDog/datadog-agent/pkg/logs/restart"
 	secagent "github.com/DataDog/datadog-agent/pkg/security/agent"
+	"github.com/DataDog/datadog-agent/pkg/security/api"
 	secconfig "github.com/DataDog/datadog-agent/pkg/security/config"
 	"github.com/DataDog/datadog-agent/pkg/security/policy"
 	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
@@ -45,11 +49,34 @@ var (
 	checkPoliciesArgs = struct {
 		dir string
 	}{}
+
+	testPolicyCmd = &cobra.Command{
+		Use:   "test-policy",
+		Short: "Evaluate recorded events against policies and return a report",
+		RunE:  testPolicy,
+	}
+
+	testPolicyArgs = struct {
+		dir    string
+		events string
+	}{}
+
+	reloadPoliciesCmd = &cobra.Command{
+		Use:   "reload-policies",
+		Short: "Reload the policies of the runtime security module of system-probe",
+		RunE:  reloadPolicies,
+	}
 )
 
 func init() {
 	runtimeCmd.AddCommand(checkPoliciesCmd)
 	checkPoliciesCmd.Flags().StringVar(&checkPoliciesArgs.dir, "policies-dir", coreconfig.DefaultRuntimePoliciesDir, "Path to policies directory")
+
+	runtimeCmd.AddCommand(testPolicyCmd)
+	testPolicyCmd.Flags().StringVar(&testPolicyArgs.dir, "policies-dir", coreconfig.DefaultRuntimePoliciesDir, "Path to policies directory")
+	testPolicyCmd.Flags().StringVar(&testPolicyArgs.events, "events", "-", "Path to a JSON-lines file of recorded events, - for the standard input")
+
+	runtimeCmd.AddCommand(reloadPoliciesCmd)
 }
 
 func checkPolicies(cmd *cobra.Command, args []string) error {
@@ -66,7 +93,7 @@ func checkPolicies(cmd *cobra.Command, args []string) error {
 	}
 
 	ruleSet := probe.NewRuleSet(rules.NewOptsWithParams(false, sprobe.SECLConstants, sprobe.InvalidDiscarders))
-	if err := policy.LoadPolicies(cfg, ruleSet); err != nil {
+	if _, err := policy.LoadPolicies(cfg, ruleSet); err != nil {
 		return err
 	}
 
@@ -83,6 +110,41 @@ func checkPolicies(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+func reloadPolicies(cmd *cobra.Command, args []string) error {
+	// we'll search for a config file named `datadog.yaml`
+	coreconfig.Datadog.SetConfigName("datadog")
+	if err := common.SetupConfig(confPath); err != nil {
+		return fmt.Errorf("unable to set up global security agent configuration: %v", err)
+	}
+
+	socketPath := coreconfig.Datadog.GetString("runtime_security_config.socket")
+	if socketPath == "" {
+		return errors.New("runtime_security_config.socket must be set")
+	}
+
+	conn, err := grpc.Dial("unix://"+socketPath, grpc.WithInsecure())
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	result, err := api.NewSecurityModuleClient(conn).ReloadPolicies(context.Background(), &api.ReloadPoliciesParams{})
+	if err != nil {
+		return errors.Wrap(err, "unable to reload the policies")
+	}
+
+	for _, err := range result.GetErrors() {
+		fmt.Printf("error: %s\n", err)
+	}
+	fmt.Printf("policies version: %s\n", result.GetVersion())
+
+	if len(result.GetErrors()) != 0 {
+		return errors.New("the policies couldn't be reloaded, the previous policies are still in use")
+	}
+
+	return nil
+}
+
 func newRuntimeReporter(stopper restart.Stopper, sourceName, sourceType string, endpoints *config.Endpoints, context *client.DestinationsContext) (event.Reporter, error) {
 	health := health.RegisterLiveness("runtime-security")
 
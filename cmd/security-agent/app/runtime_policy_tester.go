@@ -0,0 +1,171 @@
+// +build linux
+
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	secconfig "github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/policy"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// maxRecordedEventSize is the maximum size of a line of a recorded events file
+const maxRecordedEventSize = 1024 * 1024
+
+// policyTestRule describes a rule matched by a recorded event
+type policyTestRule struct {
+	ID     string            `json:"id"`
+	Macros map[string]string `json:"macros,omitempty"`
+}
+
+// policyTestDiscarder describes a discarder found for a recorded event
+type policyTestDiscarder struct {
+	Field string      `json:"field"`
+	Value interface{} `json:"value"`
+}
+
+// policyTestEvent describes the result of the evaluation of a recorded event
+type policyTestEvent struct {
+	Line       int                   `json:"line"`
+	ID         string                `json:"id,omitempty"`
+	Type       string                `json:"type"`
+	Rules      []policyTestRule      `json:"rules,omitempty"`
+	Discarders []policyTestDiscarder `json:"discarders,omitempty"`
+}
+
+// policyTestReport describes the approvers of the policies and the result of the evaluation of each recorded event
+type policyTestReport struct {
+	Approvers *sprobe.Report    `json:"approvers"`
+	Events    []policyTestEvent `json:"events"`
+}
+
+// policyTester is a ruleset listener recording the rules matched and the discarders found for the current event
+type policyTester struct {
+	current *policyTestEvent
+}
+
+// RuleMatch is called by the ruleset when a rule matches
+func (p *policyTester) RuleMatch(rule *eval.Rule, event eval.Event) {
+	match := policyTestRule{ID: rule.ID}
+
+	// sequences have no evaluator of their own
+	if evaluator := rule.GetEvaluator(); evaluator != nil && len(evaluator.Macros) > 0 {
+		match.Macros = make(map[string]string)
+		for _, id := range evaluator.Macros {
+			if macro, exists := rule.Opts.Macros[id]; exists {
+				match.Macros[id] = macro.Expression
+			}
+		}
+	}
+
+	p.current.Rules = append(p.current.Rules, match)
+}
+
+// EventDiscarderFound is called by the ruleset when a discarder is found for the event
+func (p *policyTester) EventDiscarderFound(rs *rules.RuleSet, event eval.Event, field eval.Field) {
+	value, err := event.GetFieldValue(field)
+	if err != nil {
+		return
+	}
+	p.current.Discarders = append(p.current.Discarders, policyTestDiscarder{Field: field, Value: value})
+}
+
+// testPolicies loads the policies of the given directory and evaluates the events of the JSON-lines reader against them
+func testPolicies(dir string, r io.Reader) (*policyTestReport, error) {
+	cfg := &secconfig.Config{
+		PoliciesDir:         dir,
+		EnableKernelFilters: true,
+		EnableApprovers:     true,
+		EnableDiscarders:    true,
+	}
+
+	eventCtor := func() eval.Event {
+		return sprobe.NewEvent(nil)
+	}
+
+	ruleSet := rules.NewRuleSet(&sprobe.Model{}, eventCtor, rules.NewOptsWithParams(false, sprobe.SECLConstants, sprobe.InvalidDiscarders))
+	if _, err := policy.LoadPolicies(cfg, ruleSet); err != nil {
+		return nil, err
+	}
+
+	tester := &policyTester{}
+	ruleSet.AddListener(tester)
+
+	approvers, err := sprobe.NewRuleSetApplier(cfg).Apply(ruleSet, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &policyTestReport{
+		Approvers: approvers,
+		Events:    []policyTestEvent{},
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxRecordedEventSize)
+
+	for line := 1; scanner.Scan(); line++ {
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+
+		event := sprobe.NewEvent(nil)
+		if err := json.Unmarshal(data, event); err != nil {
+			return nil, errors.Wrapf(err, "invalid event at line %d", line)
+		}
+
+		tester.current = &policyTestEvent{
+			Line: line,
+			ID:   event.ID,
+			Type: event.GetType(),
+		}
+		ruleSet.Evaluate(event)
+
+		report.Events = append(report.Events, *tester.current)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func testPolicy(cmd *cobra.Command, args []string) error {
+	var r io.Reader = os.Stdin
+	if testPolicyArgs.events != "-" {
+		f, err := os.Open(testPolicyArgs.events)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	report, err := testPolicies(testPolicyArgs.dir, r)
+	if err != nil {
+		return err
+	}
+
+	content, _ := json.MarshalIndent(report, "", "\t")
+	fmt.Printf("%s\n", string(content))
+
+	return nil
+}
@@ -19,6 +19,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/compliance/agent"
 	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
 	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/export"
 	"github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/util"
 	"github.com/DataDog/datadog-agent/pkg/util/flavor"
@@ -31,9 +32,11 @@ import (
 
 var (
 	checkArgs = struct {
-		framework string
-		file      string
-		verbose   bool
+		framework  string
+		file       string
+		verbose    bool
+		report     string
+		reportFile string
 	}{}
 )
 
@@ -41,6 +44,8 @@ func setupCheckCmd(cmd *cobra.Command) {
 	cmd.Flags().StringVarP(&checkArgs.framework, "framework", "", "", "Framework to run the checks from")
 	cmd.Flags().StringVarP(&checkArgs.file, "file", "f", "", "Compliance suite file to read rules from")
 	cmd.Flags().BoolVarP(&checkArgs.verbose, "verbose", "v", false, "Include verbose details")
+	cmd.Flags().StringVarP(&checkArgs.report, "report", "", "", fmt.Sprintf("Write a report of the results in the given format (one of %v)", export.Formats))
+	cmd.Flags().StringVarP(&checkArgs.reportFile, "report-file", "", "", "File to write the report to, instead of the standard output")
 }
 
 // CheckCmd returns a cobra command to run security agent checks
@@ -58,6 +63,15 @@ func CheckCmd(confPath *string) *cobra.Command {
 }
 
 func runCheck(cmd *cobra.Command, confPath *string, args []string) error {
+	var reportFormat export.Format
+	if checkArgs.report != "" {
+		format, err := export.ParseFormat(checkArgs.report)
+		if err != nil {
+			return err
+		}
+		reportFormat = format
+	}
+
 	err := configureLogger()
 	if err != nil {
 		return err
@@ -115,8 +129,6 @@ func runCheck(cmd *cobra.Command, confPath *string, args []string) error {
 
 	options = append(options, checks.WithHostname(hostname))
 
-	reporter := &runCheckReporter{}
-
 	if ruleID != "" {
 		log.Infof("Looking for rule with ID=%s", ruleID)
 		options = append(options, checks.WithMatchRule(checks.IsRuleID(ruleID)))
@@ -127,6 +139,14 @@ func runCheck(cmd *cobra.Command, confPath *string, args []string) error {
 		options = append(options, checks.WithMatchSuite(checks.IsFramework(checkArgs.framework)))
 	}
 
+	if reportFormat != "" {
+		// failed rules are reported with an error, which isn't a usage error
+		cmd.SilenceUsage = true
+		return runCheckReport(hostname, reportFormat, options...)
+	}
+
+	reporter := &runCheckReporter{}
+
 	if checkArgs.file != "" {
 		err = agent.RunChecksFromFile(reporter, checkArgs.file, options...)
 	} else {
@@ -141,6 +161,37 @@ func runCheck(cmd *cobra.Command, confPath *string, args []string) error {
 	return nil
 }
 
+// runCheckReport runs the checks once and writes a report of their results, returning an error when rules failed
+func runCheckReport(hostname string, format export.Format, options ...checks.BuilderOption) error {
+	configDir := config.Datadog.GetString("compliance_config.dir")
+	statuses, err := agent.RunChecksWithStatus(&discardReporter{}, configDir, checkArgs.file, options...)
+	if err != nil {
+		log.Errorf("Failed to run checks: %v", err)
+		return err
+	}
+
+	report := export.NewReport(hostname, time.Now(), statuses)
+
+	w := os.Stdout
+	if checkArgs.reportFile != "" {
+		f, err := os.Create(checkArgs.reportFile)
+		if err != nil {
+			return fmt.Errorf("unable to create report file: %w", err)
+		}
+		defer f.Close()
+		w = f
+	}
+
+	if err := report.Write(w, format); err != nil {
+		return fmt.Errorf("unable to write %s report: %w", format, err)
+	}
+
+	if failed := report.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d compliance rules failed", failed, len(report.Rules))
+	}
+	return nil
+}
+
 func configureLogger() error {
 	var (
 		logFormat = "%LEVEL | %Msg%n"
@@ -151,7 +202,14 @@ func configureLogger() error {
 		logFormat = fmt.Sprintf("%%Date(%s) | %%LEVEL | (%%ShortFilePath:%%Line in %%FuncShort) | %%Msg%%n", logDateFormat)
 		logLevel = "trace"
 	}
-	logger, err := seelog.LoggerFromWriterWithMinLevelAndFormat(os.Stdout, seelog.DebugLvl, logFormat)
+
+	// the logs must not be mixed with a report written to the standard output
+	logWriter := os.Stdout
+	if checkArgs.report != "" && checkArgs.reportFile == "" {
+		logWriter = os.Stderr
+	}
+
+	logger, err := seelog.LoggerFromWriterWithMinLevelAndFormat(logWriter, seelog.DebugLvl, logFormat)
 	if err != nil {
 		return err
 	}
@@ -175,3 +233,10 @@ func (r *runCheckReporter) Report(event *event.Event) {
 
 	fmt.Println(buf.String())
 }
+
+// discardReporter discards the events, as they are read from the status of the checks to write reports
+type discardReporter struct {
+}
+
+func (r *discardReporter) Report(event *event.Event) {
+}
@@ -0,0 +1,28 @@
+// +build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// debugCommands are the subcommands of `system-probe debug`, used to troubleshoot the system-probe offline
+var debugCommands = map[string]func(args []string) int{
+	"dns": runDebugDNS,
+}
+
+// runDebug runs a `system-probe debug` subcommand and returns the exit code of the process
+func runDebug(args []string) int {
+	if len(args) == 0 {
+		fmt.Fprintln(os.Stderr, "usage: system-probe debug <command> [flags]\n\ncommands:\n  dns\treplay a pcap capture through the DNS snooping code")
+		return 2
+	}
+
+	cmd, ok := debugCommands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown debug command %q\n", args[0])
+		return 2
+	}
+	return cmd(args[1:])
+}
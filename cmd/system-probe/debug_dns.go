@@ -0,0 +1,118 @@
+// +build linux_bpf
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/network"
+)
+
+// runDebugDNS replays a pcap capture through the DNS parser, stat keeper and reverse DNS cache of the
+// network tracer, and prints the resulting DNS stats and IP to domain mappings
+func runDebugDNS(args []string) int {
+	defaults := ebpf.NewDefaultConfig()
+
+	flags := flag.NewFlagSet("system-probe debug dns", flag.ContinueOnError)
+	pcapPath := flags.String("pcap", "", "Path to the pcap capture to replay (required)")
+	collectLocalDNS := flags.Bool("collect-local-dns", defaults.CollectLocalDNS, "Collect the stats of the DNS queries to loopback servers")
+	dnsTimeout := flags.Duration("dns-timeout", defaults.DNSTimeout, "Time after which a query without response is accounted as a timeout")
+	maxDomains := flags.Int("max-domains", defaults.MaxDNSDomains, "Maximum number of distinct domains with stats")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *pcapPath == "" {
+		fmt.Fprintln(os.Stderr, "the --pcap flag is required")
+		flags.Usage()
+		return 2
+	}
+
+	f, err := os.Open(*pcapPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to open capture: %s\n", err)
+		return 1
+	}
+	defer f.Close()
+
+	replay, err := network.ReplayDNSPcap(f, *collectLocalDNS, *dnsTimeout, *maxDomains)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to replay %s: %s\n", *pcapPath, err)
+		return 1
+	}
+
+	printDNSReplay(os.Stdout, replay)
+	return 0
+}
+
+func printDNSReplay(out io.Writer, replay *network.DNSReplay) {
+	fmt.Fprintf(out, "Packets: %d\n", replay.Packets)
+	telemetryKeys := make([]string, 0, len(replay.Telemetry))
+	for k := range replay.Telemetry {
+		telemetryKeys = append(telemetryKeys, k)
+	}
+	sort.Strings(telemetryKeys)
+	for _, k := range telemetryKeys {
+		fmt.Fprintf(out, "%s: %d\n", strings.Title(strings.Replace(k, "_", " ", -1)), replay.Telemetry[k])
+	}
+	fmt.Fprintf(out, "Pending queries: %d\n", replay.PendingQueries)
+
+	fmt.Fprintln(out, "\nDNS stats")
+	w := tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "CLIENT\tSERVER\tPROTO\tQUESTION\tSUCCESSES\tAVG SUCCESS LATENCY\tFAILURES\tAVG FAILURE LATENCY\tTIMEOUTS\tRCODES")
+	for _, s := range replay.Stats {
+		fmt.Fprintf(w, "%s:%d\t%s\t%s\t%s %s\t%d\t%s\t%d\t%s\t%d\t%s\n",
+			s.ClientIP, s.ClientPort,
+			s.ServerIP,
+			s.Protocol,
+			s.Question.Type, s.Question.Domain,
+			s.Stats.SuccessLatency.Count(), averageLatency(s.Stats.SuccessLatency),
+			s.Stats.FailureLatency.Count(), averageLatency(s.Stats.FailureLatency),
+			s.Stats.Timeouts,
+			formatRcodes(s.Stats.CountByRcode),
+		)
+	}
+	w.Flush()
+
+	fmt.Fprintln(out, "\nReverse DNS")
+	ips := make([]string, 0, len(replay.Names))
+	names := make(map[string][]string, len(replay.Names))
+	for addr, n := range replay.Names {
+		ips = append(ips, addr.String())
+		names[addr.String()] = n
+	}
+	sort.Strings(ips)
+	w = tabwriter.NewWriter(out, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "IP\tNAMES")
+	for _, ip := range ips {
+		fmt.Fprintf(w, "%s\t%s\n", ip, strings.Join(names[ip], ", "))
+	}
+	w.Flush()
+}
+
+func averageLatency(h network.LatencyHistogram) string {
+	if h.Count() == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%dµs", h.Sum/uint64(h.Count()))
+}
+
+func formatRcodes(rcodes map[uint32]uint32) string {
+	codes := make([]int, 0, len(rcodes))
+	for rcode := range rcodes {
+		codes = append(codes, int(rcode))
+	}
+	sort.Ints(codes)
+
+	parts := make([]string, 0, len(codes))
+	for _, rcode := range codes {
+		parts = append(parts, fmt.Sprintf("%d:%d", rcode, rcodes[uint32(rcode)]))
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,13 @@
+// +build linux,!linux_bpf
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+func runDebugDNS(_ []string) int {
+	fmt.Fprintln(os.Stderr, "DNS debugging requires a system-probe built with eBPF support")
+	return 1
+}
@@ -4,11 +4,17 @@ package main
 
 import (
 	"flag"
+	"os"
 
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
 func main() {
+	// Offline debugging commands, which don't start the system-probe
+	if len(os.Args) > 1 && os.Args[1] == "debug" {
+		os.Exit(runDebug(os.Args[2:]))
+	}
+
 	// Parse flags
 	flag.StringVar(&opts.configPath, "config", "/etc/datadog-agent/system-probe.yaml", "Path to system-probe config formatted as YAML")
 	flag.StringVar(&opts.pidFilePath, "pid", "", "Path to set pidfile for process")
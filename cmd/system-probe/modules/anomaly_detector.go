@@ -0,0 +1,104 @@
+// +build linux windows
+
+package modules
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/anomaly"
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/process/statsd"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// anomalyClientID is the network state client used by the anomaly detector, so that it gets
+// its own deltas of the connections, independently of the process-agent
+const anomalyClientID = "anomaly-detector"
+
+// anomalyRunner periodically looks for RTT and retransmit anomalies in the connections of the tracer
+type anomalyRunner struct {
+	tracer   connectionTracer
+	interval time.Duration
+	exit     chan struct{}
+	wg       sync.WaitGroup
+
+	// mux guards the detector and the reporter, whose telemetry is read by GetStats
+	mux      sync.Mutex
+	detector *anomaly.Detector
+	reporter *anomaly.Reporter
+}
+
+func newAnomalyRunner(cfg *config.AgentConfig, tracer connectionTracer) *anomalyRunner {
+	detectorConfig := anomaly.DefaultConfig()
+	if cfg.NetworkAnomalyThreshold > 0 {
+		detectorConfig.Threshold = cfg.NetworkAnomalyThreshold
+	}
+
+	r := &anomalyRunner{
+		tracer:   tracer,
+		interval: cfg.NetworkAnomalyInterval,
+		exit:     make(chan struct{}),
+		detector: anomaly.NewDetector(detectorConfig),
+		reporter: anomaly.NewReporter(statsd.Client, cfg.NetworkAnomalyEventCooldown, nil),
+	}
+
+	// Register the client, so that the first snapshot only contains the traffic since now
+	if _, err := tracer.GetActiveConnections(anomalyClientID); err != nil {
+		log.Warnf("unable to retrieve connections for anomaly detection: %s", err)
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run()
+	}()
+
+	log.Infof("detecting network anomalies every %s", r.interval)
+	return r
+}
+
+func (r *anomalyRunner) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.exit:
+			return
+		case now := <-ticker.C:
+			cs, err := r.tracer.GetActiveConnections(anomalyClientID)
+			if err != nil {
+				log.Errorf("unable to retrieve connections for anomaly detection: %s", err)
+				continue
+			}
+
+			r.mux.Lock()
+			anomalies := r.detector.Process(cs.Conns, now)
+			r.reporter.Report(anomalies, now)
+			r.mux.Unlock()
+
+			for _, a := range anomalies {
+				log.Debugf("network anomaly: %s to %s is %.2f, baseline %.2f", a.Metric, a.Destination, a.Value, a.Baseline)
+			}
+		}
+	}
+}
+
+// GetStats returns the telemetry of the detector and of the reporter
+func (r *anomalyRunner) GetStats() map[string]int64 {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	stats := r.detector.GetStats()
+	for k, v := range r.reporter.GetStats() {
+		stats[k] = v
+	}
+	return stats
+}
+
+// Close stops the detection
+func (r *anomalyRunner) Close() {
+	close(r.exit)
+	r.wg.Wait()
+}
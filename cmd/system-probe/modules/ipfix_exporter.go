@@ -0,0 +1,105 @@
+// +build linux windows
+
+package modules
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/ipfix"
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// ipfixClientID is the network state client used by the IPFIX exporter, so that it gets
+// its own deltas of the connections, independently of the process-agent
+const ipfixClientID = "ipfix-exporter"
+
+// ipfixRunner periodically exports the connections of the tracer to an IPFIX collector
+type ipfixRunner struct {
+	tracer   connectionTracer
+	exporter *ipfix.Exporter
+	interval time.Duration
+	exit     chan struct{}
+	wg       sync.WaitGroup
+}
+
+func newIPFIXRunner(cfg *config.AgentConfig, tracer connectionTracer) (*ipfixRunner, error) {
+	if err := validateIPFIXExportInterval(cfg.IPFIXExportInterval, config.SysProbeConfigFromConfig(cfg).ClientStateExpiry); err != nil {
+		return nil, err
+	}
+
+	exporter, err := ipfix.NewExporter(cfg.IPFIXCollector, cfg.IPFIXObservationDomainID, cfg.IPFIXTemplateRefreshInterval)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &ipfixRunner{
+		tracer:   tracer,
+		exporter: exporter,
+		interval: cfg.IPFIXExportInterval,
+		exit:     make(chan struct{}),
+	}
+
+	// Register the client, so that the first export only contains the traffic since now
+	if _, err := tracer.GetActiveConnections(ipfixClientID); err != nil {
+		log.Warnf("unable to retrieve connections for IPFIX export: %s", err)
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run()
+	}()
+
+	log.Infof("exporting connections every %s to IPFIX collector %s", r.interval, cfg.IPFIXCollector)
+	return r, nil
+}
+
+// validateIPFIXExportInterval checks that the export interval is shorter than the expiry of the
+// state of the network clients: the state of the exporter's client would otherwise be evicted
+// between two exports, along with the deltas of its connections. Until each export, the closed
+// connections are buffered for the exporter's client, up to max_closed_connections_buffered.
+func validateIPFIXExportInterval(interval, clientStateExpiry time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("invalid IPFIX export interval %s", interval)
+	}
+	if interval >= clientStateExpiry {
+		return fmt.Errorf("IPFIX export interval %s must be shorter than the client state expiry %s", interval, clientStateExpiry)
+	}
+	return nil
+}
+
+func (r *ipfixRunner) run() {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.exit:
+			return
+		case now := <-ticker.C:
+			cs, err := r.tracer.GetActiveConnections(ipfixClientID)
+			if err != nil {
+				log.Errorf("unable to retrieve connections for IPFIX export: %s", err)
+				continue
+			}
+			if err := r.exporter.Export(cs.Conns, now); err != nil {
+				log.Warnf("unable to export connections: %s", err)
+			}
+		}
+	}
+}
+
+// GetStats returns the telemetry of the exporter
+func (r *ipfixRunner) GetStats() map[string]int64 {
+	return r.exporter.GetStats()
+}
+
+// Close stops the export
+func (r *ipfixRunner) Close() {
+	close(r.exit)
+	r.wg.Wait()
+	r.exporter.Close() //nolint:errcheck
+}
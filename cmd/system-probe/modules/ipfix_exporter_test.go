@@ -0,0 +1,17 @@
+// +build linux windows
+
+package modules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateIPFIXExportInterval(t *testing.T) {
+	assert.NoError(t, validateIPFIXExportInterval(10*time.Second, 2*time.Minute))
+	assert.Error(t, validateIPFIXExportInterval(0, 2*time.Minute))
+	assert.Error(t, validateIPFIXExportInterval(2*time.Minute, 2*time.Minute))
+	assert.Error(t, validateIPFIXExportInterval(5*time.Minute, 2*time.Minute))
+}
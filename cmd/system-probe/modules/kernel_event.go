@@ -0,0 +1,77 @@
+package modules
+
+import (
+	"net/http"
+	"sync/atomic"
+
+	"github.com/DataDog/datadog-agent/cmd/system-probe/api"
+	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
+	"github.com/DataDog/datadog-agent/pkg/ebpf"
+	"github.com/DataDog/datadog-agent/pkg/process/config"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/pkg/errors"
+)
+
+// kernelEventCheck declares a system-probe module running a kernel probe, whose events are pulled by the
+// agent check of the same name on the /check/<name> endpoint
+type kernelEventCheck struct {
+	// moduleName is the name of the system-probe module
+	moduleName string
+	// checkName is the name of the kevent.Definition of the events of the probe
+	checkName string
+	// enabledCheck is the name of the check enabling the module in the system-probe configuration
+	enabledCheck string
+	// newProbe starts the probe
+	newProbe func(cfg *ebpf.Config) (ebpf.KernelEventProbe, error)
+}
+
+// newKernelEventFactory returns the factory of the module of a kernel event check
+func newKernelEventFactory(c kernelEventCheck) api.Factory {
+	return api.Factory{
+		Name: c.moduleName,
+		Fn: func(cfg *config.AgentConfig) (api.Module, error) {
+			if !cfg.CheckIsEnabled(c.enabledCheck) {
+				log.Infof("%s probe disabled", c.enabledCheck)
+				return nil, api.ErrNotEnabled
+			}
+
+			log.Infof("Starting the %s probe", c.enabledCheck)
+			probe, err := c.newProbe(config.SysProbeConfigFromConfig(cfg))
+			if err != nil {
+				return nil, errors.Wrapf(err, "unable to start the %s probe", c.enabledCheck)
+			}
+			return &kernelEventModule{check: c, probe: probe}, nil
+		},
+	}
+}
+
+var _ api.Module = &kernelEventModule{}
+
+type kernelEventModule struct {
+	check kernelEventCheck
+	probe ebpf.KernelEventProbe
+
+	// telemetry
+	requests int64
+}
+
+// Register registers the /check/<name> endpoint, returning the events collected since the last request
+func (m *kernelEventModule) Register(httpMux *http.ServeMux) error {
+	httpMux.HandleFunc("/check/"+m.check.checkName, func(w http.ResponseWriter, req *http.Request) {
+		atomic.AddInt64(&m.requests, 1)
+		utils.WriteAsJSON(w, m.probe.GetAndFlushEvents())
+	})
+
+	return nil
+}
+
+func (m *kernelEventModule) GetStats() map[string]interface{} {
+	return map[string]interface{}{
+		"check_requests": atomic.LoadInt64(&m.requests),
+	}
+}
+
+// Close stops the probe
+func (m *kernelEventModule) Close() {
+	m.probe.Close()
+}
@@ -35,26 +35,80 @@ var NetworkTracer = api.Factory{
 			return nil, api.ErrNotEnabled
 		}
 
-		// Checking whether the current OS + kernel version is supported by the tracer
-		if supported, msg := ebpf.IsTracerSupportedByOS(cfg.ExcludedBPFLinuxVersions); !supported {
-			return nil, fmt.Errorf("%s: %s", ErrSysprobeUnsupported, msg)
+		t, err := newConnectionTracer(cfg)
+		if err != nil {
+			return nil, err
 		}
 
-		log.Infof("Creating tracer for: %s", filepath.Base(os.Args[0]))
-
-		t, err := ebpf.NewTracer(config.SysProbeConfigFromConfig(cfg))
-		return &networkTracer{tracer: t}, err
+		nt := &networkTracer{tracer: t}
+		if cfg.IPFIXCollector != "" {
+			if nt.ipfix, err = newIPFIXRunner(cfg, t); err != nil {
+				log.Warnf("could not start IPFIX exporter, network tracer will continue without it: %s", err)
+			}
+		}
+		if cfg.EnableNetworkAnomalyDetection {
+			nt.anomalies = newAnomalyRunner(cfg, t)
+		}
+		return nt, nil
 	},
 }
 
-var _ api.Module = &networkTracer{}
+// newConnectionTracer returns the eBPF tracer, or the /proc/net tracer if the eBPF tracer can't run
+// on the host and the fallback is enabled
+func newConnectionTracer(cfg *config.AgentConfig) (connectionTracer, error) {
+	// Checking whether the current OS + kernel version is supported by the tracer
+	if supported, msg := ebpf.IsTracerSupportedByOS(cfg.ExcludedBPFLinuxVersions); !supported {
+		err := fmt.Errorf("%s: %s", ErrSysprobeUnsupported, msg)
+		if !cfg.EnableProcNetFallback {
+			return nil, err
+		}
+		log.Warnf("%s, falling back to the /proc/net tracer", err)
+		return ebpf.NewProcNetTracer(config.SysProbeConfigFromConfig(cfg))
+	}
+
+	log.Infof("Creating tracer for: %s", filepath.Base(os.Args[0]))
+
+	t, err := ebpf.NewTracer(config.SysProbeConfigFromConfig(cfg))
+	if err != nil {
+		if !cfg.EnableProcNetFallback {
+			return nil, err
+		}
+		log.Warnf("could not initialize the eBPF tracer, falling back to the /proc/net tracer: %s", err)
+		return ebpf.NewProcNetTracer(config.SysProbeConfigFromConfig(cfg))
+	}
+	return t, nil
+}
+
+// connectionTracer is implemented by the tracers providing the connections to the module
+type connectionTracer interface {
+	GetActiveConnections(clientID string) (*network.Connections, error)
+	SetClientFilter(clientID string, filter *network.ClientFilter)
+	GetStats() (map[string]interface{}, error)
+	DebugNetworkState(clientID string) (map[string]interface{}, error)
+	DebugNetworkMaps() (*network.Connections, error)
+	Stop()
+}
+
+var (
+	_ api.Module       = &networkTracer{}
+	_ connectionTracer = &ebpf.Tracer{}
+	_ connectionTracer = &ebpf.ProcNetTracer{}
+)
 
 type networkTracer struct {
-	tracer *ebpf.Tracer
+	tracer    connectionTracer
+	ipfix     *ipfixRunner   // nil unless a collector is configured
+	anomalies *anomalyRunner // nil unless anomaly detection is enabled
 }
 
 func (nt *networkTracer) GetStats() map[string]interface{} {
 	stats, _ := nt.tracer.GetStats()
+	if nt.ipfix != nil && stats != nil {
+		stats["ipfix"] = nt.ipfix.GetStats()
+	}
+	if nt.anomalies != nil && stats != nil {
+		stats["anomalies"] = nt.anomalies.GetStats()
+	}
 	return stats
 }
 
@@ -67,6 +121,15 @@ func (nt *networkTracer) Register(httpMux *http.ServeMux) error {
 	httpMux.HandleFunc("/connections", func(w http.ResponseWriter, req *http.Request) {
 		start := time.Now()
 		id := getClientID(req)
+		filter, err := network.ParseClientFilter(req.URL.Query())
+		if err != nil {
+			log.Warnf("invalid connection filter for client %s: %s", id, err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// the filter of the request replaces the one of the previous requests of the client
+		nt.tracer.SetClientFilter(id, filter)
+
 		cs, err := nt.tracer.GetActiveConnections(id)
 		if err != nil {
 			log.Errorf("unable to retrieve connections: %s", err)
@@ -118,6 +181,12 @@ func (nt *networkTracer) Register(httpMux *http.ServeMux) error {
 
 // Close will stop all system probe activities
 func (nt *networkTracer) Close() {
+	if nt.ipfix != nil {
+		nt.ipfix.Close()
+	}
+	if nt.anomalies != nil {
+		nt.anomalies.Close()
+	}
 	nt.tracer.Stop()
 }
 
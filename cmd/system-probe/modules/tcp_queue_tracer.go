@@ -1,49 +1,20 @@
 package modules
 
 import (
-	"net/http"
-
-	"github.com/DataDog/datadog-agent/cmd/system-probe/api"
-	"github.com/DataDog/datadog-agent/cmd/system-probe/utils"
 	"github.com/DataDog/datadog-agent/pkg/ebpf"
-	"github.com/DataDog/datadog-agent/pkg/process/config"
-	"github.com/DataDog/datadog-agent/pkg/util/log"
-	"github.com/pkg/errors"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpqueuelength"
 )
 
 // TCPQueueLength Factory
-var TCPQueueLength = api.Factory{
-	Name: "tcp_queue_length_tracer",
-	Fn: func(cfg *config.AgentConfig) (api.Module, error) {
-		if !cfg.CheckIsEnabled("TCP queue length") {
-			log.Infof("TCP queue length tracer disabled")
-			return nil, api.ErrNotEnabled
-		}
-
-		t, err := ebpf.NewTCPQueueLengthTracer(config.SysProbeConfigFromConfig(cfg))
+var TCPQueueLength = newKernelEventFactory(kernelEventCheck{
+	moduleName:   "tcp_queue_length_tracer",
+	checkName:    tcpqueuelength.CheckName,
+	enabledCheck: "TCP queue length",
+	newProbe: func(cfg *ebpf.Config) (ebpf.KernelEventProbe, error) {
+		t, err := ebpf.NewTCPQueueLengthTracer(cfg)
 		if err != nil {
-			return nil, errors.Wrapf(err, "unable to start the TCP queue length tracer")
+			return nil, err
 		}
-
-		return &tcpQueueLengthModule{t}, nil
+		return t, nil
 	},
-}
-
-var _ api.Module = &tcpQueueLengthModule{}
-
-type tcpQueueLengthModule struct {
-	*ebpf.TCPQueueLengthTracer
-}
-
-func (t *tcpQueueLengthModule) Register(httpMux *http.ServeMux) error {
-	httpMux.HandleFunc("/check/tcp_queue_length", func(w http.ResponseWriter, req *http.Request) {
-		stats := t.TCPQueueLengthTracer.GetAndFlush()
-		utils.WriteAsJSON(w, stats)
-	})
-
-	return nil
-}
-
-func (t *tcpQueueLengthModule) GetStats() map[string]interface{} {
-	return nil
-}
+})
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// FIXME: we require the `cgo` build tag because of this dep relationship:
+// github.com/DataDog/datadog-agent/pkg/process/net depends on `github.com/DataDog/agent-payload/process`,
+// which has a hard dependency on `github.com/DataDog/zstd`, which requires CGO.
+// Should be removed once `github.com/DataDog/agent-payload/process` can be imported with CGO disabled.
+// +build cgo
+// +build linux
+
+package ebpf
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/aggregator"
+	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
+	"github.com/DataDog/datadog-agent/pkg/collector/check"
+	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
+	dd_config "github.com/DataDog/datadog-agent/pkg/config"
+	process_net "github.com/DataDog/datadog-agent/pkg/process/net"
+	"github.com/DataDog/datadog-agent/pkg/tagger"
+	"github.com/DataDog/datadog-agent/pkg/util/containers"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// kernelEventMapping maps the events of a kernel event check, pulled from the system-probe, to metrics and events
+type kernelEventMapping interface {
+	// Parse parses the instance configuration
+	Parse(data []byte) error
+	// Enabled returns whether the check collects the events, as configured
+	Enabled() bool
+	// ContainerID returns the ID of the container the event comes from, if any
+	ContainerID(event interface{}) string
+	// Submit submits the metrics and events of an event, with the tags of its container
+	Submit(sender aggregator.Sender, event interface{}, tags []string)
+	// Flush submits the aggregates of the events of a run, after all of them were submitted
+	Flush(sender aggregator.Sender)
+}
+
+// KernelEventCheck pulls the events of a kernel probe of the system-probe from its /check/<name> endpoint,
+// and submits them with the mapping of the check
+type KernelEventCheck struct {
+	core.CheckBase
+	name    string
+	mapping kernelEventMapping
+
+	// getEvents returns the events collected by the system-probe since the last call
+	getEvents func(name string) ([]interface{}, error)
+}
+
+// registerKernelEventCheck registers the check of a kernel event probe, named after its kevent.Definition
+func registerKernelEventCheck(name string, newMapping func() kernelEventMapping) func() check.Check {
+	factory := func() check.Check {
+		return newKernelEventCheck(name, newMapping())
+	}
+	core.RegisterCheck(name, factory)
+	return factory
+}
+
+func newKernelEventCheck(name string, mapping kernelEventMapping) *KernelEventCheck {
+	return &KernelEventCheck{
+		CheckBase: core.NewCheckBase(name),
+		name:      name,
+		mapping:   mapping,
+		getEvents: getSystemProbeEvents,
+	}
+}
+
+func getSystemProbeEvents(name string) ([]interface{}, error) {
+	sysProbeUtil, err := process_net.GetRemoteSystemProbeUtil()
+	if err != nil {
+		return nil, err
+	}
+	return sysProbeUtil.GetCheck(name)
+}
+
+// Configure parses the check configuration and init the check
+func (c *KernelEventCheck) Configure(config, initConfig integration.Data, source string) error {
+	// TODO: Remove that hard-code and put it somewhere else
+	process_net.SetSystemProbePath(dd_config.Datadog.GetString("system_probe_config.sysprobe_socket"))
+
+	err := c.CommonConfigure(config, source)
+	if err != nil {
+		return err
+	}
+
+	return c.mapping.Parse(config)
+}
+
+// Run executes the check
+func (c *KernelEventCheck) Run() error {
+	if !c.mapping.Enabled() {
+		return nil
+	}
+
+	events, err := c.getEvents(c.name)
+	if err != nil {
+		return err
+	}
+
+	// sender is just what is used to submit the data
+	sender, err := aggregator.GetSender(c.ID())
+	if err != nil {
+		return err
+	}
+
+	for _, event := range events {
+		var tags []string
+		containerID := c.mapping.ContainerID(event)
+		if entityID := containers.BuildTaggerEntityName(containerID); entityID != "" {
+			tags, err = tagger.Tag(entityID, tagger.ChecksCardinality)
+			if err != nil {
+				log.Errorf("Error collecting tags for container %s: %s", containerID, err)
+			}
+		}
+		c.mapping.Submit(sender, event, tags)
+	}
+	c.mapping.Flush(sender)
+
+	sender.Commit()
+	return nil
+}
@@ -0,0 +1,83 @@
+// +build cgo
+// +build linux
+
+package ebpf
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/DataDog/datadog-agent/pkg/aggregator/mocksender"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/oomkill"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpqueuelength"
+	"github.com/DataDog/datadog-agent/pkg/metrics"
+)
+
+func newTestKernelEventCheck(t *testing.T, factory func() *KernelEventCheck, config string, events ...interface{}) (*KernelEventCheck, *mocksender.MockSender) {
+	c := factory()
+	require.NoError(t, c.Configure([]byte(config), nil, "test"))
+	c.getEvents = func(name string) ([]interface{}, error) {
+		return events, nil
+	}
+
+	sender := mocksender.NewMockSender(c.ID())
+	sender.SetupAcceptAll()
+	return c, sender
+}
+
+func TestOOMKillCheck(t *testing.T) {
+	factory := func() *KernelEventCheck { return OOMKillFactory().(*KernelEventCheck) }
+	c, sender := newTestKernelEventCheck(t, factory, "", oomkill.Stats{
+		Pid:   42,
+		TPid:  43,
+		FComm: "bash",
+		TComm: "stress",
+		Pages: 1024,
+	})
+	require.NoError(t, c.Run())
+
+	tags := []string{"trigger_type:system", "trigger_process_name:bash", "process_name:stress"}
+	sender.AssertCalled(t, "Count", "oom_kill.oom_process.count", float64(1), "", tags)
+	sender.AssertCalled(t, "Event", mock.MatchedBy(func(e metrics.Event) bool {
+		return e.Title == "Process OOM Killed: oom_kill_process called on stress (pid: 43)" && e.SourceTypeName == oomKillCheckName
+	}))
+	sender.AssertNumberOfCalls(t, "Commit", 1)
+}
+
+func TestOOMKillCheckDisabled(t *testing.T) {
+	factory := func() *KernelEventCheck { return OOMKillFactory().(*KernelEventCheck) }
+	c, sender := newTestKernelEventCheck(t, factory, "collect_oom_kill: false", oomkill.Stats{Pid: 42})
+	require.NoError(t, c.Run())
+	sender.AssertNotCalled(t, "Commit")
+}
+
+func TestTCPQueueLengthCheck(t *testing.T) {
+	factory := func() *KernelEventCheck { return TCPQueueLengthFactory().(*KernelEventCheck) }
+	event := tcpqueuelength.Stats{
+		Pid: 42,
+		Conn: tcpqueuelength.Conn{
+			Saddr: net.ParseIP("10.0.0.1").To4(),
+			Daddr: net.ParseIP("10.0.0.2").To4(),
+			Sport: 40000,
+			Dport: 443,
+		},
+		Rqueue: tcpqueuelength.QueueLength{Size: 100, Min: 1, Max: 50},
+		Wqueue: tcpqueuelength.QueueLength{Size: 200, Min: 2, Max: 60},
+	}
+	tags := []string{"saddr:10.0.0.1", "daddr:10.0.0.2", "sport:40000", "dport:443", "pid:42"}
+
+	c, sender := newTestKernelEventCheck(t, factory, "only_count_nb_contexts: false", event)
+	require.NoError(t, c.Run())
+	sender.AssertCalled(t, "Gauge", "tcp_queue.rqueue.size", float64(100), "", tags)
+	sender.AssertCalled(t, "Gauge", "tcp_queue.wqueue.max", float64(60), "", tags)
+	sender.AssertNotCalled(t, "Gauge", "tcp_queue.nb_contexts", mock.Anything, mock.Anything, mock.Anything)
+
+	// by default, only the number of contexts is submitted
+	c, sender = newTestKernelEventCheck(t, factory, "", event, event)
+	require.NoError(t, c.Run())
+	sender.AssertCalled(t, "Gauge", "tcp_queue.nb_contexts", float64(1), "", []string{})
+	sender.AssertNotCalled(t, "Gauge", "tcp_queue.rqueue.size", mock.Anything, mock.Anything, mock.Anything)
+}
@@ -20,44 +20,27 @@ import (
 	yaml "gopkg.in/yaml.v2"
 
 	"github.com/DataDog/datadog-agent/pkg/aggregator"
-	"github.com/DataDog/datadog-agent/pkg/autodiscovery/integration"
-	"github.com/DataDog/datadog-agent/pkg/collector/check"
-	core "github.com/DataDog/datadog-agent/pkg/collector/corechecks"
-	dd_config "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpqueuelength"
-	process_net "github.com/DataDog/datadog-agent/pkg/process/net"
-	"github.com/DataDog/datadog-agent/pkg/tagger"
-	"github.com/DataDog/datadog-agent/pkg/util/containers"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
 const (
-	tcpQueueLengthCheckName = "tcp_queue_length"
+	tcpQueueLengthCheckName = tcpqueuelength.CheckName
 )
 
 // TCPQueueLengthConfig is the config of the TCP Queue Length check
 type TCPQueueLengthConfig struct {
 	CollectTCPQueueLength bool `yaml:"collect_tcp_queue_length"`
 	OnlyCountNbContexts   bool `yaml:"only_count_nb_contexts"` // For impact analysis only. To be removed after
-}
-
-// TCPQueueLengthCheck grabs TCP queue length metrics
-type TCPQueueLengthCheck struct {
-	core.CheckBase
-	instance *TCPQueueLengthConfig
-}
 
-func init() {
-	core.RegisterCheck(tcpQueueLengthCheckName, TCPQueueLengthFactory)
+	// tagsSet holds the contexts seen since the check started, when only counting them
+	tagsSet map[string]struct{}
 }
 
 // TCPQueueLengthFactory is exported for integration testing
-func TCPQueueLengthFactory() check.Check {
-	return &TCPQueueLengthCheck{
-		CheckBase: core.NewCheckBase(tcpQueueLengthCheckName),
-		instance:  &TCPQueueLengthConfig{},
-	}
-}
+var TCPQueueLengthFactory = registerKernelEventCheck(tcpQueueLengthCheckName, func() kernelEventMapping {
+	return &TCPQueueLengthConfig{tagsSet: make(map[string]struct{})}
+})
 
 // Parse parses the check configuration and init the check
 func (t *TCPQueueLengthConfig) Parse(data []byte) error {
@@ -71,81 +54,49 @@ func (t *TCPQueueLengthConfig) Parse(data []byte) error {
 	return nil
 }
 
-//Configure parses the check configuration and init the check
-func (t *TCPQueueLengthCheck) Configure(config, initConfig integration.Data, source string) error {
-	// TODO: Remove that hard-code and put it somewhere else
-	process_net.SetSystemProbePath(dd_config.Datadog.GetString("system_probe_config.sysprobe_socket"))
-
-	err := t.CommonConfigure(config, source)
-	if err != nil {
-		return err
-	}
-
-	return t.instance.Parse(config)
+// Enabled returns whether TCP queue lengths are collected
+func (t *TCPQueueLengthConfig) Enabled() bool {
+	return t.CollectTCPQueueLength
 }
 
-var tagsSet = make(map[string]struct{})
-
-// Run executes the check
-func (t *TCPQueueLengthCheck) Run() error {
-	if !t.instance.CollectTCPQueueLength {
-		return nil
-	}
-
-	sysProbeUtil, err := process_net.GetRemoteSystemProbeUtil()
-	if err != nil {
-		return err
-	}
+// ContainerID returns the ID of the container of the process owning the socket
+func (t *TCPQueueLengthConfig) ContainerID(event interface{}) string {
+	line, _ := event.(tcpqueuelength.Stats)
+	return line.ContainerID
+}
 
-	data, err := sysProbeUtil.GetCheck("tcp_queue_length")
-	if err != nil {
-		return err
+// Submit submits the queue lengths of the socket, or only records its context
+func (t *TCPQueueLengthConfig) Submit(sender aggregator.Sender, event interface{}, tags []string) {
+	line, ok := event.(tcpqueuelength.Stats)
+	if !ok {
+		log.Error("Raw data has incorrect type")
+		return
 	}
 
-	sender, err := aggregator.GetSender(t.ID())
-	if err != nil {
-		return err
+	tags = append(tags,
+		"saddr:"+line.Conn.Saddr.String(),
+		"daddr:"+line.Conn.Daddr.String(),
+		"sport:"+strconv.Itoa(int(line.Conn.Sport)),
+		"dport:"+strconv.Itoa(int(line.Conn.Dport)),
+		"pid:"+strconv.Itoa(int(line.Pid)))
+
+	if t.OnlyCountNbContexts {
+		sort.Strings(tags)
+		t.tagsSet[strings.Join(tags, ",")] = struct{}{}
+		return
 	}
 
-	for _, lineRaw := range data {
-		line, ok := lineRaw.(tcpqueuelength.Stats)
-		if !ok {
-			log.Error("Raw data has incorrect type")
-			continue
-		}
-		entityID := containers.BuildTaggerEntityName(line.ContainerID)
-		var tags []string
-		if entityID != "" {
-			tags, err = tagger.Tag(entityID, tagger.ChecksCardinality)
-			if err != nil {
-				log.Errorf("Error collecting tags for container %s: %s", line.ContainerID, err)
-			}
-		}
-
-		tags = append(tags,
-			"saddr:"+line.Conn.Saddr.String(),
-			"daddr:"+line.Conn.Daddr.String(),
-			"sport:"+strconv.Itoa(int(line.Conn.Sport)),
-			"dport:"+strconv.Itoa(int(line.Conn.Dport)),
-			"pid:"+strconv.Itoa(int(line.Pid)))
-
-		if t.instance.OnlyCountNbContexts {
-			sort.Strings(tags)
-			tagsSet[strings.Join(tags, ",")] = struct{}{}
-		} else {
-			sender.Gauge("tcp_queue.rqueue.size", float64(line.Rqueue.Size), "", tags)
-			sender.Gauge("tcp_queue.rqueue.min", float64(line.Rqueue.Min), "", tags)
-			sender.Gauge("tcp_queue.rqueue.max", float64(line.Rqueue.Max), "", tags)
-			sender.Gauge("tcp_queue.wqueue.size", float64(line.Wqueue.Size), "", tags)
-			sender.Gauge("tcp_queue.wqueue.min", float64(line.Wqueue.Min), "", tags)
-			sender.Gauge("tcp_queue.wqueue.max", float64(line.Wqueue.Max), "", tags)
-		}
-	}
+	sender.Gauge("tcp_queue.rqueue.size", float64(line.Rqueue.Size), "", tags)
+	sender.Gauge("tcp_queue.rqueue.min", float64(line.Rqueue.Min), "", tags)
+	sender.Gauge("tcp_queue.rqueue.max", float64(line.Rqueue.Max), "", tags)
+	sender.Gauge("tcp_queue.wqueue.size", float64(line.Wqueue.Size), "", tags)
+	sender.Gauge("tcp_queue.wqueue.min", float64(line.Wqueue.Min), "", tags)
+	sender.Gauge("tcp_queue.wqueue.max", float64(line.Wqueue.Max), "", tags)
+}
 
-	if t.instance.OnlyCountNbContexts {
-		sender.Gauge("tcp_queue.nb_contexts", float64(len(tagsSet)), "", []string{})
+// Flush submits the number of contexts, when only counting them
+func (t *TCPQueueLengthConfig) Flush(sender aggregator.Sender) {
+	if t.OnlyCountNbContexts {
+		sender.Gauge("tcp_queue.nb_contexts", float64(len(t.tagsSet)), "", []string{})
 	}
-
-	sender.Commit()
-	return nil
 }
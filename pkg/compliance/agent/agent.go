@@ -92,6 +92,36 @@ func RunChecksFromFile(reporter event.Reporter, file string, options ...checks.B
 	return agent.RunChecksFromFile(file)
 }
 
+// RunChecksWithStatus runs checks from the specified file, or from the config directory when no file is
+// specified, with no scheduling and returns the status of the checks, holding the events they reported
+func RunChecksWithStatus(reporter event.Reporter, configDir, file string, options ...checks.BuilderOption) (compliance.CheckStatusList, error) {
+	builder, err := checks.NewBuilder(
+		reporter,
+		options...,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	defer builder.Close()
+
+	agent := &Agent{
+		builder:   builder,
+		configDir: configDir,
+	}
+
+	if file != "" {
+		err = agent.RunChecksFromFile(file)
+	} else {
+		err = agent.RunChecks()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return builder.GetCheckStatus(), nil
+}
+
 // Run starts the Compliance Agent
 func (a *Agent) Run() error {
 	a.scheduler.Run()
@@ -260,3 +260,38 @@ func TestRunChecksFromFile(t *testing.T) {
 	)
 	assert.NoError(err)
 }
+
+func TestRunChecksWithStatus(t *testing.T) {
+	assert := assert.New(t)
+
+	e := enterTempEnv(t)
+	defer e.leave()
+
+	reporter := &mocks.Reporter{}
+	reporter.On("Report", mock.AnythingOfType("*event.Event")).Once()
+	defer reporter.AssertExpectations(t)
+
+	dockerClient := &mocks.DockerClient{}
+	dockerClient.On("Close").Return(nil).Once()
+	defer dockerClient.AssertExpectations(t)
+
+	statuses, err := RunChecksWithStatus(
+		reporter,
+		e.dir,
+		"",
+		checks.WithMatchSuite(checks.IsFramework("cis-docker")),
+		checks.WithMatchRule(checks.IsRuleID("cis-docker-1")),
+		checks.WithHostname("the-host"),
+		checks.WithHostRootMount(e.dir),
+		checks.WithDockerClient(dockerClient),
+	)
+	assert.NoError(err)
+	assert.Len(statuses, 1)
+
+	status := statuses[0]
+	assert.Equal("cis-docker-1", status.RuleID)
+	assert.Equal("cis-docker", status.Framework)
+	assert.NoError(status.InitError)
+	assert.NotNil(status.LastEvent)
+	assert.Equal("passed", status.LastEvent.Result)
+}
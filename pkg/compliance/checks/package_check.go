@@ -0,0 +1,99 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var packageReportedFields = []string{
+	compliance.PackageFieldName,
+	compliance.PackageFieldVersion,
+	compliance.PackageFieldInstalled,
+}
+
+func resolvePackage(_ context.Context, e env.Env, id string, res compliance.Resource) (interface{}, error) {
+	if res.Package == nil {
+		return nil, fmt.Errorf("%s: expecting package resource in package check", id)
+	}
+
+	pkg := res.Package
+	if pkg.Name == "" {
+		return nil, fmt.Errorf("%s: package resource is missing name", id)
+	}
+
+	log.Debugf("%s: running package check: %s", id, pkg.Name)
+
+	packages, err := getInstalledPackages(e, cacheValidity)
+	if err != nil {
+		return nil, log.Errorf("%s: Unable to fetch installed packages: %v", id, err)
+	}
+
+	matchedPackages := packages.findPackagesByName(pkg.Name)
+
+	// a package which is not installed still resolves, so that rules can require its absence
+	if len(matchedPackages) == 0 {
+		return &eval.Instance{
+			Vars: eval.VarMap{
+				compliance.PackageFieldName:      pkg.Name,
+				compliance.PackageFieldVersion:   "",
+				compliance.PackageFieldInstalled: false,
+			},
+			Functions: eval.FunctionMap{
+				compliance.PackageFuncVersionCompare: packageVersionCompare(nil),
+			},
+		}, nil
+	}
+
+	var instances []*eval.Instance
+	for _, mp := range matchedPackages {
+		instance := &eval.Instance{
+			Vars: eval.VarMap{
+				compliance.PackageFieldName:      mp.Name,
+				compliance.PackageFieldVersion:   mp.Version,
+				compliance.PackageFieldInstalled: true,
+			},
+			Functions: eval.FunctionMap{
+				compliance.PackageFuncVersionCompare: packageVersionCompare(mp),
+			},
+		}
+		instances = append(instances, instance)
+	}
+
+	if len(instances) == 1 {
+		return instances[0], nil
+	}
+
+	return &instanceIterator{
+		instances: instances,
+	}, nil
+}
+
+// packageVersionCompare returns -1, 0 or 1 when the version of the installed package is respectively
+// lower, equal or greater than the version argument. A package which is not installed is always lower.
+func packageVersionCompare(pkg *installedPackage) eval.Function {
+	return func(_ *eval.Instance, args ...interface{}) (interface{}, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf(`invalid number of arguments, expecting 1 got %d`, len(args))
+		}
+		version, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New(`expecting string value for version argument`)
+		}
+
+		if pkg == nil {
+			return -1, nil
+		}
+		return compareVersions(pkg.Format, pkg.Version, version), nil
+	}
+}
@@ -0,0 +1,287 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestPackageCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		dpkgStatus string
+		resource   compliance.Resource
+
+		expectReport *compliance.Report
+		expectError  error
+	}{
+		{
+			name:       "minimum version installed",
+			dpkgStatus: "./testdata/package/dpkg-status",
+			resource: compliance.Resource{
+				Package: &compliance.Package{
+					Name: "sudo",
+				},
+				Condition: `package.versionCompare("1.8.31") >= 0`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"package.name":      "sudo",
+					"package.version":   "1.8.31-1ubuntu1.1",
+					"package.installed": true,
+				},
+			},
+		},
+		{
+			name:       "minimum version not installed",
+			dpkgStatus: "./testdata/package/dpkg-status",
+			resource: compliance.Resource{
+				Package: &compliance.Package{
+					Name: "openssl",
+				},
+				Condition: `package.versionCompare("1.1.1g") >= 0`,
+			},
+			expectReport: &compliance.Report{
+				Passed: false,
+				Data: event.Data{
+					"package.name":      "openssl",
+					"package.version":   "1.1.1f-1ubuntu2.1",
+					"package.installed": true,
+				},
+			},
+		},
+		{
+			name:       "removed package",
+			dpkgStatus: "./testdata/package/dpkg-status",
+			resource: compliance.Resource{
+				Package: &compliance.Package{
+					Name: "telnet",
+				},
+				Condition: `!package.installed`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"package.name":      "telnet",
+					"package.version":   "",
+					"package.installed": false,
+				},
+			},
+		},
+		{
+			name:       "no package database",
+			dpkgStatus: "./testdata/package/missing",
+			resource: compliance.Resource{
+				Package: &compliance.Package{
+					Name: "openssl",
+				},
+				Condition: `package.versionCompare("1.1.1g") >= 0`,
+			},
+			expectError: errors.New("rule-id: Unable to fetch installed packages: no supported package database found"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			cache.Cache.Delete(packageCacheKey)
+
+			env := newPackageEnv(map[string]string{dpkgStatusPath: test.dpkgStatus})
+			defer env.AssertExpectations(t)
+
+			packageCheck, err := newResourceCheck(env, "rule-id", test.resource)
+			assert.NoError(err)
+
+			report, err := packageCheck.check(env)
+			assert.Equal(test.expectReport, report)
+			assert.Equal(test.expectError, err)
+		})
+	}
+}
+
+// newPackageEnv returns an environment where the package databases are found at the given paths, and are
+// missing otherwise
+func newPackageEnv(paths map[string]string) *mocks.Env {
+	env := &mocks.Env{}
+	for _, path := range append([]string{dpkgStatusPath, rpmPackagesPath, apkInstalledPath}, unsupportedPackageDatabases...) {
+		hostPath, found := paths[path]
+		if !found {
+			hostPath = "./testdata/package/missing"
+		}
+		env.On("NormalizeToHostRoot", path).Return(hostPath).Maybe()
+	}
+	return env
+}
+
+func TestFetchInstalledPackagesUnsupportedDatabase(t *testing.T) {
+	assert := assert.New(t)
+
+	dir, err := ioutil.TempDir("", "rpm")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	sqlitePath := filepath.Join(dir, "rpmdb.sqlite")
+	assert.NoError(ioutil.WriteFile(sqlitePath, []byte("SQLite format 3\x00"), 0644))
+
+	// the packages of the rpm database would be reported as not installed
+	env := newPackageEnv(map[string]string{
+		dpkgStatusPath: "./testdata/package/dpkg-status",
+		rpmSqlitePath:  sqlitePath,
+	})
+
+	_, err = fetchInstalledPackages(env)
+	assert.EqualError(err, "unsupported package database "+sqlitePath)
+
+	_, err = fetchInstalledPackages(newPackageEnv(nil))
+	assert.Equal(errNoPackageDatabase, err)
+}
+
+func TestParseApkInstalled(t *testing.T) {
+	assert := assert.New(t)
+
+	env := newPackageEnv(map[string]string{apkInstalledPath: "./testdata/package/apk-installed"})
+	defer env.AssertExpectations(t)
+
+	packages, err := fetchInstalledPackages(env)
+	assert.NoError(err)
+	assert.Equal(installedPackages{
+		{Name: "musl", Version: "1.1.24-r9", Format: apkFormat},
+		{Name: "openssl", Version: "1.1.1g-r0", Format: apkFormat},
+	}, packages)
+}
+
+// rpmHeader builds a rpm header holding the name, version, release and optional epoch of a package
+func rpmHeader(name, version, release string, epoch uint32) []byte {
+	type entry struct {
+		tag, kind uint32
+		value     []byte
+	}
+
+	entries := []entry{
+		{rpmTagName, rpmTypeString, append([]byte(name), 0)},
+		{rpmTagVersion, rpmTypeString, append([]byte(version), 0)},
+		{rpmTagRelease, rpmTypeString, append([]byte(release), 0)},
+	}
+	if epoch != 0 {
+		value := make([]byte, 4)
+		binary.BigEndian.PutUint32(value, epoch)
+		entries = append([]entry{{rpmTagEpoch, rpmTypeInt32, value}}, entries...)
+	}
+
+	var index, store bytes.Buffer
+	for _, e := range entries {
+		binary.Write(&index, binary.BigEndian, []uint32{e.tag, e.kind, uint32(store.Len()), 1})
+		store.Write(e.value)
+	}
+
+	var header bytes.Buffer
+	binary.Write(&header, binary.BigEndian, []uint32{uint32(len(entries)), uint32(store.Len())})
+	header.Write(index.Bytes())
+	header.Write(store.Bytes())
+	return header.Bytes()
+}
+
+// bdbHash builds a little endian Berkeley DB hash database with a single hash page whose keys
+// are the header instance numbers, and whose values are stored in one overflow page each
+func bdbHash(values ...[]byte) []byte {
+	const pageSize = 512
+
+	lastPage := uint32(1 + len(values))
+	db := make([]byte, pageSize*(lastPage+1))
+	le := binary.LittleEndian
+
+	le.PutUint32(db[12:16], bdbHashMagic)
+	le.PutUint32(db[20:24], pageSize)
+	le.PutUint32(db[32:36], lastPage)
+
+	hash := db[pageSize : 2*pageSize]
+	le.PutUint16(hash[20:22], uint16(2*len(values)))
+	hash[25] = bdbPageTypeHash
+
+	// the items are stored from the end of the page, each key before its value
+	itemOffset := pageSize
+	for i, value := range values {
+		overflowPageNo := uint32(2 + i)
+
+		itemOffset -= 5
+		le.PutUint16(hash[bdbPageHeaderSize+4*i:], uint16(itemOffset))
+		hash[itemOffset] = bdbItemTypeKeyData
+		le.PutUint32(hash[itemOffset+1:], uint32(1+i))
+
+		itemOffset -= 12
+		le.PutUint16(hash[bdbPageHeaderSize+4*i+2:], uint16(itemOffset))
+		hash[itemOffset] = bdbItemTypeOffPage
+		le.PutUint32(hash[itemOffset+4:], overflowPageNo)
+		le.PutUint32(hash[itemOffset+8:], uint32(len(value)))
+
+		overflow := db[overflowPageNo*pageSize : (overflowPageNo+1)*pageSize]
+		le.PutUint16(overflow[22:24], uint16(len(value)))
+		overflow[25] = bdbPageTypeOverflow
+		copy(overflow[bdbPageHeaderSize:], value)
+	}
+
+	return db
+}
+
+func TestParseRpmPackages(t *testing.T) {
+	assert := assert.New(t)
+
+	db := bdbHash(
+		rpmHeader("openssl", "1.0.2k", "19.el7", 1),
+		rpmHeader("sudo", "1.8.23", "9.el7", 0),
+	)
+
+	packages, err := parseRpmPackages(bytes.NewReader(db), int64(len(db)))
+	assert.NoError(err)
+	assert.Equal(installedPackages{
+		{Name: "openssl", Version: "1:1.0.2k-19.el7", Format: rpmFormat},
+		{Name: "sudo", Version: "1.8.23-9.el7", Format: rpmFormat},
+	}, packages)
+
+	_, err = parseRpmPackages(bytes.NewReader(db[:1024]), 1024)
+	assert.Error(err)
+
+	_, err = parseRpmPackages(bytes.NewReader(make([]byte, 1024)), 1024)
+	assert.Error(err)
+}
+
+func TestParseRpmPackagesFixture(t *testing.T) {
+	assert := assert.New(t)
+
+	// a Berkeley DB 5.3 rpm database, holding the small headers in its hash pages, the large
+	// ones in overflow pages, and the number of the last header instance under the key 0
+	f, err := os.Open("./testdata/package/rpm-Packages")
+	assert.NoError(err)
+	defer f.Close()
+
+	info, err := f.Stat()
+	assert.NoError(err)
+
+	packages, err := parseRpmPackages(f, info.Size())
+	assert.NoError(err)
+	assert.ElementsMatch(installedPackages{
+		{Name: "setup", Version: "2.8.71-11.el7", Format: rpmFormat},
+		{Name: "basesystem", Version: "10.0-7.el7.centos", Format: rpmFormat},
+		{Name: "openssl-libs", Version: "1:1.0.2k-19.el7", Format: rpmFormat},
+		{Name: "sudo", Version: "1.8.23-9.el7", Format: rpmFormat},
+		{Name: "bash", Version: "4.2.46-34.el7", Format: rpmFormat},
+	}, packages)
+}
@@ -0,0 +1,297 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// The rpm database is a Berkeley DB hash database whose values are the headers of the installed packages,
+// by header instance number. Only the layout needed to list these headers is decoded: the metadata page,
+// the hash pages holding the small headers, and the overflow pages holding the headers which are too
+// large to be stored in the hash pages.
+const (
+	bdbHashMagic = 0x061561
+
+	bdbMetadataSize   = 72
+	bdbPageHeaderSize = 26
+
+	bdbPageTypeHashUnsorted = 2
+	bdbPageTypeOverflow     = 7
+	bdbPageTypeHash         = 13
+
+	bdbItemTypeKeyData = 1
+	bdbItemTypeOffPage = 3
+
+	rpmTagName    = 1000
+	rpmTagVersion = 1001
+	rpmTagRelease = 1002
+	rpmTagEpoch   = 1003
+
+	rpmTypeInt32  = 4
+	rpmTypeString = 6
+
+	// rpmMaxHeaderEntries bounds the number of entries of a header to detect corrupted headers
+	rpmMaxHeaderEntries = 0xffff
+)
+
+// bdbPageHeader is the header of the pages of a Berkeley DB database
+type bdbPageHeader struct {
+	NextPageNo     uint32
+	NumEntries     uint16
+	FreeAreaOffset uint16
+	PageType       uint8
+}
+
+type bdbReader struct {
+	r         io.ReaderAt
+	byteOrder binary.ByteOrder
+	pageSize  uint32
+	lastPage  uint32
+}
+
+func newBdbReader(r io.ReaderAt) (*bdbReader, error) {
+	metadata := make([]byte, bdbMetadataSize)
+	if _, err := r.ReadAt(metadata, 0); err != nil {
+		return nil, err
+	}
+
+	// the database is stored in the byte order of the host that created it
+	var byteOrder binary.ByteOrder
+	switch {
+	case binary.LittleEndian.Uint32(metadata[12:16]) == bdbHashMagic:
+		byteOrder = binary.LittleEndian
+	case binary.BigEndian.Uint32(metadata[12:16]) == bdbHashMagic:
+		byteOrder = binary.BigEndian
+	default:
+		return nil, errors.New("not a Berkeley DB hash database")
+	}
+
+	pageSize := byteOrder.Uint32(metadata[20:24])
+	if pageSize < bdbMetadataSize || pageSize > 64*1024 {
+		return nil, fmt.Errorf("invalid page size %d", pageSize)
+	}
+
+	return &bdbReader{
+		r:         r,
+		byteOrder: byteOrder,
+		pageSize:  pageSize,
+		lastPage:  byteOrder.Uint32(metadata[32:36]),
+	}, nil
+}
+
+func (b *bdbReader) readPage(pageNo uint32) ([]byte, *bdbPageHeader, error) {
+	page := make([]byte, b.pageSize)
+	if _, err := b.r.ReadAt(page, int64(pageNo)*int64(b.pageSize)); err != nil {
+		return nil, nil, err
+	}
+
+	return page, &bdbPageHeader{
+		NextPageNo:     b.byteOrder.Uint32(page[16:20]),
+		NumEntries:     b.byteOrder.Uint16(page[20:22]),
+		FreeAreaOffset: b.byteOrder.Uint16(page[22:24]),
+		PageType:       page[25],
+	}, nil
+}
+
+// readOverflow reads a value stored in a chain of overflow pages
+func (b *bdbReader) readOverflow(pageNo uint32, length uint32) ([]byte, error) {
+	value := make([]byte, 0, length)
+	for visited := uint32(0); pageNo != 0; visited++ {
+		if pageNo > b.lastPage || visited > b.lastPage {
+			return nil, fmt.Errorf("invalid overflow page %d", pageNo)
+		}
+
+		page, header, err := b.readPage(pageNo)
+		if err != nil {
+			return nil, err
+		}
+		if header.PageType != bdbPageTypeOverflow {
+			return nil, fmt.Errorf("unexpected type %d for overflow page %d", header.PageType, pageNo)
+		}
+
+		// the free area offset of an overflow page is the length of the data it holds
+		end := bdbPageHeaderSize + uint32(header.FreeAreaOffset)
+		if end > b.pageSize {
+			return nil, fmt.Errorf("invalid data length for overflow page %d", pageNo)
+		}
+
+		value = append(value, page[bdbPageHeaderSize:end]...)
+		pageNo = header.NextPageNo
+	}
+
+	if uint32(len(value)) != length {
+		return nil, fmt.Errorf("expected %d bytes of overflow data, got %d", length, len(value))
+	}
+	return value, nil
+}
+
+// item returns the type and the data of the item of a hash page at the given index. The items are
+// stored from the end of the page, so an item ends where the item of the previous index starts.
+func (b *bdbReader) item(page []byte, index int) (uint8, []byte, error) {
+	indexOffset := bdbPageHeaderSize + 2*index
+	if indexOffset+2 > len(page) {
+		return 0, nil, fmt.Errorf("invalid item index %d", index)
+	}
+
+	start := int(b.byteOrder.Uint16(page[indexOffset : indexOffset+2]))
+	end := len(page)
+	if index > 0 {
+		end = int(b.byteOrder.Uint16(page[indexOffset-2 : indexOffset]))
+	}
+	if start >= end || end > len(page) {
+		return 0, nil, fmt.Errorf("invalid offset for item %d", index)
+	}
+
+	return page[start], page[start+1 : end], nil
+}
+
+// values calls fn with the key and the value of every key/value pair of the database
+func (b *bdbReader) values(fn func(key, value []byte) error) error {
+	for pageNo := uint32(1); pageNo <= b.lastPage; pageNo++ {
+		page, header, err := b.readPage(pageNo)
+		if err != nil {
+			return err
+		}
+
+		if header.PageType != bdbPageTypeHash && header.PageType != bdbPageTypeHashUnsorted {
+			continue
+		}
+
+		// the items alternate keys and values
+		for i := 1; i < int(header.NumEntries); i += 2 {
+			keyType, key, err := b.item(page, i-1)
+			if err != nil {
+				return fmt.Errorf("hash page %d: %w", pageNo, err)
+			}
+			if keyType != bdbItemTypeKeyData {
+				key = nil
+			}
+
+			itemType, item, err := b.item(page, i)
+			if err != nil {
+				return fmt.Errorf("hash page %d: %w", pageNo, err)
+			}
+
+			var value []byte
+			switch itemType {
+			case bdbItemTypeKeyData:
+				value = item
+			case bdbItemTypeOffPage:
+				// the page number and length of the value follow 3 bytes of padding
+				if len(item) < 11 {
+					return fmt.Errorf("hash page %d: invalid off-page item %d", pageNo, i)
+				}
+				value, err = b.readOverflow(b.byteOrder.Uint32(item[3:7]), b.byteOrder.Uint32(item[7:11]))
+				if err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("hash page %d: unsupported type %d for item %d", pageNo, itemType, i)
+			}
+
+			if err := fn(key, value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseRpmHeader returns the package described by a rpm header
+func parseRpmHeader(data []byte) (*installedPackage, error) {
+	if len(data) < 8 {
+		return nil, errors.New("rpm header too short")
+	}
+
+	entries := binary.BigEndian.Uint32(data[0:4])
+	storeSize := binary.BigEndian.Uint32(data[4:8])
+	if entries > rpmMaxHeaderEntries || 8+16*uint64(entries)+uint64(storeSize) > uint64(len(data)) {
+		return nil, errors.New("invalid rpm header")
+	}
+
+	store := data[8+16*entries : 8+16*entries+storeSize]
+
+	var name, version, release, epoch string
+	for i := uint32(0); i < entries; i++ {
+		entry := data[8+16*i : 8+16*(i+1)]
+		tag := binary.BigEndian.Uint32(entry[0:4])
+		kind := binary.BigEndian.Uint32(entry[4:8])
+		offset := binary.BigEndian.Uint32(entry[8:12])
+
+		if offset >= storeSize {
+			continue
+		}
+
+		switch {
+		case kind == rpmTypeString && (tag == rpmTagName || tag == rpmTagVersion || tag == rpmTagRelease):
+			value := store[offset:]
+			if end := bytes.IndexByte(value, 0); end >= 0 {
+				value = value[:end]
+			}
+
+			switch tag {
+			case rpmTagName:
+				name = string(value)
+			case rpmTagVersion:
+				version = string(value)
+			case rpmTagRelease:
+				release = string(value)
+			}
+		case kind == rpmTypeInt32 && tag == rpmTagEpoch && offset+4 <= storeSize:
+			epoch = fmt.Sprintf("%d", binary.BigEndian.Uint32(store[offset:offset+4]))
+		}
+	}
+
+	if name == "" {
+		return nil, errors.New("rpm header without name")
+	}
+
+	if release != "" {
+		version += "-" + release
+	}
+	if epoch != "" {
+		version = epoch + ":" + version
+	}
+
+	return &installedPackage{
+		Name:    name,
+		Version: version,
+		Format:  rpmFormat,
+	}, nil
+}
+
+// parseRpmPackages lists the installed packages of a rpm Berkeley DB database
+func parseRpmPackages(r io.ReaderAt, size int64) (installedPackages, error) {
+	db, err := newBdbReader(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(db.lastPage+1)*int64(db.pageSize) > size {
+		return nil, errors.New("truncated Berkeley DB database")
+	}
+
+	var packages installedPackages
+	err = db.values(func(key, value []byte) error {
+		// the header instance 0 holds the number of the last instance, not a header
+		if bytes.Equal(key, []byte{0, 0, 0, 0}) {
+			return nil
+		}
+
+		pkg, err := parseRpmHeader(value)
+		if err != nil {
+			return err
+		}
+		packages = append(packages, pkg)
+		return nil
+	})
+	return packages, err
+}
@@ -0,0 +1,220 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/util/cache"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	packageCacheKey string = "compliance-packages"
+
+	dpkgStatusPath   = "/var/lib/dpkg/status"
+	rpmPackagesPath  = "/var/lib/rpm/Packages"
+	apkInstalledPath = "/lib/apk/db/installed"
+
+	// rpm databases in the sqlite and ndb formats, used by rpm 4.16 and later, which are not supported
+	rpmSqlitePath = "/var/lib/rpm/rpmdb.sqlite"
+	rpmNdbPath    = "/var/lib/rpm/Packages.db"
+)
+
+// errNoPackageDatabase is returned when none of the supported package databases is found on the host, in
+// which case the packages can't be reported as not installed
+var errNoPackageDatabase = errors.New("no supported package database found")
+
+// packageFormat is the format of a package, which defines how its versions are compared
+type packageFormat string
+
+const (
+	dpkgFormat = packageFormat("dpkg")
+	rpmFormat  = packageFormat("rpm")
+	apkFormat  = packageFormat("apk")
+)
+
+type installedPackage struct {
+	Name    string
+	Version string
+	Format  packageFormat
+}
+
+type installedPackages []*installedPackage
+
+func (p installedPackages) findPackagesByName(name string) installedPackages {
+	var results installedPackages
+	for _, pkg := range p {
+		if pkg.Name == name {
+			results = append(results, pkg)
+		}
+	}
+	return results
+}
+
+// packageDatabase is a database of installed packages, read by its parse function
+type packageDatabase struct {
+	path  string
+	parse func(r io.ReaderAt, size int64) (installedPackages, error)
+}
+
+var packageDatabases = []packageDatabase{
+	{path: dpkgStatusPath, parse: readerParser(parseDpkgStatus)},
+	{path: rpmPackagesPath, parse: parseRpmPackages},
+	{path: apkInstalledPath, parse: readerParser(parseApkInstalled)},
+}
+
+// unsupportedPackageDatabases lists the package databases whose presence prevents from listing all the packages
+var unsupportedPackageDatabases = []string{
+	rpmSqlitePath,
+	rpmNdbPath,
+}
+
+func readerParser(parse func(r io.Reader) (installedPackages, error)) func(r io.ReaderAt, size int64) (installedPackages, error) {
+	return func(r io.ReaderAt, size int64) (installedPackages, error) {
+		return parse(io.NewSectionReader(r, 0, size))
+	}
+}
+
+// fetchInstalledPackages lists the packages of all the package databases found on the host. It fails when none
+// is found, or when an unsupported one is, as the packages it holds would be reported as not installed.
+func fetchInstalledPackages(e env.Env) (installedPackages, error) {
+	for _, dbPath := range unsupportedPackageDatabases {
+		path := e.NormalizeToHostRoot(dbPath)
+		if _, err := os.Stat(path); err == nil {
+			return nil, fmt.Errorf("unsupported package database %s", path)
+		} else if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+
+	var packages installedPackages
+	var found bool
+	for _, db := range packageDatabases {
+		path := e.NormalizeToHostRoot(db.path)
+
+		f, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		info, err := f.Stat()
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+
+		log.Debugf("Reading installed packages from %s", path)
+		dbPackages, err := db.parse(f, info.Size())
+		f.Close()
+		if err != nil {
+			return nil, log.Errorf("failed to read installed packages from %s: %v", path, err)
+		}
+
+		packages = append(packages, dbPackages...)
+		found = true
+	}
+
+	if !found {
+		return nil, errNoPackageDatabase
+	}
+	return packages, nil
+}
+
+func getInstalledPackages(e env.Env, maxAge time.Duration) (installedPackages, error) {
+	if value, found := cache.Cache.Get(packageCacheKey); found {
+		return value.(installedPackages), nil
+	}
+
+	log.Debug("Updating package cache")
+	packages, err := fetchInstalledPackages(e)
+	if err != nil {
+		return nil, err
+	}
+
+	cache.Cache.Set(packageCacheKey, packages, maxAge)
+	return packages, nil
+}
+
+// readStanzas calls fn with the `Key: value` fields of each paragraph of a RFC 822 like file, such as the dpkg status file
+func readStanzas(r io.Reader, fn func(fields map[string]string)) error {
+	fields := make(map[string]string)
+
+	bs := bufio.NewScanner(r)
+	bs.Buffer(make([]byte, 64*1024), 1024*1024)
+	for bs.Scan() {
+		line := bs.Bytes()
+		if len(bytes.TrimSpace(line)) == 0 {
+			if len(fields) > 0 {
+				fn(fields)
+				fields = make(map[string]string)
+			}
+			continue
+		}
+
+		// continuation lines, such as the ones of the descriptions, are not needed
+		if line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+
+		parts := strings.SplitN(string(line), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields[parts[0]] = strings.TrimSpace(parts[1])
+	}
+
+	if len(fields) > 0 {
+		fn(fields)
+	}
+	return bs.Err()
+}
+
+// parseDpkgStatus lists the installed packages of a dpkg status file
+func parseDpkgStatus(r io.Reader) (installedPackages, error) {
+	var packages installedPackages
+	err := readStanzas(r, func(fields map[string]string) {
+		// the status file also lists the removed packages whose configuration files are still present
+		if status := strings.Fields(fields["Status"]); len(status) != 3 || status[2] != "installed" {
+			return
+		}
+
+		if name := fields["Package"]; name != "" {
+			packages = append(packages, &installedPackage{
+				Name:    name,
+				Version: fields["Version"],
+				Format:  dpkgFormat,
+			})
+		}
+	})
+	return packages, err
+}
+
+// parseApkInstalled lists the installed packages of an apk installed database
+func parseApkInstalled(r io.Reader) (installedPackages, error) {
+	var packages installedPackages
+	err := readStanzas(r, func(fields map[string]string) {
+		if name := fields["P"]; name != "" {
+			packages = append(packages, &installedPackage{
+				Name:    name,
+				Version: fields["V"],
+				Format:  apkFormat,
+			})
+		}
+	})
+	return packages, err
+}
@@ -0,0 +1,340 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"strconv"
+	"strings"
+)
+
+// compareVersions compares two versions following the rules of the package format, returning
+// -1, 0 or 1 when the first one is respectively lower, equal or greater than the second one
+func compareVersions(format packageFormat, a, b string) int {
+	var result int
+	switch format {
+	case dpkgFormat:
+		result = compareDpkgVersions(a, b)
+	case rpmFormat:
+		result = compareRpmVersions(a, b)
+	case apkFormat:
+		result = compareApkVersions(a, b)
+	default:
+		result = strings.Compare(a, b)
+	}
+
+	switch {
+	case result < 0:
+		return -1
+	case result > 0:
+		return 1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// splitEpoch splits the epoch, which defaults to 0, from a version
+func splitEpoch(version string) (int, string) {
+	if i := strings.IndexByte(version, ':'); i >= 0 {
+		epoch, _ := strconv.Atoi(version[:i])
+		return epoch, version[i+1:]
+	}
+	return 0, version
+}
+
+// splitRevision splits the version of a package from its revision, or release, after the last dash
+func splitRevision(version string) (string, string) {
+	if i := strings.LastIndexByte(version, '-'); i >= 0 {
+		return version[:i], version[i+1:]
+	}
+	return version, ""
+}
+
+// compareDpkgVersions compares two `[epoch:]upstream[-revision]` versions, as dpkg does
+func compareDpkgVersions(a, b string) int {
+	epochA, a := splitEpoch(a)
+	epochB, b := splitEpoch(b)
+	if epochA != epochB {
+		return epochA - epochB
+	}
+
+	upstreamA, revisionA := splitRevision(a)
+	upstreamB, revisionB := splitRevision(b)
+	if result := dpkgVerrevcmp(upstreamA, upstreamB); result != 0 {
+		return result
+	}
+	return dpkgVerrevcmp(revisionA, revisionB)
+}
+
+// dpkgOrder returns the weight of a character of a non digit part of a dpkg version: letters sort
+// before the other characters, and the tilde before anything, even the end of the part
+func dpkgOrder(s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+
+	switch c := s[i]; {
+	case isDigit(c):
+		return 0
+	case isLetter(c):
+		return int(c)
+	case c == '~':
+		return -1
+	default:
+		return int(c) + 256
+	}
+}
+
+func dpkgVerrevcmp(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		for (i < len(a) && !isDigit(a[i])) || (j < len(b) && !isDigit(b[j])) {
+			if ac, bc := dpkgOrder(a, i), dpkgOrder(b, j); ac != bc {
+				return ac - bc
+			}
+			i++
+			j++
+		}
+
+		for i < len(a) && a[i] == '0' {
+			i++
+		}
+		for j < len(b) && b[j] == '0' {
+			j++
+		}
+
+		firstDiff := 0
+		for i < len(a) && isDigit(a[i]) && j < len(b) && isDigit(b[j]) {
+			if firstDiff == 0 {
+				firstDiff = int(a[i]) - int(b[j])
+			}
+			i++
+			j++
+		}
+
+		if i < len(a) && isDigit(a[i]) {
+			return 1
+		}
+		if j < len(b) && isDigit(b[j]) {
+			return -1
+		}
+		if firstDiff != 0 {
+			return firstDiff
+		}
+	}
+	return 0
+}
+
+// compareRpmVersions compares two `[epoch:]version[-release]` versions, as rpm does
+func compareRpmVersions(a, b string) int {
+	epochA, a := splitEpoch(a)
+	epochB, b := splitEpoch(b)
+	if epochA != epochB {
+		return epochA - epochB
+	}
+
+	versionA, releaseA := splitRevision(a)
+	versionB, releaseB := splitRevision(b)
+	if result := rpmvercmp(versionA, versionB); result != 0 {
+		return result
+	}
+	return rpmvercmp(releaseA, releaseB)
+}
+
+func isAlnum(c byte) bool {
+	return isDigit(c) || isLetter(c)
+}
+
+// rpmvercmp compares the alternating numeric and alphabetic segments of two versions. Numeric
+// segments are greater than alphabetic ones, and a tilde sorts before anything, as in rpm.
+func rpmvercmp(a, b string) int {
+	if a == b {
+		return 0
+	}
+
+	for len(a) > 0 || len(b) > 0 {
+		for len(a) > 0 && !isAlnum(a[0]) && a[0] != '~' {
+			a = a[1:]
+		}
+		for len(b) > 0 && !isAlnum(b[0]) && b[0] != '~' {
+			b = b[1:]
+		}
+
+		if (len(a) > 0 && a[0] == '~') || (len(b) > 0 && b[0] == '~') {
+			if len(a) == 0 || a[0] != '~' {
+				return 1
+			}
+			if len(b) == 0 || b[0] != '~' {
+				return -1
+			}
+			a, b = a[1:], b[1:]
+			continue
+		}
+
+		if len(a) == 0 || len(b) == 0 {
+			break
+		}
+
+		matches := isLetter
+		isNum := isDigit(a[0])
+		if isNum {
+			matches = isDigit
+		}
+
+		var segA, segB string
+		segA, a = splitSegment(a, matches)
+		segB, b = splitSegment(b, matches)
+
+		if segB == "" {
+			// the segments are of different types
+			if isNum {
+				return 1
+			}
+			return -1
+		}
+
+		if isNum {
+			segA = strings.TrimLeft(segA, "0")
+			segB = strings.TrimLeft(segB, "0")
+			if len(segA) != len(segB) {
+				return len(segA) - len(segB)
+			}
+		}
+
+		if result := strings.Compare(segA, segB); result != 0 {
+			return result
+		}
+	}
+
+	switch {
+	case len(a) == 0 && len(b) == 0:
+		return 0
+	case len(a) == 0:
+		return -1
+	}
+	return 1
+}
+
+func splitSegment(s string, matches func(c byte) bool) (string, string) {
+	i := 0
+	for i < len(s) && matches(s[i]) {
+		i++
+	}
+	return s[:i], s[i:]
+}
+
+// apkSuffixes lists the suffixes of apk versions by order. The versions without suffix sort
+// after the pre-release suffixes and before the post-release ones.
+var apkSuffixes = map[string]int{
+	"alpha": -4,
+	"beta":  -3,
+	"pre":   -2,
+	"rc":    -1,
+	"cvs":   1,
+	"svn":   2,
+	"git":   3,
+	"hg":    4,
+	"p":     5,
+}
+
+type apkSuffix struct {
+	order  int
+	number int
+}
+
+type apkVersion struct {
+	numbers  []string
+	letter   byte
+	suffixes []apkSuffix
+	revision int
+}
+
+// parseApkVersion parses a `1.2.3[a][_suffix[N]...][-rN]` apk version
+func parseApkVersion(version string) apkVersion {
+	var v apkVersion
+
+	if i := strings.LastIndex(version, "-r"); i >= 0 {
+		if revision, err := strconv.Atoi(version[i+2:]); err == nil {
+			v.revision = revision
+			version = version[:i]
+		}
+	}
+
+	parts := strings.Split(version, "_")
+	for _, number := range strings.Split(parts[0], ".") {
+		if n := len(number); n > 0 && isLetter(number[n-1]) {
+			v.letter = number[n-1]
+			number = number[:n-1]
+		}
+		v.numbers = append(v.numbers, number)
+	}
+
+	for _, part := range parts[1:] {
+		name, number := splitSegment(part, isLetter)
+		suffix := apkSuffix{order: apkSuffixes[name]}
+		suffix.number, _ = strconv.Atoi(number)
+		v.suffixes = append(v.suffixes, suffix)
+	}
+
+	return v
+}
+
+// compareApkNumbers compares two components of an apk version. Components after the first one
+// with leading zeros are compared as decimal fractions, as apk does.
+func compareApkNumbers(a, b string, first bool) int {
+	if !first && (strings.HasPrefix(a, "0") || strings.HasPrefix(b, "0")) {
+		return strings.Compare(a, b)
+	}
+
+	na, _ := strconv.Atoi(a)
+	nb, _ := strconv.Atoi(b)
+	return na - nb
+}
+
+// compareApkVersions compares two apk versions
+func compareApkVersions(a, b string) int {
+	va, vb := parseApkVersion(a), parseApkVersion(b)
+
+	for i := 0; i < len(va.numbers) || i < len(vb.numbers); i++ {
+		if i >= len(va.numbers) {
+			return -1
+		}
+		if i >= len(vb.numbers) {
+			return 1
+		}
+		if result := compareApkNumbers(va.numbers[i], vb.numbers[i], i == 0); result != 0 {
+			return result
+		}
+	}
+
+	if va.letter != vb.letter {
+		return int(va.letter) - int(vb.letter)
+	}
+
+	for i := 0; i < len(va.suffixes) || i < len(vb.suffixes); i++ {
+		var sa, sb apkSuffix
+		if i < len(va.suffixes) {
+			sa = va.suffixes[i]
+		}
+		if i < len(vb.suffixes) {
+			sb = vb.suffixes[i]
+		}
+		if sa.order != sb.order {
+			return sa.order - sb.order
+		}
+		if sa.number != sb.number {
+			return sa.number - sb.number
+		}
+	}
+
+	return va.revision - vb.revision
+}
@@ -0,0 +1,54 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"fmt"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		format   packageFormat
+		a, b     string
+		expected int
+	}{
+		{dpkgFormat, "1.1.1f-1ubuntu2.1", "1.1.1g", -1},
+		{dpkgFormat, "1.1.1g-1", "1.1.1g", 1},
+		{dpkgFormat, "1.8.31-1ubuntu1.1", "1.8.31-1ubuntu1.1", 0},
+		{dpkgFormat, "1:0.9", "2.0", 1},
+		{dpkgFormat, "1.0~rc1", "1.0", -1},
+		{dpkgFormat, "1.0~rc1", "1.0~beta", 1},
+		{dpkgFormat, "1.10", "1.9", 1},
+		{dpkgFormat, "1.01", "1.1", 0},
+		{dpkgFormat, "1.0a", "1.0+", -1},
+		{rpmFormat, "1.0.2k-19.el7", "1.0.2k-16.el7", 1},
+		{rpmFormat, "1:1.0.2k-19.el7", "1.1.1g", 1},
+		{rpmFormat, "1.8.23-9.el7", "1.8.23-10.el7", -1},
+		{rpmFormat, "2.0", "2.0.1", -1},
+		{rpmFormat, "2.0a", "2.0", 1},
+		{rpmFormat, "1.0~rc1", "1.0", -1},
+		{rpmFormat, "1.0.010", "1.0.10", 0},
+		{rpmFormat, "1.0a", "1.0.1", -1},
+		{apkFormat, "1.1.1g-r0", "1.1.1f-r1", 1},
+		{apkFormat, "1.1.24-r9", "1.1.24-r10", -1},
+		{apkFormat, "1.2_rc1", "1.2", -1},
+		{apkFormat, "1.2_p1", "1.2", 1},
+		{apkFormat, "1.2_alpha", "1.2_beta", -1},
+		{apkFormat, "1.2.3", "1.2", 1},
+		{apkFormat, "1.02", "1.1", -1},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%s %s %s", test.format, test.a, test.b), func(t *testing.T) {
+			assert := assert.New(t)
+			assert.Equal(test.expected, compareVersions(test.format, test.a, test.b))
+			assert.Equal(-test.expected, compareVersions(test.format, test.b, test.a))
+		})
+	}
+}
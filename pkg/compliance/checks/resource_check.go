@@ -160,6 +160,12 @@ func resourceKindToResolverAndFields(kind compliance.ResourceKind) (resolveFunc,
 		return resolveDocker, dockerReportedFields, nil
 	case compliance.KindKubernetes:
 		return resolveKubeapiserver, kubeResourceReportedFields, nil
+	case compliance.KindPackage:
+		return resolvePackage, packageReportedFields, nil
+	case compliance.KindSysctl:
+		return resolveSysctl, sysctlReportedFields, nil
+	case compliance.KindSystemdUnit:
+		return resolveSystemdUnit, systemdUnitReportedFields, nil
 	default:
 		return nil, nil, ErrResourceKindNotSupported
 	}
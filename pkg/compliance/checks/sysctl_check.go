@@ -0,0 +1,94 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const procSysPath = "/proc/sys"
+
+var sysctlReportedFields = []string{
+	compliance.SysctlFieldName,
+	compliance.SysctlFieldValue,
+}
+
+// ErrSysctlNotFound is returned when a kernel parameter cannot be found
+var ErrSysctlNotFound = errors.New("sysctl not found")
+
+func resolveSysctl(_ context.Context, e env.Env, id string, res compliance.Resource) (interface{}, error) {
+	if res.Sysctl == nil {
+		return nil, fmt.Errorf("%s: expecting sysctl resource in sysctl check", id)
+	}
+
+	sysctl := res.Sysctl
+
+	path, err := sysctlPath(sysctl.Name)
+	if err != nil {
+		return nil, wrapErrorWithID(id, err)
+	}
+
+	path = e.NormalizeToHostRoot(path)
+
+	log.Debugf("%s: running sysctl check: %s", id, path)
+
+	var data []byte
+	if strings.HasPrefix(sysctl.Name, "net.") {
+		data, err = readNetSysctl(e, path)
+	} else {
+		data, err = ioutil.ReadFile(path)
+	}
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, wrapErrorWithID(id, fmt.Errorf("%w: %s", ErrSysctlNotFound, sysctl.Name))
+		}
+		return nil, wrapErrorWithID(id, err)
+	}
+
+	return &eval.Instance{
+		Vars: eval.VarMap{
+			compliance.SysctlFieldName:  sysctl.Name,
+			compliance.SysctlFieldValue: normalizeSysctlValue(string(data)),
+		},
+	}, nil
+}
+
+// sysctlPath returns the path of a kernel parameter under /proc/sys. As sysctl does, the
+// dots separate the components of the name, and slashes stand for the dots of a component,
+// such as the ones of VLAN interface names (`net.ipv4.conf.eth0/100.forwarding`).
+func sysctlPath(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("sysctl resource is missing name")
+	}
+
+	components := strings.Split(name, ".")
+	for i, component := range components {
+		component = strings.Replace(component, "/", ".", -1)
+		if component == "" || component == "." || component == ".." {
+			return "", fmt.Errorf("invalid sysctl name %q", name)
+		}
+		components[i] = component
+	}
+
+	return filepath.Join(append([]string{procSysPath}, components...)...), nil
+}
+
+// normalizeSysctlValue trims the value of a kernel parameter and separates its fields,
+// such as the bounds of `net.ipv4.ip_local_port_range`, with single spaces
+func normalizeSysctlValue(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
@@ -0,0 +1,140 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestSysctlCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource compliance.Resource
+
+		expectReport *compliance.Report
+		expectError  error
+	}{
+		{
+			name: "ip forwarding disabled",
+			resource: compliance.Resource{
+				Sysctl: &compliance.Sysctl{
+					Name: "net.ipv4.ip_forward",
+				},
+				Condition: `sysctl.value == "0"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"sysctl.name":  "net.ipv4.ip_forward",
+					"sysctl.value": "0",
+				},
+			},
+		},
+		{
+			name: "address space layout randomization disabled",
+			resource: compliance.Resource{
+				Sysctl: &compliance.Sysctl{
+					Name: "kernel.randomize_va_space",
+				},
+				Condition: `sysctl.value == "0"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: false,
+				Data: event.Data{
+					"sysctl.name":  "kernel.randomize_va_space",
+					"sysctl.value": "2",
+				},
+			},
+		},
+		{
+			name: "multiple values",
+			resource: compliance.Resource{
+				Sysctl: &compliance.Sysctl{
+					Name: "net.ipv4.ip_local_port_range",
+				},
+				Condition: `sysctl.value == "32768 60999"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"sysctl.name":  "net.ipv4.ip_local_port_range",
+					"sysctl.value": "32768 60999",
+				},
+			},
+		},
+		{
+			name: "dots in component",
+			resource: compliance.Resource{
+				Sysctl: &compliance.Sysctl{
+					Name: "net.ipv4.conf.eth0/100.forwarding",
+				},
+				Condition: `sysctl.value == "0"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: false,
+				Data: event.Data{
+					"sysctl.name":  "net.ipv4.conf.eth0/100.forwarding",
+					"sysctl.value": "1",
+				},
+			},
+		},
+		{
+			name: "sysctl not found",
+			resource: compliance.Resource{
+				Sysctl: &compliance.Sysctl{
+					Name: "net.ipv6.conf.all.forwarding",
+				},
+				Condition: `sysctl.value == "0"`,
+			},
+			expectError: fmt.Errorf("rule-id: %w", fmt.Errorf("%w: net.ipv6.conf.all.forwarding", ErrSysctlNotFound)),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			env := &mocks.Env{}
+			env.On("NormalizeToHostRoot", mock.AnythingOfType("string")).Return(func(path string) string {
+				return filepath.Join("./testdata/sysctl", path)
+			})
+			defer env.AssertExpectations(t)
+
+			sysctlCheck, err := newResourceCheck(env, "rule-id", test.resource)
+			assert.NoError(err)
+
+			report, err := sysctlCheck.check(env)
+			assert.Equal(test.expectReport, report)
+			assert.Equal(test.expectError, err)
+			if test.expectError != nil {
+				assert.True(errors.Is(err, ErrSysctlNotFound))
+			}
+		})
+	}
+}
+
+func TestSysctlPath(t *testing.T) {
+	assert := assert.New(t)
+
+	path, err := sysctlPath("net.ipv4.conf.eth0/100.forwarding")
+	assert.NoError(err)
+	assert.Equal("/proc/sys/net/ipv4/conf/eth0.100/forwarding", path)
+
+	for _, name := range []string{"", "net..ipv4", "net.ipv4./", "kernel.//.//.etc"} {
+		_, err = sysctlPath(name)
+		assert.Error(err, name)
+	}
+}
@@ -0,0 +1,44 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package checks
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/vishvananda/netns"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// readNetSysctl reads a kernel parameter of the network stack. The parameters under /proc/sys/net are the
+// ones of the network namespace of the reading thread, so they are read from the network namespace of
+// the init process of the host, which the security agent can only join with CAP_SYS_ADMIN when it doesn't
+// run in the host network. The parameters are read from the current namespace if the processes of the
+// host can't be seen.
+func readNetSysctl(e env.Env, path string) ([]byte, error) {
+	hostNS, err := netns.GetFromPath(e.NormalizeToHostRoot("/proc/1/ns/net"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ioutil.ReadFile(path)
+		}
+		return nil, err
+	}
+	defer hostNS.Close()
+
+	var data []byte
+	var readErr error
+	if err := util.WithNS("", hostNS, func() {
+		data, readErr = ioutil.ReadFile(path)
+	}); err != nil {
+		return nil, fmt.Errorf("unable to join the network namespace of the host, which requires CAP_SYS_ADMIN or the host network: %w", err)
+	}
+	return data, readErr
+}
@@ -0,0 +1,19 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build !linux
+
+package checks
+
+import (
+	"io/ioutil"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+)
+
+// readNetSysctl reads a kernel parameter of the network stack
+func readNetSysctl(e env.Env, path string) ([]byte, error) {
+	return ioutil.ReadFile(path)
+}
@@ -0,0 +1,79 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+	"github.com/DataDog/datadog-agent/pkg/compliance/eval"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+var systemdUnitReportedFields = []string{
+	compliance.SystemdUnitFieldName,
+	compliance.SystemdUnitFieldPath,
+	compliance.SystemdUnitFieldLoaded,
+	compliance.SystemdUnitFieldEnabled,
+	compliance.SystemdUnitFieldMasked,
+	compliance.SystemdUnitFieldWantedBy,
+}
+
+func resolveSystemdUnit(_ context.Context, e env.Env, id string, res compliance.Resource) (interface{}, error) {
+	if res.SystemdUnit == nil {
+		return nil, fmt.Errorf("%s: expecting systemd unit resource in systemd unit check", id)
+	}
+
+	name := res.SystemdUnit.Name
+
+	log.Debugf("%s: running systemd unit check: %s", id, name)
+
+	unit, err := findSystemdUnit(e, name)
+	if err != nil {
+		return nil, wrapErrorWithID(id, err)
+	}
+
+	// a unit without unit file still resolves, so that rules can require its absence
+	if unit == nil {
+		unit = &systemdUnit{
+			name: name,
+		}
+	}
+
+	return &eval.Instance{
+		Vars: eval.VarMap{
+			compliance.SystemdUnitFieldName:     unit.name,
+			compliance.SystemdUnitFieldPath:     unit.path,
+			compliance.SystemdUnitFieldLoaded:   unit.path != "",
+			compliance.SystemdUnitFieldEnabled:  !unit.masked && len(unit.wantedBy) > 0,
+			compliance.SystemdUnitFieldMasked:   unit.masked,
+			compliance.SystemdUnitFieldWantedBy: unit.wantedBy,
+		},
+		Functions: eval.FunctionMap{
+			compliance.SystemdUnitFuncProperty: systemdUnitProperty(unit.properties),
+		},
+	}, nil
+}
+
+func systemdUnitProperty(properties unitProperties) eval.Function {
+	return func(_ *eval.Instance, args ...interface{}) (interface{}, error) {
+		if len(args) != 2 {
+			return nil, fmt.Errorf(`invalid number of arguments, expecting 2 got %d`, len(args))
+		}
+		section, ok := args[0].(string)
+		if !ok {
+			return nil, errors.New(`expecting string value for section argument`)
+		}
+		key, ok := args[1].(string)
+		if !ok {
+			return nil, errors.New(`expecting string value for key argument`)
+		}
+		return properties.get(section, key), nil
+	}
+}
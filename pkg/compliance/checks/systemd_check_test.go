@@ -0,0 +1,206 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/compliance/mocks"
+
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestSystemdUnitCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource compliance.Resource
+
+		expectReport *compliance.Report
+		expectError  error
+	}{
+		{
+			name: "enabled unit",
+			resource: compliance.Resource{
+				SystemdUnit: &compliance.SystemdUnit{
+					Name: "auditd.service",
+				},
+				Condition: `systemd.unit.enabled && "multi-user.target" in systemd.unit.wantedBy`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"systemd.unit.name":     "auditd.service",
+					"systemd.unit.path":     "/lib/systemd/system/auditd.service",
+					"systemd.unit.loaded":   true,
+					"systemd.unit.enabled":  true,
+					"systemd.unit.masked":   false,
+					"systemd.unit.wantedBy": []string{"multi-user.target"},
+				},
+			},
+		},
+		{
+			name: "property overridden by drop-in",
+			resource: compliance.Resource{
+				SystemdUnit: &compliance.SystemdUnit{
+					Name: "auditd.service",
+				},
+				Condition: `systemd.unit.property("Service", "Restart") == "no" && systemd.unit.property("Service", "ExecStart") == "/sbin/auditd -s enable"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"systemd.unit.name":     "auditd.service",
+					"systemd.unit.path":     "/lib/systemd/system/auditd.service",
+					"systemd.unit.loaded":   true,
+					"systemd.unit.enabled":  true,
+					"systemd.unit.masked":   false,
+					"systemd.unit.wantedBy": []string{"multi-user.target"},
+				},
+			},
+		},
+		{
+			name: "disabled unit",
+			resource: compliance.Resource{
+				SystemdUnit: &compliance.SystemdUnit{
+					Name: "rsync.service",
+				},
+				Condition: `!systemd.unit.enabled`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"systemd.unit.name":     "rsync.service",
+					"systemd.unit.path":     "/lib/systemd/system/rsync.service",
+					"systemd.unit.loaded":   true,
+					"systemd.unit.enabled":  false,
+					"systemd.unit.masked":   false,
+					"systemd.unit.wantedBy": []string(nil),
+				},
+			},
+		},
+		{
+			name: "masked unit",
+			resource: compliance.Resource{
+				SystemdUnit: &compliance.SystemdUnit{
+					Name: "avahi-daemon.service",
+				},
+				Condition: `systemd.unit.masked && !systemd.unit.enabled`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"systemd.unit.name":     "avahi-daemon.service",
+					"systemd.unit.path":     "",
+					"systemd.unit.loaded":   false,
+					"systemd.unit.enabled":  false,
+					"systemd.unit.masked":   true,
+					"systemd.unit.wantedBy": []string{"multi-user.target"},
+				},
+			},
+		},
+		{
+			name: "template instance",
+			resource: compliance.Resource{
+				SystemdUnit: &compliance.SystemdUnit{
+					Name: "getty@tty1.service",
+				},
+				Condition: `systemd.unit.enabled && systemd.unit.property("Service", "Type") == "idle"`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"systemd.unit.name":     "getty@tty1.service",
+					"systemd.unit.path":     "/lib/systemd/system/getty@.service",
+					"systemd.unit.loaded":   true,
+					"systemd.unit.enabled":  true,
+					"systemd.unit.masked":   false,
+					"systemd.unit.wantedBy": []string{"getty.target"},
+				},
+			},
+		},
+		{
+			name: "unit not found",
+			resource: compliance.Resource{
+				SystemdUnit: &compliance.SystemdUnit{
+					Name: "telnet.socket",
+				},
+				Condition: `!systemd.unit.loaded`,
+			},
+			expectReport: &compliance.Report{
+				Passed: true,
+				Data: event.Data{
+					"systemd.unit.name":     "telnet.socket",
+					"systemd.unit.path":     "",
+					"systemd.unit.loaded":   false,
+					"systemd.unit.enabled":  false,
+					"systemd.unit.masked":   false,
+					"systemd.unit.wantedBy": []string(nil),
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert := assert.New(t)
+
+			env := &mocks.Env{}
+			env.On("NormalizeToHostRoot", mock.AnythingOfType("string")).Return(func(path string) string {
+				return filepath.Join("./testdata/systemd", path)
+			})
+			defer env.AssertExpectations(t)
+
+			systemdCheck, err := newResourceCheck(env, "rule-id", test.resource)
+			assert.NoError(err)
+
+			report, err := systemdCheck.check(env)
+			assert.Equal(test.expectReport, report)
+			assert.Equal(test.expectError, err)
+		})
+	}
+}
+
+func TestParseSystemdUnitFile(t *testing.T) {
+	assert := assert.New(t)
+
+	const unitFile = `
+# comment
+; other comment
+[Unit]
+Description=Test \
+  unit
+After=network.target
+After=local-fs.target
+
+[Service]
+Environment=A=1
+ExecStart=/bin/true
+ExecStart=
+ExecStart=/bin/false
+`
+
+	properties := make(unitProperties)
+	assert.NoError(parseSystemdUnitFile(strings.NewReader(unitFile), properties))
+	assert.Equal(unitProperties{
+		"Unit": {
+			"Description": {"Test  unit"},
+			"After":       {"network.target", "local-fs.target"},
+		},
+		"Service": {
+			"Environment": {"A=1"},
+			"ExecStart":   {"/bin/false"},
+		},
+	}, properties)
+	assert.Equal("local-fs.target", properties.get("Unit", "After"))
+	assert.Equal("", properties.get("Install", "WantedBy"))
+
+	assert.Error(parseSystemdUnitFile(strings.NewReader("Description=no section"), make(unitProperties)))
+}
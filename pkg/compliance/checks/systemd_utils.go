@@ -0,0 +1,301 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package checks
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks/env"
+)
+
+// systemdUnitPaths lists the directories of the system unit files, by order of precedence
+var systemdUnitPaths = []string{
+	"/etc/systemd/system",
+	"/run/systemd/system",
+	"/usr/local/lib/systemd/system",
+	"/lib/systemd/system",
+	"/usr/lib/systemd/system",
+}
+
+// systemdEnablementPaths lists the directories holding the symlinks created when enabling units,
+// persistently or until the next reboot
+var systemdEnablementPaths = []string{
+	"/etc/systemd/system",
+	"/run/systemd/system",
+}
+
+const (
+	systemdMaskPath = "/dev/null"
+
+	// systemdMaxSymlinks bounds the number of symlinks followed to find a unit file
+	systemdMaxSymlinks = 8
+)
+
+// unitProperties holds the values assigned to the keys of each section of a unit file
+type unitProperties map[string]map[string][]string
+
+// get returns the last value assigned to a key of a section
+func (p unitProperties) get(section, key string) string {
+	values := p[section][key]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+type systemdUnit struct {
+	name       string
+	path       string
+	masked     bool
+	wantedBy   []string
+	properties unitProperties
+}
+
+// systemdTemplateName returns the name of the template of a unit instance, such as `getty@.service`
+// for `getty@tty1.service`, or an empty string when the unit is not an instance
+func systemdTemplateName(name string) string {
+	at := strings.IndexByte(name, '@')
+	dot := strings.LastIndexByte(name, '.')
+	if at < 0 || dot < at || at+1 == dot {
+		return ""
+	}
+	return name[:at+1] + name[dot:]
+}
+
+// findSystemdUnit returns the unit named name, or nil when it has no unit file
+func findSystemdUnit(e env.Env, name string) (*systemdUnit, error) {
+	if name == "" || strings.ContainsRune(name, '/') {
+		return nil, fmt.Errorf("invalid systemd unit name %q", name)
+	}
+
+	names := []string{name}
+	if template := systemdTemplateName(name); template != "" {
+		names = append(names, template)
+	}
+
+	unit := &systemdUnit{
+		name:       name,
+		properties: make(unitProperties),
+	}
+
+	for _, n := range names {
+		for _, dir := range systemdUnitPaths {
+			path, masked, err := resolveSystemdUnitFile(e, filepath.Join(dir, n))
+			if err != nil {
+				return nil, err
+			}
+			if path == "" && !masked {
+				continue
+			}
+
+			unit.path = path
+			unit.masked = masked
+			break
+		}
+		if unit.path != "" || unit.masked {
+			break
+		}
+	}
+
+	if unit.path == "" && !unit.masked {
+		return nil, nil
+	}
+
+	wantedBy, err := findSystemdUnitEnablement(e, name)
+	if err != nil {
+		return nil, err
+	}
+	unit.wantedBy = wantedBy
+
+	if unit.masked {
+		return unit, nil
+	}
+
+	files := []string{unit.path}
+	dropIns, err := findSystemdUnitDropIns(e, names)
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, dropIns...)
+
+	for _, file := range files {
+		if err := readSystemdUnitFile(e.NormalizeToHostRoot(file), unit.properties); err != nil {
+			return nil, err
+		}
+	}
+
+	return unit, nil
+}
+
+// resolveSystemdUnitFile follows the symlinks from a unit file path, relative to the host root, and
+// returns the path of the unit file, or whether the unit is masked. An empty path and a false masked
+// flag are returned when there is no unit file.
+func resolveSystemdUnitFile(e env.Env, path string) (string, bool, error) {
+	for i := 0; i < systemdMaxSymlinks; i++ {
+		if path == systemdMaskPath {
+			return "", true, nil
+		}
+
+		info, err := os.Lstat(e.NormalizeToHostRoot(path))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return "", false, nil
+			}
+			return "", false, err
+		}
+
+		if info.Mode()&os.ModeSymlink == 0 {
+			// an empty unit file masks the unit too
+			if info.Size() == 0 {
+				return "", true, nil
+			}
+			return path, false, nil
+		}
+
+		// symlinks are resolved under the host root, as they may point to absolute paths
+		target, err := os.Readlink(e.NormalizeToHostRoot(path))
+		if err != nil {
+			return "", false, err
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		path = filepath.Clean(target)
+	}
+
+	return "", false, fmt.Errorf("too many levels of symbolic links for systemd unit file %s", path)
+}
+
+// findSystemdUnitEnablement returns the units wanting or requiring a unit through the symlinks
+// created when it was enabled, such as `multi-user.target` for `/etc/systemd/system/multi-user.target.wants/<unit>`
+func findSystemdUnitEnablement(e env.Env, name string) ([]string, error) {
+	var wantedBy []string
+	seen := make(map[string]bool)
+
+	for _, dir := range systemdEnablementPaths {
+		for _, suffix := range []string{".wants", ".requires"} {
+			matches, err := filepath.Glob(filepath.Join(e.NormalizeToHostRoot(dir), "*"+suffix, name))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, match := range matches {
+				unit := strings.TrimSuffix(filepath.Base(filepath.Dir(match)), suffix)
+				if !seen[unit] {
+					seen[unit] = true
+					wantedBy = append(wantedBy, unit)
+				}
+			}
+		}
+	}
+
+	sort.Strings(wantedBy)
+	return wantedBy, nil
+}
+
+// findSystemdUnitDropIns returns the `.conf` drop-in files of a unit, relative to the host root, sorted by
+// file name. A drop-in file overrides the files of the same name in the directories of lower precedence.
+func findSystemdUnitDropIns(e env.Env, names []string) ([]string, error) {
+	dropIns := make(map[string]string)
+
+	// the drop-ins of a unit override the ones of its template
+	for i := len(names) - 1; i >= 0; i-- {
+		for j := len(systemdUnitPaths) - 1; j >= 0; j-- {
+			dir := filepath.Join(systemdUnitPaths[j], names[i]+".d")
+
+			matches, err := filepath.Glob(filepath.Join(e.NormalizeToHostRoot(dir), "*.conf"))
+			if err != nil {
+				return nil, err
+			}
+
+			for _, match := range matches {
+				base := filepath.Base(match)
+				dropIns[base] = filepath.Join(dir, base)
+			}
+		}
+	}
+
+	var bases []string
+	for base := range dropIns {
+		bases = append(bases, base)
+	}
+	sort.Strings(bases)
+
+	var files []string
+	for _, base := range bases {
+		files = append(files, dropIns[base])
+	}
+	return files, nil
+}
+
+func readSystemdUnitFile(path string, properties unitProperties) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return parseSystemdUnitFile(f, properties)
+}
+
+// parseSystemdUnitFile adds the assignments of a unit file to the properties. As systemd does, an
+// empty assignment resets the values previously assigned to a key.
+func parseSystemdUnitFile(r io.Reader, properties unitProperties) error {
+	var section, continued string
+
+	bs := bufio.NewScanner(r)
+	for bs.Scan() {
+		line := strings.TrimSpace(bs.Text())
+
+		if continued == "" && (line == "" || line[0] == '#' || line[0] == ';') {
+			continue
+		}
+
+		// lines ending with a backslash continue on the next line
+		if strings.HasSuffix(line, `\`) {
+			continued += strings.TrimSuffix(line, `\`) + " "
+			continue
+		}
+		line = continued + line
+		continued = ""
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return fmt.Errorf("malformed section header %q", line)
+			}
+			section = line[1 : len(line)-1]
+			continue
+		}
+
+		if section == "" {
+			return errors.New("assignment outside of a section")
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if properties[section] == nil {
+			properties[section] = make(map[string][]string)
+		}
+
+		if value == "" {
+			properties[section][key] = nil
+			continue
+		}
+		properties[section][key] = append(properties[section][key], value)
+	}
+	return bs.Err()
+}
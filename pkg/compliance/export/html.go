@@ -0,0 +1,107 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package export
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+)
+
+// htmlTemplate renders a self-contained page, without external stylesheets or scripts
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"fields": func(r *RuleResult) []string { return r.fields() },
+	"value":  func(r *RuleResult, field string) string { return fmt.Sprintf("%v", r.Data[field]) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Compliance report - {{ .Hostname }}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #28292b; }
+h1 { font-size: 1.6em; }
+h2 { font-size: 1.2em; margin-top: 2em; }
+table { border-collapse: collapse; width: 100%; }
+th, td { border-bottom: 1px solid #e0e0e0; padding: 0.5em; text-align: left; vertical-align: top; }
+code { font-size: 0.9em; white-space: pre-wrap; }
+.summary span { display: inline-block; margin-right: 1em; padding: 0.3em 0.8em; border-radius: 4px; }
+.result { font-weight: bold; text-transform: uppercase; font-size: 0.85em; }
+.passed { background: #e3f5e8; color: #1d7a3a; }
+.failed { background: #fbe4e4; color: #b11f1f; }
+.error { background: #fdf0d9; color: #8a5a00; }
+.skipped { background: #eeeeee; color: #6b6b6b; }
+</style>
+</head>
+<body>
+<h1>Compliance report</h1>
+<p>Host <strong>{{ .Hostname }}</strong>, checked on {{ .Time }}</p>
+<p class="summary">
+<span class="passed">{{ .Passed }} passed</span>
+<span class="failed">{{ .Failed }} failed</span>
+<span class="error">{{ .Errors }} errors</span>
+<span class="skipped">{{ .Skipped }} skipped</span>
+</p>
+{{- range .Suites }}
+<h2>{{ .Name }}{{ if .Version }} {{ .Version }}{{ end }}</h2>
+<p><code>{{ .Source }}</code></p>
+<table>
+<tr><th>Rule</th><th>Result</th><th>Resources</th><th>Details</th></tr>
+{{- range .Rules }}
+<tr>
+<td><strong>{{ .RuleID }}</strong>{{ if .Description }}<br>{{ .Description }}{{ end }}</td>
+<td><span class="result {{ .Result }}">{{ .Result }}</span></td>
+<td>{{ range .Resources }}<div>{{ .Kind }}: <code>{{ .Condition }}</code></div>{{ end }}</td>
+<td>{{ if .Error }}<div>{{ .Error }}</div>{{ end }}{{ $rule := . }}{{ range fields . }}<div><code>{{ . }} = {{ value $rule . }}</code></div>{{ end }}</td>
+</tr>
+{{- end }}
+</table>
+{{- end }}
+</body>
+</html>
+`))
+
+type htmlSuite struct {
+	Name    string
+	Version string
+	Source  string
+	Rules   []*RuleResult
+}
+
+type htmlReport struct {
+	Hostname string
+	Time     string
+	Passed   int
+	Failed   int
+	Errors   int
+	Skipped  int
+	Suites   []htmlSuite
+}
+
+// writeHTML writes the report as a self-contained HTML summary
+func (r *Report) writeHTML(w io.Writer) error {
+	report := htmlReport{
+		Hostname: r.Hostname,
+		Time:     r.Time.UTC().Format(time.RFC1123),
+		Passed:   r.Count(event.Passed),
+		Failed:   r.Count(event.Failed),
+		Errors:   r.Count(event.Error),
+		Skipped:  r.Count(ResultSkipped),
+	}
+
+	for _, suite := range r.suites() {
+		report.Suites = append(report.Suites, htmlSuite{
+			Name:    suite.name,
+			Version: suite.version,
+			Source:  suite.source,
+			Rules:   suite.rules,
+		})
+	}
+
+	return htmlTemplate.Execute(w, report)
+}
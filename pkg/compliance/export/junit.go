@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package export
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name          `xml:"testsuites"`
+	Name     string            `xml:"name,attr"`
+	Tests    int               `xml:"tests,attr"`
+	Failures int               `xml:"failures,attr"`
+	Errors   int               `xml:"errors,attr"`
+	Skipped  int               `xml:"skipped,attr"`
+	Suites   []*junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string           `xml:"name,attr"`
+	Tests      int              `xml:"tests,attr"`
+	Failures   int              `xml:"failures,attr"`
+	Errors     int              `xml:"errors,attr"`
+	Skipped    int              `xml:"skipped,attr"`
+	Hostname   string           `xml:"hostname,attr,omitempty"`
+	Timestamp  string           `xml:"timestamp,attr"`
+	Properties []junitProperty  `xml:"properties>property,omitempty"`
+	Cases      []*junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Error     *junitMessage `xml:"error,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnit writes the report as JUnit XML, with a test suite per compliance suite and a test case per rule
+func (r *Report) writeJUnit(w io.Writer) error {
+	report := &junitTestSuites{
+		Name: "compliance",
+	}
+
+	for _, suite := range r.suites() {
+		testSuite := &junitTestSuite{
+			Name:      suite.name,
+			Hostname:  r.Hostname,
+			Timestamp: r.Time.UTC().Format(time.RFC3339),
+			Properties: []junitProperty{
+				{Name: "version", Value: suite.version},
+				{Name: "source", Value: suite.source},
+			},
+		}
+
+		for _, rule := range suite.rules {
+			testCase := &junitTestCase{
+				Name:      rule.RuleID,
+				ClassName: rule.Framework,
+				SystemOut: rule.details(),
+			}
+			if rule.Description != "" {
+				testCase.Name += ": " + rule.Description
+			}
+
+			message := &junitMessage{
+				Message: rule.message(),
+				Type:    rule.Result,
+				Text:    rule.details(),
+			}
+
+			switch rule.Result {
+			case event.Failed:
+				testCase.Failure = message
+				testSuite.Failures++
+			case event.Error:
+				testCase.Error = message
+				testSuite.Errors++
+			case ResultSkipped:
+				testCase.Skipped = &junitMessage{Message: message.Message}
+				testSuite.Skipped++
+			}
+
+			testSuite.Tests++
+			testSuite.Cases = append(testSuite.Cases, testCase)
+		}
+
+		report.Tests += testSuite.Tests
+		report.Failures += testSuite.Failures
+		report.Errors += testSuite.Errors
+		report.Skipped += testSuite.Skipped
+		report.Suites = append(report.Suites, testSuite)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package export writes the results of compliance checks as local reports
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// Format is the format of a compliance report
+type Format string
+
+const (
+	// FormatJUnit is used for JUnit XML reports
+	FormatJUnit = Format("junit")
+	// FormatSARIF is used for SARIF 2.1.0 reports
+	FormatSARIF = Format("sarif")
+	// FormatHTML is used for self-contained HTML summaries
+	FormatHTML = Format("html")
+)
+
+// Formats lists the supported report formats
+var Formats = []Format{FormatJUnit, FormatSARIF, FormatHTML}
+
+// ParseFormat returns the report format of the given name
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if string(format) == strings.ToLower(name) {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported report format %q, expecting one of %v", name, Formats)
+}
+
+// ResultSkipped is used for the rules which do not apply to the host
+const ResultSkipped = "skipped"
+
+// ResourceResult describes a resource of a rule and the expression evaluated against it
+type ResourceResult struct {
+	Kind      string `json:"kind"`
+	Condition string `json:"condition"`
+}
+
+// RuleResult describes the result of a compliance rule
+type RuleResult struct {
+	RuleID       string           `json:"rule_id"`
+	Description  string           `json:"description,omitempty"`
+	Framework    string           `json:"framework,omitempty"`
+	Suite        string           `json:"suite,omitempty"`
+	SuiteVersion string           `json:"suite_version,omitempty"`
+	Source       string           `json:"source,omitempty"`
+	Result       string           `json:"result"`
+	Error        string           `json:"error,omitempty"`
+	Resources    []ResourceResult `json:"resources,omitempty"`
+	Data         event.Data       `json:"data,omitempty"`
+}
+
+// Report holds the results of the compliance rules run on a host
+type Report struct {
+	Hostname string
+	Time     time.Time
+	Rules    []*RuleResult
+}
+
+// NewReport builds a report from the status of the checks run on a host. The suites the checks were
+// loaded from are read again for the resources and conditions of their rules.
+func NewReport(hostname string, t time.Time, statuses compliance.CheckStatusList) *Report {
+	report := &Report{
+		Hostname: hostname,
+		Time:     t,
+	}
+
+	suites := make(map[string]*compliance.Suite)
+	for _, status := range statuses {
+		result := &RuleResult{
+			RuleID:       status.RuleID,
+			Description:  status.Description,
+			Framework:    status.Framework,
+			SuiteVersion: status.Version,
+			Source:       status.Source,
+		}
+
+		suite, found := suites[status.Source]
+		if !found {
+			var err error
+			if suite, err = compliance.ParseSuite(status.Source); err != nil {
+				log.Warnf("Failed to read suite from %s: %v", status.Source, err)
+			}
+			suites[status.Source] = suite
+		}
+
+		if suite != nil {
+			result.Suite = suite.Meta.Name
+			for _, rule := range suite.Rules {
+				if rule.ID != status.RuleID {
+					continue
+				}
+				for _, resource := range rule.Resources {
+					result.Resources = append(result.Resources, ResourceResult{
+						Kind:      string(resource.Kind()),
+						Condition: resource.Condition,
+					})
+				}
+			}
+		}
+
+		switch {
+		case status.InitError == checks.ErrRuleDoesNotApply:
+			result.Result = ResultSkipped
+		case status.InitError != nil:
+			result.Result = event.Error
+			result.Error = status.InitError.Error()
+		case status.LastEvent == nil:
+			result.Result = event.Error
+			result.Error = "check did not report any result"
+		default:
+			result.Result = status.LastEvent.Result
+			if data, ok := status.LastEvent.Data.(event.Data); ok {
+				result.Data = data
+				if errorData, ok := data["error"].(string); ok && result.Result == event.Error {
+					result.Error = errorData
+				}
+			}
+		}
+
+		report.Rules = append(report.Rules, result)
+	}
+
+	return report
+}
+
+// Count returns the number of rules with the given result
+func (r *Report) Count(result string) int {
+	count := 0
+	for _, rule := range r.Rules {
+		if rule.Result == result {
+			count++
+		}
+	}
+	return count
+}
+
+// Failed returns the number of rules which failed or could not be evaluated
+func (r *Report) Failed() int {
+	return r.Count(event.Failed) + r.Count(event.Error)
+}
+
+// Write writes the report in the given format
+func (r *Report) Write(w io.Writer, format Format) error {
+	switch format {
+	case FormatJUnit:
+		return r.writeJUnit(w)
+	case FormatSARIF:
+		return r.writeSARIF(w)
+	case FormatHTML:
+		return r.writeHTML(w)
+	default:
+		return fmt.Errorf("unsupported report format %q", format)
+	}
+}
+
+// suiteResults groups the results of the rules of a suite
+type suiteResults struct {
+	name    string
+	version string
+	source  string
+	rules   []*RuleResult
+}
+
+// suites returns the results of the rules grouped by suite, in the order the suites were loaded
+func (r *Report) suites() []*suiteResults {
+	var suites []*suiteResults
+	bySource := make(map[string]*suiteResults)
+
+	for _, rule := range r.Rules {
+		suite, found := bySource[rule.Source]
+		if !found {
+			name := rule.Suite
+			if name == "" {
+				name = rule.Framework
+			}
+			suite = &suiteResults{
+				name:    name,
+				version: rule.SuiteVersion,
+				source:  rule.Source,
+			}
+			bySource[rule.Source] = suite
+			suites = append(suites, suite)
+		}
+		suite.rules = append(suite.rules, rule)
+	}
+
+	return suites
+}
+
+// message returns a human readable summary of the result of a rule
+func (r *RuleResult) message() string {
+	switch r.Result {
+	case event.Passed:
+		return fmt.Sprintf("%s: rule passed", r.RuleID)
+	case event.Failed:
+		return fmt.Sprintf("%s: rule failed", r.RuleID)
+	case ResultSkipped:
+		return fmt.Sprintf("%s: rule does not apply to this host", r.RuleID)
+	}
+	return fmt.Sprintf("%s: rule could not be evaluated: %s", r.RuleID, r.Error)
+}
+
+// details returns the resources and conditions of a rule, followed by the data reported by its check
+func (r *RuleResult) details() string {
+	var b strings.Builder
+	for _, resource := range r.Resources {
+		fmt.Fprintf(&b, "%s: %s\n", resource.Kind, resource.Condition)
+	}
+	for _, field := range r.fields() {
+		fmt.Fprintf(&b, "%s = %v\n", field, r.Data[field])
+	}
+	return b.String()
+}
+
+// fields returns the sorted fields of the data reported by the check of a rule
+func (r *RuleResult) fields() []string {
+	var fields []string
+	for field := range r.Data {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
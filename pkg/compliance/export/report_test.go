@@ -0,0 +1,213 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package export
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance"
+	"github.com/DataDog/datadog-agent/pkg/compliance/checks"
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+)
+
+const testSuite = "./testdata/cis-test.yaml"
+
+func testReport() *Report {
+	statuses := compliance.CheckStatusList{
+		{
+			RuleID:      "cis-test-1",
+			Description: "Ensure IP forwarding is disabled",
+			Framework:   "cis-test",
+			Version:     "1.0.0",
+			Source:      testSuite,
+			LastEvent: &event.Event{
+				AgentRuleID: "cis-test-1",
+				Result:      event.Passed,
+				Data: event.Data{
+					"sysctl.name":  "net.ipv4.ip_forward",
+					"sysctl.value": "0",
+				},
+			},
+		},
+		{
+			RuleID:      "cis-test-2",
+			Description: "Ensure auditd service is enabled",
+			Framework:   "cis-test",
+			Version:     "1.0.0",
+			Source:      testSuite,
+			LastEvent: &event.Event{
+				AgentRuleID: "cis-test-2",
+				Result:      event.Failed,
+				Data: event.Data{
+					"systemd.unit.name":    "auditd.service",
+					"systemd.unit.enabled": false,
+				},
+			},
+		},
+		{
+			RuleID:      "cis-test-3",
+			Description: "Ensure the docker daemon configuration is owned by root",
+			Framework:   "cis-test",
+			Version:     "1.0.0",
+			Source:      testSuite,
+			InitError:   checks.ErrRuleDoesNotApply,
+		},
+		{
+			RuleID:      "cis-test-4",
+			Description: "Ensure openssl is up to date",
+			Framework:   "cis-test",
+			Version:     "1.0.0",
+			Source:      testSuite,
+			LastEvent: &event.Event{
+				AgentRuleID: "cis-test-4",
+				Result:      event.Error,
+				Data: event.Data{
+					"error": "failed to read installed packages",
+				},
+			},
+		},
+		{
+			RuleID:    "cis-test-5",
+			Framework: "cis-test",
+			Version:   "1.0.0",
+			Source:    testSuite,
+			InitError: errors.New("audit client not initialized"),
+		},
+	}
+
+	return NewReport("the-host", time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC), statuses)
+}
+
+func TestNewReport(t *testing.T) {
+	assert := assert.New(t)
+
+	report := testReport()
+	assert.Len(report.Rules, 5)
+	assert.Equal(1, report.Count(event.Passed))
+	assert.Equal(1, report.Count(event.Failed))
+	assert.Equal(2, report.Count(event.Error))
+	assert.Equal(1, report.Count(ResultSkipped))
+	assert.Equal(3, report.Failed())
+
+	assert.Equal(&RuleResult{
+		RuleID:       "cis-test-2",
+		Description:  "Ensure auditd service is enabled",
+		Framework:    "cis-test",
+		Suite:        "CIS Test Benchmark",
+		SuiteVersion: "1.0.0",
+		Source:       testSuite,
+		Result:       event.Failed,
+		Resources: []ResourceResult{
+			{Kind: "systemd_unit", Condition: "systemd.unit.enabled"},
+		},
+		Data: event.Data{
+			"systemd.unit.name":    "auditd.service",
+			"systemd.unit.enabled": false,
+		},
+	}, report.Rules[1])
+
+	assert.Equal("failed to read installed packages", report.Rules[3].Error)
+	assert.Equal("audit client not initialized", report.Rules[4].Error)
+	assert.Empty(report.Rules[4].Resources)
+}
+
+func TestParseFormat(t *testing.T) {
+	assert := assert.New(t)
+
+	format, err := ParseFormat("SARIF")
+	assert.NoError(err)
+	assert.Equal(FormatSARIF, format)
+
+	_, err = ParseFormat("pdf")
+	assert.Error(err)
+}
+
+func TestWriteJUnit(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.NoError(testReport().Write(&buf, FormatJUnit))
+
+	var report junitTestSuites
+	assert.NoError(xml.Unmarshal(buf.Bytes(), &report))
+
+	assert.Equal(5, report.Tests)
+	assert.Equal(1, report.Failures)
+	assert.Equal(2, report.Errors)
+	assert.Equal(1, report.Skipped)
+	assert.Len(report.Suites, 1)
+
+	suite := report.Suites[0]
+	assert.Equal("CIS Test Benchmark", suite.Name)
+	assert.Equal("the-host", suite.Hostname)
+	assert.Equal("2020-06-01T12:00:00Z", suite.Timestamp)
+	assert.Len(suite.Cases, 5)
+
+	assert.Equal("cis-test-1: Ensure IP forwarding is disabled", suite.Cases[0].Name)
+	assert.Nil(suite.Cases[0].Failure)
+	assert.Equal("sysctl: sysctl.value == \"0\"\nsysctl.name = net.ipv4.ip_forward\nsysctl.value = 0\n", suite.Cases[0].SystemOut)
+
+	assert.NotNil(suite.Cases[1].Failure)
+	assert.Equal("cis-test-2: rule failed", suite.Cases[1].Failure.Message)
+	assert.NotNil(suite.Cases[2].Skipped)
+	assert.NotNil(suite.Cases[3].Error)
+	assert.Equal("cis-test-4: rule could not be evaluated: failed to read installed packages", suite.Cases[3].Error.Message)
+}
+
+func TestWriteSARIF(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.NoError(testReport().Write(&buf, FormatSARIF))
+
+	var log sarifLog
+	assert.NoError(json.Unmarshal(buf.Bytes(), &log))
+
+	assert.Equal("2.1.0", log.Version)
+	assert.Len(log.Runs, 1)
+
+	run := log.Runs[0]
+	assert.Equal("datadog-security-agent", run.Tool.Driver.Name)
+	assert.Len(run.Tool.Driver.Rules, 5)
+	assert.Equal("Ensure IP forwarding is disabled", run.Tool.Driver.Rules[0].ShortDescription.Text)
+	assert.Len(run.Results, 5)
+
+	var kinds, levels []string
+	for _, result := range run.Results {
+		kinds = append(kinds, result.Kind)
+		levels = append(levels, result.Level)
+	}
+	assert.Equal([]string{"pass", "fail", "notApplicable", "open", "open"}, kinds)
+	assert.Equal([]string{"none", "error", "none", "none", "none"}, levels)
+
+	assert.Equal(1, run.Results[1].RuleIndex)
+	assert.Contains(run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI, "file:///")
+	assert.Contains(run.Results[1].Locations[0].PhysicalLocation.ArtifactLocation.URI, "testdata/cis-test.yaml")
+}
+
+func TestWriteHTML(t *testing.T) {
+	assert := assert.New(t)
+
+	var buf bytes.Buffer
+	assert.NoError(testReport().Write(&buf, FormatHTML))
+
+	html := buf.String()
+	assert.Contains(html, "<strong>the-host</strong>")
+	assert.Contains(html, `<span class="failed">1 failed</span>`)
+	assert.Contains(html, `<span class="error">2 errors</span>`)
+	assert.Contains(html, "<h2>CIS Test Benchmark 1.0.0</h2>")
+	assert.Contains(html, "systemd_unit: <code>systemd.unit.enabled</code>")
+	assert.Contains(html, "<code>sysctl.value == &#34;0&#34;</code>")
+	assert.NotContains(html, "<script")
+	assert.NotContains(html, "<link")
+}
@@ -0,0 +1,178 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package export
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+)
+
+const (
+	sarifSchema  = "https://schemastore.azurewebsites.net/schemas/json/sarif-2.1.0-rtm.5.json"
+	sarifVersion = "2.1.0"
+
+	sarifToolName = "datadog-security-agent"
+	sarifToolURI  = "https://www.datadoghq.com/"
+)
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool        sarifTool         `json:"tool"`
+	Invocations []sarifInvocation `json:"invocations"`
+	Results     []sarifResult     `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string                 `json:"id"`
+	ShortDescription *sarifMessage          `json:"shortDescription,omitempty"`
+	Properties       map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifInvocation struct {
+	ExecutionSuccessful bool   `json:"executionSuccessful"`
+	EndTimeUTC          string `json:"endTimeUtc"`
+	Machine             string `json:"machine,omitempty"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string                 `json:"ruleId"`
+	RuleIndex  int                    `json:"ruleIndex"`
+	Kind       string                 `json:"kind"`
+	Level      string                 `json:"level"`
+	Message    sarifMessage           `json:"message"`
+	Locations  []sarifLocation        `json:"locations,omitempty"`
+	Properties map[string]interface{} `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// sarifKindAndLevel returns the kind and level of the SARIF result of a rule. SARIF requires
+// the level of the results which are not failures to be `none`.
+func sarifKindAndLevel(result string) (string, string) {
+	switch result {
+	case event.Passed:
+		return "pass", "none"
+	case event.Failed:
+		return "fail", "error"
+	case ResultSkipped:
+		return "notApplicable", "none"
+	}
+	return "open", "none"
+}
+
+// sarifFileURI returns the URI of the suite file a rule was loaded from
+func sarifFileURI(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
+
+// writeSARIF writes the report as a SARIF 2.1.0 log, with a result per rule
+func (r *Report) writeSARIF(w io.Writer) error {
+	run := sarifRun{
+		Tool: sarifTool{
+			Driver: sarifDriver{
+				Name:           sarifToolName,
+				InformationURI: sarifToolURI,
+				Rules:          []sarifRule{},
+			},
+		},
+		Invocations: []sarifInvocation{
+			{
+				ExecutionSuccessful: true,
+				EndTimeUTC:          r.Time.UTC().Format(time.RFC3339),
+				Machine:             r.Hostname,
+			},
+		},
+		Results: []sarifResult{},
+	}
+
+	for i, rule := range r.Rules {
+		driverRule := sarifRule{
+			ID: rule.RuleID,
+			Properties: map[string]interface{}{
+				"framework": rule.Framework,
+				"suite":     rule.Suite,
+				"version":   rule.SuiteVersion,
+			},
+		}
+		if rule.Description != "" {
+			driverRule.ShortDescription = &sarifMessage{Text: rule.Description}
+		}
+		run.Tool.Driver.Rules = append(run.Tool.Driver.Rules, driverRule)
+
+		kind, level := sarifKindAndLevel(rule.Result)
+		result := sarifResult{
+			RuleID:    rule.RuleID,
+			RuleIndex: i,
+			Kind:      kind,
+			Level:     level,
+			Message:   sarifMessage{Text: rule.message()},
+			Properties: map[string]interface{}{
+				"result": rule.Result,
+			},
+		}
+		if rule.Source != "" {
+			result.Locations = []sarifLocation{
+				{
+					PhysicalLocation: sarifPhysicalLocation{
+						ArtifactLocation: sarifArtifactLocation{URI: sarifFileURI(rule.Source)},
+					},
+				},
+			}
+		}
+		if len(rule.Resources) > 0 {
+			result.Properties["resources"] = rule.Resources
+		}
+		if len(rule.Data) > 0 {
+			result.Properties["data"] = rule.Data
+		}
+		run.Results = append(run.Results, result)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(&sarifLog{
+		Schema:  sarifSchema,
+		Version: sarifVersion,
+		Runs:    []sarifRun{run},
+	})
+}
@@ -30,6 +30,12 @@ const (
 	KindAudit = ResourceKind("audit")
 	// KindKubernetes is used for a KubernetesResource
 	KindKubernetes = ResourceKind("kubernetes")
+	// KindPackage is used for a Package resource
+	KindPackage = ResourceKind("package")
+	// KindSysctl is used for a Sysctl resource
+	KindSysctl = ResourceKind("sysctl")
+	// KindSystemdUnit is used for a SystemdUnit resource
+	KindSystemdUnit = ResourceKind("systemd_unit")
 	// KindCustom is used for a Custom check
 	KindCustom = ResourceKind("custom")
 )
@@ -43,6 +49,9 @@ type Resource struct {
 	Audit         *Audit              `yaml:"audit,omitempty"`
 	Docker        *DockerResource     `yaml:"docker,omitempty"`
 	KubeApiserver *KubernetesResource `yaml:"kubeApiserver,omitempty"`
+	Package       *Package            `yaml:"package,omitempty"`
+	Sysctl        *Sysctl             `yaml:"sysctl,omitempty"`
+	SystemdUnit   *SystemdUnit        `yaml:"systemdUnit,omitempty"`
 	Custom        *Custom             `yaml:"custom,omitempty"`
 	Condition     string              `yaml:"condition"`
 	Fallback      *Fallback           `yaml:"fallback,omitempty"`
@@ -65,6 +74,12 @@ func (r *Resource) Kind() ResourceKind {
 		return KindDocker
 	case r.KubeApiserver != nil:
 		return KindKubernetes
+	case r.Package != nil:
+		return KindPackage
+	case r.Sysctl != nil:
+		return KindSysctl
+	case r.SystemdUnit != nil:
+		return KindSystemdUnit
 	case r.Custom != nil:
 		return KindCustom
 	default:
@@ -252,6 +267,48 @@ type DockerResource struct {
 	Kind string `yaml:"kind"`
 }
 
+// Fields & functions available for Package
+const (
+	PackageFieldName      = "package.name"
+	PackageFieldVersion   = "package.version"
+	PackageFieldInstalled = "package.installed"
+
+	PackageFuncVersionCompare = "package.versionCompare"
+)
+
+// Package describes a package installed by the package manager of the host (dpkg, rpm or apk)
+type Package struct {
+	Name string `yaml:"name"`
+}
+
+// Fields & functions available for Sysctl
+const (
+	SysctlFieldName  = "sysctl.name"
+	SysctlFieldValue = "sysctl.value"
+)
+
+// Sysctl describes a kernel parameter, as read from /proc/sys
+type Sysctl struct {
+	Name string `yaml:"name"`
+}
+
+// Fields & functions available for SystemdUnit
+const (
+	SystemdUnitFieldName     = "systemd.unit.name"
+	SystemdUnitFieldPath     = "systemd.unit.path"
+	SystemdUnitFieldLoaded   = "systemd.unit.loaded"
+	SystemdUnitFieldEnabled  = "systemd.unit.enabled"
+	SystemdUnitFieldMasked   = "systemd.unit.masked"
+	SystemdUnitFieldWantedBy = "systemd.unit.wantedBy"
+
+	SystemdUnitFuncProperty = "systemd.unit.property"
+)
+
+// SystemdUnit describes a systemd unit, as read from its unit files and enablement symlinks
+type SystemdUnit struct {
+	Name string `yaml:"name"`
+}
+
 // Custom is a special resource handled by a dedicated function
 type Custom struct {
 	Name      string            `yaml:"name"`
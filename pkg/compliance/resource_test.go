@@ -62,6 +62,24 @@ docker:
 condition: docker.template("{{ $.Config.Healthcheck }}") != ""
 `
 
+const testResourcePackage = `
+package:
+  name: openssl
+condition: package.versionCompare("1.1.1g") >= 0
+`
+
+const testResourceSysctl = `
+sysctl:
+  name: net.ipv4.ip_forward
+condition: sysctl.value == "0"
+`
+
+const testResourceSystemdUnit = `
+systemdUnit:
+  name: auditd.service
+condition: systemd.unit.enabled
+`
+
 func TestResources(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -150,6 +168,36 @@ func TestResources(t *testing.T) {
 				Condition: `docker.template("{{ $.Config.Healthcheck }}") != ""`,
 			},
 		},
+		{
+			name:  "package",
+			input: testResourcePackage,
+			expected: Resource{
+				Package: &Package{
+					Name: "openssl",
+				},
+				Condition: `package.versionCompare("1.1.1g") >= 0`,
+			},
+		},
+		{
+			name:  "sysctl",
+			input: testResourceSysctl,
+			expected: Resource{
+				Sysctl: &Sysctl{
+					Name: "net.ipv4.ip_forward",
+				},
+				Condition: `sysctl.value == "0"`,
+			},
+		},
+		{
+			name:  "systemd unit",
+			input: testResourceSystemdUnit,
+			expected: Resource{
+				SystemdUnit: &SystemdUnit{
+					Name: "auditd.service",
+				},
+				Condition: `systemd.unit.enabled`,
+			},
+		},
 	}
 
 	for _, test := range tests {
@@ -21,6 +21,9 @@ func setupAPM(config Config) {
 	config.SetKnown("apm_config.obfuscation.remove_stack_traces")
 	config.SetKnown("apm_config.obfuscation.redis.enabled")
 	config.SetKnown("apm_config.obfuscation.memcached.enabled")
+	config.SetKnown("apm_config.obfuscation.graphql.enabled")
+	config.SetKnown("apm_config.obfuscation.credit_cards.enabled")
+	config.SetKnown("apm_config.obfuscation.credit_cards.keep_values")
 	config.SetKnown("apm_config.extra_sample_rate")
 	config.SetKnown("apm_config.dd_agent_bin")
 	config.SetKnown("apm_config.trace_writer.connection_limit")
@@ -33,6 +36,14 @@ func setupAPM(config Config) {
 	config.SetKnown("apm_config.log_throttling")
 	config.SetKnown("apm_config.bucket_size_seconds")
 	config.SetKnown("apm_config.watchdog_check_delay")
+	config.SetKnown("apm_config.stats_dimensions.http_status_class")
+	config.SetKnown("apm_config.stats_dimensions.peer_service")
+	config.SetKnown("apm_config.stats_dimensions.max_peers_per_service")
+	config.SetKnown("apm_config.span_metrics.max_contexts_per_rule")
+	config.SetKnown("apm_config.span_metrics.rules")
+	config.SetKnown("apm_config.capture.path")
+	config.SetKnown("apm_config.capture.max_file_size")
+	config.SetKnown("apm_config.capture.max_files")
 
 	if runtime.GOARCH == "386" && runtime.GOOS == "windows" {
 		// on Windows-32 bit, the trace agent isn't installed.  Set the default to disabled
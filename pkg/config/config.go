@@ -52,6 +52,9 @@ const (
 
 	// DefaultRuntimePoliciesDir is the default policies directory used by the runtime security module
 	DefaultRuntimePoliciesDir = "/etc/datadog-agent/runtime-security.d"
+
+	// DefaultRuntimeEventsFile is the default file to which the file sink of the security agent writes the runtime security events
+	DefaultRuntimeEventsFile = "/var/log/datadog/runtime-security-events.json"
 )
 
 var overrideVars = make(map[string]interface{})
@@ -704,6 +707,21 @@ func InitConfig(config Config) {
 	config.SetKnown("system_probe_config.closed_channel_size")
 	config.SetKnown("system_probe_config.dns_timeout_in_s")
 	config.SetKnown("system_probe_config.collect_dns_stats")
+	config.SetKnown("system_probe_config.max_dns_domains")
+	config.SetKnown("system_probe_config.enable_http_monitoring")
+	config.SetKnown("system_probe_config.enable_protocol_classification")
+	config.SetKnown("system_probe_config.collect_container_tags")
+	config.SetKnown("system_probe_config.collect_kubernetes_tags")
+	config.SetKnown("system_probe_config.ipfix.collector")
+	config.SetKnown("system_probe_config.ipfix.export_interval")
+	config.SetKnown("system_probe_config.ipfix.template_refresh_interval")
+	config.SetKnown("system_probe_config.ipfix.observation_domain_id")
+	config.SetKnown("system_probe_config.network_anomaly.enabled")
+	config.SetKnown("system_probe_config.network_anomaly.interval")
+	config.SetKnown("system_probe_config.network_anomaly.threshold")
+	config.SetKnown("system_probe_config.network_anomaly.event_cooldown")
+	config.SetKnown("system_probe_config.enable_proc_net_fallback")
+	config.SetKnown("system_probe_config.proc_net_poll_interval")
 	config.SetKnown("system_probe_config.offset_guess_threshold")
 	config.SetKnown("system_probe_config.enable_tcp_queue_length")
 	config.SetKnown("system_probe_config.enable_oom_kill")
@@ -735,12 +753,38 @@ func InitConfig(config Config) {
 	config.BindEnvAndSetDefault("runtime_security_config.enabled", false)
 	config.BindEnvAndSetDefault("runtime_security_config.debug", false)
 	config.BindEnvAndSetDefault("runtime_security_config.policies.dir", DefaultRuntimePoliciesDir)
+	config.BindEnvAndSetDefault("runtime_security_config.policies.lists_reload_interval", time.Minute)
+	config.BindEnvAndSetDefault("runtime_security_config.policies.watch_dir", false)
 	config.BindEnvAndSetDefault("runtime_security_config.socket", "/opt/datadog-agent/run/runtime-security.sock")
 	config.BindEnvAndSetDefault("runtime_security_config.enable_kernel_filters", true)
 	config.BindEnvAndSetDefault("runtime_security_config.syscall_monitor.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.process_lineage.enabled", true)
 	config.BindEnvAndSetDefault("runtime_security_config.run_path", defaultRunPath)
 	config.BindEnvAndSetDefault("runtime_security_config.event_server.burst", 40)
 	config.BindEnvAndSetDefault("runtime_security_config.event_server.rate", 10)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.file.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.file.path", DefaultRuntimeEventsFile)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.file.max_size", "10Mb")
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.file.max_rolls", 1)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.file.min_severity", "")
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.file.rule_ids", []string{})
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.syslog.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.syslog.network", "udp")
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.syslog.address", "localhost:514")
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.syslog.facility", 13)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.syslog.app_name", "datadog-runtime-security")
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.syslog.timeout", 5*time.Second)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.syslog.min_severity", "")
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.syslog.rule_ids", []string{})
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.webhook.enabled", false)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.webhook.url", "")
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.webhook.batch_size", 50)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.webhook.flush_interval", 5*time.Second)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.webhook.max_retries", 3)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.webhook.timeout", 10*time.Second)
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.webhook.min_severity", "")
+	config.BindEnvAndSetDefault("runtime_security_config.sinks.webhook.rule_ids", []string{})
+	config.SetKnown("runtime_security_config.sinks.webhook.headers")
 
 	// command line options
 	config.SetKnown("cmd.check.fullsketches")
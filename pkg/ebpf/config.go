@@ -29,6 +29,30 @@ type Config struct {
 	// DNSTimeout determines the length of time to wait before considering a DNS Query to have timed out
 	DNSTimeout time.Duration
 
+	// MaxDNSDomains is the maximum number of distinct queried domains for which DNS stats are collected between
+	// two client requests. The stats of the questions for other domains are aggregated under network.DNSOtherDomain.
+	MaxDNSDomains int
+
+	// EnableHTTPMonitoring specifies whether the tracer should capture the TCP traffic of the host to aggregate
+	// the latency and status codes of the HTTP/1.x requests it holds
+	EnableHTTPMonitoring bool
+
+	// EnableProtocolClassification specifies whether the tracer should capture the first payloads of the TCP
+	// connections of the host to classify their application-layer protocol
+	EnableProtocolClassification bool
+
+	// MaxHTTPStatsBuffered represents the maximum number of HTTP stats we'll buffer in memory. These stats
+	// get flushed on every client request (default 30s check interval)
+	MaxHTTPStatsBuffered int
+
+	// CollectContainerTags specifies whether the tracer should tag connections with the ID of the container of
+	// their process, read from its cgroup
+	CollectContainerTags bool
+
+	// CollectKubernetesTags specifies whether the tracer should tag connections with the pod and services of
+	// their destination, when it is a pod of the node
+	CollectKubernetesTags bool
+
 	// UDPConnTimeout determines the length of traffic inactivity between two (IP, port)-pairs before declaring a UDP
 	// connection as inactive.
 	// Note: As UDP traffic is technically "connection-less", for tracking, we consider a UDP connection to be traffic
@@ -85,6 +109,10 @@ type Config struct {
 	// default is false
 	EnableConntrackAllNamespaces bool
 
+	// ProcNetPollInterval (/proc/net tracer only) determines how often the sockets are read, to detect
+	// the connections closed between two client requests
+	ProcNetPollInterval time.Duration
+
 	// DebugPort specifies a port to run golang's expvar and pprof debug endpoint
 	DebugPort int
 
@@ -132,11 +160,14 @@ func NewDefaultConfig() *Config {
 		MaxClosedConnectionsBuffered: 50000,
 		MaxConnectionsStateBuffered:  75000,
 		MaxDNSStatsBufferred:         75000,
+		MaxHTTPStatsBuffered:         100000,
 		ClientStateExpiry:            2 * time.Minute,
 		ClosedChannelSize:            500,
+		ProcNetPollInterval:          10 * time.Second,
 		// DNS Stats related configurations
 		CollectDNSStats:      true,
 		DNSTimeout:           15 * time.Second,
+		MaxDNSDomains:        1000,
 		OffsetGuessThreshold: 400,
 		EnableMonotonicCount: false,
 	}
@@ -0,0 +1,16 @@
+package ebpf
+
+// KernelEventProbe is a kernel probe whose events are exposed by the system-probe on its /check/<name>
+// endpoint, for the agent check declared by the kevent.Definition of the same name
+type KernelEventProbe interface {
+	// GetAndFlushEvents returns the events collected since the last call, as a slice of the events
+	// of the kevent.Definition of the probe
+	GetAndFlushEvents() interface{}
+	// Close stops the probe
+	Close()
+}
+
+var (
+	_ KernelEventProbe = &OOMKillProbe{}
+	_ KernelEventProbe = &TCPQueueLengthTracer{}
+)
@@ -0,0 +1,80 @@
+// Package kevent holds the definitions of the kernel event checks: probes running in the system-probe,
+// whose events are exposed on its /check/<name> endpoint and pulled by the agent check of the same name.
+package kevent
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// Definition declares a kernel event check
+type Definition struct {
+	// Name is the name of the check, used by the system-probe endpoint and by the agent check
+	Name string
+	// Event is a value of the type of the events of the probe, as serialized by the system-probe
+	Event interface{}
+}
+
+var (
+	mux         sync.RWMutex
+	definitions = make(map[string]Definition)
+)
+
+// Register registers the definition of a kernel event check. It panics if a check with the same
+// name is already registered, or if the definition is invalid.
+func Register(d Definition) {
+	if d.Name == "" || d.Event == nil {
+		panic("kevent: invalid kernel event check definition")
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	if _, ok := definitions[d.Name]; ok {
+		panic(fmt.Sprintf("kevent: kernel event check %s registered twice", d.Name))
+	}
+	definitions[d.Name] = d
+}
+
+// Get returns the definition of the kernel event check with the given name
+func Get(name string) (Definition, bool) {
+	mux.RLock()
+	defer mux.RUnlock()
+	d, ok := definitions[name]
+	return d, ok
+}
+
+// Names returns the names of the registered kernel event checks, sorted
+func Names() []string {
+	mux.RLock()
+	defer mux.RUnlock()
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// DecodeEvents decodes the JSON list of events returned by the system-probe for the given check.
+// Each returned event has the type of the Event of the definition of the check.
+func DecodeEvents(name string, data []byte) ([]interface{}, error) {
+	d, ok := Get(name)
+	if !ok {
+		return nil, fmt.Errorf("invalid check name: %s", name)
+	}
+
+	events := reflect.New(reflect.SliceOf(reflect.TypeOf(d.Event)))
+	if err := json.Unmarshal(data, events.Interface()); err != nil {
+		return nil, fmt.Errorf("unable to decode events of check %s: %s", name, err)
+	}
+
+	slice := events.Elem()
+	decoded := make([]interface{}, slice.Len())
+	for i := range decoded {
+		decoded[i] = slice.Index(i).Interface()
+	}
+	return decoded, nil
+}
@@ -0,0 +1,44 @@
+package kevent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testEvent struct {
+	Pid   uint32 `json:"pid"`
+	Count int    `json:"count"`
+}
+
+func TestDecodeEvents(t *testing.T) {
+	Register(Definition{Name: "test_decode", Event: testEvent{}})
+
+	events, err := DecodeEvents("test_decode", []byte(`[{"pid":42,"count":3},{"pid":43,"count":1}]`))
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{testEvent{Pid: 42, Count: 3}, testEvent{Pid: 43, Count: 1}}, events)
+
+	// probes without events serialize a nil slice
+	events, err = DecodeEvents("test_decode", []byte(`null`))
+	require.NoError(t, err)
+	assert.Empty(t, events)
+
+	_, err = DecodeEvents("test_decode", []byte(`{"pid":42}`))
+	assert.Error(t, err)
+
+	_, err = DecodeEvents("unknown", []byte(`[]`))
+	assert.Error(t, err)
+}
+
+func TestRegister(t *testing.T) {
+	Register(Definition{Name: "test_register", Event: testEvent{}})
+
+	d, ok := Get("test_register")
+	require.True(t, ok)
+	assert.Equal(t, testEvent{}, d.Event)
+	assert.Contains(t, Names(), "test_register")
+
+	assert.Panics(t, func() { Register(Definition{Name: "test_register", Event: testEvent{}}) })
+	assert.Panics(t, func() { Register(Definition{Name: "test_invalid"}) })
+}
@@ -61,6 +61,11 @@ func (k *OOMKillProbe) GetAndFlush() []oomkill.Stats {
 	return results
 }
 
+// GetAndFlushEvents returns the OOM kills since the last call, as a []oomkill.Stats
+func (k *OOMKillProbe) GetAndFlushEvents() interface{} {
+	return k.GetAndFlush()
+}
+
 func (k *OOMKillProbe) Get() []oomkill.Stats {
 	if k == nil {
 		return nil
@@ -26,3 +26,8 @@ func (t *OOMKillProbe) Get() []oomkill.Stats {
 func (t *OOMKillProbe) GetAndFlush() []oomkill.Stats {
 	return nil
 }
+
+// GetAndFlushEvents is not implemented on non-linux systems
+func (t *OOMKillProbe) GetAndFlushEvents() interface{} {
+	return nil
+}
@@ -1,5 +1,14 @@
 package oomkill
 
+import "github.com/DataDog/datadog-agent/pkg/ebpf/kevent"
+
+// CheckName is the name of the OOM kill check
+const CheckName = "oom_kill"
+
+func init() {
+	kevent.Register(kevent.Definition{Name: CheckName, Event: Stats{}})
+}
+
 // Stats contains the statistics of a given socket
 type Stats struct {
 	ContainerID string `json:"containerid"`
@@ -0,0 +1,525 @@
+// +build linux
+// +build !android
+
+package ebpf
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/netlink"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// TCP states of the sockets which are not reported as connections
+const (
+	tcpTimeWait = 0x06
+	tcpClose    = 0x07
+	tcpListen   = 0x0A
+
+	// udpConnected is the state of the UDP sockets which have a remote address
+	udpConnected = 0x01
+)
+
+// ProcNetTracer is a tracer which doesn't rely on eBPF: it builds connections from periodic
+// snapshots of the /proc/<pid>/net/{tcp,udp}{,6} files of a process of every network namespace,
+// and resolves their NAT translations with conntrack. It has a lower fidelity than the eBPF tracer:
+// - these files have no traffic counters, so connections have no byte, retransmit or RTT stats
+// - connections opened and closed between two snapshots are missed
+// - only connected UDP sockets are reported
+type ProcNetTracer struct {
+	config         *Config
+	state          network.State
+	conntracker    netlink.Conntracker
+	sourceExcludes []*network.ConnectionFilter
+	destExcludes   []*network.ConnectionFilter
+	netNS          uint32
+
+	// snapshotLock guards the fields below, updated by every snapshot
+	snapshotLock sync.Mutex
+	active       map[string]network.ConnectionStats // connections of the last snapshot, by key
+	pids         map[uint64]uint32                  // PIDs of the processes owning the sockets, by socket inode
+	buf          *bytes.Buffer
+
+	exit chan struct{}
+	wg   sync.WaitGroup
+
+	// telemetry
+	snapshots      int64
+	snapshotErrors int64
+	closedConns    int64
+	skippedConns   int64
+}
+
+// NewProcNetTracer returns a ProcNetTracer snapshotting the sockets every config.ProcNetPollInterval
+func NewProcNetTracer(config *Config) (*ProcNetTracer, error) {
+	if !config.CollectTCPConns && !config.CollectUDPConns {
+		return nil, fmt.Errorf("neither TCP nor UDP connections are collected")
+	}
+	if _, err := os.Stat(path.Join(config.ProcRoot, "net/tcp")); err != nil {
+		return nil, fmt.Errorf("unable to read sockets from %s: %s", config.ProcRoot, err)
+	}
+
+	conntracker := netlink.NewNoOpConntracker()
+	if config.EnableConntrack {
+		if c, err := netlink.NewConntracker(config.ProcRoot, config.ConntrackMaxStateSize, config.ConntrackRateLimit, config.EnableConntrackAllNamespaces); err != nil {
+			log.Warnf("could not initialize conntrack, tracer will continue without NAT tracking: %s", err)
+		} else {
+			conntracker = c
+		}
+	}
+
+	netNS, err := readNetNS(path.Join(config.ProcRoot, "self"))
+	if err != nil {
+		log.Debugf("unable to read the network namespace of the sockets: %s", err)
+	}
+
+	t := &ProcNetTracer{
+		config: config,
+		state: network.NewState(
+			config.ClientStateExpiry,
+			config.MaxClosedConnectionsBuffered,
+			config.MaxConnectionsStateBuffered,
+			config.MaxDNSStatsBufferred,
+		),
+		conntracker:    conntracker,
+		sourceExcludes: network.ParseConnectionFilters(config.ExcludedSourceConnections),
+		destExcludes:   network.ParseConnectionFilters(config.ExcludedDestinationConnections),
+		netNS:          netNS,
+		active:         map[string]network.ConnectionStats{},
+		pids:           map[uint64]uint32{},
+		buf:            &bytes.Buffer{},
+		exit:           make(chan struct{}),
+	}
+
+	// The connections of the first snapshot were established before the tracer started
+	if err := t.snapshot(false); err != nil {
+		conntracker.Close()
+		return nil, err
+	}
+
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		t.poll()
+	}()
+
+	log.Infof("tracing connections from the network namespaces of %s every %s", config.ProcRoot, config.ProcNetPollInterval)
+	return t, nil
+}
+
+// poll snapshots the sockets periodically, to detect the connections which get closed between
+// two client requests
+func (t *ProcNetTracer) poll() {
+	ticker := time.NewTicker(t.config.ProcNetPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.exit:
+			return
+		case now := <-ticker.C:
+			if err := t.snapshot(true); err != nil {
+				log.Debugf("unable to snapshot connections: %s", err)
+			}
+			t.state.RemoveExpiredClients(now)
+		}
+	}
+}
+
+// Stop stops the tracer
+func (t *ProcNetTracer) Stop() {
+	close(t.exit)
+	t.wg.Wait()
+	t.conntracker.Close()
+}
+
+// GetActiveConnections returns the connections of a fresh snapshot, along with the connections
+// closed since the last request of the client
+func (t *ProcNetTracer) GetActiveConnections(clientID string) (*network.Connections, error) {
+	t.snapshotLock.Lock()
+	defer t.snapshotLock.Unlock()
+
+	if err := t.snapshotLocked(true); err != nil {
+		return nil, fmt.Errorf("error retrieving connections: %s", err)
+	}
+
+	latestTime, err := NowNanoseconds()
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving latest time: %s", err)
+	}
+
+	conns := t.state.Connections(clientID, uint64(latestTime), t.activeConnections(), nil)
+	return &network.Connections{Conns: conns}, nil
+}
+
+// SetClientFilter sets the filter of the connections returned to the given client, or removes it if nil
+func (t *ProcNetTracer) SetClientFilter(clientID string, filter *network.ClientFilter) {
+	t.state.SetClientFilter(clientID, filter)
+}
+
+// GetStats returns a map of statistics about the current tracer's internal state
+func (t *ProcNetTracer) GetStats() (map[string]interface{}, error) {
+	t.snapshotLock.Lock()
+	activeConns := len(t.active)
+	t.snapshotLock.Unlock()
+
+	return map[string]interface{}{
+		"conntrack": t.conntracker.GetStats(),
+		"state":     t.state.GetStats(),
+		"tracer": map[string]int64{
+			"snapshots":       atomic.LoadInt64(&t.snapshots),
+			"snapshot_errors": atomic.LoadInt64(&t.snapshotErrors),
+			"closed_conns":    atomic.LoadInt64(&t.closedConns),
+			"skipped_conns":   atomic.LoadInt64(&t.skippedConns),
+			"active_conns":    int64(activeConns),
+		},
+	}, nil
+}
+
+// DebugNetworkState returns a map with the current tracer's internal state, for debugging
+func (t *ProcNetTracer) DebugNetworkState(clientID string) (map[string]interface{}, error) {
+	return t.state.DumpState(clientID), nil
+}
+
+// DebugNetworkMaps returns the connections of the last snapshot without modifications from network state
+func (t *ProcNetTracer) DebugNetworkMaps() (*network.Connections, error) {
+	t.snapshotLock.Lock()
+	defer t.snapshotLock.Unlock()
+	return &network.Connections{Conns: t.activeConnections()}, nil
+}
+
+func (t *ProcNetTracer) activeConnections() []network.ConnectionStats {
+	conns := make([]network.ConnectionStats, 0, len(t.active))
+	for _, c := range t.active {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+func (t *ProcNetTracer) snapshot(established bool) error {
+	t.snapshotLock.Lock()
+	defer t.snapshotLock.Unlock()
+	return t.snapshotLocked(established)
+}
+
+// snapshotLocked reads the sockets, and stores the connections of the previous snapshot which are
+// gone as closed connections. New connections are counted as established if established is set.
+func (t *ProcNetTracer) snapshotLocked(established bool) error {
+	now, err := NowNanoseconds()
+	if err != nil {
+		return err
+	}
+
+	conns, err := t.readConnections(uint64(now))
+	if err != nil {
+		atomic.AddInt64(&t.snapshotErrors, 1)
+		return err
+	}
+	atomic.AddInt64(&t.snapshots, 1)
+
+	active := make(map[string]network.ConnectionStats, len(conns))
+	for _, c := range conns {
+		key, err := c.ByteKey(t.buf)
+		if err != nil {
+			log.Warnf("failed to create byte key: %s", err)
+			continue
+		}
+		if prev, ok := t.active[string(key)]; ok {
+			c.MonotonicTCPEstablished = prev.MonotonicTCPEstablished
+		} else if established && c.Type == network.TCP {
+			c.MonotonicTCPEstablished = 1
+		}
+		c.IPTranslation = t.conntracker.GetTranslationForConn(c)
+		active[string(key)] = c
+	}
+
+	for key, c := range t.active {
+		if _, ok := active[key]; ok {
+			continue
+		}
+		if c.Type == network.TCP {
+			c.MonotonicTCPClosed = 1
+		}
+		t.storeClosedConn(c)
+	}
+
+	t.active = active
+	return nil
+}
+
+func (t *ProcNetTracer) storeClosedConn(c network.ConnectionStats) {
+	atomic.AddInt64(&t.closedConns, 1)
+	t.state.StoreClosedConnection(c)
+	if c.IPTranslation != nil {
+		t.conntracker.DeleteTranslation(c)
+	}
+}
+
+// netNamespace is a network namespace, whose sockets are read from the net directory of one of its processes
+type netNamespace struct {
+	ino    uint32
+	netDir string
+}
+
+// readNetNamespaces returns the distinct network namespaces of the processes, or the namespace of
+// /proc/net when the namespaces of the processes can't be read
+func (t *ProcNetTracer) readNetNamespaces() []netNamespace {
+	var namespaces []netNamespace
+
+	entries, err := ioutil.ReadDir(t.config.ProcRoot)
+	if err != nil {
+		log.Debugf("unable to list processes: %s", err)
+	}
+
+	seen := make(map[uint32]struct{})
+	for _, entry := range entries {
+		if _, err := strconv.ParseUint(entry.Name(), 10, 32); err != nil || !entry.IsDir() {
+			continue
+		}
+		procDir := path.Join(t.config.ProcRoot, entry.Name())
+		ino, err := readNetNS(procDir)
+		if err != nil {
+			continue
+		}
+		if _, ok := seen[ino]; ok {
+			continue
+		}
+		seen[ino] = struct{}{}
+		namespaces = append(namespaces, netNamespace{ino: ino, netDir: path.Join(procDir, "net")})
+	}
+
+	if len(namespaces) == 0 {
+		namespaces = append(namespaces, netNamespace{ino: t.netNS, netDir: path.Join(t.config.ProcRoot, "net")})
+	}
+	return namespaces
+}
+
+// readConnections reads the connections of every network namespace
+func (t *ProcNetTracer) readConnections(now uint64) ([]network.ConnectionStats, error) {
+	var (
+		namespaces []netNamespace
+		sockets    [][2][]network.ProcNetSocket // by namespace, then by connection type
+		lastErr    error
+	)
+	inodes := make(map[uint64]struct{})
+	for _, ns := range t.readNetNamespaces() {
+		nsSockets, err := t.readSockets(ns.netDir)
+		if err != nil {
+			// the processes of the namespace may be gone
+			log.Debugf("unable to read the sockets of network namespace %d: %s", ns.ino, err)
+			lastErr = err
+			continue
+		}
+		for connType, typeSockets := range nsSockets {
+			for _, s := range typeSockets {
+				if isConnection(network.ConnectionType(connType), s) {
+					inodes[s.Inode] = struct{}{}
+				}
+			}
+		}
+		namespaces = append(namespaces, ns)
+		sockets = append(sockets, nsSockets)
+	}
+	if len(namespaces) == 0 {
+		return nil, lastErr
+	}
+
+	t.updateSocketPids(inodes)
+
+	var conns []network.ConnectionStats
+	for i, ns := range namespaces {
+		for connType, typeSockets := range sockets[i] {
+			listening := listeningPorts(network.ConnectionType(connType), typeSockets)
+			for _, s := range typeSockets {
+				if !isConnection(network.ConnectionType(connType), s) {
+					continue
+				}
+
+				c := network.ConnectionStats{
+					Source:          s.Local,
+					Dest:            s.Remote,
+					SPort:           s.LocalPort,
+					DPort:           s.RemotePort,
+					Pid:             t.pids[s.Inode],
+					NetNS:           ns.ino,
+					Type:            network.ConnectionType(connType),
+					Family:          network.AFINET,
+					Direction:       network.OUTGOING,
+					LastUpdateEpoch: now,
+				}
+				if len(s.Local.Bytes()) == 16 {
+					c.Family = network.AFINET6
+				}
+				if _, ok := listening[s.LocalPort]; ok {
+					c.Direction = network.INCOMING
+				}
+
+				if t.shouldSkipConnection(&c) {
+					atomic.AddInt64(&t.skippedConns, 1)
+					continue
+				}
+				conns = append(conns, c)
+			}
+		}
+	}
+	return conns, nil
+}
+
+// readSockets reads the sockets of the {tcp,udp}{,6} files of a net directory, by connection type
+func (t *ProcNetTracer) readSockets(netDir string) ([2][]network.ProcNetSocket, error) {
+	files := []struct {
+		name     string
+		connType network.ConnectionType
+		enabled  bool
+	}{
+		{"tcp", network.TCP, t.config.CollectTCPConns},
+		{"tcp6", network.TCP, t.config.CollectTCPConns && t.config.CollectIPv6Conns},
+		{"udp", network.UDP, t.config.CollectUDPConns},
+		{"udp6", network.UDP, t.config.CollectUDPConns && t.config.CollectIPv6Conns},
+	}
+
+	var sockets [2][]network.ProcNetSocket
+	for _, f := range files {
+		if !f.enabled {
+			continue
+		}
+		s, err := network.ReadProcNetSockets(path.Join(netDir, f.name))
+		if err != nil {
+			if f.name == "tcp" || f.name == "udp" {
+				return sockets, err
+			}
+			// IPv6 may be disabled
+			log.Debugf("unable to read %s sockets: %s", f.name, err)
+			continue
+		}
+		sockets[f.connType] = append(sockets[f.connType], s...)
+	}
+	return sockets, nil
+}
+
+// updateSocketPids resolves the PIDs of the processes owning the sockets which were not seen by the
+// previous snapshots, and forgets the sockets which are gone. The owners which can't be found aren't
+// looked up again.
+func (t *ProcNetTracer) updateSocketPids(inodes map[uint64]struct{}) {
+	for inode := range t.pids {
+		if _, ok := inodes[inode]; !ok {
+			delete(t.pids, inode)
+		}
+	}
+
+	missing := make(map[uint64]struct{})
+	for inode := range inodes {
+		if _, ok := t.pids[inode]; !ok {
+			missing[inode] = struct{}{}
+		}
+	}
+	if len(missing) == 0 {
+		return
+	}
+
+	found := readSocketInodes(t.config.ProcRoot, missing)
+	for inode := range missing {
+		t.pids[inode] = found[inode]
+	}
+}
+
+// shouldSkipConnection returns whether or not the tracer should ignore a given connection, like the eBPF tracer
+func (t *ProcNetTracer) shouldSkipConnection(conn *network.ConnectionStats) bool {
+	isDNSConnection := conn.DPort == 53 || conn.SPort == 53
+	if !t.config.CollectLocalDNS && isDNSConnection && conn.Dest.IsLoopback() {
+		return true
+	}
+	return network.IsExcludedConnection(t.sourceExcludes, t.destExcludes, conn)
+}
+
+// isConnection returns whether the socket is a connection, i.e. it has a remote end and a process
+func isConnection(connType network.ConnectionType, s network.ProcNetSocket) bool {
+	if s.Inode == 0 || s.RemotePort == 0 {
+		return false
+	}
+	if connType == network.UDP {
+		return s.State == udpConnected
+	}
+	switch s.State {
+	case tcpListen, tcpClose, tcpTimeWait:
+		return false
+	}
+	return true
+}
+
+// listeningPorts returns the ports of the listening TCP sockets, or of the unconnected UDP sockets
+func listeningPorts(connType network.ConnectionType, sockets []network.ProcNetSocket) map[uint16]struct{} {
+	ports := make(map[uint16]struct{})
+	for _, s := range sockets {
+		if (connType == network.TCP && s.IsListening()) || (connType == network.UDP && s.RemotePort == 0) {
+			ports[s.LocalPort] = struct{}{}
+		}
+	}
+	return ports
+}
+
+// readSocketInodes returns the PIDs of the processes owning the given sockets, by socket inode. It stops
+// reading the file descriptors of the processes once all the sockets are found.
+func readSocketInodes(procRoot string, inodes map[uint64]struct{}) map[uint64]uint32 {
+	pids := make(map[uint64]uint32)
+
+	entries, err := ioutil.ReadDir(procRoot)
+	if err != nil {
+		log.Debugf("unable to list processes: %s", err)
+		return pids
+	}
+
+	for _, entry := range entries {
+		pid, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+
+		fdDir := path.Join(procRoot, entry.Name(), "fd")
+		fds, err := ioutil.ReadDir(fdDir)
+		if err != nil {
+			// the process is gone or can't be inspected
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(path.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(link, "socket:[") {
+				continue
+			}
+			inode, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]"), 10, 64)
+			if err != nil {
+				continue
+			}
+			if _, ok := inodes[inode]; ok {
+				pids[inode] = uint32(pid)
+			}
+		}
+		if len(pids) == len(inodes) {
+			break
+		}
+	}
+	return pids
+}
+
+// readNetNS returns the inode of the network namespace of a process, given its /proc/<pid> directory
+func readNetNS(procDir string) (uint32, error) {
+	link, err := os.Readlink(path.Join(procDir, "ns/net"))
+	if err != nil {
+		return 0, err
+	}
+	ino, err := strconv.ParseUint(strings.TrimSuffix(strings.TrimPrefix(link, "net:["), "]"), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("invalid network namespace %s: %s", link, err)
+	}
+	return uint32(ino), nil
+}
@@ -0,0 +1,211 @@
+// +build linux
+// +build !android
+
+package ebpf
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	procNetTCPHeader = "  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode\n"
+
+	// 127.0.0.1:8080 listening
+	procNetListen = "   0: 0100007F:1F90 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 100 1 ffff88003cc20780 100 0 0 10 0\n"
+	// 127.0.0.1:8080 <- 127.0.0.1:40000
+	procNetIncoming = "   1: 0100007F:1F90 0100007F:9C40 01 00000000:00000000 00:00000000 00000000     0        0 101 1 ffff88003cc20000 20 4 1 10 -1\n"
+	// 10.0.2.15:50000 -> 10.0.2.2:443
+	procNetOutgoing = "   2: 0F02000A:C350 0202000A:01BB 01 00000000:00000000 00:00000000 00000000     0        0 102 1 ffff88003cc20000 20 4 1 10 -1\n"
+	// 10.0.2.15:50001 -> 10.0.2.2:443, in TIME_WAIT
+	procNetTimeWait = "   3: 0F02000A:C351 0202000A:01BB 06 00000000:00000000 03:00000AA4 00000000     0        0 0 3 ffff880035387000\n"
+)
+
+func TestProcNetTracer(t *testing.T) {
+	procRoot := newTestProcRoot(t)
+	defer os.RemoveAll(procRoot)
+	writeProcNetFile(t, procRoot, "tcp", procNetTCPHeader+procNetListen+procNetIncoming+procNetOutgoing+procNetTimeWait)
+	writeProcNetFile(t, procRoot, "udp", procNetTCPHeader)
+
+	cfg := NewDefaultConfig()
+	cfg.ProcRoot = procRoot
+	cfg.EnableConntrack = false
+	cfg.CollectIPv6Conns = false
+	cfg.ProcNetPollInterval = time.Hour
+
+	tr, err := NewProcNetTracer(cfg)
+	require.NoError(t, err)
+	defer tr.Stop()
+
+	conns, err := tr.GetActiveConnections("test")
+	require.NoError(t, err)
+	require.Len(t, conns.Conns, 2)
+
+	incoming := findProcNetConn(conns.Conns, 8080)
+	require.NotNil(t, incoming)
+	assert.Equal(t, network.INCOMING, incoming.Direction)
+	assert.Equal(t, uint32(42), incoming.Pid)
+	assert.Equal(t, uint32(4026531992), incoming.NetNS)
+
+	outgoing := findProcNetConn(conns.Conns, 50000)
+	require.NotNil(t, outgoing)
+	assert.Equal(t, network.OUTGOING, outgoing.Direction)
+	assert.Equal(t, util.AddressFromString("10.0.2.2"), outgoing.Dest)
+	assert.Equal(t, uint16(443), outgoing.DPort)
+	assert.Equal(t, network.TCP, outgoing.Type)
+
+	// the outgoing connection gets closed, and a new one is established
+	writeProcNetFile(t, procRoot, "tcp", procNetTCPHeader+procNetListen+procNetIncoming+
+		"   2: 0F02000A:C352 0202000A:01BB 01 00000000:00000000 00:00000000 00000000     0        0 103 1 ffff88003cc20000 20 4 1 10 -1\n")
+	require.NoError(t, tr.snapshot(true))
+	writeProcNetFile(t, procRoot, "tcp", procNetTCPHeader+procNetListen+procNetIncoming)
+
+	conns, err = tr.GetActiveConnections("test")
+	require.NoError(t, err)
+	require.Len(t, conns.Conns, 3)
+
+	closed := findProcNetConn(conns.Conns, 50000)
+	require.NotNil(t, closed)
+	assert.Equal(t, uint32(1), closed.LastTCPClosed)
+
+	shortLived := findProcNetConn(conns.Conns, 50002)
+	require.NotNil(t, shortLived)
+	assert.Equal(t, uint32(1), shortLived.LastTCPEstablished)
+	assert.Equal(t, uint32(1), shortLived.LastTCPClosed)
+
+	stats, err := tr.GetStats()
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), stats["tracer"].(map[string]int64)["closed_conns"])
+}
+
+func TestProcNetTracerUDP(t *testing.T) {
+	procRoot := newTestProcRoot(t)
+	defer os.RemoveAll(procRoot)
+	writeProcNetFile(t, procRoot, "tcp", procNetTCPHeader)
+	writeProcNetFile(t, procRoot, "udp", procNetTCPHeader+
+		// unconnected socket bound on 0.0.0.0:53
+		"   0: 00000000:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 104 2 ffff88003cc20780 0\n"+
+		// 0.0.0.0:53 <- 10.0.2.2:40000, connected
+		"   1: 0F02000A:0035 0202000A:9C40 01 00000000:00000000 00:00000000 00000000     0        0 105 2 ffff88003cc20780 0\n"+
+		// 10.0.2.15:40001 -> 8.8.8.8:53, connected
+		"   2: 0F02000A:9C41 08080808:0035 01 00000000:00000000 00:00000000 00000000     0        0 106 2 ffff88003cc20780 0\n")
+
+	cfg := NewDefaultConfig()
+	cfg.ProcRoot = procRoot
+	cfg.EnableConntrack = false
+	cfg.CollectIPv6Conns = false
+	cfg.ProcNetPollInterval = time.Hour
+
+	tr, err := NewProcNetTracer(cfg)
+	require.NoError(t, err)
+	defer tr.Stop()
+
+	conns, err := tr.GetActiveConnections("test")
+	require.NoError(t, err)
+	require.Len(t, conns.Conns, 2)
+
+	incoming := findProcNetConn(conns.Conns, 53)
+	require.NotNil(t, incoming)
+	assert.Equal(t, network.INCOMING, incoming.Direction)
+	assert.Equal(t, network.UDP, incoming.Type)
+
+	outgoing := findProcNetConn(conns.Conns, 40001)
+	require.NotNil(t, outgoing)
+	assert.Equal(t, network.OUTGOING, outgoing.Direction)
+}
+
+func TestProcNetTracerNamespaces(t *testing.T) {
+	procRoot := newTestProcRoot(t)
+	defer os.RemoveAll(procRoot)
+	writeProcNetFile(t, procRoot, "tcp", procNetTCPHeader)
+	writeProcNetFile(t, procRoot, "udp", procNetTCPHeader)
+
+	// processes 42 and 43 share the host network namespace, 44 runs in a container
+	newTestNetNS(t, procRoot, "42", "4026531992", procNetTCPHeader+procNetListen+procNetIncoming)
+	newTestNetNS(t, procRoot, "43", "4026531992", procNetTCPHeader+procNetListen+procNetIncoming)
+	newTestNetNS(t, procRoot, "44", "4026532281", procNetTCPHeader+
+		// 172.17.0.2:50000 -> 10.0.2.2:443
+		"   0: 020011AC:C350 0202000A:01BB 01 00000000:00000000 00:00000000 00000000     0        0 107 1 ffff88003cc20000 20 4 1 10 -1\n")
+	require.NoError(t, os.MkdirAll(path.Join(procRoot, "44", "fd"), 0755))
+	require.NoError(t, os.Symlink("socket:[107]", path.Join(procRoot, "44", "fd", "3")))
+
+	cfg := NewDefaultConfig()
+	cfg.ProcRoot = procRoot
+	cfg.EnableConntrack = false
+	cfg.CollectIPv6Conns = false
+	cfg.ProcNetPollInterval = time.Hour
+
+	tr, err := NewProcNetTracer(cfg)
+	require.NoError(t, err)
+	defer tr.Stop()
+
+	conns, err := tr.GetActiveConnections("test")
+	require.NoError(t, err)
+	require.Len(t, conns.Conns, 2)
+
+	incoming := findProcNetConn(conns.Conns, 8080)
+	require.NotNil(t, incoming)
+	assert.Equal(t, uint32(42), incoming.Pid)
+	assert.Equal(t, uint32(4026531992), incoming.NetNS)
+
+	container := findProcNetConn(conns.Conns, 50000)
+	require.NotNil(t, container)
+	assert.Equal(t, util.AddressFromString("172.17.0.2"), container.Source)
+	assert.Equal(t, uint32(44), container.Pid)
+	assert.Equal(t, uint32(4026532281), container.NetNS)
+
+	// the owners of the known sockets are not looked up again
+	require.NoError(t, os.RemoveAll(path.Join(procRoot, "44", "fd")))
+	conns, err = tr.GetActiveConnections("test")
+	require.NoError(t, err)
+	container = findProcNetConn(conns.Conns, 50000)
+	require.NotNil(t, container)
+	assert.Equal(t, uint32(44), container.Pid)
+}
+
+// newTestNetNS creates the network namespace and the tcp sockets of a process
+func newTestNetNS(t *testing.T, procRoot, pid, ino, tcp string) {
+	require.NoError(t, os.MkdirAll(path.Join(procRoot, pid, "ns"), 0755))
+	require.NoError(t, os.Symlink("net:["+ino+"]", path.Join(procRoot, pid, "ns", "net")))
+	require.NoError(t, os.MkdirAll(path.Join(procRoot, pid, "net"), 0755))
+	require.NoError(t, ioutil.WriteFile(path.Join(procRoot, pid, "net", "tcp"), []byte(tcp), 0644))
+	require.NoError(t, ioutil.WriteFile(path.Join(procRoot, pid, "net", "udp"), []byte(procNetTCPHeader), 0644))
+}
+
+// newTestProcRoot creates a proc filesystem with a process owning the sockets of inodes 100 to 106
+func newTestProcRoot(t *testing.T) string {
+	procRoot, err := ioutil.TempDir("", "test-proc-net-tracer")
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(path.Join(procRoot, "net"), 0755))
+	require.NoError(t, os.MkdirAll(path.Join(procRoot, "self", "ns"), 0755))
+	require.NoError(t, os.Symlink("net:[4026531992]", path.Join(procRoot, "self", "ns", "net")))
+
+	fdDir := path.Join(procRoot, "42", "fd")
+	require.NoError(t, os.MkdirAll(fdDir, 0755))
+	for i, inode := range []string{"100", "101", "102", "103", "104", "105", "106"} {
+		require.NoError(t, os.Symlink("socket:["+inode+"]", path.Join(fdDir, string('3'+rune(i)))))
+	}
+	return procRoot
+}
+
+func writeProcNetFile(t *testing.T, procRoot, name, content string) {
+	require.NoError(t, ioutil.WriteFile(path.Join(procRoot, "net", name), []byte(content), 0644))
+}
+
+func findProcNetConn(conns []network.ConnectionStats, sport uint16) *network.ConnectionStats {
+	for i := range conns {
+		if conns[i].SPort == sport {
+			return &conns[i]
+		}
+	}
+	return nil
+}
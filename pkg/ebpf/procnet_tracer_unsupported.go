@@ -0,0 +1,41 @@
+// +build !linux android
+
+package ebpf
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network"
+)
+
+// ProcNetTracer is not implemented on this platform
+type ProcNetTracer struct{}
+
+// NewProcNetTracer is not implemented on this platform
+func NewProcNetTracer(_ *Config) (*ProcNetTracer, error) {
+	return nil, ErrNotImplemented
+}
+
+// Stop is not implemented on this platform
+func (t *ProcNetTracer) Stop() {}
+
+// GetActiveConnections is not implemented on this platform
+func (t *ProcNetTracer) GetActiveConnections(_ string) (*network.Connections, error) {
+	return nil, ErrNotImplemented
+}
+
+// SetClientFilter is not implemented on this platform
+func (t *ProcNetTracer) SetClientFilter(_ string, _ *network.ClientFilter) {}
+
+// GetStats is not implemented on this platform
+func (t *ProcNetTracer) GetStats() (map[string]interface{}, error) {
+	return nil, ErrNotImplemented
+}
+
+// DebugNetworkState is not implemented on this platform
+func (t *ProcNetTracer) DebugNetworkState(_ string) (map[string]interface{}, error) {
+	return nil, ErrNotImplemented
+}
+
+// DebugNetworkMaps is not implemented on this platform
+func (t *ProcNetTracer) DebugNetworkMaps() (*network.Connections, error) {
+	return nil, ErrNotImplemented
+}
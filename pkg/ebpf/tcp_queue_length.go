@@ -108,6 +108,11 @@ func (t *TCPQueueLengthTracer) GetAndFlush() []tcpqueuelength.Stats {
 	return result
 }
 
+// GetAndFlushEvents returns the queue lengths since the last call, as a []tcpqueuelength.Stats
+func (t *TCPQueueLengthTracer) GetAndFlushEvents() interface{} {
+	return t.GetAndFlush()
+}
+
 func convertStat(in C.struct_stats) (out tcpqueuelength.Stats) {
 	out.Pid = uint32(in.pid)
 	out.ContainerID = C.GoString(&in.cgroup_name[0])
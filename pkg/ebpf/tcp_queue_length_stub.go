@@ -26,3 +26,8 @@ func (t *TCPQueueLengthTracer) Get() []tcpqueuelength.Stats {
 func (t *TCPQueueLengthTracer) GetAndFlush() []tcpqueuelength.Stats {
 	return nil
 }
+
+// GetAndFlushEvents is not implemented on non-linux systems
+func (t *TCPQueueLengthTracer) GetAndFlushEvents() interface{} {
+	return nil
+}
@@ -2,8 +2,17 @@ package tcpqueuelength
 
 import (
 	"net"
+
+	"github.com/DataDog/datadog-agent/pkg/ebpf/kevent"
 )
 
+// CheckName is the name of the TCP queue length check
+const CheckName = "tcp_queue_length"
+
+func init() {
+	kevent.Register(kevent.Definition{Name: CheckName, Event: Stats{}})
+}
+
 // QueueLength contains the size and fullness extremums of a TCP Queue
 type QueueLength struct {
 	Size int    `json:"size"`
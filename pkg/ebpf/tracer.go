@@ -14,6 +14,8 @@ import (
 
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
 	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/http"
+	"github.com/DataDog/datadog-agent/pkg/network/metadata"
 	"github.com/DataDog/datadog-agent/pkg/network/netlink"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -24,7 +26,7 @@ import (
 
 var (
 	expvarEndpoints map[string]*expvar.Map
-	expvarTypes     = []string{"conntrack", "state", "tracer", "ebpf", "kprobes", "dns"}
+	expvarTypes     = []string{"conntrack", "state", "tracer", "ebpf", "kprobes", "dns", "http", "metadata"}
 )
 
 func init() {
@@ -47,6 +49,10 @@ type Tracer struct {
 
 	reverseDNS network.ReverseDNS
 
+	httpMonitor http.Monitor
+
+	metadata metadata.Resolver
+
 	perfMap      *manager.PerfMap
 	perfHandler  *bytecode.PerfHandler
 	batchManager *PerfBatchManager
@@ -195,6 +201,7 @@ func NewTracer(config *Config) (*Tracer, error) {
 			config.CollectDNSStats,
 			config.CollectLocalDNS,
 			config.DNSTimeout,
+			config.MaxDNSDomains,
 		); err == nil {
 			reverseDNS = snooper
 		} else {
@@ -221,6 +228,25 @@ func NewTracer(config *Config) (*Tracer, error) {
 		}
 	}
 
+	// the protocol classification relies on the payloads captured by the HTTP monitor
+	httpMonitor := http.NewNullMonitor()
+	if config.EnableHTTPMonitoring || config.EnableProtocolClassification {
+		if mon, err := http.NewSocketMonitor(config.ProcRoot, config.MaxHTTPStatsBuffered, config.EnableHTTPMonitoring, config.EnableProtocolClassification); err != nil {
+			log.Warnf("could not initialize the TCP payload capture, tracer will continue without HTTP stats nor protocol classification: %s", err)
+		} else {
+			httpMonitor = mon
+		}
+	}
+
+	metadataResolver := metadata.NewNullResolver()
+	if config.CollectContainerTags || config.CollectKubernetesTags {
+		if r, err := metadata.NewResolver(config.CollectContainerTags, config.CollectKubernetesTags); err != nil {
+			log.Warnf("could not initialize connection metadata resolution, tracer will continue without connection tags: %s", err)
+		} else {
+			metadataResolver = r
+		}
+	}
+
 	state := network.NewState(
 		config.ClientStateExpiry,
 		config.MaxClosedConnectionsBuffered,
@@ -235,6 +261,8 @@ func NewTracer(config *Config) (*Tracer, error) {
 		portMapping:    portMapping,
 		udpPortMapping: udpPortMapping,
 		reverseDNS:     reverseDNS,
+		httpMonitor:    httpMonitor,
+		metadata:       metadataResolver,
 		buffer:         make([]network.ConnectionStats, 0, 512),
 		buf:            &bytes.Buffer{},
 		conntracker:    conntracker,
@@ -416,6 +444,8 @@ func (t *Tracer) storeClosedConn(cs network.ConnectionStats) {
 
 func (t *Tracer) Stop() {
 	t.reverseDNS.Close()
+	t.httpMonitor.Close()
+	t.metadata.Close()
 	_ = t.m.Stop(manager.CleanAll)
 	_ = t.perfMap.Stop(manager.CleanAll)
 	t.perfHandler.Stop()
@@ -446,9 +476,31 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 
 	conns := t.state.Connections(clientID, latestTime, latestConns, t.reverseDNS.GetDNSStats())
 	names := t.reverseDNS.Resolve(conns)
+	t.metadata.Resolve(conns)
+	t.classifyConnections(conns)
+	t.state.StoreHTTPStats(t.httpMonitor.GetAndResetAllStats())
+	httpStats := t.state.GetHTTPStats(clientID, conns)
 	tm := t.getConnTelemetry(len(latestConns))
 
-	return &network.Connections{Conns: conns, DNS: names, Telemetry: tm}, nil
+	return &network.Connections{Conns: conns, DNS: names, HTTP: httpStats, Telemetry: tm}, nil
+}
+
+// classifyConnections sets the application-layer protocol of the TCP connections, as classified
+// by the HTTP monitor from the first payload captured on them
+func (t *Tracer) classifyConnections(conns []network.ConnectionStats) {
+	if !t.config.EnableProtocolClassification {
+		return
+	}
+	for i := range conns {
+		if conns[i].Type == network.TCP {
+			conns[i].Protocol = t.httpMonitor.GetProtocol(conns[i].Source, conns[i].SPort, conns[i].Dest, conns[i].DPort)
+		}
+	}
+}
+
+// SetClientFilter sets the filter of the connections returned to the given client, or removes it if nil
+func (t *Tracer) SetClientFilter(clientID string, filter *network.ClientFilter) {
+	t.state.SetClientFilter(clientID, filter)
 }
 
 func (t *Tracer) getConnTelemetry(mapSize int) *network.ConnectionsTelemetry {
@@ -738,9 +790,11 @@ func (t *Tracer) GetStats() (map[string]interface{}, error) {
 			"expired_tcp_conns":            expiredTCP,
 			"pid_collisions":               pidCollisions,
 		},
-		"ebpf":    t.getEbpfTelemetry(),
-		"kprobes": GetProbeStats(),
-		"dns":     t.reverseDNS.GetStats(),
+		"ebpf":     t.getEbpfTelemetry(),
+		"kprobes":  GetProbeStats(),
+		"dns":      t.reverseDNS.GetStats(),
+		"http":     t.httpMonitor.GetStats(),
+		"metadata": t.metadata.GetStats(),
 	}, nil
 }
 
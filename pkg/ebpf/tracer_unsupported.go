@@ -20,6 +20,9 @@ func (t *Tracer) GetActiveConnections(_ string) (*network.Connections, error) {
 	return nil, ErrNotImplemented
 }
 
+// SetClientFilter is not implemented on this OS for Tracer
+func (t *Tracer) SetClientFilter(_ string, _ *network.ClientFilter) {}
+
 // GetStats is not implemented on this OS for Tracer
 func (t *Tracer) GetStats() (map[string]interface{}, error) {
 	return nil, ErrNotImplemented
@@ -148,6 +148,11 @@ func (t *Tracer) GetActiveConnections(clientID string) (*network.Connections, er
 	return &network.Connections{Conns: conns}, nil
 }
 
+// SetClientFilter sets the filter of the connections returned to the given client, or removes it if nil
+func (t *Tracer) SetClientFilter(clientID string, filter *network.ClientFilter) {
+	t.state.SetClientFilter(clientID, filter)
+}
+
 func (t *Tracer) resizeConnectionStatBuffer(compareSize int, buffer []network.ConnectionStats) []network.ConnectionStats {
 	if compareSize >= cap(buffer)*2 {
 		return make([]network.ConnectionStats, 0, cap(buffer)*2)
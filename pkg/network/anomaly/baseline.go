@@ -0,0 +1,33 @@
+package anomaly
+
+import "math"
+
+// baseline is an exponentially weighted moving average and variance of a series of samples
+type baseline struct {
+	alpha    float64
+	mean     float64
+	variance float64
+	samples  int
+}
+
+// newBaseline returns a baseline giving the same weight as a moving average over the given number of samples
+func newBaseline(window int) *baseline {
+	return &baseline{alpha: 2 / (float64(window) + 1)}
+}
+
+// add folds a sample into the baseline
+func (b *baseline) add(x float64) {
+	b.samples++
+	if b.samples == 1 {
+		b.mean = x
+		return
+	}
+	diff := x - b.mean
+	incr := b.alpha * diff
+	b.mean += incr
+	b.variance = (1 - b.alpha) * (b.variance + diff*incr)
+}
+
+func (b *baseline) stddev() float64 {
+	return math.Sqrt(b.variance)
+}
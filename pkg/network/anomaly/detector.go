@@ -0,0 +1,223 @@
+package anomaly
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+)
+
+// Metric is a TCP metric of a destination monitored for anomalies
+type Metric string
+
+const (
+	// RTT is the average smoothed round trip time of the connections to the destination, in µs
+	RTT Metric = "rtt"
+	// RetransmitRatio is the number of retransmits per segment sent to the destination. The number of
+	// segments is estimated from the sent bytes, since the tracer doesn't count packets.
+	RetransmitRatio Metric = "retransmit_ratio"
+)
+
+const (
+	// estimatedMSS is the segment size used to estimate the number of segments sent from the sent bytes
+	estimatedMSS = 1448
+
+	kubeServiceTagPrefix = "dest_kube_service:"
+)
+
+// Config holds the settings of a Detector
+type Config struct {
+	// Window is the number of snapshots the baseline of a destination is averaged over
+	Window int
+	// MinSamples is the number of snapshots of a destination required before reporting its anomalies
+	MinSamples int
+	// Threshold is the number of standard deviations above the baseline a value must be to be anomalous
+	Threshold float64
+	// MinIncrease is the minimum increase of a value relative to its baseline for it to be anomalous,
+	// so that very stable destinations don't report negligible deviations
+	MinIncrease float64
+	// MinSegments is the minimum number of segments sent to a destination between two snapshots for its
+	// retransmit ratio to be evaluated
+	MinSegments float64
+	// Expiry is the time after which the baseline of a destination without traffic is forgotten
+	Expiry time.Duration
+	// MaxDestinations is the maximum number of destinations with a baseline
+	MaxDestinations int
+}
+
+// DefaultConfig returns the default settings of a Detector
+func DefaultConfig() Config {
+	return Config{
+		Window:          20,
+		MinSamples:      10,
+		Threshold:       3,
+		MinIncrease:     0.5,
+		MinSegments:     100,
+		Expiry:          30 * time.Minute,
+		MaxDestinations: 10000,
+	}
+}
+
+// Anomaly is a metric of a destination deviating from its baseline
+type Anomaly struct {
+	// Destination is the Kubernetes service of the destination if known, or its IP address
+	Destination string
+	Metric      Metric
+	Value       float64
+	Baseline    float64
+	StdDev      float64
+	// Connections is the number of connections to the destination in the snapshot
+	Connections int
+}
+
+// destination holds the baselines of a destination
+type destination struct {
+	rtt             *baseline
+	retransmitRatio *baseline
+	lastSeen        time.Time
+}
+
+// sample is the aggregate of the connections to a destination in a snapshot
+type sample struct {
+	conns       int
+	rttSum      float64
+	rttConns    int
+	retransmits float64
+	segments    float64
+}
+
+// Detector compares the RTT and retransmit ratio of the destinations of successive snapshots of connections
+// to their rolling baseline. It is not safe for concurrent use.
+type Detector struct {
+	config       Config
+	destinations map[string]*destination
+
+	// telemetry
+	snapshots int64
+	anomalies int64
+	dropped   int64
+}
+
+// NewDetector returns a new Detector
+func NewDetector(config Config) *Detector {
+	return &Detector{
+		config:       config,
+		destinations: make(map[string]*destination),
+	}
+}
+
+// Process aggregates the TCP connections of a snapshot by destination, and returns the destinations whose RTT or
+// retransmit ratio deviates from their baseline. The connections must hold the traffic since the previous snapshot,
+// as returned by network.State for a given client.
+func (d *Detector) Process(conns []network.ConnectionStats, now time.Time) []Anomaly {
+	d.snapshots++
+
+	samples := make(map[string]*sample)
+	for i := range conns {
+		c := &conns[i]
+		if c.Type != network.TCP {
+			continue
+		}
+		key := destinationKey(c)
+		s, ok := samples[key]
+		if !ok {
+			s = &sample{}
+			samples[key] = s
+		}
+		s.conns++
+		if c.RTT > 0 {
+			s.rttSum += float64(c.RTT)
+			s.rttConns++
+		}
+		s.retransmits += float64(c.LastRetransmits)
+		s.segments += math.Ceil(float64(c.LastSentBytes) / estimatedMSS)
+	}
+
+	var anomalies []Anomaly
+	for key, s := range samples {
+		dest, ok := d.destinations[key]
+		if !ok {
+			if len(d.destinations) >= d.config.MaxDestinations {
+				d.dropped++
+				continue
+			}
+			dest = &destination{
+				rtt:             newBaseline(d.config.Window),
+				retransmitRatio: newBaseline(d.config.Window),
+			}
+			d.destinations[key] = dest
+		}
+		dest.lastSeen = now
+
+		if s.rttConns > 0 {
+			if a, ok := d.evaluate(dest.rtt, s.rttSum/float64(s.rttConns)); ok {
+				a.Destination, a.Metric, a.Connections = key, RTT, s.conns
+				anomalies = append(anomalies, a)
+			}
+		}
+		if s.segments >= d.config.MinSegments {
+			if a, ok := d.evaluate(dest.retransmitRatio, s.retransmits/s.segments); ok {
+				a.Destination, a.Metric, a.Connections = key, RetransmitRatio, s.conns
+				anomalies = append(anomalies, a)
+			}
+		}
+	}
+
+	sort.Slice(anomalies, func(i, j int) bool {
+		if anomalies[i].Destination != anomalies[j].Destination {
+			return anomalies[i].Destination < anomalies[j].Destination
+		}
+		return anomalies[i].Metric < anomalies[j].Metric
+	})
+
+	d.expire(now)
+	d.anomalies += int64(len(anomalies))
+	return anomalies
+}
+
+// evaluate compares the value to the baseline, then folds it into the baseline
+func (d *Detector) evaluate(b *baseline, value float64) (Anomaly, bool) {
+	a := Anomaly{Value: value, Baseline: b.mean, StdDev: b.stddev()}
+	anomalous := b.samples >= d.config.MinSamples &&
+		value > b.mean+d.config.Threshold*a.StdDev &&
+		value > b.mean*(1+d.config.MinIncrease)
+
+	b.add(value)
+	return a, anomalous
+}
+
+func (d *Detector) expire(now time.Time) {
+	for key, dest := range d.destinations {
+		if now.Sub(dest.lastSeen) > d.config.Expiry {
+			delete(d.destinations, key)
+		}
+	}
+}
+
+// GetStats returns the telemetry of the detector
+func (d *Detector) GetStats() map[string]int64 {
+	return map[string]int64{
+		"snapshots":            d.snapshots,
+		"anomalies":            d.anomalies,
+		"destinations":         int64(len(d.destinations)),
+		"dropped_destinations": d.dropped,
+	}
+}
+
+// destinationKey returns the Kubernetes service of the destination of the connection if it is tagged with one,
+// or the address of the destination, after translation
+func destinationKey(c *network.ConnectionStats) string {
+	for _, tag := range c.Tags {
+		if strings.HasPrefix(tag, kubeServiceTagPrefix) {
+			return "service:" + strings.TrimPrefix(tag, kubeServiceTagPrefix)
+		}
+	}
+
+	dest := c.Dest
+	if t := c.IPTranslation; t != nil && t.ReplSrcIP != nil {
+		dest = t.ReplSrcIP
+	}
+	return dest.String()
+}
@@ -0,0 +1,162 @@
+package anomaly
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-go/statsd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tcpConn(dest string, rtt, retransmits uint32, sentBytes uint64) network.ConnectionStats {
+	return network.ConnectionStats{
+		Source:          util.AddressFromString("10.0.0.1"),
+		Dest:            util.AddressFromString(dest),
+		SPort:           40000,
+		DPort:           443,
+		Type:            network.TCP,
+		RTT:             rtt,
+		LastRetransmits: retransmits,
+		LastSentBytes:   sentBytes,
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	b := newBaseline(3)
+	for _, x := range []float64{10, 10, 10, 10} {
+		b.add(x)
+	}
+	assert.Equal(t, 10.0, b.mean)
+	assert.Equal(t, 0.0, b.stddev())
+
+	b.add(20)
+	assert.Equal(t, 15.0, b.mean)
+	assert.Equal(t, 5.0, b.stddev())
+}
+
+func TestDetectRTTAnomaly(t *testing.T) {
+	d := NewDetector(DefaultConfig())
+	now := time.Now()
+
+	// stable RTT around 10ms, with some noise
+	for i := 0; i < 20; i++ {
+		rtt := uint32(10000 + (i%3)*500)
+		anomalies := d.Process([]network.ConnectionStats{tcpConn("10.0.0.2", rtt, 0, 0)}, now)
+		require.Empty(t, anomalies)
+		now = now.Add(30 * time.Second)
+	}
+
+	anomalies := d.Process([]network.ConnectionStats{
+		tcpConn("10.0.0.2", 40000, 0, 0),
+		tcpConn("10.0.0.2", 60000, 0, 0),
+		tcpConn("10.0.0.3", 60000, 0, 0),
+	}, now)
+	require.Len(t, anomalies, 1)
+	a := anomalies[0]
+	assert.Equal(t, "10.0.0.2", a.Destination)
+	assert.Equal(t, RTT, a.Metric)
+	assert.Equal(t, 50000.0, a.Value)
+	assert.Equal(t, 2, a.Connections)
+	assert.InDelta(t, 10500, a.Baseline, 100)
+}
+
+func TestDetectRetransmitAnomaly(t *testing.T) {
+	d := NewDetector(DefaultConfig())
+	now := time.Now()
+
+	// ~1% retransmits over 1000 segments
+	for i := 0; i < 20; i++ {
+		anomalies := d.Process([]network.ConnectionStats{tcpConn("10.0.0.2", 0, uint32(8+i%5), 1000*estimatedMSS)}, now)
+		require.Empty(t, anomalies)
+		now = now.Add(30 * time.Second)
+	}
+
+	// not enough traffic to evaluate the retransmit ratio
+	require.Empty(t, d.Process([]network.ConnectionStats{tcpConn("10.0.0.2", 0, 50, 50*estimatedMSS)}, now))
+
+	anomalies := d.Process([]network.ConnectionStats{tcpConn("10.0.0.2", 0, 100, 1000*estimatedMSS)}, now)
+	require.Len(t, anomalies, 1)
+	assert.Equal(t, RetransmitRatio, anomalies[0].Metric)
+	assert.Equal(t, 0.1, anomalies[0].Value)
+}
+
+func TestDetectorMinSamples(t *testing.T) {
+	d := NewDetector(DefaultConfig())
+	now := time.Now()
+	for i := 0; i < DefaultConfig().MinSamples-1; i++ {
+		d.Process([]network.ConnectionStats{tcpConn("10.0.0.2", 10000, 0, 0)}, now)
+	}
+	assert.Empty(t, d.Process([]network.ConnectionStats{tcpConn("10.0.0.2", 100000, 0, 0)}, now))
+}
+
+func TestDetectorDestinationKey(t *testing.T) {
+	c := tcpConn("10.0.0.2", 0, 0, 0)
+	assert.Equal(t, "10.0.0.2", destinationKey(&c))
+
+	c.IPTranslation = &network.IPTranslation{ReplSrcIP: util.AddressFromString("172.17.0.2")}
+	assert.Equal(t, "172.17.0.2", destinationKey(&c))
+
+	c.Tags = []string{"dest_pod_name:web-0", "dest_kube_service:web"}
+	assert.Equal(t, "service:web", destinationKey(&c))
+}
+
+func TestDetectorExpiry(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxDestinations = 1
+	d := NewDetector(cfg)
+	now := time.Now()
+
+	d.Process([]network.ConnectionStats{tcpConn("10.0.0.2", 10000, 0, 0), tcpConn("10.0.0.3", 10000, 0, 0)}, now)
+	assert.Equal(t, int64(1), d.GetStats()["destinations"])
+	assert.Equal(t, int64(1), d.GetStats()["dropped_destinations"])
+
+	d.Process(nil, now.Add(cfg.Expiry+time.Second))
+	assert.Equal(t, int64(0), d.GetStats()["destinations"])
+}
+
+type recordingClient struct {
+	*statsd.NoOpClient
+	gauges map[string]float64
+	events []*statsd.Event
+}
+
+func (c *recordingClient) Gauge(name string, value float64, tags []string, rate float64) error {
+	c.gauges[name] = value
+	return nil
+}
+
+func (c *recordingClient) Event(e *statsd.Event) error {
+	c.events = append(c.events, e)
+	return nil
+}
+
+func TestReporter(t *testing.T) {
+	client := &recordingClient{gauges: make(map[string]float64)}
+	r := NewReporter(client, 10*time.Minute, []string{"env:test"})
+	now := time.Now()
+
+	a := Anomaly{Destination: "service:web", Metric: RTT, Value: 50000, Baseline: 10000, StdDev: 500, Connections: 2}
+	b := Anomaly{Destination: "10.0.0.1", Metric: RTT, Value: 30000, Baseline: 10000, StdDev: 500, Connections: 1}
+	r.Report([]Anomaly{a, b}, now)
+	r.Report([]Anomaly{a}, now.Add(time.Minute))
+
+	assert.Equal(t, 1.0, client.gauges["network.tcp.anomaly.rtt.destinations"])
+	assert.Equal(t, 0.0, client.gauges["network.tcp.anomaly.retransmit_ratio.destinations"])
+
+	// the third event is rate limited
+	require.Len(t, client.events, 2)
+	e := client.events[0]
+	assert.Equal(t, "TCP round trip time to service:web is abnormally high", e.Title)
+	assert.Equal(t, "The average RTT of the 2 connections to service:web is 50.0ms, above its baseline of 10.0ms (standard deviation 0.5ms).", e.Text)
+	assert.Equal(t, "service:web", e.AggregationKey)
+	// the destination is not a tag, to keep the cardinality of the tags low
+	assert.Equal(t, []string{"metric:rtt", "env:test"}, e.Tags)
+	assert.Equal(t, statsd.Warning, e.AlertType)
+	assert.Equal(t, int64(1), r.GetStats()["events_rate_limited"])
+
+	r.Report([]Anomaly{a}, now.Add(11*time.Minute))
+	assert.Len(t, client.events, 3)
+}
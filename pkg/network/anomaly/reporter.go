@@ -0,0 +1,110 @@
+package anomaly
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+	"github.com/DataDog/datadog-go/statsd"
+)
+
+const (
+	metricPrefix = "network.tcp.anomaly."
+	eventSource  = "system-probe"
+)
+
+// metrics are the metrics monitored for anomalies
+var metrics = []Metric{RTT, RetransmitRatio}
+
+// Reporter sends the anomalies to DogStatsD: a gauge of the number of anomalous destinations by metric,
+// and an event per destination and metric at most once per cooldown period. The destinations are only
+// named in the events, as there can be many of them.
+type Reporter struct {
+	client     statsd.ClientInterface
+	cooldown   time.Duration
+	lastEvents map[string]time.Time
+	tags       []string
+
+	// telemetry
+	events      int64
+	sendErrors  int64
+	rateLimited int64
+}
+
+// NewReporter returns a new Reporter
+func NewReporter(client statsd.ClientInterface, cooldown time.Duration, tags []string) *Reporter {
+	return &Reporter{
+		client:     client,
+		cooldown:   cooldown,
+		lastEvents: make(map[string]time.Time),
+		tags:       tags,
+	}
+}
+
+// Report sends the anomalies
+func (r *Reporter) Report(anomalies []Anomaly, now time.Time) {
+	destinations := make(map[Metric]int, len(metrics))
+	for _, a := range anomalies {
+		destinations[a.Metric]++
+
+		key := a.Destination + "/" + string(a.Metric)
+		if last, ok := r.lastEvents[key]; ok && now.Sub(last) < r.cooldown {
+			r.rateLimited++
+			continue
+		}
+		r.lastEvents[key] = now
+		r.events++
+		r.send(r.client.Event(newEvent(a, now, r.tags)))
+	}
+
+	// the gauges are sent even without anomaly, so that they drop back to 0
+	for _, m := range metrics {
+		r.send(r.client.Gauge(metricPrefix+string(m)+".destinations", float64(destinations[m]), r.tags, 1))
+	}
+
+	for key, last := range r.lastEvents {
+		if now.Sub(last) >= r.cooldown {
+			delete(r.lastEvents, key)
+		}
+	}
+}
+
+func (r *Reporter) send(err error) {
+	if err != nil {
+		r.sendErrors++
+		log.Debugf("unable to send network anomaly: %s", err)
+	}
+}
+
+// GetStats returns the telemetry of the reporter
+func (r *Reporter) GetStats() map[string]int64 {
+	return map[string]int64{
+		"events":              r.events,
+		"events_rate_limited": r.rateLimited,
+		"send_errors":         r.sendErrors,
+	}
+}
+
+func newEvent(a Anomaly, now time.Time, tags []string) *statsd.Event {
+	var title, text string
+	switch a.Metric {
+	case RTT:
+		title = fmt.Sprintf("TCP round trip time to %s is abnormally high", a.Destination)
+		text = fmt.Sprintf("The average RTT of the %d connections to %s is %.1fms, above its baseline of %.1fms (standard deviation %.1fms).",
+			a.Connections, a.Destination, a.Value/1000, a.Baseline/1000, a.StdDev/1000)
+	case RetransmitRatio:
+		title = fmt.Sprintf("TCP retransmits to %s are abnormally high", a.Destination)
+		text = fmt.Sprintf("%.2f%% of the segments sent on the %d connections to %s were retransmitted, above its baseline of %.2f%% (standard deviation %.2f%%).",
+			a.Value*100, a.Connections, a.Destination, a.Baseline*100, a.StdDev*100)
+	}
+
+	return &statsd.Event{
+		Title:          title,
+		Text:           text,
+		Timestamp:      now,
+		AggregationKey: a.Destination,
+		SourceTypeName: eventSource,
+		AlertType:      statsd.Warning,
+		Tags:           append([]string{"metric:" + string(a.Metric)}, tags...),
+	}
+}
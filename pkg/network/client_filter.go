@@ -0,0 +1,214 @@
+package network
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/network/http"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// Query parameters of the client filters
+const (
+	filterCIDRParam          = "cidr"
+	filterPortParam          = "port"
+	filterPIDParam           = "pid"
+	filterNetNSParam         = "netns"
+	filterIntraHostOnlyParam = "intra_host_only"
+)
+
+// ClientFilter restricts the connections returned to a client. A connection is returned if it
+// matches every criterion set in the filter; a criterion with several values matches if any of
+// them matches. Unlike ConnectionFilter, it doesn't affect the connections tracked by the
+// system-probe, only what is sent to the client.
+type ClientFilter struct {
+	// CIDRs matches the connections with a source or destination address in one of the networks
+	CIDRs []*net.IPNet
+	// Ports matches the connections with a source or destination port in the set
+	Ports map[uint16]struct{}
+	// PIDs matches the connections of the processes in the set
+	PIDs map[uint32]struct{}
+	// NetNS matches the connections of the network namespaces (inode numbers) in the set
+	NetNS map[uint32]struct{}
+	// IntraHostOnly matches the connections between two endpoints of the host
+	IntraHostOnly bool
+}
+
+// ParseClientFilter returns the filter described by the query parameters of a /connections request:
+// - cidr: networks or addresses, e.g. cidr=10.0.0.0/8,fd00::1
+// - port: ports, e.g. port=80,443
+// - pid: process IDs
+// - netns: network namespace inode numbers
+// - intra_host_only: true to only get connections within the host
+// Parameters can be repeated or hold comma-separated values. It returns nil if no filter is set.
+func ParseClientFilter(values url.Values) (*ClientFilter, error) {
+	filter := &ClientFilter{}
+	set := false
+
+	for _, v := range splitParam(values, filterCIDRParam) {
+		ipNet, err := parseCIDR(v)
+		if err != nil {
+			return nil, err
+		}
+		filter.CIDRs = append(filter.CIDRs, ipNet)
+		set = true
+	}
+
+	for _, v := range splitParam(values, filterPortParam) {
+		port, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %q: %s", v, err)
+		}
+		if filter.Ports == nil {
+			filter.Ports = make(map[uint16]struct{})
+		}
+		filter.Ports[uint16(port)] = struct{}{}
+		set = true
+	}
+
+	var err error
+	if filter.PIDs, err = parseUint32Set(splitParam(values, filterPIDParam), "pid"); err != nil {
+		return nil, err
+	}
+	if filter.NetNS, err = parseUint32Set(splitParam(values, filterNetNSParam), "network namespace"); err != nil {
+		return nil, err
+	}
+	set = set || filter.PIDs != nil || filter.NetNS != nil
+
+	if v := values.Get(filterIntraHostOnlyParam); v != "" {
+		if filter.IntraHostOnly, err = strconv.ParseBool(v); err != nil {
+			return nil, fmt.Errorf("invalid %s value %q: %s", filterIntraHostOnlyParam, v, err)
+		}
+		set = set || filter.IntraHostOnly
+	}
+
+	if !set {
+		return nil, nil
+	}
+	return filter, nil
+}
+
+// Matches returns whether the connection matches the filter
+func (f *ClientFilter) Matches(c *ConnectionStats) bool {
+	if f.IntraHostOnly && !c.IntraHost {
+		return false
+	}
+	if f.PIDs != nil {
+		if _, ok := f.PIDs[c.Pid]; !ok {
+			return false
+		}
+	}
+	if f.NetNS != nil {
+		if _, ok := f.NetNS[c.NetNS]; !ok {
+			return false
+		}
+	}
+	if f.Ports != nil {
+		_, sok := f.Ports[c.SPort]
+		_, dok := f.Ports[c.DPort]
+		if !sok && !dok {
+			return false
+		}
+	}
+	if len(f.CIDRs) > 0 && !f.containsAddress(c.Source) && !f.containsAddress(c.Dest) {
+		return false
+	}
+	return true
+}
+
+func (f *ClientFilter) containsAddress(a util.Address) bool {
+	if a == nil {
+		return false
+	}
+	ip := util.NetIPFromAddress(a)
+	for _, ipNet := range f.CIDRs {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// filterConnections removes the connections not matching f from conns, in place
+func (f *ClientFilter) filterConnections(conns []ConnectionStats) []ConnectionStats {
+	filtered := conns[:0]
+	for i := range conns {
+		if f.Matches(&conns[i]) {
+			filtered = append(filtered, conns[i])
+		}
+	}
+	return filtered
+}
+
+// httpTuple identifies the connection of an HTTP request
+type httpTuple struct {
+	srcIP, dstIP     util.Address
+	srcPort, dstPort uint16
+}
+
+// filterHTTPStats removes from stats, in place, the stats of the requests which were not made
+// on one of conns. The requests are matched in both directions, as the HTTP stats are keyed
+// from the client to the server, whatever the direction of the connection.
+func filterHTTPStats(stats map[http.Key]*http.RequestStats, conns []ConnectionStats) {
+	tuples := make(map[httpTuple]struct{}, 2*len(conns))
+	for _, c := range conns {
+		tuples[httpTuple{c.Source, c.Dest, c.SPort, c.DPort}] = struct{}{}
+		tuples[httpTuple{c.Dest, c.Source, c.DPort, c.SPort}] = struct{}{}
+	}
+	for key := range stats {
+		if _, ok := tuples[httpTuple{key.SrcIP, key.DstIP, key.SrcPort, key.DstPort}]; !ok {
+			delete(stats, key)
+		}
+	}
+}
+
+// splitParam returns the values of the query parameter, splitting comma-separated values
+func splitParam(values url.Values, name string) []string {
+	var res []string
+	for _, v := range values[name] {
+		for _, s := range strings.Split(v, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				res = append(res, s)
+			}
+		}
+	}
+	return res
+}
+
+// parseCIDR parses a network, or an address which is turned into a single host network
+func parseCIDR(s string) (*net.IPNet, error) {
+	if strings.ContainsRune(s, '/') {
+		_, ipNet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CIDR %q: %s", s, err)
+		}
+		return ipNet, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address %q", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+func parseUint32Set(values []string, what string) (map[uint32]struct{}, error) {
+	if len(values) == 0 {
+		return nil, nil
+	}
+	set := make(map[uint32]struct{}, len(values))
+	for _, v := range values {
+		n, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %s", what, v, err)
+		}
+		set[uint32(n)] = struct{}{}
+	}
+	return set, nil
+}
@@ -0,0 +1,94 @@
+package network
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseClientFilter(t *testing.T) {
+	filter, err := ParseClientFilter(url.Values{"client_id": {"1"}})
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+
+	filter, err = ParseClientFilter(url.Values{"intra_host_only": {"false"}})
+	require.NoError(t, err)
+	assert.Nil(t, filter)
+
+	filter, err = ParseClientFilter(url.Values{
+		"cidr":            {"10.0.0.0/8,fd00::1", "192.168.1.1"},
+		"port":            {"80, 443"},
+		"pid":             {"42"},
+		"netns":           {"4026531993"},
+		"intra_host_only": {"true"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, filter)
+	require.Len(t, filter.CIDRs, 3)
+	assert.Equal(t, "10.0.0.0/8", filter.CIDRs[0].String())
+	assert.Equal(t, "fd00::1/128", filter.CIDRs[1].String())
+	assert.Equal(t, "192.168.1.1/32", filter.CIDRs[2].String())
+	assert.Equal(t, map[uint16]struct{}{80: {}, 443: {}}, filter.Ports)
+	assert.Equal(t, map[uint32]struct{}{42: {}}, filter.PIDs)
+	assert.Equal(t, map[uint32]struct{}{4026531993: {}}, filter.NetNS)
+	assert.True(t, filter.IntraHostOnly)
+
+	for _, invalid := range []url.Values{
+		{"cidr": {"10.0.0.0/33"}},
+		{"cidr": {"10.0.0"}},
+		{"port": {"65536"}},
+		{"pid": {"-1"}},
+		{"netns": {"abc"}},
+		{"intra_host_only": {"maybe"}},
+	} {
+		_, err := ParseClientFilter(invalid)
+		assert.Error(t, err, "%v", invalid)
+	}
+}
+
+func TestClientFilterMatches(t *testing.T) {
+	conn := ConnectionStats{
+		Pid:       42,
+		NetNS:     1234,
+		Source:    util.AddressFromString("10.0.0.1"),
+		Dest:      util.AddressFromString("192.168.1.1"),
+		SPort:     40000,
+		DPort:     80,
+		IntraHost: false,
+	}
+
+	parse := func(query string) *ClientFilter {
+		values, err := url.ParseQuery(query)
+		require.NoError(t, err)
+		filter, err := ParseClientFilter(values)
+		require.NoError(t, err)
+		require.NotNil(t, filter)
+		return filter
+	}
+
+	for query, matches := range map[string]bool{
+		"cidr=10.0.0.0/8":                true,
+		"cidr=192.168.1.1":               true,
+		"cidr=172.16.0.0/12":             false,
+		"cidr=fd00::/8":                  false,
+		"port=80":                        true,
+		"port=40000":                     true,
+		"port=443":                       false,
+		"pid=1,42":                       true,
+		"pid=43":                         false,
+		"netns=1234":                     true,
+		"netns=1":                        false,
+		"intra_host_only=true":           false,
+		"cidr=10.0.0.0/8&port=80&pid=42": true,
+		"cidr=10.0.0.0/8&port=80&pid=43": false,
+		"cidr=172.16.0.0/12&port=80":     false,
+	} {
+		assert.Equal(t, matches, parse(query).Matches(&conn), query)
+	}
+
+	conn.IntraHost = true
+	assert.True(t, parse("intra_host_only=true").Matches(&conn))
+}
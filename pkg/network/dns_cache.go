@@ -35,6 +35,8 @@ type reverseDNSCache struct {
 	oversizedLogLimit *util.LogLimit
 }
 
+// newReverseDNSCache returns a cache whose entries are expired every expirationPeriod. If it is zero,
+// the entries are only expired by the calls to Expire.
 func newReverseDNSCache(size int, ttl, expirationPeriod time.Duration) *reverseDNSCache {
 	cache := &reverseDNSCache{
 		data:              make(map[util.Address]*dnsCacheVal),
@@ -44,6 +46,9 @@ func newReverseDNSCache(size int, ttl, expirationPeriod time.Duration) *reverseD
 		oversizedLogLimit: util.NewLogLimit(10, time.Minute*10),
 		maxDomainsPerIP:   1000,
 	}
+	if expirationPeriod == 0 {
+		return cache
+	}
 
 	ticker := time.NewTicker(expirationPeriod)
 	go func() {
@@ -141,6 +146,18 @@ func (c *reverseDNSCache) Get(conns []ConnectionStats, now time.Time) map[util.A
 	return resolved
 }
 
+// dump returns the names of all the IPs of the cache
+func (c *reverseDNSCache) dump() map[util.Address][]string {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	names := make(map[util.Address][]string, len(c.data))
+	for addr, val := range c.data {
+		names[addr] = val.copy()
+	}
+	return names
+}
+
 func (c *reverseDNSCache) Len() int {
 	return int(atomic.LoadInt64(&c.length))
 }
@@ -166,7 +183,7 @@ func (c *reverseDNSCache) Stats() map[string]int64 {
 }
 
 func (c *reverseDNSCache) Close() {
-	c.exit <- struct{}{}
+	close(c.exit)
 }
 
 func (c *reverseDNSCache) Expire(now time.Time) {
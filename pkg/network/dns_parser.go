@@ -127,16 +127,23 @@ func (p *dnsParser) parseAnswerInto(
 	t *translation,
 	pktInfo *dnsPacketInfo,
 ) error {
-	// Only consider singleton, A-record questions
+	// Only consider singleton questions
 	if len(dns.Questions) != 1 {
 		return errSkippedPayload
 	}
 
+	// Only A-record questions are relevant for the reverse DNS cache, while stats are
+	// collected for all the question types
 	question := dns.Questions[0]
-	if question.Type != layers.DNSTypeA || question.Class != layers.DNSClassIN {
+	if question.Class != layers.DNSClassIN || (question.Type != layers.DNSTypeA && !p.collectDNSStats) {
 		return errSkippedPayload
 	}
 
+	pktInfo.question = DNSQuestion{
+		Domain: string(bytes.ToLower(question.Name)),
+		Type:   QueryType(question.Type),
+	}
+
 	// Only consider responses
 	if !dns.QR {
 		pktInfo.pktType = Query
@@ -149,6 +156,11 @@ func (p *dnsParser) parseAnswerInto(
 		return nil
 	}
 
+	pktInfo.pktType = SuccessfulResponse
+	if question.Type != layers.DNSTypeA {
+		return nil
+	}
+
 	var alias []byte
 	domainQueried := question.Name
 
@@ -162,8 +174,6 @@ func (p *dnsParser) parseAnswerInto(
 	p.extractIPsInto(alias, domainQueried, dns.Answers, t)
 	p.extractIPsInto(alias, domainQueried, dns.Additionals, t)
 	t.dns = string(domainQueried)
-
-	pktInfo.pktType = SuccessfulResponse
 	return nil
 }
 
@@ -0,0 +1,209 @@
+// +build linux_bpf
+
+package network
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+)
+
+// ethernetHeaderLen is the length of the ethernet header the DNS parser expects the packets to start with
+const ethernetHeaderLen = 14
+
+// DNSReplayStats are the DNS stats of a client socket for a single question
+type DNSReplayStats struct {
+	ClientIP   util.Address
+	ClientPort uint16
+	ServerIP   util.Address
+	Protocol   ConnectionType
+	Question   DNSQuestion
+	Stats      DNSStats
+}
+
+// DNSReplay is the result of the replay of a packet capture through the DNS snooping code
+type DNSReplay struct {
+	// Stats holds the DNS stats computed from the capture, sorted by client, server and question
+	Stats []DNSReplayStats
+	// Names holds the domains each IP resolves to at the end of the capture, as stored by the reverse DNS cache
+	Names map[util.Address][]string
+	// Telemetry holds the counters of the snooper, as reported by the system-probe
+	Telemetry map[string]int64
+	// Packets is the number of packets read from the capture
+	Packets int64
+	// PendingQueries is the number of queries without response, which were sent less than the DNS
+	// timeout before the end of the capture
+	PendingQueries int
+}
+
+// ReplayDNSPcap feeds the packets of a pcap capture to the DNS parser, stat keeper and reverse DNS cache used
+// by the SocketFilterSnooper, and returns the resulting stats and IP to domain mappings. Queries without response
+// at the end of the capture are accounted as timeouts if they were sent more than dnsTimeout before the last packet.
+func ReplayDNSPcap(r io.Reader, collectLocalDNS bool, dnsTimeout time.Duration, maxDNSDomains int) (*DNSReplay, error) {
+	reader, err := pcapgo.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read pcap header: %s", err)
+	}
+	linkType := reader.LinkType()
+	if !isSupportedLinkType(linkType) {
+		return nil, fmt.Errorf("unsupported link type %s", linkType)
+	}
+
+	// no packet source: packets are processed synchronously, in the order of the capture.
+	// The cache entries and the queries without response are expired along the time of the capture,
+	// rather than the wall clock.
+	snooper := &SocketFilterSnooper{
+		parser:          newDNSParser(true),
+		cache:           newReverseDNSCache(dnsCacheSize, dnsCacheTTL, 0),
+		statKeeper:      newUnexpiredDNSStatkeeper(dnsTimeout, maxDNSDomains),
+		translation:     new(translation),
+		collectLocalDNS: collectLocalDNS,
+	}
+	defer func() {
+		snooper.cache.Close()
+		snooper.statKeeper.Close()
+	}()
+
+	replay := &DNSReplay{}
+	var buf []byte
+	var lastTs, lastCacheExpiration, lastStateExpiration time.Time
+	for {
+		data, ci, err := reader.ReadPacketData()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("error reading packet %d: %s", replay.Packets+1, err)
+		}
+
+		replay.Packets++
+		lastTs = ci.Timestamp
+		if replay.Packets == 1 {
+			lastCacheExpiration, lastStateExpiration = lastTs, lastTs
+		}
+
+		// expire the cache entries and the queries without response, as the snooper does periodically
+		if lastTs.Sub(lastCacheExpiration) >= dnsCacheExpirationPeriod {
+			snooper.cache.Expire(lastTs)
+			lastCacheExpiration = lastTs
+		}
+		if lastTs.Sub(lastStateExpiration) >= dnsTimeout {
+			snooper.statKeeper.removeExpiredStates(lastTs.Add(-dnsTimeout))
+			lastStateExpiration = lastTs
+		}
+		buf = toEthernetFrame(linkType, data, buf)
+		if buf == nil {
+			continue
+		}
+		snooper.processPacket(buf, ci.Timestamp)
+	}
+
+	// expire the cache entries and the queries without response at the end of the capture
+	snooper.cache.Expire(lastTs)
+	snooper.statKeeper.removeExpiredStates(lastTs.Add(-dnsTimeout))
+	snooper.statKeeper.mux.Lock()
+	replay.PendingQueries = len(snooper.statKeeper.state)
+	snooper.statKeeper.mux.Unlock()
+
+	replay.Stats = flattenDNSStats(snooper.GetDNSStats())
+	replay.Names = snooper.cache.dump()
+	replay.Telemetry = map[string]int64{
+		"decoding_errors":   snooper.decodingErrors,
+		"truncated_packets": snooper.truncatedPkts,
+		"queries":           snooper.queries,
+		"successes":         snooper.successes,
+		"other_successes":   snooper.otherSuccesses,
+		"errors":            snooper.errors,
+	}
+	return replay, nil
+}
+
+func isSupportedLinkType(linkType layers.LinkType) bool {
+	switch linkType {
+	case layers.LinkTypeEthernet, layers.LinkTypeLinuxSLL, layers.LinkTypeRaw, layers.LinkTypeIPv4, layers.LinkTypeIPv6:
+		return true
+	}
+	return false
+}
+
+// toEthernetFrame returns the packet as an ethernet frame, which is what the packets captured by the
+// SocketFilterSnooper are. The frame of packets captured on other link types is built in buf.
+// It returns nil if the packet can't be converted.
+func toEthernetFrame(linkType layers.LinkType, data []byte, buf []byte) []byte {
+	var etherType layers.EthernetType
+	switch linkType {
+	case layers.LinkTypeEthernet:
+		return data
+	case layers.LinkTypeLinuxSLL:
+		// the 16 bytes header of "any" interface captures ends with the protocol of the packet
+		if len(data) < 16 {
+			return nil
+		}
+		etherType = layers.EthernetType(binary.BigEndian.Uint16(data[14:16]))
+		data = data[16:]
+	default:
+		if len(data) == 0 {
+			return nil
+		}
+		switch data[0] >> 4 {
+		case 4:
+			etherType = layers.EthernetTypeIPv4
+		case 6:
+			etherType = layers.EthernetTypeIPv6
+		default:
+			return nil
+		}
+	}
+
+	buf = append(buf[:0], make([]byte, ethernetHeaderLen)...)
+	binary.BigEndian.PutUint16(buf[12:ethernetHeaderLen], uint16(etherType))
+	return append(buf, data...)
+}
+
+// flattenDNSStats returns the stats of the stat keeper as a sorted list
+func flattenDNSStats(stats map[dnsKey]dnsStatsByQuestion) []DNSReplayStats {
+	var flattened []DNSReplayStats
+	for key, byQuestion := range stats {
+		for question, s := range byQuestion {
+			flattened = append(flattened, DNSReplayStats{
+				ClientIP:   key.clientIP,
+				ClientPort: key.clientPort,
+				ServerIP:   key.serverIP,
+				Protocol:   key.protocol,
+				Question:   question,
+				Stats: DNSStats{
+					SuccessLatency: s.successLatency,
+					FailureLatency: s.failureLatency,
+					Timeouts:       s.timeouts,
+					CountByRcode:   formatRcodes(s.countByRcode),
+				},
+			})
+		}
+	}
+
+	sort.Slice(flattened, func(i, j int) bool {
+		a, b := flattened[i], flattened[j]
+		if a.ClientIP.String() != b.ClientIP.String() {
+			return a.ClientIP.String() < b.ClientIP.String()
+		}
+		if a.ClientPort != b.ClientPort {
+			return a.ClientPort < b.ClientPort
+		}
+		if a.ServerIP.String() != b.ServerIP.String() {
+			return a.ServerIP.String() < b.ServerIP.String()
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		if a.Question.Domain != b.Question.Domain {
+			return a.Question.Domain < b.Question.Domain
+		}
+		return a.Question.Type < b.Question.Type
+	})
+	return flattened
+}
@@ -0,0 +1,163 @@
+// +build linux_bpf
+
+package network
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	pcapClientIP = net.ParseIP("10.0.2.15").To4()
+	pcapServerIP = net.ParseIP("8.8.8.8").To4()
+)
+
+type pcapPacket struct {
+	ts   time.Time
+	data []byte
+}
+
+// dnsPacket returns a DNS query, or a response if answers or rcode are set, between the client and the server
+func dnsPacket(t *testing.T, id uint16, domain string, response bool, rcode layers.DNSResponseCode, answers ...net.IP) []byte {
+	dns := &layers.DNS{
+		ID:           id,
+		QR:           response,
+		ResponseCode: rcode,
+		Questions:    []layers.DNSQuestion{{Name: []byte(domain), Type: layers.DNSTypeA, Class: layers.DNSClassIN}},
+	}
+	for _, ip := range answers {
+		dns.Answers = append(dns.Answers, layers.DNSResourceRecord{
+			Name: []byte(domain), Type: layers.DNSTypeA, Class: layers.DNSClassIN, TTL: 60, IP: ip,
+		})
+	}
+
+	ip := &layers.IPv4{Version: 4, TTL: 64, Protocol: layers.IPProtocolUDP, SrcIP: pcapClientIP, DstIP: pcapServerIP}
+	udp := &layers.UDP{SrcPort: 40000, DstPort: 53}
+	if response {
+		ip.SrcIP, ip.DstIP = ip.DstIP, ip.SrcIP
+		udp.SrcPort, udp.DstPort = udp.DstPort, udp.SrcPort
+	}
+	require.NoError(t, udp.SetNetworkLayerForChecksum(ip))
+
+	buf := gopacket.NewSerializeBuffer()
+	opts := gopacket.SerializeOptions{FixLengths: true, ComputeChecksums: true}
+	require.NoError(t, gopacket.SerializeLayers(buf, opts, ip, udp, dns))
+	return buf.Bytes()
+}
+
+// writePcap returns a capture of the IP packets, with headers of the given link type
+func writePcap(t *testing.T, linkType layers.LinkType, packets []pcapPacket) *bytes.Buffer {
+	out := &bytes.Buffer{}
+	w := pcapgo.NewWriter(out)
+	require.NoError(t, w.WriteFileHeader(65536, linkType))
+
+	for _, p := range packets {
+		var header []byte
+		switch linkType {
+		case layers.LinkTypeEthernet:
+			header = make([]byte, 14)
+			header[12], header[13] = 0x08, 0x00
+		case layers.LinkTypeLinuxSLL:
+			header = make([]byte, 16)
+			header[14], header[15] = 0x08, 0x00
+		}
+		data := append(header, p.data...)
+		ci := gopacket.CaptureInfo{Timestamp: p.ts, CaptureLength: len(data), Length: len(data)}
+		require.NoError(t, w.WritePacket(ci, data))
+	}
+	return out
+}
+
+func TestReplayDNSPcap(t *testing.T) {
+	start := time.Unix(1600000000, 0)
+	packets := []pcapPacket{
+		// successful query, answered in 3ms
+		{start, dnsPacket(t, 1, "www.example.com", false, 0)},
+		{start.Add(3 * time.Millisecond), dnsPacket(t, 1, "www.example.com", true, 0, net.ParseIP("1.2.3.4"), net.ParseIP("1.2.3.5"))},
+		// failed query
+		{start.Add(time.Second), dnsPacket(t, 2, "missing.example.com", false, 0)},
+		{start.Add(time.Second + 10*time.Millisecond), dnsPacket(t, 2, "missing.example.com", true, layers.DNSResponseCodeNXDomain)},
+		// timed out query
+		{start.Add(2 * time.Second), dnsPacket(t, 3, "slow.example.com", false, 0)},
+		// query without response at the end of the capture
+		{start.Add(20 * time.Second), dnsPacket(t, 4, "last.example.com", false, 0)},
+	}
+
+	for _, linkType := range []layers.LinkType{layers.LinkTypeEthernet, layers.LinkTypeLinuxSLL, layers.LinkTypeRaw} {
+		t.Run(linkType.String(), func(t *testing.T) {
+			replay, err := ReplayDNSPcap(writePcap(t, linkType, packets), false, 15*time.Second, 1000)
+			require.NoError(t, err)
+
+			assert.Equal(t, int64(6), replay.Packets)
+			assert.Equal(t, 1, replay.PendingQueries)
+			assert.Equal(t, int64(4), replay.Telemetry["queries"])
+			assert.Equal(t, int64(1), replay.Telemetry["successes"])
+			assert.Equal(t, int64(1), replay.Telemetry["errors"])
+			assert.Equal(t, int64(0), replay.Telemetry["decoding_errors"])
+
+			require.Len(t, replay.Stats, 3)
+			for _, s := range replay.Stats {
+				assert.Equal(t, util.AddressFromNetIP(pcapClientIP), s.ClientIP)
+				assert.Equal(t, util.AddressFromNetIP(pcapServerIP), s.ServerIP)
+				assert.Equal(t, uint16(40000), s.ClientPort)
+				assert.Equal(t, UDP, s.Protocol)
+			}
+
+			missing := replay.Stats[0]
+			assert.Equal(t, DNSQuestion{Domain: "missing.example.com", Type: TypeA}, missing.Question)
+			assert.Equal(t, uint32(1), missing.Stats.FailureLatency.Count())
+			assert.Equal(t, uint64(10000), missing.Stats.FailureLatency.Sum)
+			assert.Equal(t, map[uint32]uint32{uint32(layers.DNSResponseCodeNXDomain): 1}, missing.Stats.CountByRcode)
+
+			slow := replay.Stats[1]
+			assert.Equal(t, "slow.example.com", slow.Question.Domain)
+			assert.Equal(t, uint32(1), slow.Stats.Timeouts)
+
+			www := replay.Stats[2]
+			assert.Equal(t, "www.example.com", www.Question.Domain)
+			assert.Equal(t, uint32(1), www.Stats.SuccessLatency.Count())
+			assert.Equal(t, uint64(3000), www.Stats.SuccessLatency.Sum)
+
+			assert.Equal(t, map[util.Address][]string{
+				util.AddressFromString("1.2.3.4"): {"www.example.com"},
+				util.AddressFromString("1.2.3.5"): {"www.example.com"},
+			}, replay.Names)
+		})
+	}
+}
+
+func TestReplayDNSPcapExpiration(t *testing.T) {
+	// a capture older than the cache TTL, whose entries must be expired along the time of the capture
+	start := time.Unix(1600000000, 0)
+	packets := []pcapPacket{
+		{start, dnsPacket(t, 1, "old.example.com", false, 0)},
+		{start.Add(time.Millisecond), dnsPacket(t, 1, "old.example.com", true, 0, net.ParseIP("1.2.3.4"))},
+		{start.Add(dnsCacheTTL), dnsPacket(t, 2, "new.example.com", false, 0)},
+		{start.Add(dnsCacheTTL + time.Millisecond), dnsPacket(t, 2, "new.example.com", true, 0, net.ParseIP("1.2.3.5"))},
+		{start.Add(dnsCacheTTL + time.Minute), dnsPacket(t, 3, "last.example.com", false, 0)},
+	}
+
+	replay, err := ReplayDNSPcap(writePcap(t, layers.LinkTypeEthernet, packets), false, 15*time.Second, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, map[util.Address][]string{
+		util.AddressFromString("1.2.3.5"): {"new.example.com"},
+	}, replay.Names)
+	assert.Equal(t, 1, replay.PendingQueries)
+}
+
+func TestReplayDNSPcapUnsupportedLinkType(t *testing.T) {
+	_, err := ReplayDNSPcap(writePcap(t, layers.LinkTypeIEEE802_11, nil), false, 15*time.Second, 1000)
+	require.Error(t, err)
+
+	_, err = ReplayDNSPcap(bytes.NewReader([]byte("not a pcap file")), false, 15*time.Second, 1000)
+	require.Error(t, err)
+}
@@ -47,8 +47,11 @@ type SocketFilterSnooper struct {
 
 	// DNS telemetry, values calculated *till* the last tick in pollStats
 	queries   int64
-	successes int64
+	successes int64 // successful responses to A queries, which are the ones resolving connection addresses
 	errors    int64
+
+	// successful responses to the other query types, only accounted in the DNS stats
+	otherSuccesses int64
 }
 
 // NewSocketFilterSnooper returns a new SocketFilterSnooper
@@ -58,6 +61,7 @@ func NewSocketFilterSnooper(
 	collectDNSStats bool,
 	collectLocalDNS bool,
 	dnsTimeout time.Duration,
+	maxDNSDomains int,
 ) (*SocketFilterSnooper, error) {
 
 	var (
@@ -79,7 +83,7 @@ func NewSocketFilterSnooper(
 	cache := newReverseDNSCache(dnsCacheSize, dnsCacheTTL, dnsCacheExpirationPeriod)
 	var statKeeper *dnsStatKeeper
 	if collectDNSStats {
-		statKeeper = newDNSStatkeeper(dnsTimeout)
+		statKeeper = newDNSStatkeeper(dnsTimeout, maxDNSDomains)
 	}
 	snooper := &SocketFilterSnooper{
 		source:          packetSrc,
@@ -113,7 +117,7 @@ func (s *SocketFilterSnooper) Resolve(connections []ConnectionStats) map[util.Ad
 	return s.cache.Get(connections, time.Now())
 }
 
-func (s *SocketFilterSnooper) GetDNSStats() map[dnsKey]dnsStats {
+func (s *SocketFilterSnooper) GetDNSStats() map[dnsKey]dnsStatsByQuestion {
 	if s.statKeeper == nil {
 		return nil
 	}
@@ -130,6 +134,7 @@ func (s *SocketFilterSnooper) GetStats() map[string]int64 {
 	stats["truncated_packets"] = atomic.LoadInt64(&s.truncatedPkts)
 	stats["queries"] = atomic.LoadInt64(&s.queries)
 	stats["successes"] = atomic.LoadInt64(&s.successes)
+	stats["other_successes"] = atomic.LoadInt64(&s.otherSuccesses)
 	stats["errors"] = atomic.LoadInt64(&s.errors)
 	stats["timestamp_micro_secs"] = time.Now().UnixNano() / 1000
 	return stats
@@ -151,7 +156,7 @@ func (s *SocketFilterSnooper) Close() {
 // call since the underlying memory content gets invalidated by `afpacket`.
 // The *translation is recycled and re-used in subsequent calls and it should not be accessed concurrently.
 // The second parameter `ts` is the time when the packet was captured off the wire. This is used for latency calculation
-// and much more reliable than calling time.Now() at the user layer.
+// and the expiration of the cache entries, and much more reliable than calling time.Now() at the user layer.
 func (s *SocketFilterSnooper) processPacket(data []byte, ts time.Time) {
 	t := s.getCachedTranslation()
 	pktInfo := dnsPacketInfo{}
@@ -173,8 +178,12 @@ func (s *SocketFilterSnooper) processPacket(data []byte, ts time.Time) {
 	}
 
 	if pktInfo.pktType == SuccessfulResponse {
-		s.cache.Add(t, time.Now())
-		atomic.AddInt64(&s.successes, 1)
+		if pktInfo.question.Type == TypeA {
+			s.cache.Add(t, ts)
+			atomic.AddInt64(&s.successes, 1)
+		} else {
+			atomic.AddInt64(&s.otherSuccesses, 1)
+		}
 	} else if pktInfo.pktType == FailedResponse {
 		atomic.AddInt64(&s.errors, 1)
 	} else {
@@ -66,6 +66,7 @@ func getSnooper(
 		collectStats,
 		collectLocalDNS,
 		dnsTimeout,
+		1000,
 	)
 	require.NoError(t, err)
 	return mgr, reverseDNS
@@ -229,7 +230,7 @@ func getKey(
 func getStats(
 	snooper *SocketFilterSnooper,
 	expectedCount int,
-) map[dnsKey]dnsStats {
+) map[dnsKey]dnsStatsByQuestion {
 	timeout := time.After(1 * time.Second)
 Loop:
 	// Wait until DNS stats becomes available
@@ -239,7 +240,7 @@ Loop:
 			break Loop
 		default:
 			// Break if we have processed all the expected responses
-			if snooper.successes+snooper.errors >= int64(expectedCount) {
+			if snooper.successes+snooper.otherSuccesses+snooper.errors >= int64(expectedCount) {
 				break Loop
 			}
 		}
@@ -272,12 +273,21 @@ func TestDNSOverTCPSuccessfulResponseCount(t *testing.T) {
 	require.Equal(t, 1, len(allStats))
 
 	// Exactly one rcode (0, success) is expected
-	require.Equal(t, 1, len(allStats[key].countByRcode))
-
-	assert.Equal(t, uint32(len(domains)), allStats[key].countByRcode[uint8(layers.DNSResponseCodeNoErr)])
-	assert.True(t, allStats[key].successLatencySum >= uint64(1))
-	assert.Equal(t, uint32(0), allStats[key].timeouts)
-	assert.Equal(t, uint64(0), allStats[key].failureLatencySum)
+	total := allStats[key].total()
+	require.Equal(t, 1, len(total.countByRcode))
+
+	assert.Equal(t, uint32(len(domains)), total.countByRcode[uint8(layers.DNSResponseCodeNoErr)])
+	assert.True(t, total.successLatency.Sum >= uint64(1))
+	assert.Equal(t, uint32(0), total.timeouts)
+	assert.Equal(t, uint64(0), total.failureLatency.Sum)
+
+	// Each domain has its own stats
+	require.Equal(t, len(domains), len(allStats[key]))
+	for _, domain := range domains {
+		stats := allStats[key][DNSQuestion{Domain: domain, Type: TypeA}]
+		assert.Equal(t, uint32(1), stats.countByRcode[uint8(layers.DNSResponseCodeNoErr)])
+		assert.Equal(t, uint32(1), stats.successLatency.Count())
+	}
 }
 
 type handler struct{}
@@ -327,13 +337,15 @@ func TestDNSFailedResponseCount(t *testing.T) {
 	require.Equal(t, 2, len(allStats))
 
 	// First check the one sent over TCP. Expected error type: NXDomain
-	require.Equal(t, 1, len(allStats[key1].countByRcode))
-	assert.Equal(t, uint32(len(domains)), allStats[key1].countByRcode[uint8(layers.DNSResponseCodeNXDomain)])
+	total1 := allStats[key1].total()
+	require.Equal(t, 1, len(total1.countByRcode))
+	assert.Equal(t, uint32(len(domains)), total1.countByRcode[uint8(layers.DNSResponseCodeNXDomain)])
 
 	// Next check the one sent over UDP. Expected error type: ServFail
 	key2 := getKey(queryIP, queryPort, localhost, UDP)
-	require.Equal(t, 1, len(allStats[key2].countByRcode))
-	assert.Equal(t, uint32(len(domains)), allStats[key2].countByRcode[uint8(layers.DNSResponseCodeServFail)])
+	total2 := allStats[key2].total()
+	require.Equal(t, 1, len(total2.countByRcode))
+	assert.Equal(t, uint32(len(domains)), total2.countByRcode[uint8(layers.DNSResponseCodeServFail)])
 }
 
 func TestDNSOverUDPTimeoutCount(t *testing.T) {
@@ -348,10 +360,11 @@ func TestDNSOverUDPTimeoutCount(t *testing.T) {
 	allStats := getStats(reverseDNS, 1)
 	key := getKey(queryIP, queryPort, invalidServerIP, UDP)
 	require.Equal(t, 1, len(allStats))
-	assert.Equal(t, 0, len(allStats[key].countByRcode))
-	assert.Equal(t, uint32(1), allStats[key].timeouts)
-	assert.Equal(t, uint64(0), allStats[key].successLatencySum)
-	assert.Equal(t, uint64(0), allStats[key].failureLatencySum)
+	total := allStats[key].total()
+	assert.Equal(t, 0, len(total.countByRcode))
+	assert.Equal(t, uint32(1), total.timeouts)
+	assert.Equal(t, uint64(0), total.successLatency.Sum)
+	assert.Equal(t, uint64(0), total.failureLatency.Sum)
 }
 
 func TestParsingError(t *testing.T) {
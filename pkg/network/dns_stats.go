@@ -1,6 +1,7 @@
 package network
 
 import (
+	"fmt"
 	"sync"
 	"time"
 
@@ -8,10 +9,127 @@ import (
 )
 
 type dnsStats struct {
-	successLatencySum uint64 // Stored in µs
-	failureLatencySum uint64
-	timeouts          uint32
-	countByRcode      map[uint8]uint32
+	successLatency LatencyHistogram
+	failureLatency LatencyHistogram
+	timeouts       uint32
+	countByRcode   map[uint8]uint32
+}
+
+// QueryType is the type (QTYPE) of a DNS question
+type QueryType uint16
+
+// Query types commonly found in DNS questions
+const (
+	TypeA     QueryType = 1
+	TypeNS    QueryType = 2
+	TypeCNAME QueryType = 5
+	TypeSOA   QueryType = 6
+	TypePTR   QueryType = 12
+	TypeMX    QueryType = 15
+	TypeTXT   QueryType = 16
+	TypeAAAA  QueryType = 28
+	TypeSRV   QueryType = 33
+)
+
+var queryTypeNames = map[QueryType]string{
+	TypeA:     "A",
+	TypeNS:    "NS",
+	TypeCNAME: "CNAME",
+	TypeSOA:   "SOA",
+	TypePTR:   "PTR",
+	TypeMX:    "MX",
+	TypeTXT:   "TXT",
+	TypeAAAA:  "AAAA",
+	TypeSRV:   "SRV",
+}
+
+func (q QueryType) String() string {
+	if name, ok := queryTypeNames[q]; ok {
+		return name
+	}
+	return fmt.Sprintf("TYPE%d", uint16(q))
+}
+
+// DNSOtherDomain is the domain under which the stats of the questions are aggregated once the
+// maximum number of domains is reached
+const DNSOtherDomain = "*"
+
+// DNSQuestion is the domain and the query type of a DNS question
+type DNSQuestion struct {
+	Domain string
+	Type   QueryType
+}
+
+// DNSLatencyBuckets holds the upper bounds, in µs, of the buckets of a LatencyHistogram.
+var DNSLatencyBuckets = [...]uint64{
+	1000, 2000, 5000, 10000, 20000, 50000, 100000, 200000, 500000, 1000000,
+}
+
+// LatencyHistogram holds the distribution of DNS response latencies
+type LatencyHistogram struct {
+	// Sum is the sum of the latencies, in µs
+	Sum uint64
+	// Counts holds the number of latencies lower or equal to the matching bound of DNSLatencyBuckets,
+	// and greater than the previous one. The last count holds the latencies above all bounds.
+	Counts [len(DNSLatencyBuckets) + 1]uint32
+}
+
+// Count returns the number of latencies in the histogram
+func (h LatencyHistogram) Count() uint32 {
+	var n uint32
+	for _, c := range h.Counts {
+		n += c
+	}
+	return n
+}
+
+func (h *LatencyHistogram) add(latency uint64) {
+	h.Sum += latency
+	i := 0
+	for i < len(DNSLatencyBuckets) && latency > DNSLatencyBuckets[i] {
+		i++
+	}
+	h.Counts[i]++
+}
+
+func (h *LatencyHistogram) merge(o LatencyHistogram) {
+	h.Sum += o.Sum
+	for i, c := range o.Counts {
+		h.Counts[i] += c
+	}
+}
+
+// DNSStats holds the DNS stats of a connection for a single DNS question
+type DNSStats struct {
+	SuccessLatency LatencyHistogram
+	FailureLatency LatencyHistogram
+	Timeouts       uint32
+	CountByRcode   map[uint32]uint32
+}
+
+// dnsStatsByQuestion holds the DNS stats of a connection, by question
+type dnsStatsByQuestion map[DNSQuestion]dnsStats
+
+// merge adds the stats of o to s
+func (s *dnsStats) merge(o dnsStats) {
+	s.timeouts += o.timeouts
+	s.successLatency.merge(o.successLatency)
+	s.failureLatency.merge(o.failureLatency)
+	if s.countByRcode == nil {
+		s.countByRcode = make(map[uint8]uint32, len(o.countByRcode))
+	}
+	for rcode, count := range o.countByRcode {
+		s.countByRcode[rcode] += count
+	}
+}
+
+// total returns the stats of all the questions combined
+func (b dnsStatsByQuestion) total() dnsStats {
+	var total dnsStats
+	for _, stats := range b {
+		total.merge(stats)
+	}
+	return total
 }
 
 type dnsKey struct {
@@ -43,33 +161,33 @@ const (
 type dnsPacketInfo struct {
 	transactionID uint16
 	key           dnsKey
+	question      DNSQuestion
 	pktType       DNSPacketType
 	rCode         uint8 // responseCode
 }
 
 type stateKey struct {
-	key dnsKey
-	id  uint16
+	key      dnsKey
+	id       uint16
+	question DNSQuestion
 }
 
 type dnsStatKeeper struct {
 	mux              sync.Mutex
-	stats            map[dnsKey]dnsStats
+	stats            map[dnsKey]dnsStatsByQuestion
 	state            map[stateKey]uint64
+	domains          map[string]struct{} // domains with stats since the last reset
 	expirationPeriod time.Duration
 	exit             chan struct{}
 	maxSize          int // maximum size of the state map
+	maxDomains       int // maximum number of distinct domains between two resets
 	deleteCount      int
 }
 
-func newDNSStatkeeper(timeout time.Duration) *dnsStatKeeper {
-	statsKeeper := &dnsStatKeeper{
-		stats:            make(map[dnsKey]dnsStats),
-		state:            make(map[stateKey]uint64),
-		expirationPeriod: timeout,
-		exit:             make(chan struct{}),
-		maxSize:          MaxStateMapSize,
-	}
+// newDNSStatkeeper returns a stat keeper which periodically accounts the queries without
+// response for more than timeout as timeouts
+func newDNSStatkeeper(timeout time.Duration, maxDomains int) *dnsStatKeeper {
+	statsKeeper := newUnexpiredDNSStatkeeper(timeout, maxDomains)
 
 	ticker := time.NewTicker(statsKeeper.expirationPeriod)
 	go func() {
@@ -86,22 +204,54 @@ func newDNSStatkeeper(timeout time.Duration) *dnsStatKeeper {
 	return statsKeeper
 }
 
+// newUnexpiredDNSStatkeeper returns a stat keeper whose queries without response are only
+// expired by the calls to removeExpiredStates
+func newUnexpiredDNSStatkeeper(timeout time.Duration, maxDomains int) *dnsStatKeeper {
+	return &dnsStatKeeper{
+		stats:            make(map[dnsKey]dnsStatsByQuestion),
+		state:            make(map[stateKey]uint64),
+		domains:          make(map[string]struct{}),
+		expirationPeriod: timeout,
+		exit:             make(chan struct{}),
+		maxSize:          MaxStateMapSize,
+		maxDomains:       maxDomains,
+	}
+}
+
 func microSecs(t time.Time) uint64 {
 	return uint64(t.UnixNano() / 1000)
 }
 
-func (d *dnsStatKeeper) getStats(key dnsKey) dnsStats {
-	stats, ok := d.stats[key]
+// getStats returns the stats of the given key and question. Questions whose domain exceeds
+// the maximum number of domains are accounted under DNSOtherDomain.
+func (d *dnsStatKeeper) getStats(key dnsKey, question DNSQuestion) (dnsStats, DNSQuestion) {
+	if _, ok := d.domains[question.Domain]; !ok {
+		if d.maxDomains > 0 && len(d.domains) >= d.maxDomains {
+			question.Domain = DNSOtherDomain
+		} else {
+			d.domains[question.Domain] = struct{}{}
+		}
+	}
+	stats, ok := d.stats[key][question]
 	if !ok {
 		stats.countByRcode = make(map[uint8]uint32)
 	}
-	return stats
+	return stats, question
+}
+
+func (d *dnsStatKeeper) setStats(key dnsKey, question DNSQuestion, stats dnsStats) {
+	byQuestion, ok := d.stats[key]
+	if !ok {
+		byQuestion = make(dnsStatsByQuestion)
+		d.stats[key] = byQuestion
+	}
+	byQuestion[question] = stats
 }
 
 func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 	d.mux.Lock()
 	defer d.mux.Unlock()
-	sk := stateKey{key: info.key, id: info.transactionID}
+	sk := stateKey{key: info.key, id: info.transactionID, question: info.question}
 
 	if info.pktType == Query {
 		if len(d.state) == d.maxSize {
@@ -126,7 +276,7 @@ func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 
 	latency := microSecs(ts) - start
 
-	stats := d.getStats(info.key)
+	stats, question := d.getStats(info.key, info.question)
 
 	// Note: time.Duration in the agent version of go (1.12.9) does not have the Microseconds method.
 	if latency > uint64(d.expirationPeriod.Microseconds()) {
@@ -134,20 +284,21 @@ func (d *dnsStatKeeper) ProcessPacketInfo(info dnsPacketInfo, ts time.Time) {
 	} else {
 		stats.countByRcode[info.rCode]++
 		if info.pktType == SuccessfulResponse {
-			stats.successLatencySum += latency
+			stats.successLatency.add(latency)
 		} else if info.pktType == FailedResponse {
-			stats.failureLatencySum += latency
+			stats.failureLatency.add(latency)
 		}
 	}
 
-	d.stats[info.key] = stats
+	d.setStats(info.key, question, stats)
 }
 
-func (d *dnsStatKeeper) GetAndResetAllStats() map[dnsKey]dnsStats {
+func (d *dnsStatKeeper) GetAndResetAllStats() map[dnsKey]dnsStatsByQuestion {
 	d.mux.Lock()
 	defer d.mux.Unlock()
 	ret := d.stats // No deep copy needed since `d.stats` gets reset
-	d.stats = make(map[dnsKey]dnsStats)
+	d.stats = make(map[dnsKey]dnsStatsByQuestion)
+	d.domains = make(map[string]struct{})
 	return ret
 }
 
@@ -160,9 +311,9 @@ func (d *dnsStatKeeper) removeExpiredStates(earliestTs time.Time) {
 		if v < threshold {
 			delete(d.state, k)
 			d.deleteCount++
-			stats := d.getStats(k.key)
+			stats, question := d.getStats(k.key, k.question)
 			stats.timeouts++
-			d.stats[k.key] = stats
+			d.setStats(k.key, question, stats)
 		}
 	}
 
@@ -180,5 +331,5 @@ func (d *dnsStatKeeper) removeExpiredStates(earliestTs time.Time) {
 }
 
 func (d *dnsStatKeeper) Close() {
-	d.exit <- struct{}{}
+	close(d.exit)
 }
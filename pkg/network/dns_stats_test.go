@@ -33,7 +33,7 @@ func testLatency(
 	expectedFailureLatency uint64,
 	expectedTimeouts uint32,
 ) {
-	sk := newDNSStatkeeper(DNSTimeoutSecs * time.Second)
+	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 1000)
 	key := getSampleDNSKey()
 	qPkt := dnsPacketInfo{transactionID: 1, pktType: Query, key: key}
 	then := time.Now()
@@ -48,9 +48,9 @@ func testLatency(
 	stats = sk.GetAndResetAllStats()
 	require.Contains(t, stats, key)
 
-	assert.Equal(t, expectedSuccessLatency, stats[key].successLatencySum)
-	assert.Equal(t, expectedFailureLatency, stats[key].failureLatencySum)
-	assert.Equal(t, expectedTimeouts, stats[key].timeouts)
+	assert.Equal(t, expectedSuccessLatency, stats[key].total().successLatency.Sum)
+	assert.Equal(t, expectedFailureLatency, stats[key].total().failureLatency.Sum)
+	assert.Equal(t, expectedTimeouts, stats[key].total().timeouts)
 }
 
 func TestSuccessLatency(t *testing.T) {
@@ -69,7 +69,7 @@ func TestTimeout(t *testing.T) {
 }
 
 func TestExpiredStateRemoval(t *testing.T) {
-	sk := newDNSStatkeeper(DNSTimeoutSecs * time.Second)
+	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 1000)
 	key := getSampleDNSKey()
 	qPkt1 := dnsPacketInfo{transactionID: 1, pktType: Query, key: key}
 	rPkt1 := dnsPacketInfo{transactionID: 1, key: key, pktType: SuccessfulResponse}
@@ -89,9 +89,85 @@ func TestExpiredStateRemoval(t *testing.T) {
 	stats := sk.GetAndResetAllStats()
 	require.Contains(t, stats, key)
 
-	require.Contains(t, stats[key].countByRcode, uint8(0))
-	assert.Equal(t, uint32(2), stats[key].countByRcode[0])
-	assert.Equal(t, uint32(1), stats[key].timeouts)
+	total := stats[key].total()
+	require.Contains(t, total.countByRcode, uint8(0))
+	assert.Equal(t, uint32(2), total.countByRcode[0])
+	assert.Equal(t, uint32(1), total.timeouts)
+}
+
+func TestStatsByQuestion(t *testing.T) {
+	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 1000)
+	key := getSampleDNSKey()
+	fooA := DNSQuestion{Domain: "foo.com", Type: TypeA}
+	fooAAAA := DNSQuestion{Domain: "foo.com", Type: TypeAAAA}
+	bar := DNSQuestion{Domain: "bar.com", Type: TypeA}
+
+	then := time.Now()
+	for i, q := range []DNSQuestion{fooA, fooAAAA, bar} {
+		id := uint16(i)
+		sk.ProcessPacketInfo(dnsPacketInfo{transactionID: id, key: key, question: q, pktType: Query}, then)
+		sk.ProcessPacketInfo(dnsPacketInfo{transactionID: id, key: key, question: q, pktType: SuccessfulResponse}, then.Add(time.Duration(i+1)*time.Millisecond))
+	}
+	// a response to a different question is not matched
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 5, key: key, question: fooA, pktType: Query}, then)
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 5, key: key, question: bar, pktType: SuccessfulResponse}, then)
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats[key], 3)
+	assert.Equal(t, uint64(1000), stats[key][fooA].successLatency.Sum)
+	assert.Equal(t, uint64(2000), stats[key][fooAAAA].successLatency.Sum)
+	assert.Equal(t, uint64(3000), stats[key][bar].successLatency.Sum)
+	assert.Equal(t, uint32(1), stats[key][bar].countByRcode[0])
+}
+
+func TestMaxDomains(t *testing.T) {
+	sk := newDNSStatkeeper(DNSTimeoutSecs*time.Second, 2)
+	key := getSampleDNSKey()
+
+	now := time.Now()
+	for i, domain := range []string{"a.com", "b.com", "c.com", "a.com", "d.com"} {
+		q := DNSQuestion{Domain: domain, Type: TypeA}
+		id := uint16(i)
+		sk.ProcessPacketInfo(dnsPacketInfo{transactionID: id, key: key, question: q, pktType: Query}, now)
+		sk.ProcessPacketInfo(dnsPacketInfo{transactionID: id, key: key, question: q, pktType: FailedResponse, rCode: 3}, now)
+	}
+
+	stats := sk.GetAndResetAllStats()
+	require.Len(t, stats[key], 3)
+	assert.Equal(t, uint32(2), stats[key][DNSQuestion{Domain: "a.com", Type: TypeA}].countByRcode[3])
+	assert.Equal(t, uint32(1), stats[key][DNSQuestion{Domain: "b.com", Type: TypeA}].countByRcode[3])
+	assert.Equal(t, uint32(2), stats[key][DNSQuestion{Domain: DNSOtherDomain, Type: TypeA}].countByRcode[3])
+
+	// the domains are reset along with the stats
+	q := DNSQuestion{Domain: "c.com", Type: TypeA}
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 1, key: key, question: q, pktType: Query}, now)
+	sk.ProcessPacketInfo(dnsPacketInfo{transactionID: 1, key: key, question: q, pktType: SuccessfulResponse}, now)
+	assert.Contains(t, sk.GetAndResetAllStats()[key], q)
+}
+
+func TestLatencyHistogram(t *testing.T) {
+	var h LatencyHistogram
+	for _, latency := range []uint64{0, 1000, 1001, 7000, 1000000, 5000000} {
+		h.add(latency)
+	}
+	assert.Equal(t, uint64(6009001), h.Sum)
+	assert.Equal(t, uint32(6), h.Count())
+	assert.Equal(t, uint32(2), h.Counts[0])
+	assert.Equal(t, uint32(1), h.Counts[1])
+	assert.Equal(t, uint32(1), h.Counts[3])
+	assert.Equal(t, uint32(1), h.Counts[len(DNSLatencyBuckets)-1])
+	assert.Equal(t, uint32(1), h.Counts[len(DNSLatencyBuckets)])
+
+	var merged LatencyHistogram
+	merged.merge(h)
+	merged.merge(h)
+	assert.Equal(t, 2*h.Sum, merged.Sum)
+	assert.Equal(t, uint32(4), merged.Counts[0])
+}
+
+func TestQueryTypeString(t *testing.T) {
+	assert.Equal(t, "AAAA", TypeAAAA.String())
+	assert.Equal(t, "TYPE65", QueryType(65).String())
 }
 
 func BenchmarkStats(b *testing.B) {
@@ -111,7 +187,7 @@ func BenchmarkStats(b *testing.B) {
 			b.ResetTimer()
 			b.ReportAllocs()
 			for i := 0; i < b.N; i++ {
-				sk := newDNSStatkeeper(1000 * time.Second)
+				sk := newDNSStatkeeper(1000*time.Second, 1000)
 				for j := 0; j < numPackets; j++ {
 					sk.ProcessPacketInfo(packets[j], ts)
 				}
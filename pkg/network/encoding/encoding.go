@@ -26,6 +26,8 @@ type Marshaler interface {
 // Unmarshaler is an interface implemented by all Connections deserializers
 type Unmarshaler interface {
 	Unmarshal([]byte) (*model.Connections, error)
+	// UnmarshalExtras decodes the data of the payload which has no equivalent in model.Connections
+	UnmarshalExtras([]byte) (*ConnectionsExtras, error)
 }
 
 // GetMarshaler returns the appropriate Marshaler based on the given accept header
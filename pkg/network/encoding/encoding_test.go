@@ -3,9 +3,12 @@ package encoding
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -163,3 +166,108 @@ func TestSerialization(t *testing.T) {
 		}
 	})
 }
+
+func TestSerializationExtras(t *testing.T) {
+	httpStats := new(http.RequestStats)
+	httpStats.AddRequest(200, time.Millisecond)
+	httpStats.AddRequest(503, 3*time.Millisecond)
+
+	in := &network.Connections{
+		Conns: []network.ConnectionStats{
+			{
+				Source: util.AddressFromString("10.1.1.1"),
+				Dest:   util.AddressFromString("8.8.8.8"),
+				SPort:  1000,
+				DPort:  53,
+				Type:   network.UDP,
+				DNSStatsByDomain: map[network.DNSQuestion]network.DNSStats{
+					{Domain: "golang.org", Type: network.TypeAAAA}: {
+						FailureLatency: network.LatencyHistogram{Sum: 3000, Counts: [11]uint32{0, 0, 1}},
+						CountByRcode:   map[uint32]uint32{3: 1},
+					},
+					{Domain: "golang.org", Type: network.TypeA}: {
+						SuccessLatency: network.LatencyHistogram{Sum: 1500, Counts: [11]uint32{1, 1}},
+						Timeouts:       1,
+						CountByRcode:   map[uint32]uint32{0: 2},
+					},
+				},
+			},
+			{
+				Source:   util.AddressFromString("10.1.1.1"),
+				Dest:     util.AddressFromString("10.2.2.2"),
+				Protocol: protocols.Postgres,
+				Tags:     []string{"container_id:abc", "dest_pod_name:db-0"},
+			},
+		},
+		HTTP: map[http.Key]*http.RequestStats{
+			{
+				SrcIP:   util.AddressFromString("10.1.1.1"),
+				DstIP:   util.AddressFromString("10.2.2.2"),
+				SrcPort: 1001,
+				DstPort: 80,
+				Path:    "/users/*",
+				Method:  http.MethodGet,
+			}: httpStats,
+		},
+	}
+
+	expected := &ConnectionsExtras{
+		Conns: []*ConnectionExtras{
+			{
+				DnsStatsByDomain: []*DNSDomainStats{
+					{
+						Domain:         "golang.org",
+						QueryType:      uint32(network.TypeA),
+						SuccessLatency: &DNSLatencyHistogram{Sum: 1500, Counts: []uint32{1, 1, 0, 0, 0, 0, 0, 0, 0, 0, 0}},
+						Timeouts:       1,
+						CountByRcode:   map[uint32]uint32{0: 2},
+					},
+					{
+						Domain:         "golang.org",
+						QueryType:      uint32(network.TypeAAAA),
+						FailureLatency: &DNSLatencyHistogram{Sum: 3000, Counts: []uint32{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}},
+						CountByRcode:   map[uint32]uint32{3: 1},
+					},
+				},
+			},
+			{Protocol: "postgres", Tags: []string{"container_id:abc", "dest_pod_name:db-0"}},
+		},
+		DnsLatencyBuckets: network.DNSLatencyBuckets[:],
+	}
+
+	for _, contentType := range []string{"application/json", "application/protobuf"} {
+		t.Run(contentType, func(t *testing.T) {
+			blob, err := GetMarshaler(contentType).Marshal(in)
+			require.NoError(t, err)
+
+			// the payload can still be decoded by the clients which don't know about the extras
+			result, err := GetUnmarshaler(contentType).Unmarshal(blob)
+			require.NoError(t, err)
+			require.Len(t, result.Conns, 2)
+			assert.Equal(t, int32(53), result.Conns[0].Raddr.Port)
+
+			extras, err := GetUnmarshaler(contentType).UnmarshalExtras(blob)
+			require.NoError(t, err)
+			require.Len(t, extras.Conns, 2)
+			assert.Equal(t, expected.DnsLatencyBuckets, extras.DnsLatencyBuckets)
+			assert.Equal(t, expected.Conns[0].DnsStatsByDomain, extras.Conns[0].DnsStatsByDomain)
+			assert.Empty(t, extras.Conns[0].Protocol)
+			assert.Empty(t, extras.Conns[1].DnsStatsByDomain)
+			assert.Equal(t, expected.Conns[1].Protocol, extras.Conns[1].Protocol)
+			assert.Empty(t, extras.Conns[0].Tags)
+			assert.Equal(t, expected.Conns[1].Tags, extras.Conns[1].Tags)
+
+			require.Len(t, extras.HttpAggregations, 1)
+			agg := extras.HttpAggregations[0]
+			assert.Equal(t, "10.1.1.1", agg.SrcIP)
+			assert.Equal(t, int32(80), agg.DstPort)
+			assert.Equal(t, "/users/*", agg.Path)
+			assert.Equal(t, "GET", agg.Method)
+			assert.Equal(t, []uint32{0, 1, 0, 0, 1}, agg.CountByStatusClass)
+			require.NotNil(t, agg.Latencies)
+			assert.Equal(t, int64(2), agg.Latencies.Cnt)
+			assert.Equal(t, float64(4*time.Millisecond), agg.Latencies.Sum)
+			assert.NotEmpty(t, agg.Latencies.K)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package encoding
+
+import (
+	"sort"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/network/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/gogo/protobuf/proto"
+)
+
+// ConnectionsExtras holds the data of network.Connections which has no equivalent in
+// model.Connections. Its fields are numbered after the ones of model.Connections, so that
+// a payload made of both messages can still be decoded as a model.Connections by the
+// clients which don't know about the extras.
+type ConnectionsExtras struct {
+	// Conns holds the extras of the connections, in the order of model.Connections.Conns
+	Conns []*ConnectionExtras `protobuf:"bytes,100,rep,name=connsExtras" json:"connsExtras,omitempty"`
+	// DnsLatencyBuckets holds the upper bounds, in µs, of the buckets of the DNS latency histograms
+	DnsLatencyBuckets []uint64 `protobuf:"varint,101,rep,packed,name=dnsLatencyBuckets" json:"dnsLatencyBuckets,omitempty"`
+	// HttpAggregations holds the HTTP stats of the connections
+	HttpAggregations []*HTTPAggregation `protobuf:"bytes,102,rep,name=httpAggregations" json:"httpAggregations,omitempty"`
+}
+
+// Reset implements proto.Message
+func (m *ConnectionsExtras) Reset() { *m = ConnectionsExtras{} }
+
+// String implements proto.Message
+func (m *ConnectionsExtras) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message
+func (*ConnectionsExtras) ProtoMessage() {}
+
+// empty returns whether none of the connections has extras
+func (m *ConnectionsExtras) empty() bool {
+	if len(m.HttpAggregations) > 0 {
+		return false
+	}
+	for _, c := range m.Conns {
+		if len(c.DnsStatsByDomain) > 0 || c.Protocol != "" || len(c.Tags) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ConnectionExtras holds the data of a network.ConnectionStats which has no equivalent in
+// model.Connection
+type ConnectionExtras struct {
+	// DnsStatsByDomain holds the DNS stats of the connection by domain and query type
+	DnsStatsByDomain []*DNSDomainStats `protobuf:"bytes,1,rep,name=dnsStatsByDomain" json:"dnsStatsByDomain,omitempty"`
+	// Protocol is the application-layer protocol of the connection, empty if unknown
+	Protocol string `protobuf:"bytes,2,opt,name=protocol,proto3" json:"protocol,omitempty"`
+	// Tags holds the container and Kubernetes tags of the connection
+	Tags []string `protobuf:"bytes,3,rep,name=tags" json:"tags,omitempty"`
+}
+
+// Reset implements proto.Message
+func (m *ConnectionExtras) Reset() { *m = ConnectionExtras{} }
+
+// String implements proto.Message
+func (m *ConnectionExtras) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message
+func (*ConnectionExtras) ProtoMessage() {}
+
+// DNSDomainStats holds the DNS stats of a connection for a domain and a query type
+type DNSDomainStats struct {
+	Domain         string               `protobuf:"bytes,1,opt,name=domain,proto3" json:"domain,omitempty"`
+	QueryType      uint32               `protobuf:"varint,2,opt,name=queryType,proto3" json:"queryType,omitempty"`
+	SuccessLatency *DNSLatencyHistogram `protobuf:"bytes,3,opt,name=successLatency" json:"successLatency,omitempty"`
+	FailureLatency *DNSLatencyHistogram `protobuf:"bytes,4,opt,name=failureLatency" json:"failureLatency,omitempty"`
+	Timeouts       uint32               `protobuf:"varint,5,opt,name=timeouts,proto3" json:"timeouts,omitempty"`
+	CountByRcode   map[uint32]uint32    `protobuf:"bytes,6,rep,name=countByRcode" json:"countByRcode,omitempty" protobuf_key:"varint,1,opt,name=key,proto3" protobuf_val:"varint,2,opt,name=value,proto3"`
+}
+
+// Reset implements proto.Message
+func (m *DNSDomainStats) Reset() { *m = DNSDomainStats{} }
+
+// String implements proto.Message
+func (m *DNSDomainStats) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message
+func (*DNSDomainStats) ProtoMessage() {}
+
+// DNSLatencyHistogram holds the distribution of DNS response latencies, along the buckets
+// of ConnectionsExtras.DnsLatencyBuckets
+type DNSLatencyHistogram struct {
+	// Sum is the sum of the latencies, in µs
+	Sum uint64 `protobuf:"varint,1,opt,name=sum,proto3" json:"sum,omitempty"`
+	// Counts holds the number of latencies by bucket, the last one holding the latencies above all bounds
+	Counts []uint32 `protobuf:"varint,2,rep,packed,name=counts" json:"counts,omitempty"`
+}
+
+// Reset implements proto.Message
+func (m *DNSLatencyHistogram) Reset() { *m = DNSLatencyHistogram{} }
+
+// String implements proto.Message
+func (m *DNSLatencyHistogram) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message
+func (*DNSLatencyHistogram) ProtoMessage() {}
+
+// HTTPAggregation holds the stats of the requests made on a connection, from the client (source)
+// to the server (destination), for a path prefix with a method
+type HTTPAggregation struct {
+	SrcIP   string `protobuf:"bytes,1,opt,name=srcIP,proto3" json:"srcIP,omitempty"`
+	DstIP   string `protobuf:"bytes,2,opt,name=dstIP,proto3" json:"dstIP,omitempty"`
+	SrcPort int32  `protobuf:"varint,3,opt,name=srcPort,proto3" json:"srcPort,omitempty"`
+	DstPort int32  `protobuf:"varint,4,opt,name=dstPort,proto3" json:"dstPort,omitempty"`
+	Path    string `protobuf:"bytes,5,opt,name=path,proto3" json:"path,omitempty"`
+	Method  string `protobuf:"bytes,6,opt,name=method,proto3" json:"method,omitempty"`
+	// CountByStatusClass holds the number of requests by status class, from 1xx at index 0 to 5xx at index 4
+	CountByStatusClass []uint32 `protobuf:"varint,7,rep,packed,name=countByStatusClass" json:"countByStatusClass,omitempty"`
+	// Latencies holds the distribution of the request latencies, in ns
+	Latencies *HTTPLatencySketch `protobuf:"bytes,8,opt,name=latencies" json:"latencies,omitempty"`
+}
+
+// Reset implements proto.Message
+func (m *HTTPAggregation) Reset() { *m = HTTPAggregation{} }
+
+// String implements proto.Message
+func (m *HTTPAggregation) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message
+func (*HTTPAggregation) ProtoMessage() {}
+
+// HTTPLatencySketch holds a latency sketch in the format of the distribution metrics: its
+// summary, and its bins, keyed along the default configuration of the quantile package
+type HTTPLatencySketch struct {
+	Cnt int64    `protobuf:"varint,1,opt,name=cnt,proto3" json:"cnt,omitempty"`
+	Min float64  `protobuf:"fixed64,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max float64  `protobuf:"fixed64,3,opt,name=max,proto3" json:"max,omitempty"`
+	Sum float64  `protobuf:"fixed64,4,opt,name=sum,proto3" json:"sum,omitempty"`
+	K   []int32  `protobuf:"zigzag32,5,rep,packed,name=k" json:"k,omitempty"`
+	N   []uint32 `protobuf:"varint,6,rep,packed,name=n" json:"n,omitempty"`
+}
+
+// Reset implements proto.Message
+func (m *HTTPLatencySketch) Reset() { *m = HTTPLatencySketch{} }
+
+// String implements proto.Message
+func (m *HTTPLatencySketch) String() string { return proto.CompactTextString(m) }
+
+// ProtoMessage implements proto.Message
+func (*HTTPLatencySketch) ProtoMessage() {}
+
+// FormatConnectionsExtras returns the extras of the given connections
+func FormatConnectionsExtras(conns *network.Connections) *ConnectionsExtras {
+	extras := &ConnectionsExtras{
+		Conns:             make([]*ConnectionExtras, len(conns.Conns)),
+		DnsLatencyBuckets: network.DNSLatencyBuckets[:],
+		HttpAggregations:  formatHTTPStats(conns.HTTP),
+	}
+	for i, conn := range conns.Conns {
+		extras.Conns[i] = FormatConnectionExtras(conn)
+	}
+	return extras
+}
+
+// FormatConnectionExtras returns the extras of a ConnectionStats
+func FormatConnectionExtras(conn network.ConnectionStats) *ConnectionExtras {
+	return &ConnectionExtras{
+		DnsStatsByDomain: formatDNSStatsByDomain(conn.DNSStatsByDomain),
+		Protocol:         formatProtocol(conn.Protocol),
+		Tags:             conn.Tags,
+	}
+}
+
+func formatProtocol(p protocols.Protocol) string {
+	if p == protocols.Unknown {
+		return ""
+	}
+	return p.String()
+}
+
+func formatDNSStatsByDomain(stats map[network.DNSQuestion]network.DNSStats) []*DNSDomainStats {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	res := make([]*DNSDomainStats, 0, len(stats))
+	for question, st := range stats {
+		res = append(res, &DNSDomainStats{
+			Domain:         question.Domain,
+			QueryType:      uint32(question.Type),
+			SuccessLatency: formatDNSLatencyHistogram(st.SuccessLatency),
+			FailureLatency: formatDNSLatencyHistogram(st.FailureLatency),
+			Timeouts:       st.Timeouts,
+			CountByRcode:   st.CountByRcode,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].Domain != res[j].Domain {
+			return res[i].Domain < res[j].Domain
+		}
+		return res[i].QueryType < res[j].QueryType
+	})
+	return res
+}
+
+func formatDNSLatencyHistogram(h network.LatencyHistogram) *DNSLatencyHistogram {
+	if h.Count() == 0 {
+		return nil
+	}
+	return &DNSLatencyHistogram{Sum: h.Sum, Counts: append([]uint32(nil), h.Counts[:]...)}
+}
+
+func formatHTTPStats(stats map[http.Key]*http.RequestStats) []*HTTPAggregation {
+	if len(stats) == 0 {
+		return nil
+	}
+
+	res := make([]*HTTPAggregation, 0, len(stats))
+	for key, st := range stats {
+		agg := &HTTPAggregation{
+			SrcIP:              key.SrcIP.String(),
+			DstIP:              key.DstIP.String(),
+			SrcPort:            int32(key.SrcPort),
+			DstPort:            int32(key.DstPort),
+			Path:               key.Path,
+			Method:             key.Method.String(),
+			CountByStatusClass: make([]uint32, http.NumStatusClasses),
+		}
+		for i, c := range st.Counts {
+			agg.CountByStatusClass[i] = uint32(c)
+		}
+		if st.Latencies != nil {
+			b := st.Latencies.Basic
+			k, n := st.Latencies.Cols()
+			agg.Latencies = &HTTPLatencySketch{Cnt: b.Cnt, Min: b.Min, Max: b.Max, Sum: b.Sum, K: k, N: n}
+		}
+		res = append(res, agg)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		a, b := res[i], res[j]
+		if a.SrcIP != b.SrcIP {
+			return a.SrcIP < b.SrcIP
+		}
+		if a.DstIP != b.DstIP {
+			return a.DstIP < b.DstIP
+		}
+		if a.SrcPort != b.SrcPort {
+			return a.SrcPort < b.SrcPort
+		}
+		if a.DstPort != b.DstPort {
+			return a.DstPort < b.DstPort
+		}
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		return a.Method < b.Method
+	})
+	return res
+}
@@ -6,7 +6,10 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 )
 
-// FormatConnection converts a ConnectionStats into an model.Connection
+// FormatConnection converts a ConnectionStats into an model.Connection.
+// The DNS stats by domain and query type, the application-layer protocol and the tags have no
+// equivalent in model.Connection, so they are formatted separately by FormatConnectionExtras,
+// as are the HTTP stats of network.Connections by FormatConnectionsExtras.
 func FormatConnection(conn network.ConnectionStats) *model.Connection {
 	return &model.Connection{
 		Pid:                    int32(conn.Pid),
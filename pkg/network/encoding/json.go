@@ -2,6 +2,7 @@ package encoding
 
 import (
 	"bytes"
+	"encoding/json"
 
 	model "github.com/DataDog/agent-payload/process"
 	"github.com/DataDog/datadog-agent/pkg/network"
@@ -11,6 +12,10 @@ import (
 // ContentTypeJSON holds the HTML content-type of a JSON payload
 const ContentTypeJSON = "application/json"
 
+// jsonUnmarshaler ignores the fields of the payload which are not part of the decoded message,
+// such as the extras when decoding a model.Connections
+var jsonUnmarshaler = jsonpb.Unmarshaler{AllowUnknownFields: true}
+
 type jsonSerializer struct {
 	marshaller jsonpb.Marshaler
 }
@@ -22,19 +27,49 @@ func (j jsonSerializer) Marshal(conns *network.Connections) ([]byte, error) {
 	}
 	payload := &model.Connections{Conns: agentConns, Dns: FormatDNS(conns.DNS), Telemetry: FormatTelemetry(conns.Telemetry)}
 	writer := new(bytes.Buffer)
-	err := j.marshaller.Marshal(writer, payload)
-	return writer.Bytes(), err
+	if err := j.marshaller.Marshal(writer, payload); err != nil {
+		return nil, err
+	}
+
+	extras := FormatConnectionsExtras(conns)
+	if extras.empty() {
+		return writer.Bytes(), nil
+	}
+	return j.mergeExtras(writer.Bytes(), extras)
+}
+
+// mergeExtras adds the fields of extras to the JSON object of a model.Connections
+func (j jsonSerializer) mergeExtras(blob []byte, extras *ConnectionsExtras) ([]byte, error) {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(blob, &fields); err != nil {
+		return nil, err
+	}
+	writer := new(bytes.Buffer)
+	if err := j.marshaller.Marshal(writer, extras); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(writer.Bytes(), &fields); err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
 }
 
 func (jsonSerializer) Unmarshal(blob []byte) (*model.Connections, error) {
 	conns := new(model.Connections)
-	reader := bytes.NewReader(blob)
-	if err := jsonpb.Unmarshal(reader, conns); err != nil {
+	if err := jsonUnmarshaler.Unmarshal(bytes.NewReader(blob), conns); err != nil {
 		return nil, err
 	}
 	return conns, nil
 }
 
+func (jsonSerializer) UnmarshalExtras(blob []byte) (*ConnectionsExtras, error) {
+	extras := new(ConnectionsExtras)
+	if err := jsonUnmarshaler.Unmarshal(bytes.NewReader(blob), extras); err != nil {
+		return nil, err
+	}
+	return extras, nil
+}
+
 func (j jsonSerializer) ContentType() string {
 	return ContentTypeJSON
 }
@@ -24,7 +24,21 @@ func (protoSerializer) Marshal(conns *network.Connections) ([]byte, error) {
 		Telemetry: FormatTelemetry(conns.Telemetry),
 	}
 
-	return proto.Marshal(payload)
+	blob, err := proto.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// the extras are appended as fields unknown to model.Connections
+	extras := FormatConnectionsExtras(conns)
+	if extras.empty() {
+		return blob, nil
+	}
+	extrasBlob, err := proto.Marshal(extras)
+	if err != nil {
+		return nil, err
+	}
+	return append(blob, extrasBlob...), nil
 }
 
 func (protoSerializer) Unmarshal(blob []byte) (*model.Connections, error) {
@@ -35,6 +49,14 @@ func (protoSerializer) Unmarshal(blob []byte) (*model.Connections, error) {
 	return conns, nil
 }
 
+func (protoSerializer) UnmarshalExtras(blob []byte) (*ConnectionsExtras, error) {
+	extras := new(ConnectionsExtras)
+	if err := proto.Unmarshal(blob, extras); err != nil {
+		return nil, err
+	}
+	return extras, nil
+}
+
 func (p protoSerializer) ContentType() string {
 	return ContentTypeProtobuf
 }
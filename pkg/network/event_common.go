@@ -7,6 +7,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/network/http"
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/dustin/go-humanize"
 )
@@ -74,6 +76,7 @@ func (d ConnectionDirection) String() string {
 type Connections struct {
 	DNS       map[util.Address][]string
 	Conns     []ConnectionStats
+	HTTP      map[http.Key]*http.RequestStats
 	Telemetry *ConnectionsTelemetry
 }
 
@@ -139,6 +142,14 @@ type ConnectionStats struct {
 	DNSSuccessLatencySum   uint64
 	DNSFailureLatencySum   uint64
 	DNSCountByRcode        map[uint32]uint32
+	DNSStatsByDomain       map[DNSQuestion]DNSStats
+
+	// Protocol is the application-layer protocol of the connection, as classified from its first payload bytes
+	Protocol protocols.Protocol
+
+	// Tags holds the metadata of the connection, such as the container of its process or the
+	// Kubernetes pod of its destination
+	Tags []string
 }
 
 // IPTranslation can be associated with a connection to show the connection is NAT'd
@@ -249,6 +260,14 @@ func ConnectionSummary(c ConnectionStats, names map[util.Address][]string) strin
 		)
 	}
 
+	if c.Protocol != protocols.Unknown {
+		str += fmt.Sprintf(", protocol %s", c.Protocol)
+	}
+
+	if len(c.Tags) > 0 {
+		str += fmt.Sprintf(", tags [%s]", strings.Join(c.Tags, " "))
+	}
+
 	return str
 }
 
@@ -0,0 +1,115 @@
+// Package http aggregates the HTTP/1.x requests made on the connections tracked by the
+// system-probe: request counts by status class and latency distributions, by connection,
+// path prefix and method.
+package http
+
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/quantile"
+)
+
+// Method is the method of an HTTP request
+type Method uint8
+
+// HTTP request methods
+const (
+	MethodUnknown Method = iota
+	MethodGet
+	MethodPost
+	MethodPut
+	MethodDelete
+	MethodHead
+	MethodOptions
+	MethodPatch
+)
+
+var methodNames = [...]string{
+	MethodUnknown: "UNKNOWN",
+	MethodGet:     "GET",
+	MethodPost:    "POST",
+	MethodPut:     "PUT",
+	MethodDelete:  "DELETE",
+	MethodHead:    "HEAD",
+	MethodOptions: "OPTIONS",
+	MethodPatch:   "PATCH",
+}
+
+func (m Method) String() string {
+	if int(m) < len(methodNames) {
+		return methodNames[m]
+	}
+	return methodNames[MethodUnknown]
+}
+
+// Key identifies a group of requests: the requests made on a connection, from the
+// client (source) to the server (destination), for a path prefix with a method
+type Key struct {
+	SrcIP   util.Address
+	DstIP   util.Address
+	SrcPort uint16
+	DstPort uint16
+	Path    string
+	Method  Method
+}
+
+// NumStatusClasses is the number of HTTP status classes, from 1xx to 5xx
+const NumStatusClasses = 5
+
+// sketchConfig is the configuration of the latency sketches
+var sketchConfig = quantile.Default()
+
+// RequestStats holds the stats of a group of requests
+type RequestStats struct {
+	// Counts holds the number of requests by status class, from 1xx at index 0 to 5xx at index 4
+	Counts [NumStatusClasses]int
+	// Latencies holds the distribution of the request latencies, in ns
+	Latencies *quantile.Sketch
+}
+
+// AddRequest accounts a request which got a response with the given status code after latency.
+// Requests with an invalid status code are ignored.
+func (r *RequestStats) AddRequest(statusCode int, latency time.Duration) {
+	class := statusCode/100 - 1
+	if class < 0 || class >= NumStatusClasses {
+		return
+	}
+	r.Counts[class]++
+	if r.Latencies == nil {
+		r.Latencies = &quantile.Sketch{}
+	}
+	r.Latencies.Insert(sketchConfig, float64(latency))
+}
+
+// CombineWith merges the stats of o into r, without mutating o
+func (r *RequestStats) CombineWith(o *RequestStats) {
+	for i, c := range o.Counts {
+		r.Counts[i] += c
+	}
+	if o.Latencies == nil {
+		return
+	}
+	if r.Latencies == nil {
+		r.Latencies = &quantile.Sketch{}
+	}
+	r.Latencies.Merge(sketchConfig, o.Latencies)
+}
+
+// Count returns the number of requests
+func (r *RequestStats) Count() int {
+	var n int
+	for _, c := range r.Counts {
+		n += c
+	}
+	return n
+}
+
+// Percentile returns the latency below which the fraction p (between 0 and 1) of the
+// requests completed
+func (r *RequestStats) Percentile(p float64) time.Duration {
+	if r.Latencies == nil {
+		return 0
+	}
+	return time.Duration(r.Latencies.Quantile(sketchConfig, p))
+}
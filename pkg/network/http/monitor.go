@@ -0,0 +1,40 @@
+package http
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+// Monitor aggregates the HTTP requests made on the connections of the host
+type Monitor interface {
+	// GetAndResetAllStats returns the stats aggregated since the last call
+	GetAndResetAllStats() map[Key]*RequestStats
+	// GetProtocol returns the protocol of the connection between the given endpoints,
+	// as classified from the first payload captured on it
+	GetProtocol(srcIP util.Address, srcPort uint16, dstIP util.Address, dstPort uint16) protocols.Protocol
+	GetStats() map[string]int64
+	Close()
+}
+
+// NewNullMonitor returns a dummy implementation of Monitor
+func NewNullMonitor() Monitor {
+	return nullMonitor{}
+}
+
+type nullMonitor struct{}
+
+func (nullMonitor) GetAndResetAllStats() map[Key]*RequestStats {
+	return nil
+}
+
+func (nullMonitor) GetProtocol(_ util.Address, _ uint16, _ util.Address, _ uint16) protocols.Protocol {
+	return protocols.Unknown
+}
+
+func (nullMonitor) GetStats() map[string]int64 {
+	return map[string]int64{}
+}
+
+func (nullMonitor) Close() {}
+
+var _ Monitor = nullMonitor{}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/hashicorp/golang-lru/simplelru"
+)
+
+const (
+	// maxPathSegments is the number of leading segments kept in a normalized path
+	maxPathSegments = 3
+
+	// wildcard replaces the path segments which are identifiers
+	wildcard = "*"
+)
+
+// pathNormalizer turns request paths into path prefixes, replacing the segments which look
+// like identifiers with a wildcard so that requests on different resources of the same
+// kind are aggregated together. Normalized paths are cached in a bounded LRU cache.
+// It is not safe for concurrent use.
+type pathNormalizer struct {
+	cache *simplelru.LRU
+
+	// telemetry
+	hits   int64
+	misses int64
+}
+
+func newPathNormalizer(size int) *pathNormalizer {
+	cache, err := simplelru.NewLRU(size, nil)
+	if err != nil {
+		// only happens with a non-positive size
+		cache, _ = simplelru.NewLRU(1, nil)
+	}
+	return &pathNormalizer{cache: cache}
+}
+
+// Normalize returns the path prefix of the given request target
+func (n *pathNormalizer) Normalize(path string) string {
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	if v, ok := n.cache.Get(path); ok {
+		n.hits++
+		return v.(string)
+	}
+	n.misses++
+	normalized := normalizePath(path)
+	n.cache.Add(path, normalized)
+	return normalized
+}
+
+// normalizePath keeps the first maxPathSegments segments of path, replacing the ones which
+// are identifiers with a wildcard.
+func normalizePath(path string) string {
+	if !strings.HasPrefix(path, "/") {
+		// absolute-form (proxies) or asterisk-form request targets
+		if i := strings.Index(path, "://"); i >= 0 {
+			path = path[i+3:]
+			if j := strings.IndexByte(path, '/'); j >= 0 {
+				path = path[j:]
+			} else {
+				path = "/"
+			}
+		} else {
+			return path
+		}
+	}
+
+	var b strings.Builder
+	b.Grow(len(path))
+	segments := 0
+	for rest := path[1:]; ; {
+		var segment string
+		if i := strings.IndexByte(rest, '/'); i >= 0 {
+			segment, rest = rest[:i], rest[i+1:]
+		} else {
+			segment, rest = rest, ""
+		}
+		if segments == maxPathSegments {
+			break
+		}
+		b.WriteByte('/')
+		if isIdentifier(segment) {
+			b.WriteString(wildcard)
+		} else {
+			b.WriteString(segment)
+		}
+		segments++
+		if rest == "" {
+			break
+		}
+	}
+	return b.String()
+}
+
+// isIdentifier reports whether the path segment s looks like an identifier: a number, a
+// UUID or a long hexadecimal string.
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	var digits, hex, dashes int
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case '0' <= c && c <= '9':
+			digits++
+		case 'a' <= c && c <= 'f', 'A' <= c && c <= 'F':
+			hex++
+		case c == '-':
+			dashes++
+		default:
+			return false
+		}
+	}
+	switch {
+	case digits == len(s):
+		return true
+	case dashes == 4 && len(s) == 36:
+		// UUID
+		return true
+	case dashes == 0 && digits > 0 && len(s) >= 16:
+		// hashes and object IDs
+		return true
+	}
+	return false
+}
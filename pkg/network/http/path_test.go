@@ -0,0 +1,40 @@
+package http
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePath(t *testing.T) {
+	for in, out := range map[string]string{
+		"/":                                    "/",
+		"/health":                              "/health",
+		"/users/":                              "/users",
+		"/users/123":                           "/users/*",
+		"/users/123/posts/456":                 "/users/*/posts",
+		"/v2/objects/5f3e9a1b2c4d6e8f0a1b2c3d": "/v2/objects/*",
+		"/orders/550e8400-e29b-41d4-a716-446655440000/items": "/orders/*/items",
+		"/assets/cafe":               "/assets/cafe",
+		"http://example.com/users/1": "/users/*",
+		"http://example.com":         "/",
+		"*":                          "*",
+	} {
+		assert.Equal(t, out, normalizePath(in), in)
+	}
+}
+
+func TestPathNormalizerCache(t *testing.T) {
+	n := newPathNormalizer(2)
+	assert.Equal(t, "/users/*", n.Normalize("/users/1?page=2"))
+	assert.Equal(t, "/users/*", n.Normalize("/users/1"))
+	assert.EqualValues(t, 1, n.hits)
+	assert.EqualValues(t, 1, n.misses)
+
+	n.Normalize("/users/2")
+	n.Normalize("/users/3")
+	// the cache is bounded
+	assert.Equal(t, 2, n.cache.Len())
+	assert.Equal(t, "/users/*", n.Normalize("/users/1"))
+	assert.EqualValues(t, 4, n.misses)
+}
@@ -0,0 +1,155 @@
+// +build linux_bpf
+
+package http
+
+import (
+	"fmt"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/google/gopacket/afpacket"
+	"golang.org/x/net/bpf"
+)
+
+var _ Monitor = &SocketMonitor{}
+
+// SocketMonitor is a Monitor capturing the TCP traffic of the host on a RAW_SOCKET
+type SocketMonitor struct {
+	source     *afpacket.TPacket
+	statKeeper *statKeeper
+	exit       chan struct{}
+	wg         sync.WaitGroup
+}
+
+// NewSocketMonitor returns a new SocketMonitor capturing the traffic of the root network namespace.
+// At most maxStats keys are aggregated between two calls to GetAndResetAllStats. The HTTP requests
+// are aggregated if monitorHTTP is set, and the protocol of the connections is classified if
+// classifyProtocols is set.
+func NewSocketMonitor(rootPath string, maxStats int, monitorHTTP, classifyProtocols bool) (*SocketMonitor, error) {
+	var (
+		source *afpacket.TPacket
+		srcErr error
+	)
+
+	// Create the RAW_SOCKET inside the root network namespace
+	nsErr := util.WithRootNS(rootPath, func() {
+		source, srcErr = newTCPPacketSource()
+	})
+	if nsErr != nil {
+		return nil, nsErr
+	}
+	if srcErr != nil {
+		return nil, srcErr
+	}
+
+	m := &SocketMonitor{
+		source:     source,
+		statKeeper: newStatKeeper(maxStats, pathCacheSize, requestTimeout, monitorHTTP, classifyProtocols),
+		exit:       make(chan struct{}),
+	}
+
+	m.wg.Add(1)
+	go func() {
+		m.pollPackets()
+		m.wg.Done()
+	}()
+
+	return m, nil
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call
+func (m *SocketMonitor) GetAndResetAllStats() map[Key]*RequestStats {
+	return m.statKeeper.GetAndResetAllStats(time.Now())
+}
+
+// GetProtocol returns the protocol of the connection between the given endpoints
+func (m *SocketMonitor) GetProtocol(srcIP util.Address, srcPort uint16, dstIP util.Address, dstPort uint16) protocols.Protocol {
+	return m.statKeeper.GetProtocol(srcIP, srcPort, dstIP, dstPort)
+}
+
+// GetStats returns the telemetry of the monitor
+func (m *SocketMonitor) GetStats() map[string]int64 {
+	stats := m.statKeeper.GetStats()
+	if sourceStats, err := m.source.Stats(); err == nil {
+		stats["packets_processed"] = sourceStats.Packets
+		stats["socket_polls"] = sourceStats.Polls
+	}
+	return stats
+}
+
+// Close stops the capture
+func (m *SocketMonitor) Close() {
+	close(m.exit)
+	m.wg.Wait()
+	m.source.Close()
+}
+
+func (m *SocketMonitor) pollPackets() {
+	for {
+		data, captureInfo, err := m.source.ZeroCopyReadPacketData()
+
+		// Properly synchronizes termination process
+		select {
+		case <-m.exit:
+			return
+		default:
+		}
+
+		if err == nil {
+			m.statKeeper.ProcessPacket(data, captureInfo.Timestamp)
+			continue
+		}
+
+		// Immediately retry for EAGAIN
+		if err == syscall.EAGAIN {
+			continue
+		}
+
+		// Sleep briefly and try again
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// snapLen is the number of bytes captured from each packet, which covers the request
+// and status lines of most HTTP messages
+const snapLen = 512
+
+// newTCPPacketSource returns a RAW_SOCKET capturing the beginning of the TCP packets
+func newTCPPacketSource() (*afpacket.TPacket, error) {
+	rawSocket, err := afpacket.NewTPacket(
+		afpacket.OptPollTimeout(1*time.Second),
+		afpacket.OptFrameSize(4096),
+		afpacket.OptBlockSize(4096*128),
+		afpacket.OptNumBlocks(8),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("error creating raw socket: %s", err)
+	}
+
+	filter, err := bpf.Assemble([]bpf.Instruction{
+		// EtherType
+		bpf.LoadAbsolute{Off: 12, Size: 2},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x0800, SkipTrue: 0, SkipFalse: 2},
+		// IPv4 protocol
+		bpf.LoadAbsolute{Off: 23, Size: 1},
+		bpf.Jump{Skip: 2},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: 0x86dd, SkipTrue: 0, SkipFalse: 3},
+		// IPv6 next header
+		bpf.LoadAbsolute{Off: 20, Size: 1},
+		bpf.JumpIf{Cond: bpf.JumpEqual, Val: syscall.IPPROTO_TCP, SkipTrue: 0, SkipFalse: 1},
+		bpf.RetConstant{Val: snapLen},
+		bpf.RetConstant{Val: 0},
+	})
+	if err != nil {
+		rawSocket.Close()
+		return nil, fmt.Errorf("error assembling socket filter: %s", err)
+	}
+	if err := rawSocket.SetBPF(filter); err != nil {
+		rawSocket.Close()
+		return nil, fmt.Errorf("error attaching filter to socket: %s", err)
+	}
+	return rawSocket, nil
+}
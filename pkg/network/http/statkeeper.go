@@ -0,0 +1,307 @@
+package http
+
+import (
+	"bytes"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+)
+
+const (
+	// pathCacheSize is the number of normalized paths kept in cache
+	pathCacheSize = 1000
+
+	// requestTimeout is the time after which a request without response is discarded
+	requestTimeout = 30 * time.Second
+
+	// protocolTimeout is the time after which the protocol of a connection without
+	// traffic is forgotten
+	protocolTimeout = 10 * time.Minute
+)
+
+// connTuple identifies the direction of a TCP connection
+type connTuple struct {
+	srcIP   util.Address
+	dstIP   util.Address
+	srcPort uint16
+	dstPort uint16
+}
+
+func (t connTuple) reverse() connTuple {
+	return connTuple{srcIP: t.dstIP, dstIP: t.srcIP, srcPort: t.dstPort, dstPort: t.srcPort}
+}
+
+// pendingRequest is a request waiting for its response
+type pendingRequest struct {
+	method Method
+	path   string
+	ts     time.Time
+}
+
+// classifiedConn is a connection whose protocol was classified from its first payload
+type classifiedConn struct {
+	protocol protocols.Protocol
+	lastSeen time.Time
+}
+
+// statKeeper matches the HTTP/1.x requests and responses found in captured packets and
+// aggregates them by Key. It also classifies the protocol of the connections from the first
+// payload captured on them.
+type statKeeper struct {
+	mux sync.Mutex
+
+	decoder *gopacket.DecodingLayerParser
+	layers  []gopacket.LayerType
+	ipv4    *layers.IPv4
+	ipv6    *layers.IPv6
+	tcp     *layers.TCP
+
+	pending        map[connTuple]pendingRequest
+	stats          map[Key]*RequestStats
+	paths          *pathNormalizer
+	maxStats       int // maximum number of keys between two resets
+	maxPending     int // maximum number of requests waiting for a response
+	requestTimeout time.Duration
+
+	classified    map[connTuple]*classifiedConn // by tuple of the first payload
+	maxClassified int                           // maximum number of classified connections
+
+	monitorHTTP       bool // whether the HTTP requests are aggregated
+	classifyProtocols bool // whether the protocol of the connections is classified
+
+	// telemetry
+	requests        int64
+	responses       int64
+	orphanResponses int64 // responses without a matching request
+	timeouts        int64 // requests which got no response in time
+	pendingDropped  int64 // requests dropped because of maxPending
+	statsDropped    int64 // responses dropped because of maxStats
+	classifyDropped int64 // connections not classified because of maxClassified
+}
+
+func newStatKeeper(maxStats, pathCacheSize int, requestTimeout time.Duration, monitorHTTP, classifyProtocols bool) *statKeeper {
+	s := &statKeeper{
+		ipv4:              &layers.IPv4{},
+		ipv6:              &layers.IPv6{},
+		tcp:               &layers.TCP{},
+		pending:           make(map[connTuple]pendingRequest),
+		stats:             make(map[Key]*RequestStats),
+		paths:             newPathNormalizer(pathCacheSize),
+		maxStats:          maxStats,
+		maxPending:        maxStats,
+		requestTimeout:    requestTimeout,
+		classified:        make(map[connTuple]*classifiedConn),
+		maxClassified:     maxStats,
+		monitorHTTP:       monitorHTTP,
+		classifyProtocols: classifyProtocols,
+	}
+	s.decoder = gopacket.NewDecodingLayerParser(layers.LayerTypeEthernet, &layers.Ethernet{}, s.ipv4, s.ipv6, s.tcp)
+	// the TCP payload is not decoded any further
+	s.decoder.IgnoreUnsupported = true
+	return s
+}
+
+// ProcessPacket processes the given Ethernet frame, captured at ts
+func (s *statKeeper) ProcessPacket(data []byte, ts time.Time) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	if err := s.decoder.DecodeLayers(data, &s.layers); err != nil || len(s.layers) == 0 {
+		return
+	}
+	if s.layers[len(s.layers)-1] != layers.LayerTypeTCP || len(s.tcp.Payload) == 0 {
+		return
+	}
+
+	var tuple connTuple
+	switch s.layers[1] {
+	case layers.LayerTypeIPv4:
+		tuple.srcIP = util.AddressFromNetIP(s.ipv4.SrcIP)
+		tuple.dstIP = util.AddressFromNetIP(s.ipv4.DstIP)
+	case layers.LayerTypeIPv6:
+		tuple.srcIP = util.AddressFromNetIP(s.ipv6.SrcIP)
+		tuple.dstIP = util.AddressFromNetIP(s.ipv6.DstIP)
+	default:
+		return
+	}
+	tuple.srcPort = uint16(s.tcp.SrcPort)
+	tuple.dstPort = uint16(s.tcp.DstPort)
+
+	if s.classifyProtocols {
+		s.classify(tuple, s.tcp.Payload, ts)
+	}
+	if s.monitorHTTP {
+		s.processPayload(tuple, s.tcp.Payload, ts)
+	}
+}
+
+// classify classifies the protocol of the connection of tuple if payload is the first one
+// captured on it, in either direction
+func (s *statKeeper) classify(tuple connTuple, payload []byte, ts time.Time) {
+	c, ok := s.classified[tuple]
+	if !ok {
+		c, ok = s.classified[tuple.reverse()]
+	}
+	if ok {
+		c.lastSeen = ts
+		return
+	}
+	if len(s.classified) >= s.maxClassified {
+		s.classifyDropped++
+		return
+	}
+	s.classified[tuple] = &classifiedConn{protocol: protocols.Classify(payload), lastSeen: ts}
+}
+
+// GetProtocol returns the protocol of the connection between the given endpoints, in either
+// direction, or protocols.Unknown if it could not be classified
+func (s *statKeeper) GetProtocol(srcIP util.Address, srcPort uint16, dstIP util.Address, dstPort uint16) protocols.Protocol {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	tuple := connTuple{srcIP: srcIP, dstIP: dstIP, srcPort: srcPort, dstPort: dstPort}
+	c, ok := s.classified[tuple]
+	if !ok {
+		c, ok = s.classified[tuple.reverse()]
+	}
+	if !ok {
+		return protocols.Unknown
+	}
+	return c.protocol
+}
+
+func (s *statKeeper) processPayload(tuple connTuple, payload []byte, ts time.Time) {
+	if method, path, ok := parseRequestLine(payload); ok {
+		s.requests++
+		if _, ok := s.pending[tuple]; !ok && len(s.pending) >= s.maxPending {
+			s.pendingDropped++
+			return
+		}
+		// pipelined requests are not supported: only the last request of a connection is kept
+		s.pending[tuple] = pendingRequest{method: method, path: s.paths.Normalize(path), ts: ts}
+		return
+	}
+
+	statusCode, ok := parseStatusLine(payload)
+	if !ok {
+		return
+	}
+	s.responses++
+	client := tuple.reverse()
+	req, ok := s.pending[client]
+	if !ok {
+		s.orphanResponses++
+		return
+	}
+	delete(s.pending, client)
+
+	key := Key{
+		SrcIP:   client.srcIP,
+		DstIP:   client.dstIP,
+		SrcPort: client.srcPort,
+		DstPort: client.dstPort,
+		Path:    req.path,
+		Method:  req.method,
+	}
+	stats, ok := s.stats[key]
+	if !ok {
+		if len(s.stats) >= s.maxStats {
+			s.statsDropped++
+			return
+		}
+		stats = new(RequestStats)
+		s.stats[key] = stats
+	}
+	stats.AddRequest(statusCode, ts.Sub(req.ts))
+}
+
+// GetAndResetAllStats returns the stats aggregated since the last call, and expires the
+// requests which have been waiting for a response for too long, as well as the protocols
+// of the connections without traffic
+func (s *statKeeper) GetAndResetAllStats(now time.Time) map[Key]*RequestStats {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+
+	for tuple, req := range s.pending {
+		if now.Sub(req.ts) > s.requestTimeout {
+			delete(s.pending, tuple)
+			s.timeouts++
+		}
+	}
+	for tuple, c := range s.classified {
+		if now.Sub(c.lastSeen) > protocolTimeout {
+			delete(s.classified, tuple)
+		}
+	}
+
+	ret := s.stats // No deep copy needed since `s.stats` gets reset
+	s.stats = make(map[Key]*RequestStats)
+	return ret
+}
+
+// GetStats returns the telemetry of the stat keeper
+func (s *statKeeper) GetStats() map[string]int64 {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return map[string]int64{
+		"requests":          s.requests,
+		"responses":         s.responses,
+		"orphan_responses":  s.orphanResponses,
+		"timeouts":          s.timeouts,
+		"pending_dropped":   s.pendingDropped,
+		"stats_dropped":     s.statsDropped,
+		"pending_requests":  int64(len(s.pending)),
+		"path_cache_hits":   s.paths.hits,
+		"path_cache_misses": s.paths.misses,
+		"classified_conns":  int64(len(s.classified)),
+		"classify_dropped":  s.classifyDropped,
+	}
+}
+
+var requestMethods = map[string]Method{
+	"GET":     MethodGet,
+	"POST":    MethodPost,
+	"PUT":     MethodPut,
+	"DELETE":  MethodDelete,
+	"HEAD":    MethodHead,
+	"OPTIONS": MethodOptions,
+	"PATCH":   MethodPatch,
+}
+
+var httpVersionPrefix = []byte("HTTP/1.")
+
+// parseRequestLine parses the HTTP/1.x request line found at the beginning of payload
+func parseRequestLine(payload []byte) (method Method, path string, ok bool) {
+	sp := bytes.IndexByte(payload, ' ')
+	if sp <= 0 || sp > len("OPTIONS") {
+		return MethodUnknown, "", false
+	}
+	if method, ok = requestMethods[string(payload[:sp])]; !ok {
+		return MethodUnknown, "", false
+	}
+	rest := payload[sp+1:]
+	end := bytes.IndexByte(rest, ' ')
+	if end <= 0 || !bytes.HasPrefix(rest[end+1:], httpVersionPrefix) {
+		return MethodUnknown, "", false
+	}
+	return method, string(rest[:end]), true
+}
+
+// parseStatusLine parses the HTTP/1.x status line found at the beginning of payload
+func parseStatusLine(payload []byte) (statusCode int, ok bool) {
+	// HTTP/1.x NNN
+	const codeOffset = len("HTTP/1.x ")
+	if len(payload) < codeOffset+3 || !bytes.HasPrefix(payload, httpVersionPrefix) || payload[codeOffset-1] != ' ' {
+		return 0, false
+	}
+	code, err := strconv.Atoi(string(payload[codeOffset : codeOffset+3]))
+	if err != nil || code < 100 || code > 599 {
+		return 0, false
+	}
+	return code, true
+}
@@ -0,0 +1,153 @@
+package http
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network/protocols"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// replay feeds the packets of the given capture file to the stat keeper, and returns
+// the timestamp of the last one.
+func replay(t *testing.T, s *statKeeper, file string) time.Time {
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	r, err := pcapgo.NewReader(f)
+	require.NoError(t, err)
+
+	var last time.Time
+	for {
+		data, ci, err := r.ReadPacketData()
+		if err != nil {
+			break
+		}
+		s.ProcessPacket(data, ci.Timestamp)
+		last = ci.Timestamp
+	}
+	return last
+}
+
+func TestStatKeeperFixture(t *testing.T) {
+	s := newStatKeeper(100, 100, 30*time.Second, true, true)
+	last := replay(t, s, "testdata/http.pcap")
+
+	client4, server4 := util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2")
+	client6, server6 := util.AddressFromString("fd00::1"), util.AddressFromString("fd00::2")
+
+	stats := s.GetAndResetAllStats(last)
+	require.Len(t, stats, 4)
+
+	getUser := stats[Key{SrcIP: client4, DstIP: server4, SrcPort: 40000, DstPort: 80, Path: "/users/*", Method: MethodGet}]
+	require.NotNil(t, getUser)
+	assert.Equal(t, [NumStatusClasses]int{0, 1, 0, 1, 0}, getUser.Counts)
+	assert.InDelta(t, 10*time.Millisecond, getUser.Percentile(0), float64(time.Millisecond))
+	assert.InDelta(t, 20*time.Millisecond, getUser.Percentile(1), float64(time.Millisecond))
+
+	postUsers := stats[Key{SrcIP: client4, DstIP: server4, SrcPort: 40000, DstPort: 80, Path: "/users", Method: MethodPost}]
+	require.NotNil(t, postUsers)
+	assert.Equal(t, [NumStatusClasses]int{0, 1, 0, 0, 0}, postUsers.Counts)
+	assert.InDelta(t, 30*time.Millisecond, postUsers.Percentile(0.5), float64(time.Millisecond))
+
+	// distinct connections are aggregated separately
+	failed := stats[Key{SrcIP: client4, DstIP: server4, SrcPort: 40001, DstPort: 80, Path: "/users/*", Method: MethodGet}]
+	require.NotNil(t, failed)
+	assert.Equal(t, [NumStatusClasses]int{0, 0, 0, 0, 1}, failed.Counts)
+
+	deleteItems := stats[Key{SrcIP: client6, DstIP: server6, SrcPort: 50000, DstPort: 8080, Path: "/api/v1/orders", Method: MethodDelete}]
+	require.NotNil(t, deleteItems)
+	assert.Equal(t, 1, deleteItems.Count())
+
+	telemetry := s.GetStats()
+	assert.EqualValues(t, 6, telemetry["requests"])
+	assert.EqualValues(t, 6, telemetry["responses"])
+	assert.EqualValues(t, 1, telemetry["orphan_responses"])
+	assert.EqualValues(t, 1, telemetry["pending_requests"])
+
+	// the request left without a response eventually times out
+	assert.Empty(t, s.GetAndResetAllStats(last.Add(time.Minute)))
+	assert.EqualValues(t, 1, s.GetStats()["timeouts"])
+	assert.EqualValues(t, 0, s.GetStats()["pending_requests"])
+}
+
+func TestStatKeeperMaxStats(t *testing.T) {
+	s := newStatKeeper(1, 100, 30*time.Second, true, true)
+	last := replay(t, s, "testdata/http.pcap")
+	assert.Len(t, s.GetAndResetAllStats(last), 1)
+	assert.EqualValues(t, 3, s.GetStats()["stats_dropped"])
+}
+
+func TestParseRequestLine(t *testing.T) {
+	for _, tt := range []struct {
+		payload string
+		method  Method
+		path    string
+		ok      bool
+	}{
+		{"GET / HTTP/1.1\r\n", MethodGet, "/", true},
+		{"PATCH /a/b HTTP/1.0\r\n", MethodPatch, "/a/b", true},
+		{"GET / HTTP/2.0\r\n", MethodUnknown, "", false},
+		{"BREW /pot HTTP/1.1\r\n", MethodUnknown, "", false},
+		{"GET /\r\n", MethodUnknown, "", false},
+		{"HTTP/1.1 200 OK\r\n", MethodUnknown, "", false},
+	} {
+		method, path, ok := parseRequestLine([]byte(tt.payload))
+		assert.Equal(t, tt.ok, ok, tt.payload)
+		assert.Equal(t, tt.method, method, tt.payload)
+		assert.Equal(t, tt.path, path, tt.payload)
+	}
+}
+
+func TestParseStatusLine(t *testing.T) {
+	for _, tt := range []struct {
+		payload string
+		code    int
+		ok      bool
+	}{
+		{"HTTP/1.1 200 OK\r\n", 200, true},
+		{"HTTP/1.0 503 Service Unavailable\r\n", 503, true},
+		{"HTTP/1.1 20", 0, false},
+		{"HTTP/1.1 999 Unknown\r\n", 0, false},
+		{"HTTP/2 200\r\n", 0, false},
+		{"GET / HTTP/1.1\r\n", 0, false},
+	} {
+		code, ok := parseStatusLine([]byte(tt.payload))
+		assert.Equal(t, tt.ok, ok, tt.payload)
+		assert.Equal(t, tt.code, code, tt.payload)
+	}
+}
+
+func TestStatKeeperClassify(t *testing.T) {
+	s := newStatKeeper(100, 100, 30*time.Second, true, true)
+	last := replay(t, s, "testdata/http.pcap")
+
+	client, server := util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2")
+	assert.Equal(t, protocols.HTTP, s.GetProtocol(client, 40000, server, 80))
+	// the connection is found from either side
+	assert.Equal(t, protocols.HTTP, s.GetProtocol(server, 80, client, 40000))
+	assert.Equal(t, protocols.HTTP, s.GetProtocol(util.AddressFromString("fd00::2"), 8080, util.AddressFromString("fd00::1"), 50000))
+	assert.Equal(t, protocols.Unknown, s.GetProtocol(client, 40000, server, 443))
+
+	// the protocols of the connections without traffic are eventually forgotten
+	s.GetAndResetAllStats(last.Add(protocolTimeout / 2))
+	assert.Equal(t, protocols.HTTP, s.GetProtocol(client, 40000, server, 80))
+	s.GetAndResetAllStats(last.Add(2 * protocolTimeout))
+	assert.Equal(t, protocols.Unknown, s.GetProtocol(client, 40000, server, 80))
+	assert.EqualValues(t, 0, s.GetStats()["classified_conns"])
+}
+
+func TestStatKeeperClassifyWithoutHTTPMonitoring(t *testing.T) {
+	s := newStatKeeper(100, 100, 30*time.Second, false, true)
+	replay(t, s, "testdata/http.pcap")
+
+	client, server := util.AddressFromString("10.0.0.1"), util.AddressFromString("10.0.0.2")
+	assert.Equal(t, protocols.HTTP, s.GetProtocol(client, 40000, server, 80))
+	assert.Empty(t, s.GetAndResetAllStats(time.Now()))
+	assert.EqualValues(t, 0, s.GetStats()["requests"])
+}
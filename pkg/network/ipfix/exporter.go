@@ -0,0 +1,172 @@
+package ipfix
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+)
+
+// maxMessageLen is the maximum length of the messages sent by the exporter, which keeps
+// them under the usual path MTU
+const maxMessageLen = 1400
+
+// Exporter sends connections as IPFIX data records to a collector over UDP.
+// Templates are sent with the first message and then every templateRefresh, as required
+// by the RFC for UDP transports.
+type Exporter struct {
+	mux sync.Mutex
+
+	conn                net.Conn
+	observationDomainID uint32
+	templateRefresh     time.Duration
+	lastTemplates       time.Time
+	sequence            uint32 // number of data records sent so far
+
+	// telemetry
+	messagesSent int64
+	recordsSent  int64
+	sendErrors   int64
+}
+
+// NewExporter returns an Exporter sending to the collector listening on the UDP address
+// collectorAddr ("host:port")
+func NewExporter(collectorAddr string, observationDomainID uint32, templateRefresh time.Duration) (*Exporter, error) {
+	conn, err := net.Dial("udp", collectorAddr)
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to IPFIX collector %s: %s", collectorAddr, err)
+	}
+	return &Exporter{
+		conn:                conn,
+		observationDomainID: observationDomainID,
+		templateRefresh:     templateRefresh,
+	}, nil
+}
+
+// Export sends the given connections, along with the templates when they are due.
+// The traffic exported for each connection is the one since the previous call to
+// GetActiveConnections, i.e. LastSentBytes and LastRecvBytes.
+func (e *Exporter) Export(conns []network.ConnectionStats, now time.Time) error {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+
+	var firstErr error
+	for _, msg := range e.buildMessages(conns, now) {
+		if _, err := e.conn.Write(msg); err != nil {
+			e.sendErrors++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("error sending IPFIX message: %s", err)
+			}
+			continue
+		}
+		e.messagesSent++
+	}
+	return firstErr
+}
+
+// GetStats returns the telemetry of the exporter
+func (e *Exporter) GetStats() map[string]int64 {
+	e.mux.Lock()
+	defer e.mux.Unlock()
+	return map[string]int64{
+		"messages_sent": e.messagesSent,
+		"records_sent":  e.recordsSent,
+		"send_errors":   e.sendErrors,
+	}
+}
+
+// Close closes the connection to the collector
+func (e *Exporter) Close() error {
+	return e.conn.Close()
+}
+
+// buildMessages encodes the connections into messages of at most maxMessageLen bytes.
+// It updates the sequence number, which counts records even if their message is lost.
+func (e *Exporter) buildMessages(conns []network.ConnectionStats, now time.Time) [][]byte {
+	var (
+		msgs    [][]byte
+		msg     []byte
+		records uint32 // number of data records in msg
+		set     int    // offset of the current data set in msg, or 0
+		setID   uint16
+	)
+
+	newMessage := func() {
+		msg = make([]byte, messageHeaderLen, maxMessageLen)
+		records, set, setID = 0, 0, 0
+	}
+	closeSet := func() {
+		if set != 0 {
+			binary.BigEndian.PutUint16(msg[set+2:], uint16(len(msg)-set))
+			set = 0
+		}
+	}
+	flush := func() {
+		closeSet()
+		if len(msg) == messageHeaderLen {
+			return
+		}
+		e.putHeader(msg, now)
+		msgs = append(msgs, msg)
+		e.sequence += records
+		e.recordsSent += int64(records)
+	}
+
+	newMessage()
+	if e.lastTemplates.IsZero() || now.Sub(e.lastTemplates) >= e.templateRefresh {
+		msg = appendTemplateSet(msg)
+		e.lastTemplates = now
+	}
+
+	for i := range conns {
+		c := &conns[i]
+		if c.LastSentBytes == 0 && c.LastRecvBytes == 0 {
+			// no traffic to report since the last export
+			continue
+		}
+
+		t := templateFor(c)
+		needed := t.recordLen()
+		if t.id != setID {
+			needed += setHeaderLen
+		}
+		if len(msg)+needed > maxMessageLen {
+			flush()
+			newMessage()
+		}
+		if t.id != setID {
+			closeSet()
+			set, setID = len(msg), t.id
+			msg = appendUint16(msg, t.id)
+			msg = appendUint16(msg, 0) // length, filled by closeSet
+		}
+		msg = appendDataRecord(msg, c)
+		records++
+	}
+	flush()
+	return msgs
+}
+
+// putHeader writes the message header at the beginning of msg
+func (e *Exporter) putHeader(msg []byte, now time.Time) {
+	binary.BigEndian.PutUint16(msg[0:], version)
+	binary.BigEndian.PutUint16(msg[2:], uint16(len(msg)))
+	binary.BigEndian.PutUint32(msg[4:], uint32(now.Unix()))
+	binary.BigEndian.PutUint32(msg[8:], e.sequence)
+	binary.BigEndian.PutUint32(msg[12:], e.observationDomainID)
+}
+
+// appendTemplateSet appends a template set describing all the templates to b
+func appendTemplateSet(b []byte) []byte {
+	start := len(b)
+	b = appendUint16(b, templateSetID)
+	b = appendUint16(b, 0)
+	for _, t := range templates {
+		b = t.appendTemplateRecord(b)
+	}
+	binary.BigEndian.PutUint16(b[start+2:], uint16(len(b)-start))
+	return b
+}
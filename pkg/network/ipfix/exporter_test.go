@@ -0,0 +1,222 @@
+package ipfix
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// set is a set decoded from a message
+type set struct {
+	id      uint16
+	content []byte
+}
+
+// decodeMessage checks the header of msg and returns its sequence number and sets
+func decodeMessage(t *testing.T, msg []byte) (uint32, []set) {
+	require.True(t, len(msg) >= messageHeaderLen)
+	require.EqualValues(t, version, binary.BigEndian.Uint16(msg[0:]))
+	require.EqualValues(t, len(msg), binary.BigEndian.Uint16(msg[2:]))
+	seq := binary.BigEndian.Uint32(msg[8:])
+
+	var sets []set
+	for rest := msg[messageHeaderLen:]; len(rest) > 0; {
+		require.True(t, len(rest) >= setHeaderLen)
+		l := int(binary.BigEndian.Uint16(rest[2:]))
+		require.True(t, l >= setHeaderLen && l <= len(rest))
+		sets = append(sets, set{id: binary.BigEndian.Uint16(rest), content: rest[setHeaderLen:l]})
+		rest = rest[l:]
+	}
+	return seq, sets
+}
+
+func newConn(src, dst string, sport, dport uint16, sent, recv uint64) network.ConnectionStats {
+	family := network.AFINET
+	if net.ParseIP(src).To4() == nil {
+		family = network.AFINET6
+	}
+	return network.ConnectionStats{
+		Source:        util.AddressFromString(src),
+		Dest:          util.AddressFromString(dst),
+		SPort:         sport,
+		DPort:         dport,
+		Family:        family,
+		Type:          network.TCP,
+		Direction:     network.OUTGOING,
+		LastSentBytes: sent,
+		LastRecvBytes: recv,
+	}
+}
+
+func TestTemplateSet(t *testing.T) {
+	b := appendTemplateSet(nil)
+	assert.EqualValues(t, templateSetID, binary.BigEndian.Uint16(b))
+	assert.EqualValues(t, len(b), binary.BigEndian.Uint16(b[2:]))
+
+	rest := b[setHeaderLen:]
+	for _, tmpl := range templates {
+		assert.Equal(t, tmpl.id, binary.BigEndian.Uint16(rest))
+		count := int(binary.BigEndian.Uint16(rest[2:]))
+		assert.Equal(t, len(tmpl.fields), count)
+		rest = rest[4:]
+
+		var recordLen int
+		for i := 0; i < count; i++ {
+			id := binary.BigEndian.Uint16(rest)
+			recordLen += int(binary.BigEndian.Uint16(rest[2:]))
+			rest = rest[4:]
+			if id&enterpriseBit != 0 {
+				assert.EqualValues(t, reversePEN, binary.BigEndian.Uint32(rest))
+				assert.EqualValues(t, ieOctetDeltaCount, id&^enterpriseBit)
+				rest = rest[4:]
+			}
+		}
+		assert.Equal(t, tmpl.recordLen(), recordLen)
+	}
+	assert.Empty(t, rest)
+}
+
+func TestDataRecordIPv4(t *testing.T) {
+	c := newConn("10.0.0.1", "10.0.0.2", 40000, 80, 100, 200)
+	b := appendDataRecord(nil, &c)
+	require.Len(t, b, templateV4.recordLen())
+
+	assert.Equal(t, []byte{10, 0, 0, 1}, b[0:4])
+	assert.Equal(t, []byte{10, 0, 0, 2}, b[4:8])
+	assert.EqualValues(t, 40000, binary.BigEndian.Uint16(b[8:]))
+	assert.EqualValues(t, 80, binary.BigEndian.Uint16(b[10:]))
+	assert.EqualValues(t, 6, b[12])
+	assert.EqualValues(t, biflowInitiator, b[13])
+	assert.EqualValues(t, 100, binary.BigEndian.Uint64(b[14:]))
+	assert.EqualValues(t, 200, binary.BigEndian.Uint64(b[22:]))
+
+	// no translation: post-NAT fields hold the original tuple
+	assert.Equal(t, b[0:4], b[30:34])
+	assert.Equal(t, b[4:8], b[34:38])
+	assert.Equal(t, b[8:12], b[38:42])
+}
+
+func TestDataRecordNAT(t *testing.T) {
+	c := newConn("10.0.0.1", "10.96.0.10", 40000, 53, 10, 20)
+	c.Type = network.UDP
+	c.Direction = network.INCOMING
+	c.IPTranslation = &network.IPTranslation{
+		ReplSrcIP:   util.AddressFromString("10.1.2.3"),
+		ReplDstIP:   util.AddressFromString("192.168.1.1"),
+		ReplSrcPort: 5353,
+		ReplDstPort: 30000,
+	}
+	b := appendDataRecord(nil, &c)
+
+	assert.EqualValues(t, 17, b[12])
+	assert.EqualValues(t, biflowReverseInitiator, b[13])
+	assert.Equal(t, []byte{192, 168, 1, 1}, b[30:34])
+	assert.Equal(t, []byte{10, 1, 2, 3}, b[34:38])
+	assert.EqualValues(t, 30000, binary.BigEndian.Uint16(b[38:]))
+	assert.EqualValues(t, 5353, binary.BigEndian.Uint16(b[40:]))
+}
+
+func TestDataRecordIPv6(t *testing.T) {
+	c := newConn("2001:db8::1", "2001:db8::2", 40000, 443, 1, 2)
+	b := appendDataRecord(nil, &c)
+	require.Len(t, b, templateV6.recordLen())
+	assert.Equal(t, []byte(net.ParseIP("2001:db8::1")), b[0:16])
+	assert.Equal(t, []byte(net.ParseIP("2001:db8::2")), b[16:32])
+}
+
+func TestBuildMessages(t *testing.T) {
+	e := &Exporter{templateRefresh: time.Minute}
+	now := time.Now()
+
+	conns := []network.ConnectionStats{
+		newConn("10.0.0.1", "10.0.0.2", 40000, 80, 100, 200),
+		newConn("10.0.0.1", "10.0.0.3", 40001, 80, 0, 0), // idle, not exported
+		newConn("2001:db8::1", "2001:db8::2", 40002, 443, 1, 2),
+		newConn("10.0.0.1", "10.0.0.4", 40003, 80, 5, 0),
+	}
+
+	msgs := e.buildMessages(conns, now)
+	require.Len(t, msgs, 1)
+	seq, sets := decodeMessage(t, msgs[0])
+	assert.EqualValues(t, 0, seq)
+	require.Len(t, sets, 4)
+	assert.EqualValues(t, templateSetID, sets[0].id)
+	assert.EqualValues(t, templateIDv4, sets[1].id)
+	assert.Len(t, sets[1].content, templateV4.recordLen())
+	assert.EqualValues(t, templateIDv6, sets[2].id)
+	assert.EqualValues(t, templateIDv4, sets[3].id)
+	assert.EqualValues(t, 3, e.sequence)
+
+	// templates are not sent again before the refresh interval
+	msgs = e.buildMessages(conns[:1], now.Add(time.Second))
+	require.Len(t, msgs, 1)
+	seq, sets = decodeMessage(t, msgs[0])
+	assert.EqualValues(t, 3, seq)
+	require.Len(t, sets, 1)
+	assert.EqualValues(t, templateIDv4, sets[0].id)
+
+	// but they are after it, even without any data record
+	msgs = e.buildMessages(nil, now.Add(time.Minute))
+	require.Len(t, msgs, 1)
+	_, sets = decodeMessage(t, msgs[0])
+	require.Len(t, sets, 1)
+	assert.EqualValues(t, templateSetID, sets[0].id)
+
+	assert.Empty(t, e.buildMessages(nil, now.Add(time.Minute+time.Second)))
+}
+
+func TestBuildMessagesSplit(t *testing.T) {
+	e := &Exporter{templateRefresh: time.Minute}
+	conns := make([]network.ConnectionStats, 100)
+	for i := range conns {
+		conns[i] = newConn("10.0.0.1", "10.0.0.2", uint16(40000+i), 80, 1, 1)
+	}
+
+	msgs := e.buildMessages(conns, time.Now())
+	require.True(t, len(msgs) > 1)
+
+	var records int
+	for _, msg := range msgs {
+		assert.True(t, len(msg) <= maxMessageLen)
+		seq, sets := decodeMessage(t, msg)
+		assert.EqualValues(t, records, seq)
+		for _, s := range sets {
+			if s.id == templateIDv4 {
+				records += len(s.content) / templateV4.recordLen()
+			}
+		}
+	}
+	assert.Equal(t, len(conns), records)
+}
+
+func TestExport(t *testing.T) {
+	collector, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer collector.Close()
+
+	e, err := NewExporter(collector.LocalAddr().String(), 42, time.Minute)
+	require.NoError(t, err)
+	defer e.Close()
+
+	conns := []network.ConnectionStats{newConn("10.0.0.1", "10.0.0.2", 40000, 80, 100, 200)}
+	require.NoError(t, e.Export(conns, time.Now()))
+
+	buf := make([]byte, 65535)
+	collector.SetReadDeadline(time.Now().Add(5 * time.Second)) //nolint:errcheck
+	n, _, err := collector.ReadFrom(buf)
+	require.NoError(t, err)
+
+	_, sets := decodeMessage(t, buf[:n])
+	assert.EqualValues(t, 42, binary.BigEndian.Uint32(buf[12:]))
+	require.Len(t, sets, 2)
+
+	stats := e.GetStats()
+	assert.EqualValues(t, 1, stats["messages_sent"])
+	assert.EqualValues(t, 1, stats["records_sent"])
+}
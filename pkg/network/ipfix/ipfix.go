@@ -0,0 +1,209 @@
+// Package ipfix exports the connections tracked by the system-probe as IPFIX (RFC 7011)
+// flow records, so that they can be fed to existing flow collectors.
+package ipfix
+
+import (
+	"encoding/binary"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+)
+
+const (
+	// version is the IPFIX protocol version
+	version = 10
+
+	// messageHeaderLen is the length of the header of an IPFIX message
+	messageHeaderLen = 16
+	// setHeaderLen is the length of the header of a set
+	setHeaderLen = 4
+
+	// templateSetID is the set ID of the template sets
+	templateSetID = 2
+
+	// templateIDv4 and templateIDv6 are the IDs of the templates of the IPv4 and IPv6 records
+	templateIDv4 = 256
+	templateIDv6 = 257
+
+	// enterpriseBit flags the information elements which have an enterprise number
+	enterpriseBit = 0x8000
+
+	// reversePEN is the private enterprise number of the reverse information elements
+	// of bidirectional flows (RFC 5103)
+	reversePEN = 29305
+)
+
+// Information elements, as assigned by IANA
+const (
+	ieOctetDeltaCount                  = 1
+	ieProtocolIdentifier               = 4
+	ieSourceTransportPort              = 7
+	ieSourceIPv4Address                = 8
+	ieDestinationTransportPort         = 11
+	ieDestinationIPv4Address           = 12
+	ieSourceIPv6Address                = 27
+	ieDestinationIPv6Address           = 28
+	iePostNATSourceIPv4Address         = 225
+	iePostNATDestinationIPv4Address    = 226
+	iePostNAPTSourceTransportPort      = 227
+	iePostNAPTDestinationTransportPort = 228
+	ieBiflowDirection                  = 239
+	iePostNATSourceIPv6Address         = 281
+	iePostNATDestinationIPv6Address    = 282
+)
+
+// Values of the biflowDirection information element
+const (
+	biflowArbitrary        = 0
+	biflowInitiator        = 1
+	biflowReverseInitiator = 2
+)
+
+// fieldSpec is a field specifier of a template
+type fieldSpec struct {
+	id         uint16
+	length     uint16
+	enterprise uint32 // 0 for IANA information elements
+}
+
+// template describes the layout of the records of one address family
+type template struct {
+	id     uint16
+	fields []fieldSpec
+}
+
+func newTemplate(id uint16, addrLen uint16, srcIE, dstIE, postNATSrcIE, postNATDstIE uint16) *template {
+	return &template{
+		id: id,
+		fields: []fieldSpec{
+			{id: srcIE, length: addrLen},
+			{id: dstIE, length: addrLen},
+			{id: ieSourceTransportPort, length: 2},
+			{id: ieDestinationTransportPort, length: 2},
+			{id: ieProtocolIdentifier, length: 1},
+			{id: ieBiflowDirection, length: 1},
+			// bytes sent from the source to the destination
+			{id: ieOctetDeltaCount, length: 8},
+			// bytes sent from the destination to the source
+			{id: ieOctetDeltaCount, length: 8, enterprise: reversePEN},
+			{id: postNATSrcIE, length: addrLen},
+			{id: postNATDstIE, length: addrLen},
+			{id: iePostNAPTSourceTransportPort, length: 2},
+			{id: iePostNAPTDestinationTransportPort, length: 2},
+		},
+	}
+}
+
+var (
+	templateV4 = newTemplate(templateIDv4, 4, ieSourceIPv4Address, ieDestinationIPv4Address, iePostNATSourceIPv4Address, iePostNATDestinationIPv4Address)
+	templateV6 = newTemplate(templateIDv6, 16, ieSourceIPv6Address, ieDestinationIPv6Address, iePostNATSourceIPv6Address, iePostNATDestinationIPv6Address)
+	templates  = []*template{templateV4, templateV6}
+)
+
+// recordLen returns the length of the records of the template
+func (t *template) recordLen() int {
+	var n int
+	for _, f := range t.fields {
+		n += int(f.length)
+	}
+	return n
+}
+
+// appendTemplateRecord appends the template record of t to b
+func (t *template) appendTemplateRecord(b []byte) []byte {
+	b = appendUint16(b, t.id)
+	b = appendUint16(b, uint16(len(t.fields)))
+	for _, f := range t.fields {
+		if f.enterprise != 0 {
+			b = appendUint16(b, f.id|enterpriseBit)
+			b = appendUint16(b, f.length)
+			b = appendUint32(b, f.enterprise)
+			continue
+		}
+		b = appendUint16(b, f.id)
+		b = appendUint16(b, f.length)
+	}
+	return b
+}
+
+// templateFor returns the template of the records of the given connection
+func templateFor(c *network.ConnectionStats) *template {
+	if c.Family == network.AFINET6 {
+		return templateV6
+	}
+	return templateV4
+}
+
+// appendDataRecord appends the data record of the connection c, following the template
+// returned by templateFor, to b
+func appendDataRecord(b []byte, c *network.ConnectionStats) []byte {
+	addrLen := 4
+	if c.Family == network.AFINET6 {
+		addrLen = 16
+	}
+
+	b = appendAddress(b, c.Source, addrLen)
+	b = appendAddress(b, c.Dest, addrLen)
+	b = appendUint16(b, c.SPort)
+	b = appendUint16(b, c.DPort)
+	b = append(b, protocolNumber(c.Type), biflowDirection(c.Direction))
+	b = appendUint64(b, c.LastSentBytes)
+	b = appendUint64(b, c.LastRecvBytes)
+
+	// Without translation, the post-NAT fields hold the original values
+	postSrc, postDst, postSPort, postDPort := c.Source, c.Dest, c.SPort, c.DPort
+	if t := c.IPTranslation; t != nil {
+		// the reply goes from the translated destination to the translated source
+		postSrc, postDst, postSPort, postDPort = t.ReplDstIP, t.ReplSrcIP, t.ReplDstPort, t.ReplSrcPort
+	}
+	b = appendAddress(b, postSrc, addrLen)
+	b = appendAddress(b, postDst, addrLen)
+	b = appendUint16(b, postSPort)
+	b = appendUint16(b, postDPort)
+	return b
+}
+
+func protocolNumber(t network.ConnectionType) byte {
+	if t == network.UDP {
+		return 17
+	}
+	return 6
+}
+
+func biflowDirection(d network.ConnectionDirection) byte {
+	switch d {
+	case network.OUTGOING:
+		// the host, which is the source, initiated the connection
+		return biflowInitiator
+	case network.INCOMING:
+		return biflowReverseInitiator
+	}
+	return biflowArbitrary
+}
+
+// appendAddress appends the addrLen bytes of the address a to b, or zeros if a is missing or
+// of another family
+func appendAddress(b []byte, a util.Address, addrLen int) []byte {
+	if a != nil {
+		if raw := a.Bytes(); len(raw) == addrLen {
+			return append(b, raw...)
+		}
+	}
+	return append(b, make([]byte, addrLen)...)
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,89 @@
+package metadata
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+)
+
+// containerIDLookup returns the ID of the container of a process, or an empty string if the
+// process doesn't run in a container
+type containerIDLookup func(pid int) (string, error)
+
+type containerEntry struct {
+	id       string
+	resolved time.Time
+}
+
+// containerCache caches the container IDs of the processes of the connections. Entries of the
+// processes without connections are evicted on every call to resolve.
+type containerCache struct {
+	// mux guards the entries and the telemetry, read by getStats from other goroutines than the one resolving
+	mux     sync.Mutex
+	lookup  containerIDLookup
+	ttl     time.Duration
+	entries map[uint32]containerEntry
+
+	// telemetry
+	lookups      int64
+	lookupErrors int64
+}
+
+func newContainerCache(lookup containerIDLookup, ttl time.Duration) *containerCache {
+	return &containerCache{
+		lookup:  lookup,
+		ttl:     ttl,
+		entries: make(map[uint32]containerEntry),
+	}
+}
+
+// resolve adds the container_id tag to the connections of processes running in a container
+func (c *containerCache) resolve(conns []network.ConnectionStats) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	now := time.Now()
+	seen := make(map[uint32]containerEntry, len(c.entries))
+
+	for i := range conns {
+		conn := &conns[i]
+		entry, ok := seen[conn.Pid]
+		if !ok {
+			entry = c.get(conn.Pid, now)
+			seen[conn.Pid] = entry
+		}
+		if entry.id != "" {
+			conn.Tags = append(conn.Tags, "container_id:"+entry.id)
+		}
+	}
+
+	c.entries = seen
+}
+
+// get returns the entry of a process, looked up again once expired. The cache must be locked.
+func (c *containerCache) get(pid uint32, now time.Time) containerEntry {
+	if entry, ok := c.entries[pid]; ok && now.Sub(entry.resolved) < c.ttl {
+		return entry
+	}
+
+	c.lookups++
+	id, err := c.lookup(int(pid))
+	if err != nil {
+		// the process is likely gone, don't retry before the entry expires
+		c.lookupErrors++
+		id = ""
+	}
+	return containerEntry{id: id, resolved: now}
+}
+
+func (c *containerCache) getStats() map[string]int64 {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+
+	return map[string]int64{
+		"container_lookups":       c.lookups,
+		"container_lookup_errors": c.lookupErrors,
+		"containers_cached":       int64(len(c.entries)),
+	}
+}
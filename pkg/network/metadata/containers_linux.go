@@ -0,0 +1,15 @@
+// +build linux
+
+package metadata
+
+import (
+	"github.com/DataDog/datadog-agent/pkg/util/containers/providers"
+
+	// register the cgroup container implementation
+	_ "github.com/DataDog/datadog-agent/pkg/util/containers/providers/cgroup"
+)
+
+// newContainerIDLookup returns a lookup reading the container IDs from the cgroups of the processes
+func newContainerIDLookup() (containerIDLookup, error) {
+	return providers.ContainerImpl().ContainerIDForPID, nil
+}
@@ -0,0 +1,9 @@
+// +build !linux
+
+package metadata
+
+import "errors"
+
+func newContainerIDLookup() (containerIDLookup, error) {
+	return nil, errors.New("container tags are only supported on linux")
+}
@@ -0,0 +1,115 @@
+package metadata
+
+import (
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// podInfo holds the tags of a pod
+type podInfo struct {
+	name      string
+	namespace string
+	services  []string
+}
+
+func (p *podInfo) tags() []string {
+	tags := make([]string, 0, 2+len(p.services))
+	tags = append(tags, "dest_pod_name:"+p.name, "dest_kube_namespace:"+p.namespace)
+	for _, svc := range p.services {
+		tags = append(tags, "dest_kube_service:"+svc)
+	}
+	return tags
+}
+
+// podSource returns the pods by IP address
+type podSource func() (map[string]*podInfo, error)
+
+// newPodSource returns a source listing the pods of the cluster from the API server, so that the
+// connections to the pods of the other nodes are tagged as well. It falls back on the pods of the
+// node, listed from the kubelet, if the API server can't be reached. The watches of the API server
+// are stopped when exit is closed.
+func newPodSource(exit <-chan struct{}) (podSource, error) {
+	source, err := newClusterPodSource(exit)
+	if err == nil {
+		return source, nil
+	}
+	log.Debugf("unable to list the pods of the cluster, falling back on the pods of the node: %s", err)
+	return newNodePodSource()
+}
+
+// podCache holds the tags of the pods by IP address
+type podCache struct {
+	source podSource
+
+	mux  sync.RWMutex
+	tags map[string][]string
+
+	// telemetry, guarded by mux
+	refreshes     int64
+	refreshErrors int64
+}
+
+func newPodCache(source podSource) *podCache {
+	return &podCache{
+		source: source,
+		tags:   map[string][]string{},
+	}
+}
+
+// refresh lists the pods again
+func (c *podCache) refresh() error {
+	pods, err := c.source()
+
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.refreshes++
+	if err != nil {
+		// keep the pods of the last successful refresh
+		c.refreshErrors++
+		return err
+	}
+
+	tags := make(map[string][]string, len(pods))
+	for ip, pod := range pods {
+		tags[ip] = pod.tags()
+	}
+	c.tags = tags
+	return nil
+}
+
+// resolve adds the tags of the destination pod to the connections
+func (c *podCache) resolve(conns []network.ConnectionStats) {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+
+	if len(c.tags) == 0 {
+		return
+	}
+	for i := range conns {
+		conn := &conns[i]
+		if tags, ok := c.tags[destination(conn).String()]; ok {
+			conn.Tags = append(conn.Tags, tags...)
+		}
+	}
+}
+
+// destination returns the address of the destination of the connection, after translation
+func destination(conn *network.ConnectionStats) util.Address {
+	if t := conn.IPTranslation; t != nil && t.ReplSrcIP != nil {
+		return t.ReplSrcIP
+	}
+	return conn.Dest
+}
+
+func (c *podCache) getStats() map[string]int64 {
+	c.mux.RLock()
+	defer c.mux.RUnlock()
+	return map[string]int64{
+		"pod_refreshes":      c.refreshes,
+		"pod_refresh_errors": c.refreshErrors,
+		"pod_ips":            int64(len(c.tags)),
+	}
+}
@@ -0,0 +1,91 @@
+// +build kubeapiserver
+
+package metadata
+
+import (
+	"sort"
+
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/apiserver"
+)
+
+// newClusterPodSource returns a source listing the pods of the cluster from the caches of shared
+// informers, so that the API server only streams the changes of the pods and endpoints to each node
+// instead of being listed at every refresh. The services of the pods are the ones whose endpoints
+// target them. The informers are stopped when exit is closed.
+func newClusterPodSource(exit <-chan struct{}) (podSource, error) {
+	cl, err := apiserver.GetAPIClient()
+	if err != nil {
+		return nil, err
+	}
+
+	podInformer := cl.InformerFactory.Core().V1().Pods()
+	endpointsInformer := cl.InformerFactory.Core().V1().Endpoints()
+	informers := map[apiserver.InformerName]cache.SharedInformer{
+		apiserver.PodsInformer:              podInformer.Informer(),
+		apiserver.InformerName("endpoints"): endpointsInformer.Informer(),
+	}
+
+	cl.InformerFactory.Start(exit)
+	if err := apiserver.SyncInformers(informers); err != nil {
+		return nil, err
+	}
+
+	return func() (map[string]*podInfo, error) {
+		pods, err := podInformer.Lister().List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		endpoints, err := endpointsInformer.Lister().List(labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+		return buildClusterPodIndex(pods, endpoints), nil
+	}, nil
+}
+
+// buildClusterPodIndex indexes the running pods by IP address. Host network pods are skipped, since
+// their address is the one of their node.
+func buildClusterPodIndex(pods []*v1.Pod, endpoints []*v1.Endpoints) map[string]*podInfo {
+	index := make(map[string]*podInfo, len(pods))
+	for _, pod := range pods {
+		if pod.Spec.HostNetwork || pod.Status.PodIP == "" || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		index[pod.Status.PodIP] = &podInfo{
+			name:      pod.Name,
+			namespace: pod.Namespace,
+		}
+	}
+
+	// the endpoints of a service are named after it
+	for _, ep := range endpoints {
+		for _, subset := range ep.Subsets {
+			for _, addr := range subset.Addresses {
+				info, ok := index[addr.IP]
+				if !ok || info.namespace != ep.Namespace || addr.TargetRef == nil || addr.TargetRef.Kind != "Pod" {
+					continue
+				}
+				info.services = appendService(info.services, ep.Name)
+			}
+		}
+	}
+	for _, info := range index {
+		sort.Strings(info.services)
+	}
+	return index
+}
+
+// appendService appends svc to services, unless already present since a service can target a pod
+// through several subsets
+func appendService(services []string, svc string) []string {
+	for _, s := range services {
+		if s == svc {
+			return services
+		}
+	}
+	return append(services, svc)
+}
@@ -0,0 +1,57 @@
+// +build kubeapiserver
+
+package metadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestBuildClusterPodIndex(t *testing.T) {
+	newPod := func(name, namespace, ip string, hostNetwork bool) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       v1.PodSpec{HostNetwork: hostNetwork},
+			Status:     v1.PodStatus{PodIP: ip, Phase: v1.PodRunning},
+		}
+	}
+	newEndpoints := func(name, namespace string, ips ...string) *v1.Endpoints {
+		ep := &v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace}}
+		for _, ip := range ips {
+			ep.Subsets = append(ep.Subsets, v1.EndpointSubset{
+				Addresses: []v1.EndpointAddress{{IP: ip, TargetRef: &v1.ObjectReference{Kind: "Pod"}}},
+			})
+		}
+		return ep
+	}
+	succeeded := newPod("job-0", "default", "10.4.0.7", false)
+	succeeded.Status.Phase = v1.PodSucceeded
+
+	pods := []*v1.Pod{
+		succeeded,
+		newPod("web-0", "default", "10.4.0.5", false),
+		// pod of another node
+		newPod("db-0", "storage", "10.8.0.6", false),
+		newPod("node-exporter", "monitoring", "192.168.1.10", true),
+		newPod("pending", "default", "", false),
+	}
+	endpoints := []*v1.Endpoints{
+		newEndpoints("web", "default", "10.4.0.5", "10.4.0.5"),
+		newEndpoints("web-headless", "default", "10.4.0.5"),
+		// an address of another namespace doesn't match
+		newEndpoints("db", "default", "10.8.0.6"),
+	}
+
+	index := buildClusterPodIndex(pods, endpoints)
+	require.Len(t, index, 2)
+	assert.Equal(t, &podInfo{name: "web-0", namespace: "default", services: []string{"web", "web-headless"}}, index["10.4.0.5"])
+	assert.Equal(t, &podInfo{name: "db-0", namespace: "storage"}, index["10.8.0.6"])
+
+	// services are optional
+	index = buildClusterPodIndex(pods, nil)
+	assert.Empty(t, index["10.4.0.5"].services)
+}
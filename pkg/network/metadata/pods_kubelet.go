@@ -0,0 +1,80 @@
+// +build kubelet
+
+package metadata
+
+import (
+	"sort"
+	"strings"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/config"
+	"github.com/DataDog/datadog-agent/pkg/util/clusteragent"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+// newNodePodSource returns a source listing the pods of the node from the kubelet. The services of the
+// pods are pulled from the cluster agent, when it is enabled.
+func newNodePodSource() (podSource, error) {
+	kubeUtil, err := kubelet.GetKubeUtil()
+	if err != nil {
+		return nil, err
+	}
+
+	return func() (map[string]*podInfo, error) {
+		pods, err := kubeUtil.GetLocalPodList()
+		if err != nil {
+			return nil, err
+		}
+		return buildPodIndex(pods, getServices(kubeUtil)), nil
+	}, nil
+}
+
+// getServices returns the services of the pods of the node, or nil if they are not available
+func getServices(kubeUtil kubelet.KubeUtilInterface) apiv1.NamespacesPodsStringsSet {
+	if !config.Datadog.GetBool("cluster_agent.enabled") {
+		return nil
+	}
+
+	dcaClient, err := clusteragent.GetClusterAgentClient()
+	if err != nil {
+		log.Debugf("unable to get the cluster agent client: %s", err)
+		return nil
+	}
+	nodeName, err := kubeUtil.GetNodename()
+	if err != nil {
+		log.Debugf("unable to get the node name: %s", err)
+		return nil
+	}
+	services, err := dcaClient.GetPodsMetadataForNode(nodeName)
+	if err != nil {
+		log.Debugf("unable to get the services of the pods of node %s from the cluster agent: %s", nodeName, err)
+		return nil
+	}
+	return services
+}
+
+// buildPodIndex indexes the pods by IP address. Host network pods are skipped, since their address
+// is the one of the host.
+func buildPodIndex(pods []*kubelet.Pod, services apiv1.NamespacesPodsStringsSet) map[string]*podInfo {
+	index := make(map[string]*podInfo, len(pods))
+	for _, pod := range pods {
+		if pod.Spec.HostNetwork || pod.Status.PodIP == "" {
+			continue
+		}
+
+		info := &podInfo{
+			name:      pod.Metadata.Name,
+			namespace: pod.Metadata.Namespace,
+		}
+		if podServices, ok := services[pod.Metadata.Namespace][pod.Metadata.Name]; ok {
+			for _, svc := range podServices.List() {
+				// the cluster agent returns either service names or kube_service tags
+				info.services = append(info.services, strings.TrimPrefix(svc, "kube_service:"))
+			}
+			sort.Strings(info.services)
+		}
+		index[pod.Status.PodIP] = info
+	}
+	return index
+}
@@ -0,0 +1,43 @@
+// +build kubelet
+
+package metadata
+
+import (
+	"testing"
+
+	apiv1 "github.com/DataDog/datadog-agent/pkg/clusteragent/api/v1"
+	"github.com/DataDog/datadog-agent/pkg/util/kubernetes/kubelet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestBuildPodIndex(t *testing.T) {
+	newPod := func(name, namespace, ip string, hostNetwork bool) *kubelet.Pod {
+		return &kubelet.Pod{
+			Metadata: kubelet.PodMetadata{Name: name, Namespace: namespace},
+			Spec:     kubelet.Spec{HostNetwork: hostNetwork},
+			Status:   kubelet.Status{PodIP: ip},
+		}
+	}
+	pods := []*kubelet.Pod{
+		newPod("web-0", "default", "10.4.0.5", false),
+		newPod("db-0", "storage", "10.4.0.6", false),
+		newPod("node-exporter", "monitoring", "192.168.1.10", true),
+		newPod("pending", "default", "", false),
+	}
+	services := apiv1.NamespacesPodsStringsSet{
+		"default": apiv1.MapStringSet{
+			"web-0": sets.NewString("web", "kube_service:web-headless"),
+		},
+	}
+
+	index := buildPodIndex(pods, services)
+	require.Len(t, index, 2)
+	assert.Equal(t, &podInfo{name: "web-0", namespace: "default", services: []string{"web", "web-headless"}}, index["10.4.0.5"])
+	assert.Equal(t, &podInfo{name: "db-0", namespace: "storage"}, index["10.4.0.6"])
+
+	// services are optional
+	index = buildPodIndex(pods, nil)
+	assert.Empty(t, index["10.4.0.5"].services)
+}
@@ -0,0 +1,9 @@
+// +build !kubeapiserver
+
+package metadata
+
+import "errors"
+
+func newClusterPodSource(exit <-chan struct{}) (podSource, error) {
+	return nil, errors.New("kubernetes apiserver support not compiled in")
+}
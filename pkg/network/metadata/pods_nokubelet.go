@@ -0,0 +1,9 @@
+// +build !kubelet
+
+package metadata
+
+import "errors"
+
+func newNodePodSource() (podSource, error) {
+	return nil, errors.New("kubelet support not compiled in")
+}
@@ -0,0 +1,138 @@
+// Package metadata enriches the connections tracked by the system-probe with the container of
+// their process and the Kubernetes pod and services of their destination.
+package metadata
+
+import (
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// containerCacheTTL is the duration after which the container of a PID is resolved again,
+	// as PIDs get reused
+	containerCacheTTL = 5 * time.Minute
+
+	// podRefreshInterval is the interval at which the pods are indexed again, from the kubelet or
+	// from the informers caches of the cluster pods
+	podRefreshInterval = 30 * time.Second
+)
+
+// Resolver adds metadata tags to connections
+type Resolver interface {
+	// Resolve sets the Tags of the given connections
+	Resolve(conns []network.ConnectionStats)
+	GetStats() map[string]int64
+	Close()
+}
+
+// NewNullResolver returns a dummy implementation of Resolver
+func NewNullResolver() Resolver {
+	return nullResolver{}
+}
+
+type nullResolver struct{}
+
+func (nullResolver) Resolve([]network.ConnectionStats) {}
+
+func (nullResolver) GetStats() map[string]int64 {
+	return map[string]int64{}
+}
+
+func (nullResolver) Close() {}
+
+var _ Resolver = nullResolver{}
+
+// resolver tags connections with:
+// - container_id: the container of the process of the connection, read from its cgroup
+// - dest_pod_name, dest_kube_namespace, dest_kube_service: the pod of the destination of the
+// connection, when it is a pod of the cluster. For translated connections, the destination is
+// the one after translation, so that connections to a service IP are tagged with the pod
+// serving them.
+type resolver struct {
+	containers *containerCache // nil if container tags are disabled
+	pods       *podCache       // nil if Kubernetes tags are disabled
+
+	exit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewResolver returns a Resolver adding the container tags and/or Kubernetes tags to connections.
+// The Kubernetes tags are only added if the API server, or the kubelet of the node, can be reached.
+func NewResolver(containerTags, kubernetesTags bool) (Resolver, error) {
+	r := &resolver{exit: make(chan struct{})}
+
+	if containerTags {
+		lookup, err := newContainerIDLookup()
+		if err != nil {
+			return nil, err
+		}
+		r.containers = newContainerCache(lookup, containerCacheTTL)
+	}
+
+	if kubernetesTags {
+		source, err := newPodSource(r.exit)
+		if err != nil {
+			return nil, err
+		}
+		r.pods = newPodCache(source)
+
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			r.refreshPods()
+		}()
+	}
+
+	return r, nil
+}
+
+func (r *resolver) refreshPods() {
+	ticker := time.NewTicker(podRefreshInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.pods.refresh(); err != nil {
+			log.Debugf("unable to refresh the pods: %s", err)
+		}
+
+		select {
+		case <-r.exit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *resolver) Resolve(conns []network.ConnectionStats) {
+	if r.containers != nil {
+		r.containers.resolve(conns)
+	}
+	if r.pods != nil {
+		r.pods.resolve(conns)
+	}
+}
+
+func (r *resolver) GetStats() map[string]int64 {
+	stats := map[string]int64{}
+	if r.containers != nil {
+		for k, v := range r.containers.getStats() {
+			stats[k] = v
+		}
+	}
+	if r.pods != nil {
+		for k, v := range r.pods.getStats() {
+			stats[k] = v
+		}
+	}
+	return stats
+}
+
+func (r *resolver) Close() {
+	close(r.exit)
+	r.wg.Wait()
+}
+
+var _ Resolver = &resolver{}
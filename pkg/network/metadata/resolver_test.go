@@ -0,0 +1,119 @@
+package metadata
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/network"
+	"github.com/DataDog/datadog-agent/pkg/process/util"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerCache(t *testing.T) {
+	lookups := map[int]int{}
+	lookup := func(pid int) (string, error) {
+		lookups[pid]++
+		switch pid {
+		case 1:
+			return "", nil
+		case 2:
+			return "abcdef", nil
+		}
+		return "", errors.New("no such process")
+	}
+	cache := newContainerCache(lookup, time.Minute)
+
+	conns := []network.ConnectionStats{{Pid: 1}, {Pid: 2}, {Pid: 2}, {Pid: 3}}
+	cache.resolve(conns)
+	assert.Empty(t, conns[0].Tags)
+	assert.Equal(t, []string{"container_id:abcdef"}, conns[1].Tags)
+	assert.Equal(t, []string{"container_id:abcdef"}, conns[2].Tags)
+	assert.Empty(t, conns[3].Tags)
+	assert.Equal(t, map[int]int{1: 1, 2: 1, 3: 1}, lookups)
+
+	// PIDs are looked up once per TTL
+	cache.resolve([]network.ConnectionStats{{Pid: 2}})
+	assert.Equal(t, 1, lookups[2])
+
+	// entries of the processes without connections are evicted
+	assert.Len(t, cache.entries, 1)
+	cache.resolve([]network.ConnectionStats{{Pid: 1}})
+	assert.Equal(t, 2, lookups[1])
+
+	stats := cache.getStats()
+	assert.EqualValues(t, 4, stats["container_lookups"])
+	assert.EqualValues(t, 1, stats["container_lookup_errors"])
+}
+
+func TestContainerCacheConcurrentStats(t *testing.T) {
+	cache := newContainerCache(func(int) (string, error) { return "abcdef", nil }, 0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			cache.getStats()
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		cache.resolve([]network.ConnectionStats{{Pid: uint32(i)}})
+	}
+	<-done
+
+	assert.EqualValues(t, 100, cache.getStats()["container_lookups"])
+}
+
+func TestContainerCacheExpiry(t *testing.T) {
+	var calls int
+	cache := newContainerCache(func(int) (string, error) {
+		calls++
+		return "abcdef", nil
+	}, 0)
+
+	cache.resolve([]network.ConnectionStats{{Pid: 1}})
+	cache.resolve([]network.ConnectionStats{{Pid: 1}})
+	assert.Equal(t, 2, calls)
+}
+
+func TestPodCache(t *testing.T) {
+	var err error
+	pods := map[string]*podInfo{
+		"10.4.0.5": {name: "web-0", namespace: "default", services: []string{"web"}},
+		"10.4.0.6": {name: "db-0", namespace: "storage"},
+	}
+	cache := newPodCache(func() (map[string]*podInfo, error) {
+		return pods, err
+	})
+	require.NoError(t, cache.refresh())
+
+	conns := []network.ConnectionStats{
+		{Dest: util.AddressFromString("10.4.0.5")},
+		{
+			// connection to a service IP, translated to a pod
+			Dest: util.AddressFromString("10.96.0.10"),
+			IPTranslation: &network.IPTranslation{
+				ReplSrcIP: util.AddressFromString("10.4.0.6"),
+				ReplDstIP: util.AddressFromString("10.4.0.1"),
+			},
+		},
+		{Dest: util.AddressFromString("8.8.8.8")},
+	}
+	cache.resolve(conns)
+	assert.Equal(t, []string{"dest_pod_name:web-0", "dest_kube_namespace:default", "dest_kube_service:web"}, conns[0].Tags)
+	assert.Equal(t, []string{"dest_pod_name:db-0", "dest_kube_namespace:storage"}, conns[1].Tags)
+	assert.Empty(t, conns[2].Tags)
+
+	// the pods of the last successful refresh are kept on errors
+	err = errors.New("kubelet unavailable")
+	assert.Error(t, cache.refresh())
+	conns = []network.ConnectionStats{{Dest: util.AddressFromString("10.4.0.5")}}
+	cache.resolve(conns)
+	assert.Len(t, conns[0].Tags, 3)
+
+	stats := cache.getStats()
+	assert.EqualValues(t, 2, stats["pod_refreshes"])
+	assert.EqualValues(t, 1, stats["pod_refresh_errors"])
+	assert.EqualValues(t, 2, stats["pod_ips"])
+}
@@ -3,10 +3,16 @@ package network
 import (
 	"bufio"
 	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
 	"io"
+	"net"
 	"os"
 	"strconv"
+	"unsafe"
 
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
 
@@ -85,6 +91,132 @@ func readProcNetWithStatus(path string, status int64) ([]uint16, error) {
 	return ports, nil
 }
 
+// hostByteOrder is the byte order of the 32-bit words of the addresses in /proc/net files
+var hostByteOrder = getHostByteOrder()
+
+func getHostByteOrder() binary.ByteOrder {
+	var i int32 = 0x01020304
+	if *(*byte)(unsafe.Pointer(&i)) == 0x04 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}
+
+// ProcNetSocket is a socket listed in a /proc/net/{tcp,udp}{,6} file
+type ProcNetSocket struct {
+	Local      util.Address
+	Remote     util.Address
+	LocalPort  uint16
+	RemotePort uint16
+	// State is the TCP state of the socket. UDP sockets are either TCP_ESTABLISHED (1) when
+	// connected, or TCP_CLOSE (7).
+	State int64
+	// Inode is the inode of the socket, or 0 if the socket isn't owned by a process anymore
+	// (e.g. TCP connections in TIME_WAIT)
+	Inode uint64
+}
+
+// IsListening returns whether the socket is a TCP socket listening for connections
+func (s ProcNetSocket) IsListening() bool {
+	return s.State == tcpListen
+}
+
+// ReadProcNetSockets reads a /proc/net/{tcp,udp}{,6} file and returns all the sockets it lists.
+// Addresses of IPv4-mapped IPv6 sockets are returned as IPv4 addresses.
+func ReadProcNetSockets(path string) ([]ProcNetSocket, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	reader := bufio.NewReader(f)
+
+	// Skip header line
+	_, _ = reader.ReadBytes('\n')
+
+	var sockets []ProcNetSocket
+	for {
+		b, err := reader.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+
+		if len(bytes.TrimSpace(b)) > 0 {
+			if socket, perr := parseProcNetSocket(b); perr != nil {
+				log.Debugf("error parsing %s entry: %s", path, perr)
+			} else {
+				sockets = append(sockets, socket)
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+	}
+
+	return sockets, nil
+}
+
+func parseProcNetSocket(line []byte) (ProcNetSocket, error) {
+	var s ProcNetSocket
+	var err error
+
+	iter := &fieldIterator{data: line}
+	iter.nextField() // entry number
+
+	if s.Local, s.LocalPort, err = parseProcNetAddress(iter.nextField()); err != nil {
+		return s, err
+	}
+	if s.Remote, s.RemotePort, err = parseProcNetAddress(iter.nextField()); err != nil {
+		return s, err
+	}
+
+	rawState := iter.nextField()
+	if s.State, err = strconv.ParseInt(string(rawState), 16, 0); err != nil {
+		return s, fmt.Errorf("error parsing state [%s] as hex: %s", rawState, err)
+	}
+
+	iter.nextField() // tx_queue:rx_queue
+	iter.nextField() // tr:tm->when
+	iter.nextField() // retrnsmt
+	iter.nextField() // uid
+	iter.nextField() // timeout
+
+	rawInode := iter.nextField()
+	if s.Inode, err = strconv.ParseUint(string(rawInode), 10, 64); err != nil {
+		return s, fmt.Errorf("error parsing inode [%s]: %s", rawInode, err)
+	}
+	return s, nil
+}
+
+// parseProcNetAddress parses an address formatted as ADDR:PORT, where ADDR is the hex
+// representation of the address as 32-bit words in host byte order
+func parseProcNetAddress(raw []byte) (util.Address, uint16, error) {
+	idx := bytes.IndexByte(raw, ':')
+	if idx == -1 {
+		return nil, 0, fmt.Errorf("invalid address [%s]", raw)
+	}
+
+	port, err := strconv.ParseUint(string(raw[idx+1:]), 16, 16)
+	if err != nil {
+		return nil, 0, fmt.Errorf("error parsing port [%s] as hex: %s", raw[idx+1:], err)
+	}
+
+	rawIP := raw[:idx]
+	if len(rawIP) != 8 && len(rawIP) != 32 {
+		return nil, 0, fmt.Errorf("invalid address [%s]", raw)
+	}
+	ip := make(net.IP, len(rawIP)/2)
+	if _, err := hex.Decode(ip, rawIP); err != nil {
+		return nil, 0, fmt.Errorf("error parsing address [%s] as hex: %s", rawIP, err)
+	}
+	for i := 0; i < len(ip); i += 4 {
+		binary.BigEndian.PutUint32(ip[i:], hostByteOrder.Uint32(ip[i:]))
+	}
+	return util.AddressFromNetIP(ip), uint16(port), nil
+}
+
 type fieldIterator struct {
 	data []byte
 }
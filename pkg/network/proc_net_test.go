@@ -5,6 +5,7 @@ import (
 	"os"
 	"testing"
 
+	"github.com/DataDog/datadog-agent/pkg/process/util"
 	"github.com/stretchr/testify/require"
 )
 
@@ -45,6 +46,77 @@ func TestReadProcNet(t *testing.T) {
 	}
 }
 
+func TestReadProcNetSockets(t *testing.T) {
+	tests := [...]struct {
+		input    string
+		expected []ProcNetSocket
+	}{
+		{
+			input: `  sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 0200007F:B600 00000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 61632 1 ffff88003cc20780 100 0 0 10 0
+   8: 0F02000A:0016 0202000A:C121 01 00000000:00000000 02:00091FA3 00000000     0        0 20179 3 ffff88003cc20000 20 4 1 10 -1`,
+			expected: []ProcNetSocket{
+				{
+					Local:     util.AddressFromString("127.0.0.2"),
+					Remote:    util.AddressFromString("0.0.0.0"),
+					LocalPort: 46592,
+					State:     tcpListen,
+					Inode:     61632,
+				},
+				{
+					Local:      util.AddressFromString("10.0.2.15"),
+					Remote:     util.AddressFromString("10.0.2.2"),
+					LocalPort:  22,
+					RemotePort: 49441,
+					State:      1,
+					Inode:      20179,
+				},
+			},
+		},
+		{
+			input: `  sl  local_address                         remote_address                        st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode
+   0: 00000000000000000000000000000000:ADA0 00000000000000000000000000000000:0000 0A 00000000:00000000 00:00000000 00000000     0        0 16755 1 ffff88003b34b180 100 0 0 10 0
+   7: 00000000000000000000000001000000:EBCE 00000000000000000000000001000000:303A 06 00000000:00000000 03:00000AA4 00000000     0        0 0 3 ffff880035387000
+   8: 0000000000000000FFFF00000100007F:EBD0 0000000000000000FFFF00000200007F:303A 01 00000000:00000000 00:00000000 00000000     0        0 4242 1 ffff880035387118`,
+			expected: []ProcNetSocket{
+				{
+					Local:     util.AddressFromString("::"),
+					Remote:    util.AddressFromString("::"),
+					LocalPort: 44448,
+					State:     tcpListen,
+					Inode:     16755,
+				},
+				{
+					Local:      util.AddressFromString("::1"),
+					Remote:     util.AddressFromString("::1"),
+					LocalPort:  60366,
+					RemotePort: 12346,
+					State:      6,
+				},
+				{
+					Local:      util.AddressFromString("127.0.0.1"),
+					Remote:     util.AddressFromString("127.0.0.2"),
+					LocalPort:  60368,
+					RemotePort: 12346,
+					State:      1,
+					Inode:      4242,
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		file, err := writeTestFile(tt.input)
+		require.NoError(t, err)
+		//noinspection GoDeferInLoop
+		defer func() { _ = os.Remove(file.Name()) }()
+
+		sockets, err := ReadProcNetSockets(file.Name())
+		require.NoError(t, err)
+		require.Equal(t, tt.expected, sockets)
+	}
+}
+
 func writeTestFile(content string) (f *os.File, err error) {
 	tmpfile, err := ioutil.TempFile("", "test-proc-net")
 
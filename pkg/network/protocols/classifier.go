@@ -0,0 +1,287 @@
+// Package protocols classifies the application-layer protocol of network connections
+// from the first bytes of their payload.
+package protocols
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+
+	"golang.org/x/net/http2/hpack"
+)
+
+// Protocol is an application-layer protocol
+type Protocol uint8
+
+const (
+	// Unknown means the protocol could not be classified
+	Unknown Protocol = iota
+	// HTTP is HTTP/1.0 or HTTP/1.1
+	HTTP
+	// HTTP2 is HTTP/2 (including h2c)
+	HTTP2
+	// TLS is TLS or SSL, whatever the protocol it wraps
+	TLS
+	// GRPC is gRPC, over cleartext HTTP/2
+	GRPC
+	// Postgres is the PostgreSQL frontend/backend protocol
+	Postgres
+	// MySQL is the MySQL client/server protocol
+	MySQL
+	// Redis is the Redis serialization protocol (RESP)
+	Redis
+	// Kafka is the Kafka wire protocol
+	Kafka
+)
+
+var protocolNames = [...]string{
+	Unknown:  "unknown",
+	HTTP:     "http",
+	HTTP2:    "http2",
+	TLS:      "tls",
+	GRPC:     "grpc",
+	Postgres: "postgres",
+	MySQL:    "mysql",
+	Redis:    "redis",
+	Kafka:    "kafka",
+}
+
+func (p Protocol) String() string {
+	if int(p) < len(protocolNames) {
+		return protocolNames[p]
+	}
+	return protocolNames[Unknown]
+}
+
+// Classify returns the protocol of a connection given the first payload bytes sent on
+// it, either by the client or by the server. It returns Unknown when the payload does
+// not match any protocol, which includes payloads which are too short to be conclusive.
+func Classify(payload []byte) Protocol {
+	switch {
+	case isTLS(payload):
+		return TLS
+	case bytes.HasPrefix(payload, http2Preface):
+		if isGRPC(payload[len(http2Preface):]) {
+			return GRPC
+		}
+		return HTTP2
+	case isHTTP(payload):
+		return HTTP
+	case isPostgres(payload):
+		return Postgres
+	case isMySQL(payload):
+		return MySQL
+	case isRedis(payload):
+		return Redis
+	case isKafka(payload):
+		return Kafka
+	}
+	return Unknown
+}
+
+// isTLS reports whether the payload starts with a TLS handshake record holding a
+// ClientHello or a ServerHello.
+func isTLS(p []byte) bool {
+	const (
+		recordHandshake = 0x16
+		clientHello     = 0x01
+		serverHello     = 0x02
+	)
+	if len(p) < 6 || p[0] != recordHandshake || p[1] != 0x03 || p[2] > 0x04 {
+		return false
+	}
+	if length := binary.BigEndian.Uint16(p[3:5]); length == 0 || length > 1<<14+2048 {
+		return false
+	}
+	return p[5] == clientHello || p[5] == serverHello
+}
+
+var http2Preface = []byte("PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n")
+
+// isGRPC reports whether the HTTP/2 frames following the connection preface hold a
+// HEADERS frame with a gRPC content-type.
+func isGRPC(frames []byte) bool {
+	const (
+		frameHeaderLen = 9
+		frameHeaders   = 0x1
+		flagPadded     = 0x8
+		flagPriority   = 0x20
+	)
+	var grpc bool
+	dec := hpack.NewDecoder(4096, func(f hpack.HeaderField) {
+		if f.Name == "content-type" && strings.HasPrefix(f.Value, "application/grpc") {
+			grpc = true
+		}
+	})
+	for len(frames) >= frameHeaderLen && !grpc {
+		length := int(frames[0])<<16 | int(frames[1])<<8 | int(frames[2])
+		typ, flags := frames[3], frames[4]
+		if len(frames) < frameHeaderLen+length {
+			// only the beginning of the frame was captured
+			return false
+		}
+		payload := frames[frameHeaderLen : frameHeaderLen+length]
+		frames = frames[frameHeaderLen+length:]
+		if typ != frameHeaders {
+			continue
+		}
+		if flags&flagPadded != 0 {
+			if len(payload) < 1 || int(payload[0]) >= len(payload) {
+				return false
+			}
+			payload = payload[1 : len(payload)-int(payload[0])]
+		}
+		if flags&flagPriority != 0 {
+			if len(payload) < 5 {
+				return false
+			}
+			payload = payload[5:]
+		}
+		if _, err := dec.Write(payload); err != nil {
+			return false
+		}
+	}
+	return grpc
+}
+
+var httpMethods = []string{"GET", "POST", "PUT", "DELETE", "HEAD", "OPTIONS", "PATCH", "CONNECT", "TRACE"}
+
+// isHTTP reports whether the payload starts with an HTTP/1.x request or status line.
+func isHTTP(p []byte) bool {
+	if bytes.HasPrefix(p, []byte("HTTP/1.0 ")) || bytes.HasPrefix(p, []byte("HTTP/1.1 ")) {
+		return true
+	}
+	line := p
+	if i := bytes.IndexByte(p, '\n'); i >= 0 {
+		line = p[:i]
+	}
+	for _, m := range httpMethods {
+		if len(line) > len(m) && line[len(m)] == ' ' && string(line[:len(m)]) == m {
+			return bytes.Contains(line, []byte(" HTTP/1."))
+		}
+	}
+	return false
+}
+
+// isPostgres reports whether the payload is a Postgres startup message (protocol 3.0) or
+// an SSL or GSSAPI encryption request.
+func isPostgres(p []byte) bool {
+	const (
+		protocolV3 = 196608
+		sslRequest = 80877103
+		gssRequest = 80877104
+	)
+	if len(p) < 8 {
+		return false
+	}
+	length := binary.BigEndian.Uint32(p[0:4])
+	code := binary.BigEndian.Uint32(p[4:8])
+	switch code {
+	case sslRequest, gssRequest:
+		return length == 8
+	case protocolV3:
+		// the startup parameters are NUL-terminated strings, the first one being a name,
+		// and the list is terminated by an empty string
+		if length <= 8 || length > 10000 || len(p) == 8 || !isPrintable(p[8]) {
+			return false
+		}
+		return len(p) < int(length) || p[length-1] == 0
+	}
+	return false
+}
+
+// isMySQL reports whether the payload is the initial handshake (protocol v10) sent by a
+// MySQL server.
+func isMySQL(p []byte) bool {
+	const protocolV10 = 0x0a
+	if len(p) < 6 {
+		return false
+	}
+	length := int(p[0]) | int(p[1])<<8 | int(p[2])<<16
+	if seq := p[3]; seq != 0 || p[4] != protocolV10 || length < 2 || length > 1024 {
+		return false
+	}
+	// the server version is a NUL-terminated printable string
+	version := p[5:]
+	if len(version) > length-1 {
+		version = version[:length-1]
+	}
+	i := bytes.IndexByte(version, 0)
+	if i <= 0 {
+		return false
+	}
+	for _, c := range version[:i] {
+		if !isPrintable(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRedis reports whether the payload is a RESP command, that is an array of bulk strings.
+func isRedis(p []byte) bool {
+	if len(p) < 4 || p[0] != '*' {
+		return false
+	}
+	n, rest, ok := readRESPInt(p[1:])
+	if !ok || n <= 0 || len(rest) == 0 {
+		return false
+	}
+	if rest[0] != '$' {
+		return false
+	}
+	_, _, ok = readRESPInt(rest[1:])
+	return ok
+}
+
+// readRESPInt reads the CRLF-terminated integer starting p.
+func readRESPInt(p []byte) (n int, rest []byte, ok bool) {
+	i := 0
+	for ; i < len(p) && i < 10 && '0' <= p[i] && p[i] <= '9'; i++ {
+		n = n*10 + int(p[i]-'0')
+	}
+	if i == 0 || !bytes.HasPrefix(p[i:], []byte("\r\n")) {
+		return 0, nil, false
+	}
+	return n, p[i+2:], true
+}
+
+// isKafka reports whether the payload is a Kafka request: a size, followed by a request
+// header holding a known API key and version, a correlation ID and a client ID.
+func isKafka(p []byte) bool {
+	const (
+		headerLen     = 14 // size, api key, api version, correlation ID, client ID length
+		maxAPIKey     = 67
+		maxAPIVersion = 13
+	)
+	if len(p) < headerLen {
+		return false
+	}
+	size := int32(binary.BigEndian.Uint32(p[0:4]))
+	apiKey := int16(binary.BigEndian.Uint16(p[4:6]))
+	apiVersion := int16(binary.BigEndian.Uint16(p[6:8]))
+	correlationID := int32(binary.BigEndian.Uint32(p[8:12]))
+	clientIDLen := int16(binary.BigEndian.Uint16(p[12:14]))
+	if size < headerLen-4 || apiKey < 0 || apiKey > maxAPIKey || apiVersion < 0 || apiVersion > maxAPIVersion || correlationID < 0 {
+		return false
+	}
+	if clientIDLen < 0 {
+		// null client ID
+		return clientIDLen == -1
+	}
+	if int(clientIDLen) > int(size)-(headerLen-4) {
+		return false
+	}
+	clientID := p[headerLen:]
+	if len(clientID) > int(clientIDLen) {
+		clientID = clientID[:clientIDLen]
+	}
+	for _, c := range clientID {
+		if !isPrintable(c) {
+			return false
+		}
+	}
+	return true
+}
+
+func isPrintable(c byte) bool { return c >= 0x20 && c < 0x7f }
@@ -0,0 +1,96 @@
+package protocols
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	"github.com/google/gopacket/pcapgo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// readPayloads returns the TCP payloads of the packets of the given capture file.
+func readPayloads(t *testing.T, file string) [][]byte {
+	f, err := os.Open(file)
+	require.NoError(t, err)
+	defer f.Close()
+
+	r, err := pcapgo.NewReader(f)
+	require.NoError(t, err)
+
+	var payloads [][]byte
+	for {
+		data, _, err := r.ReadPacketData()
+		if err != nil {
+			break
+		}
+		pkt := gopacket.NewPacket(data, layers.LayerTypeEthernet, gopacket.Default)
+		tcp, ok := pkt.Layer(layers.LayerTypeTCP).(*layers.TCP)
+		require.True(t, ok)
+		payloads = append(payloads, tcp.Payload)
+	}
+	return payloads
+}
+
+func TestClassifyFixtures(t *testing.T) {
+	expected := []Protocol{
+		HTTP,     // request
+		HTTP,     // response
+		TLS,      // ClientHello
+		HTTP2,    // preface, SETTINGS and HEADERS
+		GRPC,     // same, with a gRPC content-type
+		Postgres, // startup message
+		Postgres, // SSL request
+		MySQL,    // server greeting
+		Redis,    // GET command
+		Kafka,    // ApiVersions request
+		Unknown,  // SSH banner
+		Unknown,  // random bytes
+	}
+
+	payloads := readPayloads(t, "testdata/protocols.pcap")
+	require.Len(t, payloads, len(expected))
+	for i, payload := range payloads {
+		assert.Equal(t, expected[i], Classify(payload), "packet #%d", i)
+	}
+}
+
+func TestClassifyTruncated(t *testing.T) {
+	for i, payload := range readPayloads(t, "testdata/protocols.pcap") {
+		// a few bytes are never enough to classify a payload
+		assert.Equal(t, Unknown, Classify(payload[:3]), "packet #%d", i)
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for _, tt := range []struct {
+		payload  string
+		expected Protocol
+	}{
+		{"", Unknown},
+		{"GET / HTTP/1.0\r\n\r\n", HTTP},
+		{"GET /index.html\r\n", Unknown}, // HTTP/0.9
+		{"GETTER / HTTP/1.1\r\n", Unknown},
+		{"HTTP/2.0 200 OK\r\n", Unknown},
+		{"\x16\x03\x01\x00\x05\x01", TLS},
+		{"\x16\x03\x05\x00\x05\x01", Unknown},
+		{"\x17\x03\x03\x00\x05\x01", Unknown}, // application data
+		{"*1\r\n$4\r\nPING\r\n", Redis},
+		{"+OK\r\n", Unknown},
+		{"*x\r\n$4\r\n", Unknown},
+		{"\x00\x00\x00\x0a\x00\x03\x00\x00user", Unknown}, // invalid Postgres startup length
+		{"\x0a\x00\x00\x00\x0a\x35\x2e\x37\x00\x01", MySQL},
+		{"\x0a\x00\x00\x01\x0a\x35\x2e\x37\x00\x01", Unknown}, // not the first packet
+		{"\x00\x00\x00\x0a\x00\x12\x00\x03\x00\x00\x00\x01\xff\xff", Kafka},
+		{"\x00\x00\x00\x0a\x00\x90\x00\x03\x00\x00\x00\x01\xff\xff", Unknown}, // unknown API key
+	} {
+		assert.Equal(t, tt.expected, Classify([]byte(tt.payload)), "%q", tt.payload)
+	}
+}
+
+func TestProtocolString(t *testing.T) {
+	assert.Equal(t, "grpc", GRPC.String())
+	assert.Equal(t, "unknown", Protocol(42).String())
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/network/http"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -33,12 +34,23 @@ type State interface {
 		clientID string,
 		latestTime uint64,
 		latestConns []ConnectionStats,
-		dns map[dnsKey]dnsStats,
+		dns map[dnsKey]dnsStatsByQuestion,
 	) []ConnectionStats
 
 	// StoreClosedConnection stores a new closed connection
 	StoreClosedConnection(conn ConnectionStats)
 
+	// StoreHTTPStats stores the latest HTTP stats for all clients
+	StoreHTTPStats(stats map[http.Key]*http.RequestStats)
+
+	// GetHTTPStats returns the HTTP stats stored for the given client since its last call.
+	// If the client has a filter, only the stats of the requests made on conns, the connections
+	// returned to the client, are kept.
+	GetHTTPStats(clientID string, conns []ConnectionStats) map[http.Key]*http.RequestStats
+
+	// SetClientFilter sets the filter of the connections returned to the given client, or removes it if nil
+	SetClientFilter(clientID string, filter *ClientFilter)
+
 	// RemoveClient stops tracking stateful data for a given client
 	RemoveClient(clientID string)
 
@@ -63,6 +75,7 @@ type telemetry struct {
 	timeSyncCollisions int64
 	dnsStatsDropped    int64
 	dnsPidCollisions   int64
+	httpStatsDropped   int64
 }
 
 type stats struct {
@@ -78,13 +91,15 @@ type client struct {
 
 	closedConnections map[string]ConnectionStats
 	stats             map[string]*stats
-	dnsStats          map[dnsKey]dnsStats
+	dnsStats          map[dnsKey]dnsStatsByQuestion
+	httpStats         map[http.Key]*http.RequestStats
 }
 
 type networkState struct {
 	sync.Mutex
 
 	clients   map[string]*client
+	filters   map[string]*ClientFilter // by client ID, set independently of the client registration
 	telemetry telemetry
 
 	buf             *bytes.Buffer // Shared buffer
@@ -101,6 +116,7 @@ type networkState struct {
 func NewState(clientExpiry time.Duration, maxClosedConns, maxClientStats int, maxDNSStats int) State {
 	return &networkState{
 		clients:        map[string]*client{},
+		filters:        map[string]*ClientFilter{},
 		telemetry:      telemetry{},
 		clientExpiry:   clientExpiry,
 		maxClosedConns: maxClosedConns,
@@ -129,7 +145,7 @@ func (ns *networkState) Connections(
 	id string,
 	latestTime uint64,
 	latestConns []ConnectionStats,
-	dnsStats map[dnsKey]dnsStats,
+	dnsStats map[dnsKey]dnsStatsByQuestion,
 ) []ConnectionStats {
 	ns.Lock()
 	defer ns.Unlock()
@@ -155,13 +171,14 @@ func (ns *networkState) Connections(
 		}
 
 		ns.determineConnectionIntraHost(latestConns)
-		if len(dnsStats) > 0 {
-			ns.storeDNSStats(dnsStats)
-			ns.addDNSStats(id, latestConns)
-		}
 		// copy to ensure return value doesn't get clobbered
 		conns := make([]ConnectionStats, len(latestConns))
 		copy(conns, latestConns)
+		conns = ns.filterConnections(id, conns)
+		if len(dnsStats) > 0 {
+			ns.storeDNSStats(dnsStats)
+			ns.addDNSStats(id, conns)
+		}
 		return conns
 	}
 
@@ -182,6 +199,7 @@ func (ns *networkState) Connections(
 	// Flush closed connection map and stats
 	ns.clients[id].closedConnections = map[string]ConnectionStats{}
 	ns.determineConnectionIntraHost(conns)
+	conns = ns.filterConnections(id, conns)
 	if len(dnsStats) > 0 {
 		ns.storeDNSStats(dnsStats)
 		ns.addDNSStats(id, conns)
@@ -208,24 +226,42 @@ func (ns *networkState) addDNSStats(id string, conns []ConnectionStats) {
 			continue
 		}
 
-		if dnsStats, ok := ns.clients[id].dnsStats[key]; ok {
+		if byQuestion, ok := ns.clients[id].dnsStats[key]; ok {
+			dnsStats := byQuestion.total()
 			conn.DNSTimeouts = dnsStats.timeouts
 			conn.DNSSuccessfulResponses = dnsStats.countByRcode[DNSResponseCodeNoError]
-			conn.DNSSuccessLatencySum = dnsStats.successLatencySum
-			conn.DNSFailureLatencySum = dnsStats.failureLatencySum
-			conn.DNSCountByRcode = make(map[uint32]uint32)
+			conn.DNSSuccessLatencySum = dnsStats.successLatency.Sum
+			conn.DNSFailureLatencySum = dnsStats.failureLatency.Sum
+			conn.DNSCountByRcode = formatRcodes(dnsStats.countByRcode)
 			var total uint32
-			for rcode, count := range dnsStats.countByRcode {
-				conn.DNSCountByRcode[uint32(rcode)] = count
+			for _, count := range dnsStats.countByRcode {
 				total += count
 			}
 			conn.DNSFailedResponses = total - conn.DNSSuccessfulResponses
+
+			conn.DNSStatsByDomain = make(map[DNSQuestion]DNSStats, len(byQuestion))
+			for question, stats := range byQuestion {
+				conn.DNSStatsByDomain[question] = DNSStats{
+					SuccessLatency: stats.successLatency,
+					FailureLatency: stats.failureLatency,
+					Timeouts:       stats.timeouts,
+					CountByRcode:   formatRcodes(stats.countByRcode),
+				}
+			}
 		}
 		seen[key] = struct{}{}
 	}
 
 	// flush the DNS stats
-	ns.clients[id].dnsStats = make(map[dnsKey]dnsStats)
+	ns.clients[id].dnsStats = make(map[dnsKey]dnsStatsByQuestion)
+}
+
+func formatRcodes(countByRcode map[uint8]uint32) map[uint32]uint32 {
+	rcodes := make(map[uint32]uint32, len(countByRcode))
+	for rcode, count := range countByRcode {
+		rcodes[uint32(rcode)] = count
+	}
+	return rcodes
 }
 
 // getConnsByKey returns a mapping of byte-key -> connection for easier access + manipulation
@@ -281,28 +317,93 @@ func (ns *networkState) StoreClosedConnection(conn ConnectionStats) {
 }
 
 // storeDNSStats stores latest DNS stats for all clients
-func (ns *networkState) storeDNSStats(stats map[dnsKey]dnsStats) {
+func (ns *networkState) storeDNSStats(stats map[dnsKey]dnsStatsByQuestion) {
 	for key, dns := range stats {
 		for _, client := range ns.clients {
 			// If we've seen DNS stats for this key already, let's combine the two
-			if prev, ok := client.dnsStats[key]; ok {
-				prev.timeouts += dns.timeouts
-				prev.successLatencySum += dns.successLatencySum
-				prev.failureLatencySum += dns.failureLatencySum
-				for rcode, count := range dns.countByRcode {
-					prev.countByRcode[rcode] += count
+			prev, ok := client.dnsStats[key]
+			if !ok {
+				if len(client.dnsStats) >= ns.maxDNSStats {
+					ns.telemetry.dnsStatsDropped++
+					continue
 				}
+				prev = make(dnsStatsByQuestion, len(dns))
 				client.dnsStats[key] = prev
-			} else if len(client.dnsStats) >= ns.maxDNSStats {
-				ns.telemetry.dnsStatsDropped++
-				continue
-			} else {
-				client.dnsStats[key] = dns
 			}
+			// The stats are merged into a per-client copy since they are shared by all the clients
+			for question, stats := range dns {
+				merged := prev[question]
+				merged.merge(stats)
+				prev[question] = merged
+			}
+		}
+	}
+}
+
+// StoreHTTPStats stores the latest HTTP stats for all clients
+func (ns *networkState) StoreHTTPStats(stats map[http.Key]*http.RequestStats) {
+	ns.Lock()
+	defer ns.Unlock()
+
+	for key, st := range stats {
+		for _, client := range ns.clients {
+			prev, ok := client.httpStats[key]
+			if !ok {
+				if len(client.httpStats) >= ns.maxClientStats {
+					ns.telemetry.httpStatsDropped++
+					continue
+				}
+				prev = new(http.RequestStats)
+				client.httpStats[key] = prev
+			}
+			// The stats are merged into a per-client copy since they are shared by all the clients
+			prev.CombineWith(st)
 		}
 	}
 }
 
+// GetHTTPStats returns the HTTP stats stored for the given client since its last call.
+// If the client has a filter, only the stats of the requests made on conns, the connections
+// returned to the client, are kept.
+func (ns *networkState) GetHTTPStats(id string, conns []ConnectionStats) map[http.Key]*http.RequestStats {
+	ns.Lock()
+	defer ns.Unlock()
+
+	client, ok := ns.clients[id]
+	if !ok {
+		return nil
+	}
+	stats := client.httpStats
+	client.httpStats = map[http.Key]*http.RequestStats{}
+	if _, ok := ns.filters[id]; ok {
+		filterHTTPStats(stats, conns)
+	}
+	return stats
+}
+
+// SetClientFilter sets the filter of the connections returned to the given client.
+// The stats of the filtered out connections are still tracked, so that changing the filter
+// doesn't affect the stats of the connections returned afterwards.
+func (ns *networkState) SetClientFilter(clientID string, filter *ClientFilter) {
+	ns.Lock()
+	defer ns.Unlock()
+
+	if filter == nil {
+		delete(ns.filters, clientID)
+		return
+	}
+	ns.filters[clientID] = filter
+}
+
+// filterConnections removes the connections not matching the filter of the client, if any
+func (ns *networkState) filterConnections(id string, conns []ConnectionStats) []ConnectionStats {
+	filter, ok := ns.filters[id]
+	if !ok {
+		return conns
+	}
+	return filter.filterConnections(conns)
+}
+
 // newClient creates a new client and returns true if the given client already exists
 func (ns *networkState) newClient(clientID string) (*client, bool) {
 	if c, ok := ns.clients[clientID]; ok {
@@ -313,7 +414,8 @@ func (ns *networkState) newClient(clientID string) (*client, bool) {
 		lastFetch:         time.Now(),
 		stats:             map[string]*stats{},
 		closedConnections: map[string]ConnectionStats{},
-		dnsStats:          map[dnsKey]dnsStats{},
+		dnsStats:          map[dnsKey]dnsStatsByQuestion{},
+		httpStats:         map[http.Key]*http.RequestStats{},
 	}
 	ns.clients[clientID] = c
 	return c, false
@@ -466,6 +568,7 @@ func (ns *networkState) RemoveClient(clientID string) {
 	ns.Lock()
 	defer ns.Unlock()
 	delete(ns.clients, clientID)
+	delete(ns.filters, clientID)
 }
 
 func (ns *networkState) RemoveExpiredClients(now time.Time) {
@@ -476,6 +579,7 @@ func (ns *networkState) RemoveExpiredClients(now time.Time) {
 		if c.lastFetch.Add(ns.clientExpiry).Before(now) {
 			log.Debugf("expiring client: %s, had %d stats and %d closed connections", id, len(c.stats), len(c.closedConnections))
 			delete(ns.clients, id)
+			delete(ns.filters, id)
 		}
 	}
 }
@@ -499,6 +603,7 @@ func (ns *networkState) RemoveConnections(keys []string) {
 		s += " [%d closed connections dropped]"
 		s += " [%d dns stats dropped]"
 		s += " [%d DNS pid collisions]"
+		s += " [%d http stats dropped]"
 		s += " [%d time sync collisions]"
 		log.Warnf(s,
 			ns.telemetry.unorderedConns,
@@ -507,6 +612,7 @@ func (ns *networkState) RemoveConnections(keys []string) {
 			ns.telemetry.closedConnDropped,
 			ns.telemetry.dnsStatsDropped,
 			ns.telemetry.dnsPidCollisions,
+			ns.telemetry.httpStatsDropped,
 			ns.telemetry.timeSyncCollisions)
 	}
 
@@ -537,6 +643,7 @@ func (ns *networkState) GetStats() map[string]interface{} {
 			"time_sync_collisions": ns.telemetry.timeSyncCollisions,
 			"dns_stats_dropped":    ns.telemetry.dnsStatsDropped,
 			"dns_pid_collisions":   ns.telemetry.dnsPidCollisions,
+			"http_stats_dropped":   ns.telemetry.httpStatsDropped,
 		},
 		"current_time":       time.Now().Unix(),
 		"latest_bpf_time_ns": ns.latestTimeEpoch,
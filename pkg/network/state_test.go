@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/DataDog/datadog-agent/pkg/network/http"
 	"github.com/DataDog/datadog-agent/pkg/process/util"
 
 	"github.com/stretchr/testify/assert"
@@ -1146,11 +1147,13 @@ func TestDNSStatsWithMultipleClients(t *testing.T) {
 
 	dKey := dnsKey{clientIP: c.Source, clientPort: c.SPort, serverIP: c.Dest, protocol: c.Type}
 
-	getStats := func() map[dnsKey]dnsStats {
-		stats := make(map[dnsKey]dnsStats)
+	question := DNSQuestion{Domain: "foo.com", Type: TypeA}
+
+	getStats := func() map[dnsKey]dnsStatsByQuestion {
+		stats := make(map[dnsKey]dnsStatsByQuestion)
 		countByRcode := make(map[uint8]uint32)
 		countByRcode[uint8(DNSResponseCodeNoError)] = 1
-		stats[dKey] = dnsStats{countByRcode: countByRcode}
+		stats[dKey] = dnsStatsByQuestion{question: {countByRcode: countByRcode}}
 		return stats
 	}
 
@@ -1180,6 +1183,56 @@ func TestDNSStatsWithMultipleClients(t *testing.T) {
 	require.Len(t, conns, 1)
 	// 2nd client should get accumulated stats
 	assert.EqualValues(t, 3, conns[0].DNSSuccessfulResponses)
+	assert.Equal(t, map[uint32]uint32{DNSResponseCodeNoError: 3}, conns[0].DNSStatsByDomain[question].CountByRcode)
+}
+
+func TestDNSStatsByDomain(t *testing.T) {
+	c := ConnectionStats{
+		Pid:    123,
+		Type:   UDP,
+		Family: AFINET,
+		Source: util.AddressFromString("10.0.0.1"),
+		Dest:   util.AddressFromString("8.8.8.8"),
+		SPort:  1000,
+		DPort:  53,
+	}
+	dKey := dnsKey{clientIP: c.Source, clientPort: c.SPort, serverIP: c.Dest, protocol: c.Type}
+
+	fooA := DNSQuestion{Domain: "foo.com", Type: TypeA}
+	fooAAAA := DNSQuestion{Domain: "foo.com", Type: TypeAAAA}
+	var fast, slow LatencyHistogram
+	fast.add(800)
+	slow.add(300000)
+	stats := map[dnsKey]dnsStatsByQuestion{
+		dKey: {
+			fooA:    {successLatency: fast, countByRcode: map[uint8]uint32{DNSResponseCodeNoError: 1}},
+			fooAAAA: {failureLatency: slow, timeouts: 2, countByRcode: map[uint8]uint32{3: 1}},
+		},
+	}
+
+	client := "client"
+	state := newDefaultState()
+	assert.Len(t, state.Connections(client, latestEpochTime(), nil, nil), 0)
+
+	c.LastUpdateEpoch = latestEpochTime()
+	conns := state.Connections(client, latestEpochTime(), []ConnectionStats{c}, stats)
+	require.Len(t, conns, 1)
+	conn := conns[0]
+
+	// aggregated stats
+	assert.EqualValues(t, 1, conn.DNSSuccessfulResponses)
+	assert.EqualValues(t, 1, conn.DNSFailedResponses)
+	assert.EqualValues(t, 2, conn.DNSTimeouts)
+	assert.EqualValues(t, 800, conn.DNSSuccessLatencySum)
+	assert.EqualValues(t, 300000, conn.DNSFailureLatencySum)
+
+	// stats by domain and query type
+	require.Len(t, conn.DNSStatsByDomain, 2)
+	assert.EqualValues(t, 1, conn.DNSStatsByDomain[fooA].SuccessLatency.Counts[0])
+	assert.EqualValues(t, 1, conn.DNSStatsByDomain[fooA].SuccessLatency.Count())
+	assert.EqualValues(t, 2, conn.DNSStatsByDomain[fooAAAA].Timeouts)
+	assert.EqualValues(t, 1, conn.DNSStatsByDomain[fooAAAA].FailureLatency.Counts[8])
+	assert.Equal(t, map[uint32]uint32{3: 1}, conn.DNSStatsByDomain[fooAAAA].CountByRcode)
 }
 
 func TestDNSStatsPIDCollisions(t *testing.T) {
@@ -1194,10 +1247,10 @@ func TestDNSStatsPIDCollisions(t *testing.T) {
 	}
 
 	dKey := dnsKey{clientIP: c.Source, clientPort: c.SPort, serverIP: c.Dest, protocol: c.Type}
-	stats := make(map[dnsKey]dnsStats)
+	stats := make(map[dnsKey]dnsStatsByQuestion)
 	countByRcode := make(map[uint8]uint32)
 	countByRcode[DNSResponseCodeNoError] = 1
-	stats[dKey] = dnsStats{countByRcode: countByRcode}
+	stats[dKey] = dnsStatsByQuestion{{Domain: "foo.com", Type: TypeA}: {countByRcode: countByRcode}}
 
 	client := "client"
 	state := newDefaultState()
@@ -1246,3 +1299,146 @@ func newDefaultState() State {
 	// Using values from ebpf.NewDefaultConfig()
 	return NewState(2*time.Minute, 50000, 75000, 75000)
 }
+
+func TestHTTPStatsWithMultipleClients(t *testing.T) {
+	key := http.Key{
+		SrcIP:   util.AddressFromString("10.0.0.1"),
+		DstIP:   util.AddressFromString("10.0.0.2"),
+		SrcPort: 1000,
+		DstPort: 80,
+		Path:    "/users/*",
+		Method:  http.MethodGet,
+	}
+	getStats := func() map[http.Key]*http.RequestStats {
+		stats := new(http.RequestStats)
+		stats.AddRequest(200, time.Millisecond)
+		return map[http.Key]*http.RequestStats{key: stats}
+	}
+
+	client1 := "client1"
+	client2 := "client2"
+	state := newDefaultState()
+
+	// Register the clients
+	assert.Len(t, state.Connections(client1, latestEpochTime(), nil, nil), 0)
+	assert.Len(t, state.Connections(client2, latestEpochTime(), nil, nil), 0)
+	assert.Empty(t, state.GetHTTPStats(client1, nil))
+
+	state.StoreHTTPStats(getStats())
+	stats := state.GetHTTPStats(client1, nil)
+	require.Contains(t, stats, key)
+	assert.Equal(t, 1, stats[key].Count())
+	// stats are flushed once retrieved
+	assert.Empty(t, state.GetHTTPStats(client1, nil))
+
+	// 2nd client should get accumulated stats
+	state.StoreHTTPStats(getStats())
+	stats = state.GetHTTPStats(client2, nil)
+	require.Contains(t, stats, key)
+	assert.Equal(t, 2, stats[key].Count())
+	assert.Equal(t, [http.NumStatusClasses]int{0, 2, 0, 0, 0}, stats[key].Counts)
+
+	assert.Nil(t, state.GetHTTPStats("unknown", nil))
+}
+
+func TestHTTPStatsWithClientFilter(t *testing.T) {
+	client1 := "client1"
+	client2 := "client2"
+	state := newDefaultState()
+
+	conn := ConnectionStats{
+		Pid:    42,
+		Type:   TCP,
+		Family: AFINET,
+		Source: util.AddressFromString("10.0.0.2"),
+		Dest:   util.AddressFromString("10.0.0.1"),
+		SPort:  80,
+		DPort:  1000,
+	}
+	other := conn
+	other.Pid = 43
+	other.DPort = 1001
+
+	newKey := func(c ConnectionStats) http.Key {
+		// the connections are seen from the server, the requests from the client
+		return http.Key{SrcIP: c.Dest, DstIP: c.Source, SrcPort: c.DPort, DstPort: c.SPort, Path: "/", Method: http.MethodGet}
+	}
+	getStats := func() map[http.Key]*http.RequestStats {
+		stats := map[http.Key]*http.RequestStats{}
+		for _, c := range []ConnectionStats{conn, other} {
+			st := new(http.RequestStats)
+			st.AddRequest(200, time.Millisecond)
+			stats[newKey(c)] = st
+		}
+		return stats
+	}
+
+	state.SetClientFilter(client1, &ClientFilter{PIDs: map[uint32]struct{}{42: {}}})
+	conns1 := state.Connections(client1, latestEpochTime(), []ConnectionStats{conn, other}, nil)
+	require.Len(t, conns1, 1)
+	conns2 := state.Connections(client2, latestEpochTime(), []ConnectionStats{conn, other}, nil)
+	require.Len(t, conns2, 2)
+
+	state.StoreHTTPStats(getStats())
+
+	// only the stats of the requests made on the connections returned to the client are kept
+	stats := state.GetHTTPStats(client1, conns1)
+	assert.Len(t, stats, 1)
+	assert.Contains(t, stats, newKey(conn))
+
+	// the clients without filter get all the stats
+	stats = state.GetHTTPStats(client2, conns2[:1])
+	assert.Len(t, stats, 2)
+}
+
+func TestClientFilter(t *testing.T) {
+	client1 := "client1"
+	client2 := "client2"
+	state := newDefaultState()
+
+	conn := ConnectionStats{
+		Pid:                42,
+		Type:               TCP,
+		Family:             AFINET,
+		Source:             util.AddressFromString("10.0.0.1"),
+		Dest:               util.AddressFromString("10.0.0.2"),
+		SPort:              40000,
+		DPort:              80,
+		MonotonicSentBytes: 10,
+	}
+	other := conn
+	other.Pid = 43
+	other.SPort = 40001
+
+	state.SetClientFilter(client1, &ClientFilter{PIDs: map[uint32]struct{}{42: {}}})
+
+	// the filter applies to the first call registering the client
+	conns := state.Connections(client1, latestEpochTime(), []ConnectionStats{conn, other}, nil)
+	require.Len(t, conns, 1)
+	assert.Equal(t, uint32(42), conns[0].Pid)
+
+	// and to the other clients' calls only if they set it
+	conns = state.Connections(client2, latestEpochTime(), []ConnectionStats{conn, other}, nil)
+	assert.Len(t, conns, 2)
+
+	// filtered out connections are still tracked, so that removing the filter gives correct deltas
+	other.MonotonicSentBytes += 5
+	conns = state.Connections(client1, latestEpochTime(), []ConnectionStats{conn, other}, nil)
+	require.Len(t, conns, 1)
+
+	state.SetClientFilter(client1, nil)
+	other.MonotonicSentBytes += 5
+	conns = state.Connections(client1, latestEpochTime(), []ConnectionStats{conn, other}, nil)
+	require.Len(t, conns, 2)
+	for _, c := range conns {
+		if c.Pid == 43 {
+			assert.Equal(t, uint64(5), c.LastSentBytes)
+		}
+	}
+
+	// filters are removed along with their client
+	state.SetClientFilter(client1, &ClientFilter{IntraHostOnly: true})
+	state.RemoveClient(client1)
+	conns = state.Connections(client1, latestEpochTime(), []ConnectionStats{conn, other}, nil)
+	assert.Len(t, conns, 2)
+}
@@ -107,6 +107,33 @@ type AgentConfig struct {
 	// DNS stats configuration
 	CollectDNSStats bool
 	DNSTimeout      time.Duration
+	MaxDNSDomains   int
+
+	// HTTP monitoring configuration
+	EnableHTTPMonitoring bool
+
+	// Protocol classification configuration
+	EnableProtocolClassification bool
+
+	// Connection tags configuration
+	CollectContainerTags  bool
+	CollectKubernetesTags bool
+
+	// IPFIX export configuration
+	IPFIXCollector               string
+	IPFIXExportInterval          time.Duration
+	IPFIXTemplateRefreshInterval time.Duration
+	IPFIXObservationDomainID     uint32
+
+	// Network anomaly detection configuration
+	EnableNetworkAnomalyDetection bool
+	NetworkAnomalyInterval        time.Duration
+	NetworkAnomalyThreshold       float64
+	NetworkAnomalyEventCooldown   time.Duration
+
+	// /proc/net tracer configuration, used when the eBPF tracer can't run on the host
+	EnableProcNetFallback bool
+	ProcNetPollInterval   time.Duration
 
 	// Orchestrator collection configuration
 	OrchestrationCollectionEnabled bool
@@ -226,6 +253,11 @@ func NewDefaultAgentConfig(canAccessContainers bool) *AgentConfig {
 		OffsetGuessThreshold:         400,
 		EnableTracepoints:            false,
 		CollectDNSStats:              true,
+		IPFIXExportInterval:          10 * time.Second,
+		IPFIXTemplateRefreshInterval: 5 * time.Minute,
+		ProcNetPollInterval:          10 * time.Second,
+		NetworkAnomalyInterval:       30 * time.Second,
+		NetworkAnomalyEventCooldown:  10 * time.Minute,
 
 		// Check config
 		EnabledChecks: enabledChecks,
@@ -52,6 +52,19 @@ func SysProbeConfigFromConfig(cfg *AgentConfig) *ebpf.Config {
 		tracerConfig.DNSTimeout = cfg.DNSTimeout
 	}
 
+	if max := cfg.MaxDNSDomains; max > 0 {
+		tracerConfig.MaxDNSDomains = max
+	}
+
+	tracerConfig.EnableHTTPMonitoring = cfg.EnableHTTPMonitoring
+	tracerConfig.EnableProtocolClassification = cfg.EnableProtocolClassification
+	tracerConfig.CollectContainerTags = cfg.CollectContainerTags
+	tracerConfig.CollectKubernetesTags = cfg.CollectKubernetesTags
+
+	if cfg.ProcNetPollInterval > 0 {
+		tracerConfig.ProcNetPollInterval = cfg.ProcNetPollInterval
+	}
+
 	tracerConfig.MaxTrackedConnections = cfg.MaxTrackedConnections
 	tracerConfig.ProcRoot = util.GetProcRoot()
 	tracerConfig.BPFDebug = cfg.SysProbeBPFDebug
@@ -57,6 +57,46 @@ func (a *AgentConfig) loadSysProbeYamlConfig(path string) error {
 		a.DNSTimeout = config.Datadog.GetDuration(key(spNS, "dns_timeout_in_s")) * time.Second
 	}
 
+	a.EnableHTTPMonitoring = config.Datadog.GetBool(key(spNS, "enable_http_monitoring"))
+	a.EnableProtocolClassification = config.Datadog.GetBool(key(spNS, "enable_protocol_classification"))
+
+	if config.Datadog.IsSet(key(spNS, "max_dns_domains")) {
+		a.MaxDNSDomains = config.Datadog.GetInt(key(spNS, "max_dns_domains"))
+	}
+
+	a.CollectContainerTags = config.Datadog.GetBool(key(spNS, "collect_container_tags"))
+	a.CollectKubernetesTags = config.Datadog.GetBool(key(spNS, "collect_kubernetes_tags"))
+
+	// IPFIX export of the connections, enabled by setting a collector address
+	a.IPFIXCollector = config.Datadog.GetString(key(spNS, "ipfix", "collector"))
+	if config.Datadog.IsSet(key(spNS, "ipfix", "export_interval")) {
+		a.IPFIXExportInterval = config.Datadog.GetDuration(key(spNS, "ipfix", "export_interval")) * time.Second
+	}
+	if config.Datadog.IsSet(key(spNS, "ipfix", "template_refresh_interval")) {
+		a.IPFIXTemplateRefreshInterval = config.Datadog.GetDuration(key(spNS, "ipfix", "template_refresh_interval")) * time.Second
+	}
+	if config.Datadog.IsSet(key(spNS, "ipfix", "observation_domain_id")) {
+		a.IPFIXObservationDomainID = uint32(config.Datadog.GetInt(key(spNS, "ipfix", "observation_domain_id")))
+	}
+
+	// Detection of RTT and retransmit anomalies in the connections
+	a.EnableNetworkAnomalyDetection = config.Datadog.GetBool(key(spNS, "network_anomaly", "enabled"))
+	if config.Datadog.IsSet(key(spNS, "network_anomaly", "interval")) {
+		a.NetworkAnomalyInterval = config.Datadog.GetDuration(key(spNS, "network_anomaly", "interval")) * time.Second
+	}
+	if config.Datadog.IsSet(key(spNS, "network_anomaly", "threshold")) {
+		a.NetworkAnomalyThreshold = config.Datadog.GetFloat64(key(spNS, "network_anomaly", "threshold"))
+	}
+	if config.Datadog.IsSet(key(spNS, "network_anomaly", "event_cooldown")) {
+		a.NetworkAnomalyEventCooldown = config.Datadog.GetDuration(key(spNS, "network_anomaly", "event_cooldown")) * time.Second
+	}
+
+	// Fallback on the polling of /proc/net when eBPF is not available
+	a.EnableProcNetFallback = config.Datadog.GetBool(key(spNS, "enable_proc_net_fallback"))
+	if config.Datadog.IsSet(key(spNS, "proc_net_poll_interval")) {
+		a.ProcNetPollInterval = config.Datadog.GetDuration(key(spNS, "proc_net_poll_interval")) * time.Second
+	}
+
 	if config.Datadog.GetBool(key(spNS, "enabled")) {
 		a.EnabledChecks = append(a.EnabledChecks, "connections")
 		if !a.Enabled {
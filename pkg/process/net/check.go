@@ -3,21 +3,28 @@
 package net
 
 import (
-	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 
-	"github.com/DataDog/datadog-agent/pkg/ebpf/oomkill"
-	"github.com/DataDog/datadog-agent/pkg/ebpf/tcpqueuelength"
+	"github.com/DataDog/datadog-agent/pkg/ebpf/kevent"
+
+	// register the kernel event checks
+	_ "github.com/DataDog/datadog-agent/pkg/ebpf/oomkill"
+	_ "github.com/DataDog/datadog-agent/pkg/ebpf/tcpqueuelength"
 )
 
 const (
 	checksURL = "http://unix/check"
 )
 
-// GetCheck returns the output of the specified check
+// GetCheck returns the output of the specified check. Each element has the type of the events of the
+// kernel event check, as declared by its kevent.Definition.
 func (r *RemoteSysProbeUtil) GetCheck(check string) ([]interface{}, error) {
+	if _, ok := kevent.Get(check); !ok {
+		return nil, fmt.Errorf("Invalid check name: %s", check)
+	}
+
 	req, err := http.NewRequest("GET", fmt.Sprintf("%s/%s", checksURL, check), nil)
 	if err != nil {
 		return nil, err
@@ -26,7 +33,9 @@ func (r *RemoteSysProbeUtil) GetCheck(check string) ([]interface{}, error) {
 	resp, err := r.httpClient.Do(req)
 	if err != nil {
 		return nil, err
-	} else if resp.StatusCode != http.StatusOK {
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("conn request failed: socket %s, url %s, status code: %d", r.path, fmt.Sprintf("%s/%s", checksURL, check), resp.StatusCode)
 	}
 
@@ -35,29 +44,5 @@ func (r *RemoteSysProbeUtil) GetCheck(check string) ([]interface{}, error) {
 		return nil, err
 	}
 
-	if check == "tcp_queue_length" {
-		var stats []tcpqueuelength.Stats
-		err = json.Unmarshal(body, &stats)
-		if err != nil {
-			return nil, err
-		}
-		s := make([]interface{}, len(stats))
-		for i, v := range stats {
-			s[i] = v
-		}
-		return s, nil
-	} else if check == "oom_kill" {
-		var stats []oomkill.Stats
-		err = json.Unmarshal(body, &stats)
-		if err != nil {
-			return nil, err
-		}
-		s := make([]interface{}, len(stats))
-		for i, v := range stats {
-			s[i] = v
-		}
-		return s, nil
-	}
-
-	return nil, fmt.Errorf("Invalid check name: %s", check)
+	return kevent.DecodeEvents(check, body)
 }
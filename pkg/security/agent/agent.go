@@ -27,6 +27,7 @@ import (
 type RuntimeSecurityAgent struct {
 	hostname      string
 	reporter      event.Reporter
+	sinks         Sinks
 	conn          *grpc.ClientConn
 	running       atomic.Value
 	wg            sync.WaitGroup
@@ -41,15 +42,22 @@ func NewRuntimeSecurityAgent(hostname string, reporter event.Reporter) (*Runtime
 		return nil, errors.New("runtime_security_config.socket must be set")
 	}
 
+	sinks, err := NewSinks()
+	if err != nil {
+		return nil, err
+	}
+
 	path := "unix://" + socketPath
 	conn, err := grpc.Dial(path, grpc.WithInsecure())
 	if err != nil {
+		sinks.Close()
 		return nil, err
 	}
 
 	return &RuntimeSecurityAgent{
 		conn:     conn,
 		reporter: reporter,
+		sinks:    sinks,
 		hostname: hostname,
 	}, nil
 }
@@ -65,6 +73,9 @@ func (rsa *RuntimeSecurityAgent) Stop() {
 	rsa.running.Store(false)
 	rsa.wg.Wait()
 	rsa.conn.Close()
+	if err := rsa.sinks.Close(); err != nil {
+		log.Errorf("Failed to close the runtime security event sinks: %s", err)
+	}
 }
 
 // StartEventListener starts listening for new events from system-probe
@@ -110,23 +121,29 @@ func (rsa *RuntimeSecurityAgent) StartEventListener() {
 	}
 }
 
-// SendSecurityEvent sends a security event with the provided status
-func (rsa *RuntimeSecurityAgent) SendSecurityEvent(evt *api.SecurityEventMessage, status string) {
-	event := &event.Event{
+func (rsa *RuntimeSecurityAgent) newSecurityEvent(evt *api.SecurityEventMessage) *event.Event {
+	return &event.Event{
 		AgentRuleID:  evt.RuleID,
 		ResourceID:   rsa.hostname,
 		ResourceType: "host",
 		Tags:         evt.Tags,
 		Data:         json.RawMessage(evt.GetData()),
 	}
+}
 
-	rsa.reporter.Report(event)
+// SendSecurityEvent sends a security event with the provided status
+func (rsa *RuntimeSecurityAgent) SendSecurityEvent(evt *api.SecurityEventMessage, status string) {
+	rsa.reporter.Report(rsa.newSecurityEvent(evt))
 }
 
 // DispatchEvent dispatches a security event message to the subsytems of the runtime security agent
 func (rsa *RuntimeSecurityAgent) DispatchEvent(evt *api.SecurityEventMessage) {
-	// For now simply log to Datadog
 	rsa.SendSecurityEvent(evt, message.StatusAlert)
+
+	// Forward the event to the local sinks as well
+	if err := rsa.sinks.Send(rsa.newSecurityEvent(evt)); err != nil {
+		log.Warnf("Failed to forward event of rule `%s`: %s", evt.RuleID, err)
+	}
 }
 
 // GetStatus returns the current status on the agent
@@ -0,0 +1,132 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+)
+
+// FileSink writes the events as JSON lines to a local file, rotated once it reaches its maximum size
+type FileSink struct {
+	sync.Mutex
+	path     string
+	maxSize  int64
+	maxRolls int
+	file     *os.File
+	size     int64
+}
+
+// Send writes the event to the file
+func (s *FileSink) Send(evt *event.Event) error {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		return errors.New("file sink closed")
+	}
+
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(data)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := s.file.Write(data)
+	s.size += int64(n)
+	return err
+}
+
+// rotate renames the current file to `<path>.1`, shifting the previous rolls, and reopens an empty file
+func (s *FileSink) rotate() error {
+	if err := s.file.Close(); err != nil {
+		return err
+	}
+	s.file = nil
+
+	if s.maxRolls > 0 {
+		for i := s.maxRolls - 1; i > 0; i-- {
+			roll := fmt.Sprintf("%s.%d", s.path, i)
+			if err := os.Rename(roll, fmt.Sprintf("%s.%d", s.path, i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(s.path, s.path+".1"); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+
+	return s.open()
+}
+
+func (s *FileSink) open() error {
+	file, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0640)
+	if err != nil {
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+// Close closes the file
+func (s *FileSink) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	return err
+}
+
+// NewFileSink returns a sink writing the events to the given file. The file is rotated once it
+// reaches maxSize bytes, keeping maxRolls previous files. A maxSize of 0 disables the rotation.
+func NewFileSink(path string, maxSize int64, maxRolls int) (*FileSink, error) {
+	if path == "" {
+		return nil, errors.New("no path provided")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	s := &FileSink{
+		path:     path,
+		maxSize:  maxSize,
+		maxRolls: maxRolls,
+	}
+
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
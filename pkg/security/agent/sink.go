@@ -0,0 +1,210 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	coreconfig "github.com/DataDog/datadog-agent/pkg/config"
+)
+
+// severities lists the severities of the rules, from the lowest to the highest
+var severities = map[string]int{
+	"low":      1,
+	"medium":   2,
+	"high":     3,
+	"critical": 4,
+}
+
+// Sink defines the interface of the destinations to which the runtime security events are forwarded,
+// in addition to Datadog
+type Sink interface {
+	Send(evt *event.Event) error
+	Close() error
+}
+
+// SinkFilter selects the events forwarded to a sink
+type SinkFilter struct {
+	// MinSeverity is the lowest severity of the forwarded events. Events without severity are
+	// only forwarded when it is empty.
+	MinSeverity string
+	// RuleIDs lists the rules whose events are forwarded. Events of all the rules are forwarded when it is empty.
+	RuleIDs []string
+}
+
+// Validate checks the filter
+func (f *SinkFilter) Validate() error {
+	if _, found := severities[f.MinSeverity]; f.MinSeverity != "" && !found {
+		return fmt.Errorf("unknown severity `%s`", f.MinSeverity)
+	}
+	return nil
+}
+
+// Match returns whether the event passes the filter
+func (f *SinkFilter) Match(evt *event.Event) bool {
+	if len(f.RuleIDs) > 0 {
+		var found bool
+		for _, id := range f.RuleIDs {
+			if id == evt.AgentRuleID {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if f.MinSeverity != "" {
+		severity, found := severities[getSeverity(evt)]
+		if !found || severity < severities[f.MinSeverity] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getSeverity returns the severity of an event, set by the `severity` tag of its rule
+func getSeverity(evt *event.Event) string {
+	for _, tag := range evt.Tags {
+		if strings.HasPrefix(tag, "severity:") {
+			return strings.ToLower(strings.TrimPrefix(tag, "severity:"))
+		}
+	}
+	return ""
+}
+
+// filteredSink forwards to a sink the events that pass a filter
+type filteredSink struct {
+	Sink
+	name   string
+	filter SinkFilter
+}
+
+// Send forwards the event to the sink if it passes the filter
+func (s *filteredSink) Send(evt *event.Event) error {
+	if !s.filter.Match(evt) {
+		return nil
+	}
+
+	if err := s.Sink.Send(evt); err != nil {
+		return errors.Wrapf(err, "failed to send event to the %s sink", s.name)
+	}
+	return nil
+}
+
+// NewFilteredSink returns a sink forwarding to the given sink the events that pass the filter
+func NewFilteredSink(name string, sink Sink, filter SinkFilter) (Sink, error) {
+	if err := filter.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "invalid filter for the %s sink", name)
+	}
+
+	return &filteredSink{
+		Sink:   sink,
+		name:   name,
+		filter: filter,
+	}, nil
+}
+
+// Sinks is the list of sinks enabled in the configuration
+type Sinks []Sink
+
+// Send forwards the event to all the sinks
+func (s Sinks) Send(evt *event.Event) error {
+	var result *multierror.Error
+	for _, sink := range s {
+		if err := sink.Send(evt); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// Close closes all the sinks
+func (s Sinks) Close() error {
+	var result *multierror.Error
+	for _, sink := range s {
+		if err := sink.Close(); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+	return result.ErrorOrNil()
+}
+
+// NewSinks returns the sinks enabled in the `runtime_security_config.sinks` section of the configuration
+func NewSinks() (Sinks, error) {
+	var sinks Sinks
+
+	newSink := func(name string, fnc func(prefix string) (Sink, error)) error {
+		prefix := "runtime_security_config.sinks." + name + "."
+		if !coreconfig.Datadog.GetBool(prefix + "enabled") {
+			return nil
+		}
+
+		sink, err := fnc(prefix)
+		if err != nil {
+			return errors.Wrapf(err, "failed to create the %s sink", name)
+		}
+
+		filter := SinkFilter{
+			MinSeverity: strings.ToLower(coreconfig.Datadog.GetString(prefix + "min_severity")),
+			RuleIDs:     coreconfig.Datadog.GetStringSlice(prefix + "rule_ids"),
+		}
+
+		filtered, err := NewFilteredSink(name, sink, filter)
+		if err != nil {
+			sink.Close()
+			return err
+		}
+
+		sinks = append(sinks, filtered)
+		return nil
+	}
+
+	err := newSink("file", func(prefix string) (Sink, error) {
+		return NewFileSink(
+			coreconfig.Datadog.GetString(prefix+"path"),
+			int64(coreconfig.Datadog.GetSizeInBytes(prefix+"max_size")),
+			coreconfig.Datadog.GetInt(prefix+"max_rolls"),
+		)
+	})
+	if err == nil {
+		err = newSink("syslog", func(prefix string) (Sink, error) {
+			return NewSyslogSink(
+				coreconfig.Datadog.GetString(prefix+"network"),
+				coreconfig.Datadog.GetString(prefix+"address"),
+				coreconfig.Datadog.GetInt(prefix+"facility"),
+				coreconfig.Datadog.GetString(prefix+"app_name"),
+				coreconfig.Datadog.GetDuration(prefix+"timeout"),
+			)
+		})
+	}
+	if err == nil {
+		err = newSink("webhook", func(prefix string) (Sink, error) {
+			return NewWebhookSink(WebhookSinkOpts{
+				URL:           coreconfig.Datadog.GetString(prefix + "url"),
+				Headers:       coreconfig.Datadog.GetStringMapString(prefix + "headers"),
+				BatchSize:     coreconfig.Datadog.GetInt(prefix + "batch_size"),
+				FlushInterval: coreconfig.Datadog.GetDuration(prefix + "flush_interval"),
+				MaxRetries:    coreconfig.Datadog.GetInt(prefix + "max_retries"),
+				Timeout:       coreconfig.Datadog.GetDuration(prefix + "timeout"),
+			})
+		})
+	}
+
+	if err != nil {
+		sinks.Close()
+		return nil, err
+	}
+
+	return sinks, nil
+}
@@ -0,0 +1,354 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+)
+
+func newTestEvent(ruleID, severity string) *event.Event {
+	evt := &event.Event{
+		AgentRuleID:  ruleID,
+		ResourceID:   "host",
+		ResourceType: "host",
+		Tags:         []string{"rule_id:" + ruleID},
+		Data:         json.RawMessage(`{"process":{"name":"bash"}}`),
+	}
+	if severity != "" {
+		evt.Tags = append(evt.Tags, "severity:"+severity)
+	}
+	return evt
+}
+
+type testSink struct {
+	events []*event.Event
+}
+
+func (s *testSink) Send(evt *event.Event) error {
+	s.events = append(s.events, evt)
+	return nil
+}
+
+func (s *testSink) Close() error {
+	return nil
+}
+
+func TestSinkFilter(t *testing.T) {
+	tests := []struct {
+		filter   SinkFilter
+		event    *event.Event
+		expected bool
+	}{
+		{SinkFilter{}, newTestEvent("a", ""), true},
+		{SinkFilter{MinSeverity: "high"}, newTestEvent("a", "critical"), true},
+		{SinkFilter{MinSeverity: "high"}, newTestEvent("a", "High"), true},
+		{SinkFilter{MinSeverity: "high"}, newTestEvent("a", "medium"), false},
+		{SinkFilter{MinSeverity: "low"}, newTestEvent("a", ""), false},
+		{SinkFilter{RuleIDs: []string{"a", "b"}}, newTestEvent("b", ""), true},
+		{SinkFilter{RuleIDs: []string{"a", "b"}}, newTestEvent("c", "critical"), false},
+		{SinkFilter{MinSeverity: "medium", RuleIDs: []string{"a"}}, newTestEvent("a", "low"), false},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expected, test.filter.Match(test.event), "filter %+v, event %+v", test.filter, test.event)
+	}
+
+	if _, err := NewFilteredSink("test", &testSink{}, SinkFilter{MinSeverity: "urgent"}); err == nil {
+		t.Error("an unknown severity should be rejected")
+	}
+
+	sink := &testSink{}
+	filtered, err := NewFilteredSink("test", sink, SinkFilter{MinSeverity: "high"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filtered.Send(newTestEvent("a", "low"))
+	filtered.Send(newTestEvent("b", "high"))
+	if assert.Len(t, sink.events, 1) {
+		assert.Equal(t, "b", sink.events[0].AgentRuleID)
+	}
+}
+
+func TestFileSinkRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "file-sink")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	evt := newTestEvent("a", "high")
+	data, _ := json.Marshal(evt)
+	lineSize := int64(len(data) + 1)
+
+	path := filepath.Join(dir, "events", "events.json")
+	sink, err := NewFileSink(path, 2*lineSize, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 7 events: 2 in each roll, the oldest ones being dropped, and 1 in the current file
+	for i := 0; i < 7; i++ {
+		if err := sink.Send(evt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	countLines := func(path string) int {
+		f, err := os.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer f.Close()
+
+		var count int
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var decoded event.Event
+			if err := json.Unmarshal(scanner.Bytes(), &decoded); err != nil {
+				t.Fatal(err)
+			}
+			count++
+		}
+		return count
+	}
+
+	assert.Equal(t, 1, countLines(path))
+	assert.Equal(t, 2, countLines(path+".1"))
+	assert.Equal(t, 2, countLines(path+".2"))
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Error(t, sink.Send(evt))
+}
+
+func TestSyslogSink(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	sink, err := NewSyslogSink("udp", conn.LocalAddr().String(), 13, "runtime security", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	if err := sink.Send(newTestEvent("shell_spawned_by_nginx", "critical")); err != nil {
+		t.Fatal(err)
+	}
+
+	buf := make([]byte, 4096)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// facility 13 (log audit) and severity 2 (critical)
+	re := regexp.MustCompile(`^<106>1 \d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{6}(Z|[+-]\d{2}:\d{2}) \S+ runtimesecurity \d+ shell_spawned_by_nginx - (\{.*\})$`)
+	matches := re.FindSubmatch(buf[:n])
+	if matches == nil {
+		t.Fatalf("unexpected syslog message: %s", buf[:n])
+	}
+
+	var decoded event.Event
+	if err := json.Unmarshal(matches[2], &decoded); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "shell_spawned_by_nginx", decoded.AgentRuleID)
+
+	if _, err := NewSyslogSink("udp", "127.0.0.1:514", 24, "app", time.Second); err == nil {
+		t.Error("an invalid facility should be rejected")
+	}
+	if _, err := NewSyslogSink("http", "127.0.0.1:514", 13, "app", time.Second); err == nil {
+		t.Error("an unsupported network should be rejected")
+	}
+}
+
+func TestSyslogSinkReconnection(t *testing.T) {
+	// reserve an address on which no server listens yet
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	sink, err := NewSyslogSink("tcp", address, 13, "app", time.Second)
+	if err != nil {
+		t.Fatalf("the sink should be created while the syslog server is down: %s", err)
+	}
+	defer sink.Close()
+	sink.retryDelay = 10 * time.Millisecond
+
+	// the event is queued while the syslog server is down
+	if err := sink.Send(newTestEvent("shell_spawned_by_nginx", "critical")); err != nil {
+		t.Fatal(err)
+	}
+
+	if ln, err = net.Listen("tcp", address); err != nil {
+		t.Skipf("failed to listen on %s: %s", address, err)
+	}
+	defer ln.Close()
+
+	conn, err := ln.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := bufio.NewReader(conn).ReadString(' ')
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Regexp(t, `^\d+ $`, line)
+}
+
+func TestSyslogSinkQueueFull(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := ln.Addr().String()
+	ln.Close()
+
+	sink, err := NewSyslogSink("tcp", address, 13, "app", time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sink.Close()
+
+	// the events are dropped, without blocking, once the queue is full
+	var dropped int
+	start := time.Now()
+	for i := 0; i < syslogQueueSize+2; i++ {
+		if err := sink.Send(newTestEvent("shell_spawned_by_nginx", "critical")); err != nil {
+			dropped++
+		}
+	}
+	assert.NotZero(t, dropped)
+	assert.True(t, time.Since(start) < time.Second, "sending the events shouldn't block")
+}
+
+func TestWebhookSink(t *testing.T) {
+	var lock sync.Mutex
+	var requests int
+	var batches [][]event.Event
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		// fail every other request to check the retries
+		if requests++; requests%2 == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		if r.Header.Get("Authorization") != "Bearer token" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var batch []event.Event
+		if err := json.NewDecoder(r.Body).Decode(&batch); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		batches = append(batches, batch)
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookSink(WebhookSinkOpts{
+		URL:           server.URL,
+		Headers:       map[string]string{"Authorization": "Bearer token"},
+		BatchSize:     3,
+		FlushInterval: time.Hour,
+		MaxRetries:    1,
+		Timeout:       5 * time.Second,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.retryDelay = time.Millisecond
+
+	for i := 0; i < 5; i++ {
+		if err := sink.Send(newTestEvent(fmt.Sprintf("rule_%d", i), "")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the last events are flushed on close
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	assert.Equal(t, 4, requests)
+	if assert.Len(t, batches, 2) {
+		assert.Len(t, batches[0], 3)
+		assert.Len(t, batches[1], 2)
+		assert.Equal(t, "rule_4", batches[1][1].AgentRuleID)
+	}
+}
+
+func TestWebhookSinkRejected(t *testing.T) {
+	var lock sync.Mutex
+	var requests int
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		requests++
+		lock.Unlock()
+
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	sink, err := NewWebhookSink(WebhookSinkOpts{
+		URL:           server.URL,
+		BatchSize:     1,
+		FlushInterval: time.Hour,
+		MaxRetries:    3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink.retryDelay = time.Millisecond
+
+	sink.Send(newTestEvent("a", ""))
+	sink.Close()
+
+	lock.Lock()
+	defer lock.Unlock()
+
+	// client errors are not retried
+	assert.Equal(t, 1, requests)
+}
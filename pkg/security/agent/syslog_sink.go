@@ -0,0 +1,262 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// syslogTimeLayout is the RFC 5424 timestamp layout
+	syslogTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+	// syslogMaxMsgIDLen is the maximum length of the MSGID field of a RFC 5424 message
+	syslogMaxMsgIDLen = 32
+	// syslogQueueSize is the number of messages queued before events get dropped
+	syslogQueueSize = 1000
+	// syslogRetryDelay is the delay before the first retry of a message, doubled for each subsequent retry
+	syslogRetryDelay = time.Second
+	// syslogMaxRetryDelay is the maximum delay between two retries of a message
+	syslogMaxRetryDelay = time.Minute
+)
+
+// syslogSeverities maps the severities of the rules to the RFC 5424 severities
+var syslogSeverities = map[string]int{
+	"critical": 2,
+	"high":     3,
+	"medium":   4,
+	"low":      5,
+}
+
+// syslogInfoSeverity is the RFC 5424 severity of the events without severity
+const syslogInfoSeverity = 6
+
+// SyslogSink sends the events to a syslog server, formatted following RFC 5424. The messages
+// are framed with octet counting, as defined by RFC 6587, on stream connections. The messages are
+// queued and sent from a goroutine, which connects when the first message is sent, reconnects when
+// the connection is lost, and retries with an exponential backoff while the server is unreachable.
+type SyslogSink struct {
+	network    string
+	address    string
+	facility   int
+	hostname   string
+	appName    string
+	procID     int
+	timeout    time.Duration
+	messages   chan []byte
+	stop       chan struct{}
+	wg         sync.WaitGroup
+	retryDelay time.Duration
+
+	// conn is only used by the goroutine sending the messages
+	conn net.Conn
+}
+
+// format returns the RFC 5424 message of an event, whose MSGID is the ID of the rule and MSG the JSON event
+func (s *SyslogSink) format(evt *event.Event, now time.Time) ([]byte, error) {
+	data, err := json.Marshal(evt)
+	if err != nil {
+		return nil, err
+	}
+
+	severity, found := syslogSeverities[getSeverity(evt)]
+	if !found {
+		severity = syslogInfoSeverity
+	}
+
+	msgID := syslogField(evt.AgentRuleID, syslogMaxMsgIDLen)
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "<%d>1 %s %s %s %d %s - ", s.facility*8+severity, now.Format(syslogTimeLayout), s.hostname, s.appName, s.procID, msgID)
+	buf.Write(data)
+
+	return buf.Bytes(), nil
+}
+
+// syslogField returns a RFC 5424 header field made of the printable characters of the given value
+func syslogField(value string, maxLen int) string {
+	field := make([]byte, 0, len(value))
+	for i := 0; i < len(value) && len(field) < maxLen; i++ {
+		if c := value[i]; c > 32 && c < 127 {
+			field = append(field, c)
+		}
+	}
+
+	if len(field) == 0 {
+		return "-"
+	}
+	return string(field)
+}
+
+func (s *SyslogSink) isStream() bool {
+	switch s.network {
+	case "tcp", "tcp4", "tcp6", "unix":
+		return true
+	}
+	return false
+}
+
+func isSyslogNetwork(network string) bool {
+	switch network {
+	case "tcp", "tcp4", "tcp6", "unix", "udp", "udp4", "udp6", "unixgram":
+		return true
+	}
+	return false
+}
+
+func (s *SyslogSink) connect() error {
+	conn, err := net.DialTimeout(s.network, s.address, s.timeout)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+	return nil
+}
+
+func (s *SyslogSink) write(msg []byte) error {
+	err := s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if err == nil {
+		_, err = s.conn.Write(msg)
+	}
+
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+	return err
+}
+
+// Send queues the event to be sent to the syslog server
+func (s *SyslogSink) Send(evt *event.Event) error {
+	msg, err := s.format(evt, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if s.isStream() {
+		msg = append([]byte(fmt.Sprintf("%d ", len(msg))), msg...)
+	}
+
+	select {
+	case s.messages <- msg:
+		return nil
+	default:
+		return errors.New("syslog queue full, event dropped")
+	}
+}
+
+func (s *SyslogSink) run() {
+	defer s.wg.Done()
+	defer s.closeConn()
+
+	for {
+		select {
+		case msg := <-s.messages:
+			delay := s.retryDelay
+			for err := s.send(msg); err != nil; err = s.send(msg) {
+				log.Warnf("Failed to send runtime security event to the syslog server at %s://%s, retrying in %s: %s", s.network, s.address, delay, err)
+
+				select {
+				case <-time.After(delay):
+				case <-s.stop:
+					log.Warnf("Dropping %d runtime security events queued for the syslog server", len(s.messages)+1)
+					return
+				}
+				if delay *= 2; delay > syslogMaxRetryDelay {
+					delay = syslogMaxRetryDelay
+				}
+			}
+		case <-s.stop:
+			// send the queued messages before leaving, without retrying
+			for {
+				select {
+				case msg := <-s.messages:
+					if err := s.send(msg); err != nil {
+						log.Warnf("Dropping %d runtime security events queued for the syslog server: %s", len(s.messages)+1, err)
+						return
+					}
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send sends a message to the syslog server, connecting to it first if there is no connection yet or if
+// the connection was lost
+func (s *SyslogSink) send(msg []byte) error {
+	if s.conn != nil {
+		if err := s.write(msg); err == nil {
+			return nil
+		}
+	}
+
+	if err := s.connect(); err != nil {
+		return err
+	}
+	return s.write(msg)
+}
+
+func (s *SyslogSink) closeConn() {
+	if s.conn != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+// Close sends the queued events and stops the sink
+func (s *SyslogSink) Close() error {
+	close(s.stop)
+	s.wg.Wait()
+	return nil
+}
+
+// NewSyslogSink returns a sink sending the events to the syslog server listening at the given address. The
+// timeout applies to the connection and to the writing of each event.
+func NewSyslogSink(network, address string, facility int, appName string, timeout time.Duration) (*SyslogSink, error) {
+	if !isSyslogNetwork(network) {
+		return nil, fmt.Errorf("unsupported syslog network `%s`", network)
+	}
+	if facility < 0 || facility > 23 {
+		return nil, fmt.Errorf("invalid syslog facility %d", facility)
+	}
+	if timeout <= 0 {
+		return nil, fmt.Errorf("invalid syslog timeout %s", timeout)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "-"
+	}
+
+	s := &SyslogSink{
+		network:    network,
+		address:    address,
+		facility:   facility,
+		hostname:   syslogField(hostname, 255),
+		appName:    syslogField(appName, 48),
+		procID:     os.Getpid(),
+		timeout:    timeout,
+		messages:   make(chan []byte, syslogQueueSize),
+		stop:       make(chan struct{}),
+		retryDelay: syslogRetryDelay,
+	}
+
+	s.wg.Add(1)
+	go s.run()
+
+	return s, nil
+}
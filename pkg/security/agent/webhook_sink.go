@@ -0,0 +1,184 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/compliance/event"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// webhookQueueFactor is the number of batches queued before events get dropped
+	webhookQueueFactor = 10
+	// webhookRetryDelay is the delay before the first retry of a batch, doubled for each subsequent retry
+	webhookRetryDelay = time.Second
+)
+
+// WebhookSinkOpts defines the options of a webhook sink
+type WebhookSinkOpts struct {
+	URL           string
+	Headers       map[string]string
+	BatchSize     int
+	FlushInterval time.Duration
+	MaxRetries    int
+	Timeout       time.Duration
+}
+
+// WebhookSink posts the events in batches, as JSON arrays, to an HTTP endpoint. The batches are
+// posted once full or at every flush interval, and retried on network or server errors.
+type WebhookSink struct {
+	opts       WebhookSinkOpts
+	client     *http.Client
+	events     chan *event.Event
+	stop       chan struct{}
+	wg         sync.WaitGroup
+	retryDelay time.Duration
+}
+
+// Send queues the event for the next batch
+func (s *WebhookSink) Send(evt *event.Event) error {
+	select {
+	case s.events <- evt:
+		return nil
+	default:
+		return errors.New("webhook queue full, event dropped")
+	}
+}
+
+func (s *WebhookSink) run() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*event.Event, 0, s.opts.BatchSize)
+
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := s.post(batch); err != nil {
+			log.Errorf("Failed to post %d runtime security events to the webhook: %s", len(batch), err)
+		}
+		batch = batch[:0]
+	}
+
+	for {
+		select {
+		case evt := <-s.events:
+			if batch = append(batch, evt); len(batch) >= s.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-s.stop:
+			// flush the queued events before leaving
+			for {
+				select {
+				case evt := <-s.events:
+					if batch = append(batch, evt); len(batch) >= s.opts.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					return
+				}
+			}
+		}
+	}
+}
+
+// post sends a batch of events, retrying with an exponential backoff unless the endpoint rejected the request
+func (s *WebhookSink) post(batch []*event.Event) error {
+	data, err := json.Marshal(batch)
+	if err != nil {
+		return err
+	}
+
+	delay := s.retryDelay
+	for retry := 0; ; retry++ {
+		var retryable bool
+		if retryable, err = s.postOnce(data); err == nil || !retryable || retry >= s.opts.MaxRetries {
+			return err
+		}
+
+		log.Debugf("Failed to post runtime security events to the webhook, retrying in %s: %s", delay, err)
+
+		select {
+		case <-time.After(delay):
+		case <-s.stop:
+			// the agent is stopping, retry without waiting
+		}
+		delay *= 2
+	}
+}
+
+// postOnce sends a batch of events and returns whether the request can be retried if it failed
+func (s *WebhookSink) postOnce(data []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, s.opts.URL, bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range s.opts.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
+
+// Close flushes the queued events and stops the sink
+func (s *WebhookSink) Close() error {
+	close(s.stop)
+	s.wg.Wait()
+	return nil
+}
+
+// NewWebhookSink returns a sink posting the events to a webhook
+func NewWebhookSink(opts WebhookSinkOpts) (*WebhookSink, error) {
+	if opts.URL == "" {
+		return nil, errors.New("no url provided")
+	}
+	if opts.BatchSize <= 0 {
+		return nil, fmt.Errorf("invalid batch size %d", opts.BatchSize)
+	}
+	if opts.FlushInterval <= 0 {
+		return nil, fmt.Errorf("invalid flush interval %s", opts.FlushInterval)
+	}
+
+	s := &WebhookSink{
+		opts:       opts,
+		client:     &http.Client{Timeout: opts.Timeout},
+		events:     make(chan *event.Event, opts.BatchSize*webhookQueueFactor),
+		stop:       make(chan struct{}),
+		retryDelay: webhookRetryDelay,
+	}
+
+	s.wg.Add(1)
+	go s.run()
+
+	return s, nil
+}
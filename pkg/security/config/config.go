@@ -6,6 +6,8 @@
 package config
 
 import (
+	"time"
+
 	aconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/process/config"
 )
@@ -30,6 +32,9 @@ type Config struct {
 	SyscallMonitor      bool
 	EventServerBurst    int
 	EventServerRate     int
+	ListsReloadInterval time.Duration
+	WatchPoliciesDir    bool
+	ProcessLineage      bool
 }
 
 // NewConfig returns a new Config object
@@ -45,6 +50,9 @@ func NewConfig(cfg *config.AgentConfig) (*Config, error) {
 		PoliciesDir:         aconfig.Datadog.GetString("runtime_security_config.policies.dir"),
 		EventServerBurst:    aconfig.Datadog.GetInt("runtime_security_config.event_server.burst"),
 		EventServerRate:     aconfig.Datadog.GetInt("runtime_security_config.event_server.rate"),
+		ListsReloadInterval: aconfig.Datadog.GetDuration("runtime_security_config.policies.lists_reload_interval"),
+		WatchPoliciesDir:    aconfig.Datadog.GetBool("runtime_security_config.policies.watch_dir"),
+		ProcessLineage:      aconfig.Datadog.GetBool("runtime_security_config.process_lineage.enabled"),
 	}
 
 	if cfg != nil {
@@ -194,6 +194,8 @@ enum event_type
     EVENT_SETXATTR,
     EVENT_REMOVEXATTR,
     EVENT_EXEC,
+    EVENT_FORK,
+    EVENT_EXIT,
 };
 
 struct kevent_t {
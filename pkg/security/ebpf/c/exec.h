@@ -4,6 +4,7 @@
 #include "filters.h"
 #include "syscalls.h"
 #include "container.h"
+#include "process.h"
 
 struct _tracepoint_sched_process_fork
 {
@@ -18,6 +19,30 @@ struct _tracepoint_sched_process_fork
     pid_t child_pid;
 };
 
+struct exec_event_t {
+    struct kevent_t event;
+    struct process_context_t process;
+    struct container_context_t container;
+    struct syscall_t syscall;
+    struct file_t file;
+};
+
+struct fork_event_t {
+    struct kevent_t event;
+    struct process_context_t process;
+    struct container_context_t container;
+    struct syscall_t syscall;
+    u32 pid;
+    u32 padding;
+};
+
+struct exit_event_t {
+    struct kevent_t event;
+    struct process_context_t process;
+    struct container_context_t container;
+    struct syscall_t syscall;
+};
+
 void __attribute__((always_inline)) copy_proc_cache(struct proc_cache_t *dst, struct proc_cache_t *src) {
     dst->executable = src->executable;
     copy_container_id(dst->container_id, src->container_id);
@@ -42,6 +67,23 @@ struct bpf_map_def SEC("maps/pid_cookie") pid_cookie = {
     .namespace = "",
 };
 
+// process_lineage holds whether the lineage of the processes is tracked, which is resolved in user space
+// from the exec, fork and exit events
+struct bpf_map_def SEC("maps/process_lineage") process_lineage = {
+    .type = BPF_MAP_TYPE_ARRAY,
+    .key_size = sizeof(u32),
+    .value_size = sizeof(u32),
+    .max_entries = 1,
+    .pinning = 0,
+    .namespace = "",
+};
+
+int __attribute__((always_inline)) is_process_lineage_enabled() {
+    u32 key = 0;
+    u32 *enabled = bpf_map_lookup_elem(&process_lineage, &key);
+    return enabled && *enabled;
+}
+
 int __attribute__((always_inline)) trace__sys_execveat() {
     struct syscall_cache_t syscall = {
         .type = EVENT_EXEC,
@@ -102,6 +144,26 @@ int __attribute__((always_inline)) vfs_handle_exec_event(struct pt_regs *ctx, st
     // insert pid <-> cookie mapping
     bpf_map_update_elem(&pid_cookie, &tgid, &cookie, BPF_ANY);
 
+    if (is_process_lineage_enabled()) {
+        // resolve the path of the executed binary so that the user space process cache can be updated
+        struct dentry *dentry = get_path_dentry(path);
+        struct path_key_t key = get_key(dentry, path);
+        resolve_dentry(dentry, key, NULL);
+
+        struct exec_event_t event = {
+            .event.type = EVENT_EXEC,
+            .syscall = {
+                .timestamp = bpf_ktime_get_ns(),
+            },
+            .file = entry.executable,
+        };
+
+        struct proc_cache_t *proc_entry = fill_process_data(&event.process);
+        fill_container_data(proc_entry, &event.container);
+
+        send_event(ctx, event);
+    }
+
     pop_syscall();
 
     return 0;
@@ -122,6 +184,25 @@ int sched_process_fork(struct _tracepoint_sched_process_fork *args)
         u32 cookie_key = *cookie;
         bpf_map_update_elem(&pid_cookie, &pid, &cookie_key, BPF_ANY);
     }
+
+    if (!is_process_lineage_enabled()) {
+        return 0;
+    }
+
+    // the tracepoint is called from the context of the parent
+    struct fork_event_t event = {
+        .event.type = EVENT_FORK,
+        .syscall = {
+            .timestamp = bpf_ktime_get_ns(),
+        },
+        .pid = pid,
+    };
+
+    struct proc_cache_t *entry = fill_process_data(&event.process);
+    fill_container_data(entry, &event.container);
+
+    send_event(args, event);
+
     return 0;
 }
 
@@ -136,6 +217,24 @@ int kprobe_do_exit(struct pt_regs *ctx) {
         bpf_map_delete_elem(&pid_cookie, &tgid);
     }
     // (do not delete cookie <-> proc_cache entry since it can be used by a parent process)
+
+    if (!is_process_lineage_enabled()) {
+        return 0;
+    }
+
+    // notify the exits of threads as well, their forks being also notified to user space
+    struct exit_event_t event = {
+        .event.type = EVENT_EXIT,
+        .syscall = {
+            .timestamp = bpf_ktime_get_ns(),
+        },
+    };
+
+    struct proc_cache_t *entry = fill_process_data(&event.process);
+    fill_container_data(entry, &event.container);
+
+    send_event(ctx, event);
+
     return 0;
 }
 
@@ -14,9 +14,14 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"runtime"
+	"sync"
+	"syscall"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
 
@@ -32,9 +37,16 @@ import (
 	"github.com/DataDog/datadog-go/statsd"
 )
 
+// ruleSetApplier creates the rulesets and applies them to the in-kernel filters
+type ruleSetApplier interface {
+	NewRuleSet(opts *rules.Opts) *rules.RuleSet
+	ApplyRuleSet(rs *rules.RuleSet) (*sprobe.Report, error)
+}
+
 // Module represents the system-probe module for the runtime security agent
 type Module struct {
 	probe        *sprobe.Probe
+	applier      ruleSetApplier
 	config       *config.Config
 	ruleSet      *rules.RuleSet
 	eventServer  *EventServer
@@ -42,6 +54,11 @@ type Module struct {
 	listener     net.Listener
 	statsdClient *statsd.Client
 	rateLimiter  *RateLimiter
+	// rulesLock prevents the evaluation of events while the ruleset, or its lists, are being reloaded
+	rulesLock     sync.RWMutex
+	reloadLock    sync.Mutex
+	policyVersion string
+	cancelFnc     context.CancelFunc
 }
 
 // Register the runtime security agent module
@@ -68,15 +85,22 @@ func (m *Module) Register(httpMux *http.ServeMux) error {
 	m.probe.SetEventHandler(m)
 	m.ruleSet.AddListener(m)
 
-	go m.statsMonitor(context.Background())
+	var ctx context.Context
+	ctx, m.cancelFnc = context.WithCancel(context.Background())
+
+	go m.statsMonitor(ctx)
+
+	if m.config.ListsReloadInterval > 0 {
+		go m.listsMonitor(ctx)
+	}
+
+	go m.policyMonitor(ctx)
 
 	if err := m.probe.Start(); err != nil {
 		return err
 	}
 
-	rsa := sprobe.NewRuleSetApplier(m.config)
-
-	report, err := rsa.Apply(m.ruleSet, m.probe)
+	report, err := m.probe.ApplyRuleSet(m.ruleSet)
 	if err != nil {
 		log.Warn(err)
 	}
@@ -95,6 +119,10 @@ func (m *Module) Register(httpMux *http.ServeMux) error {
 
 // Close the module
 func (m *Module) Close() {
+	if m.cancelFnc != nil {
+		m.cancelFnc()
+	}
+
 	if m.grpcServer != nil {
 		m.grpcServer.Stop()
 	}
@@ -125,9 +153,161 @@ func (m *Module) EventDiscarderFound(rs *rules.RuleSet, event eval.Event, field
 
 // HandleEvent is called by the probe when an event arrives from the kernel
 func (m *Module) HandleEvent(event *sprobe.Event) {
+	m.rulesLock.RLock()
+	defer m.rulesLock.RUnlock()
+
 	m.ruleSet.Evaluate(event)
 }
 
+// reloadLists loads again the lists of the policies declared in a separate file. When a list changed, the
+// approvers of the ruleset are applied again and the discarders, that may no longer be valid, are flushed.
+func (m *Module) reloadLists() error {
+	m.rulesLock.Lock()
+	defer m.rulesLock.Unlock()
+
+	updated, err := policy.ReloadLists(m.ruleSet)
+	if len(updated) == 0 {
+		return err
+	}
+	log.Infof("lists %v were updated", updated)
+
+	rsa := sprobe.NewRuleSetApplier(m.config)
+
+	report, applyErr := rsa.ApplyApprovers(m.ruleSet, m.probe)
+	if applyErr != nil {
+		return applyErr
+	}
+
+	if flushErr := m.probe.FlushDiscarders(); flushErr != nil {
+		return flushErr
+	}
+
+	content, _ := json.MarshalIndent(report, "", "\t")
+	log.Debug(string(content))
+
+	return err
+}
+
+// ReloadPolicies loads again the policies of the policies directory. The new ruleset is swapped in and applied
+// to the probe only if all the policies were successfully loaded, otherwise the current ruleset is kept. It
+// returns the version of the policies in use.
+func (m *Module) ReloadPolicies() (string, error) {
+	m.reloadLock.Lock()
+	defer m.reloadLock.Unlock()
+
+	ruleSet := m.applier.NewRuleSet(rules.NewOptsWithParams(m.config.Debug, sprobe.SECLConstants, sprobe.InvalidDiscarders))
+
+	version, err := policy.LoadPolicies(m.config, ruleSet)
+	if err != nil {
+		return m.getPolicyVersion(), err
+	}
+	ruleSet.AddListener(m)
+
+	m.rulesLock.Lock()
+	defer m.rulesLock.Unlock()
+
+	report, err := m.applier.ApplyRuleSet(ruleSet)
+	if err != nil {
+		// restore the kernel filters of the current ruleset
+		if _, restoreErr := m.applier.ApplyRuleSet(m.ruleSet); restoreErr != nil {
+			log.Errorf("failed to restore the current ruleset: %s", restoreErr)
+		}
+		return m.policyVersion, errors.Wrap(err, "failed to apply the new ruleset")
+	}
+
+	ruleIDs := ruleSet.ListRuleIDs()
+	m.rateLimiter.Apply(ruleIDs)
+	m.eventServer.Apply(ruleIDs)
+
+	m.ruleSet = ruleSet
+	m.policyVersion = version
+
+	log.Infof("policies version %s loaded", version)
+
+	content, _ := json.MarshalIndent(report, "", "\t")
+	log.Debug(string(content))
+
+	return version, nil
+}
+
+func (m *Module) getPolicyVersion() string {
+	m.rulesLock.RLock()
+	defer m.rulesLock.RUnlock()
+
+	return m.policyVersion
+}
+
+// policyMonitor reloads the policies when system-probe receives a SIGHUP or, if enabled, when a policy file
+// of the policies directory is modified
+func (m *Module) policyMonitor(ctx context.Context) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	var events chan fsnotify.Event
+	if m.config.WatchPoliciesDir {
+		watcher, err := fsnotify.NewWatcher()
+		if err != nil {
+			log.Errorf("failed to watch the policies directory: %s", err)
+		} else {
+			defer watcher.Close()
+
+			if err := watcher.Add(m.config.PoliciesDir); err != nil {
+				log.Errorf("failed to watch the policies directory: %s", err)
+			} else {
+				events = watcher.Events
+			}
+		}
+	}
+
+	// editors usually trigger several events when a file is saved, the reload is delayed to coalesce them
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case <-signals:
+			log.Info("SIGHUP received, reloading the policies")
+			m.reloadPolicies()
+		case event := <-events:
+			if filepath.Ext(event.Name) != ".policy" {
+				continue
+			}
+			debounce = time.After(time.Second)
+		case <-debounce:
+			debounce = nil
+			log.Info("the policies directory was modified, reloading the policies")
+			m.reloadPolicies()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (m *Module) reloadPolicies() {
+	if _, err := m.ReloadPolicies(); err != nil {
+		log.Errorf("failed to reload the policies: %s", err)
+	}
+}
+
+func (m *Module) listsMonitor(ctx context.Context) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ticker := time.NewTicker(m.config.ListsReloadInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.reloadLists(); err != nil {
+				log.Warn(err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 func (m *Module) statsMonitor(ctx context.Context) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
@@ -167,6 +347,9 @@ func (m *Module) GetStats() map[string]interface{} {
 
 // GetRuleSet returns the set of loaded rules
 func (m *Module) GetRuleSet() *rules.RuleSet {
+	m.rulesLock.RLock()
+	defer m.rulesLock.RUnlock()
+
 	return m.ruleSet
 }
 
@@ -204,20 +387,25 @@ func NewModule(cfg *aconfig.AgentConfig) (api.Module, error) {
 	}
 
 	ruleSet := probe.NewRuleSet(rules.NewOptsWithParams(config.Debug, sprobe.SECLConstants, sprobe.InvalidDiscarders))
-	if err := policy.LoadPolicies(config, ruleSet); err != nil {
+	version, err := policy.LoadPolicies(config, ruleSet)
+	if err != nil {
 		return nil, err
 	}
 
 	m := &Module{
-		config:       config,
-		probe:        probe,
-		ruleSet:      ruleSet,
-		eventServer:  NewEventServer(ruleSet.ListRuleIDs(), config),
-		grpcServer:   grpc.NewServer(),
-		statsdClient: statsdClient,
-		rateLimiter:  NewRateLimiter(ruleSet.ListRuleIDs()),
+		config:        config,
+		probe:         probe,
+		applier:       probe,
+		ruleSet:       ruleSet,
+		policyVersion: version,
+		eventServer:   NewEventServer(ruleSet.ListRuleIDs(), config),
+		grpcServer:    grpc.NewServer(),
+		statsdClient:  statsdClient,
+		rateLimiter:   NewRateLimiter(ruleSet.ListRuleIDs()),
 	}
 
+	m.eventServer.module = m
+
 	sapi.RegisterSecurityModuleServer(m.grpcServer, m.eventServer)
 
 	return m, nil
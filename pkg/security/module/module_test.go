@@ -0,0 +1,170 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	sprobe "github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// testApplier records the rulesets applied, and fails to apply the ones which aren't restored
+type testApplier struct {
+	applied []*rules.RuleSet
+	current *rules.RuleSet
+	fail    bool
+}
+
+func (a *testApplier) NewRuleSet(opts *rules.Opts) *rules.RuleSet {
+	return rules.NewRuleSet(&sprobe.Model{}, func() eval.Event { return sprobe.NewEvent(nil) }, opts)
+}
+
+func (a *testApplier) ApplyRuleSet(rs *rules.RuleSet) (*sprobe.Report, error) {
+	a.applied = append(a.applied, rs)
+	if a.fail && rs != a.current {
+		return nil, errors.New("failed to apply the ruleset")
+	}
+	return &sprobe.Report{}, nil
+}
+
+func writeTestPolicy(t *testing.T, dir, content string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, "test.policy"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestModule(t *testing.T, dir string) (*Module, *testApplier) {
+	writeTestPolicy(t, dir, `---
+rules:
+  - id: kept
+    expression: open.filename == "/etc/passwd"
+  - id: dropped
+    expression: unlink.filename == "/etc/passwd"
+`)
+
+	cfg := &config.Config{PoliciesDir: dir, EventServerBurst: 1, EventServerRate: 1}
+	applier := &testApplier{}
+
+	m := &Module{
+		config:      cfg,
+		applier:     applier,
+		rateLimiter: NewRateLimiter(nil),
+		eventServer: NewEventServer(nil, cfg),
+	}
+
+	version, err := m.ReloadPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	applier.current = m.ruleSet
+
+	if version == "" || m.policyVersion != version {
+		t.Fatalf("unexpected policies version `%s`", version)
+	}
+
+	return m, applier
+}
+
+func hasRule(ruleIDs []string, id string) bool {
+	for _, ruleID := range ruleIDs {
+		if ruleID == id {
+			return true
+		}
+	}
+	return false
+}
+
+const testReloadedPolicy = `---
+rules:
+  - id: kept
+    expression: open.filename == "/etc/passwd"
+  - id: added
+    expression: open.filename == "/etc/shadow"
+`
+
+func TestReloadPolicies(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, applier := newTestModule(t, dir)
+	previousVersion := m.policyVersion
+
+	writeTestPolicy(t, dir, testReloadedPolicy)
+
+	version, err := m.ReloadPolicies()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if version == previousVersion || m.policyVersion != version {
+		t.Errorf("the policies version should have changed, got `%s`", version)
+	}
+	if len(applier.applied) != 2 || applier.applied[1] != m.ruleSet {
+		t.Error("the new ruleset should have been applied")
+	}
+	if ruleIDs := m.ruleSet.ListRuleIDs(); len(ruleIDs) != 2 || hasRule(ruleIDs, "dropped") || !hasRule(ruleIDs, "added") {
+		t.Error("the new ruleset should have been swapped in")
+	}
+	if _, found := m.rateLimiter.limiters["added"]; !found {
+		t.Error("the rate limiter should limit the rules of the new ruleset")
+	}
+	if _, found := m.eventServer.expiredEvents["dropped"]; found {
+		t.Error("the event server shouldn't count the expired events of the rules of the previous ruleset")
+	}
+}
+
+func TestReloadPoliciesRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, applier := newTestModule(t, dir)
+	previousVersion, previousRuleSet := m.policyVersion, m.ruleSet
+
+	// a policy which can't be loaded isn't applied
+	writeTestPolicy(t, dir, "rules:\n  - id: invalid\n    expression: open.filename ==\n")
+	if version, err := m.ReloadPolicies(); err == nil || version != previousVersion {
+		t.Errorf("expected an error and the previous version, got `%s` and %v", version, err)
+	}
+	if len(applier.applied) != 1 {
+		t.Error("a ruleset which can't be loaded shouldn't be applied")
+	}
+
+	// a ruleset which can't be applied is replaced by the current one
+	applier.fail = true
+	writeTestPolicy(t, dir, testReloadedPolicy)
+	if version, err := m.ReloadPolicies(); err == nil || version != previousVersion {
+		t.Errorf("expected an error and the previous version, got `%s` and %v", version, err)
+	}
+	if len(applier.applied) != 3 || applier.applied[2] != previousRuleSet {
+		t.Error("the current ruleset should have been applied again")
+	}
+	if m.ruleSet != previousRuleSet || m.policyVersion != previousVersion {
+		t.Error("the current ruleset should have been kept")
+	}
+	if _, found := m.rateLimiter.limiters["added"]; found {
+		t.Error("the rate limiter shouldn't limit the rules of the rejected ruleset")
+	}
+	if _, found := m.eventServer.expiredEvents["dropped"]; !found {
+		t.Error("the event server should keep counting the expired events of the current ruleset")
+	}
+}
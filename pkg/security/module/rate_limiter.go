@@ -9,6 +9,7 @@ package module
 
 import (
 	"fmt"
+	"sync"
 	"sync/atomic"
 
 	"github.com/DataDog/datadog-go/statsd"
@@ -47,6 +48,7 @@ func NewLimiter(limit rate.Limit, burst int) *Limiter {
 
 // RateLimiter describes a set of rule rate limiters
 type RateLimiter struct {
+	sync.RWMutex
 	limiters map[string]*Limiter
 }
 
@@ -61,8 +63,27 @@ func NewRateLimiter(ids []string) *RateLimiter {
 	}
 }
 
+// Apply a set of rules, keeping the limiters of the rules that were already limited
+func (rl *RateLimiter) Apply(ruleIDs []string) {
+	rl.Lock()
+	defer rl.Unlock()
+
+	limiters := make(map[string]*Limiter)
+	for _, id := range ruleIDs {
+		if limiter, found := rl.limiters[id]; found {
+			limiters[id] = limiter
+		} else {
+			limiters[id] = NewLimiter(defaultLimit, defaultBurst)
+		}
+	}
+	rl.limiters = limiters
+}
+
 // Allow returns true if a specific rule shall be allowed to sent a new event
 func (rl *RateLimiter) Allow(ruleID string) bool {
+	rl.RLock()
+	defer rl.RUnlock()
+
 	ruleLimiter, ok := rl.limiters[ruleID]
 	if !ok {
 		return false
@@ -84,6 +105,9 @@ type RateLimiterStat struct {
 // GetStats returns a map indexed by ruleIDs that describes the amount of events
 // that were dropped because of the rate limiter
 func (rl *RateLimiter) GetStats() map[string]RateLimiterStat {
+	rl.RLock()
+	defer rl.RUnlock()
+
 	stats := make(map[string]RateLimiterStat)
 	for ruleID, ruleLimiter := range rl.limiters {
 		stats[ruleID] = RateLimiterStat{
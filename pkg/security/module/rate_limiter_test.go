@@ -0,0 +1,37 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+
+import (
+	"testing"
+)
+
+func TestRateLimiterApply(t *testing.T) {
+	rl := NewRateLimiter([]string{"kept", "dropped"})
+	kept := rl.limiters["kept"]
+
+	rl.Apply([]string{"kept", "added"})
+
+	if rl.limiters["kept"] != kept {
+		t.Error("the limiter of a rule still applied should be kept")
+	}
+	if _, found := rl.limiters["dropped"]; found {
+		t.Error("the limiter of a dropped rule should be removed")
+	}
+	if rl.limiters["added"] == nil {
+		t.Error("a limiter should be created for an added rule")
+	}
+
+	if !rl.Allow("added") {
+		t.Error("the events of an added rule should be allowed")
+	}
+	if rl.Allow("dropped") {
+		t.Error("the events of a dropped rule shouldn't be allowed")
+	}
+}
@@ -8,12 +8,15 @@
 package module
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/DataDog/datadog-go/statsd"
+	"github.com/hashicorp/go-multierror"
 	"golang.org/x/time/rate"
 
 	"github.com/DataDog/datadog-agent/pkg/security/api"
@@ -27,9 +30,11 @@ import (
 // EventServer represents a gRPC server in charge of receiving events sent by
 // the runtime security system-probe module and forwards them to Datadog
 type EventServer struct {
+	sync.RWMutex
 	msgs          chan *api.SecurityEventMessage
 	expiredEvents map[string]*int64
 	rate          *Limiter
+	module        *Module
 }
 
 // GetEvents waits for security events
@@ -58,6 +63,28 @@ LOOP:
 	return nil
 }
 
+// ReloadPolicies reloads the policies of the runtime security module and returns the version of the policies
+// in use, along with the errors that prevented the new policies from being loaded
+func (e *EventServer) ReloadPolicies(ctx context.Context, params *api.ReloadPoliciesParams) (*api.ReloadPoliciesResultMessage, error) {
+	version, err := e.module.ReloadPolicies()
+
+	result := &api.ReloadPoliciesResultMessage{
+		Version: version,
+	}
+
+	if err != nil {
+		if merr, ok := err.(*multierror.Error); ok {
+			for _, err := range merr.Errors {
+				result.Errors = append(result.Errors, err.Error())
+			}
+		} else {
+			result.Errors = append(result.Errors, err.Error())
+		}
+	}
+
+	return result, nil
+}
+
 // SendEvent forwards events sent by the runtime security module to Datadog
 func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event) {
 	data, err := json.Marshal(rules.RuleEvent{Event: event, RuleID: rule.ID})
@@ -65,7 +92,12 @@ func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event) {
 		return
 	}
 	tags := append(rule.Tags, "rule_id:"+rule.ID)
-	tags = append(tags, event.(*sprobe.Event).GetTags()...)
+	// a sequence is tagged with the event of its last step
+	probeEvent := event
+	if seqEvent, ok := event.(*rules.SequenceEvent); ok {
+		probeEvent = seqEvent.Event
+	}
+	tags = append(tags, probeEvent.(*sprobe.Event).GetTags()...)
 	log.Infof("Sending event message for rule `%s` to security-agent `%s` with tags %v", rule.ID, string(data), tags)
 
 	msg := &api.SecurityEventMessage{
@@ -97,6 +129,9 @@ func (e *EventServer) SendEvent(rule *eval.Rule, event eval.Event) {
 
 // expireEvent updates the count of expired messages for the appropriate rule
 func (e *EventServer) expireEvent(msg *api.SecurityEventMessage) {
+	e.RLock()
+	defer e.RUnlock()
+
 	// Update metric
 	count, ok := e.expiredEvents[msg.RuleID]
 	if ok {
@@ -108,6 +143,9 @@ func (e *EventServer) expireEvent(msg *api.SecurityEventMessage) {
 // GetStats returns a map indexed by ruleIDs that describes the amount of events
 // that were expired or rate limited before reaching
 func (e *EventServer) GetStats() map[string]int64 {
+	e.RLock()
+	defer e.RUnlock()
+
 	stats := make(map[string]int64)
 	for ruleID, val := range e.expiredEvents {
 		stats[ruleID] = atomic.SwapInt64(val, 0)
@@ -128,6 +166,23 @@ func (e *EventServer) SendStats(client *statsd.Client) error {
 	return nil
 }
 
+// Apply a set of rules, keeping the count of expired events of the rules already known
+func (e *EventServer) Apply(ruleIDs []string) {
+	e.Lock()
+	defer e.Unlock()
+
+	expiredEvents := make(map[string]*int64)
+	for _, id := range ruleIDs {
+		if val, found := e.expiredEvents[id]; found {
+			expiredEvents[id] = val
+		} else {
+			var val int64
+			expiredEvents[id] = &val
+		}
+	}
+	e.expiredEvents = expiredEvents
+}
+
 // NewEventServer returns a new gRPC event server
 func NewEventServer(ids []string, cfg *config.Config) *EventServer {
 	es := &EventServer{
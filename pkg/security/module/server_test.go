@@ -0,0 +1,34 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux_bpf
+
+package module
+
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+)
+
+func TestEventServerApply(t *testing.T) {
+	es := NewEventServer([]string{"kept", "dropped"}, &config.Config{EventServerBurst: 1, EventServerRate: 1})
+	atomic.AddInt64(es.expiredEvents["kept"], 2)
+
+	es.Apply([]string{"kept", "added"})
+
+	stats := es.GetStats()
+	if len(stats) != 2 {
+		t.Fatalf("expected the stats of 2 rules, got %v", stats)
+	}
+	if stats["kept"] != 2 {
+		t.Errorf("the expired events of a rule still applied should be kept, got %d", stats["kept"])
+	}
+	if val, found := stats["added"]; !found || val != 0 {
+		t.Errorf("a counter should be created for an added rule, got %v", stats)
+	}
+}
@@ -6,12 +6,17 @@
 package policy
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
+	"strings"
 
 	"github.com/DataDog/datadog-agent/pkg/security/config"
 	"github.com/DataDog/datadog-agent/pkg/security/rules"
@@ -21,11 +26,13 @@ import (
 	"gopkg.in/yaml.v2"
 )
 
-// Policy represents a policy file which is composed of a list of rules and macros
+// Policy represents a policy file which is composed of a list of rules, sequences, macros and lists
 type Policy struct {
-	Version string                   `yaml:"version"`
-	Rules   []*rules.RuleDefinition  `yaml:"rules"`
-	Macros  []*rules.MacroDefinition `yaml:"macros"`
+	Version   string                      `yaml:"version"`
+	Rules     []*rules.RuleDefinition     `yaml:"rules"`
+	Sequences []*rules.SequenceDefinition `yaml:"sequences"`
+	Macros    []*rules.MacroDefinition    `yaml:"macros"`
+	Lists     []*rules.ListDefinition     `yaml:"lists"`
 }
 
 var ruleIDPattern = `^([a-zA-Z0-9]*_*)*$`
@@ -44,6 +51,19 @@ func LoadPolicy(r io.Reader) (*Policy, error) {
 		return nil, errors.Wrap(err, "failed to load policy")
 	}
 
+	for _, listDef := range policy.Lists {
+		if listDef.ID == "" {
+			return nil, errors.New("list has no name")
+		}
+		if !checkRuleID(listDef.ID) {
+			return nil, fmt.Errorf("list ID does not match pattern %s", ruleIDPattern)
+		}
+
+		if len(listDef.Values) != 0 && listDef.File != "" {
+			return nil, fmt.Errorf("list %s has both values and a file", listDef.ID)
+		}
+	}
+
 	for _, macroDef := range policy.Macros {
 		if macroDef.ID == "" {
 			return nil, errors.New("macro has no name")
@@ -70,18 +90,46 @@ func LoadPolicy(r io.Reader) (*Policy, error) {
 		}
 	}
 
+	for _, seqDef := range policy.Sequences {
+		if seqDef.ID == "" {
+			return nil, errors.New("sequence has no name")
+		}
+		if !checkRuleID(seqDef.ID) {
+			return nil, fmt.Errorf("sequence ID does not match pattern %s", ruleIDPattern)
+		}
+
+		if len(seqDef.Steps) < 2 {
+			return nil, fmt.Errorf("sequence %s has less than 2 steps", seqDef.ID)
+		}
+		for _, stepDef := range seqDef.Steps {
+			if stepDef == nil || stepDef.Expression == "" {
+				return nil, fmt.Errorf("sequence %s has a step with no expression", seqDef.ID)
+			}
+		}
+
+		if seqDef.Key == "" {
+			return nil, fmt.Errorf("sequence %s has no key", seqDef.ID)
+		}
+		if seqDef.Window <= 0 {
+			return nil, fmt.Errorf("sequence %s has no window", seqDef.ID)
+		}
+	}
+
 	return policy, nil
 }
 
-// LoadPolicies loads the policies listed in the configuration and apply them to the given ruleset
-func LoadPolicies(config *config.Config, ruleSet *rules.RuleSet) error {
+// LoadPolicies loads the policies listed in the configuration and apply them to the given ruleset. It returns the
+// version of the policies, a digest of the content of the policy files and of the list files they reference.
+func LoadPolicies(config *config.Config, ruleSet *rules.RuleSet) (string, error) {
 	var result *multierror.Error
 
 	policyFiles, err := ioutil.ReadDir(config.PoliciesDir)
 	if err != nil {
-		return err
+		return "", err
 	}
 
+	digest := sha256.New()
+
 	// Load and parse policies
 	for _, policyPath := range policyFiles {
 		filename := policyPath.Name()
@@ -92,20 +140,50 @@ func LoadPolicies(config *config.Config, ruleSet *rules.RuleSet) error {
 			continue
 		}
 
-		// Open policy path
-		f, err := os.Open(filepath.Join(config.PoliciesDir, filename))
+		// Read policy path
+		content, err := ioutil.ReadFile(filepath.Join(config.PoliciesDir, filename))
 		if err != nil {
 			result = multierror.Append(result, errors.Wrapf(err, "failed to load policy `%s`", policyPath))
 			continue
 		}
 
+		digest.Write([]byte(filename))
+		digest.Write(content)
+
 		// Parse policy file
-		policy, err := LoadPolicy(f)
+		policy, err := LoadPolicy(bytes.NewReader(content))
 		if err != nil {
 			result = multierror.Append(result, errors.Wrapf(err, "failed to load policy `%s`", policyPath))
 			continue
 		}
 
+		// Load the values of the lists declared in a separate file
+		var lists []*rules.ListDefinition
+		for _, listDef := range policy.Lists {
+			if listDef.File != "" {
+				if !filepath.IsAbs(listDef.File) {
+					listDef.File = filepath.Join(config.PoliciesDir, listDef.File)
+				}
+
+				if listDef.Values, err = LoadListFile(listDef.File); err != nil {
+					result = multierror.Append(result, errors.Wrapf(err, "failed to load list `%s`", listDef.ID))
+					continue
+				}
+
+				// the values of the list are part of the policies, so that a reload picks up their changes
+				digest.Write([]byte(listDef.File))
+				for _, value := range listDef.Values {
+					digest.Write([]byte(fmt.Sprintf("%v\n", value)))
+				}
+			}
+			lists = append(lists, listDef)
+		}
+
+		// Add the lists to the ruleset, before the macros and the rules referencing them
+		if err := ruleSet.AddLists(lists); err != nil {
+			result = multierror.Append(result, err)
+		}
+
 		// Add the macros to the ruleset and generate macros evaluators
 		if err := ruleSet.AddMacros(policy.Macros); err != nil {
 			result = multierror.Append(result, err)
@@ -115,7 +193,70 @@ func LoadPolicies(config *config.Config, ruleSet *rules.RuleSet) error {
 		if err := ruleSet.AddRules(policy.Rules); err != nil {
 			result = multierror.Append(result, err)
 		}
+
+		// Add the sequences to the ruleset and generate the evaluators of their steps
+		if err := ruleSet.AddSequences(policy.Sequences); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return hex.EncodeToString(digest.Sum(nil))[:16], result.ErrorOrNil()
+}
+
+// LoadListFile loads the values of a list from a file holding one value per line. Empty lines
+// and lines starting with `#` are ignored.
+func LoadListFile(filename string) ([]interface{}, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var values []interface{}
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		value := strings.TrimSpace(scanner.Text())
+		if value == "" || strings.HasPrefix(value, "#") {
+			continue
+		}
+		values = append(values, value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
+// ReloadLists loads again the values of the lists of the ruleset declared in a separate file and
+// updates the ruleset accordingly. It returns the IDs of the lists whose values changed.
+func ReloadLists(ruleSet *rules.RuleSet) ([]rules.ListID, error) {
+	var result *multierror.Error
+	var updated []rules.ListID
+
+	for _, listDef := range ruleSet.GetListDefinitions() {
+		if listDef.File == "" {
+			continue
+		}
+
+		values, err := LoadListFile(listDef.File)
+		if err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "failed to load list `%s`", listDef.ID))
+			continue
+		}
+
+		changed, err := ruleSet.UpdateList(listDef.ID, values)
+		if err != nil {
+			result = multierror.Append(result, err)
+			continue
+		}
+
+		if changed {
+			updated = append(updated, listDef.ID)
+		}
 	}
 
-	return result.ErrorOrNil()
+	return updated, result.ErrorOrNil()
 }
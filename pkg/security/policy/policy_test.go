@@ -0,0 +1,88 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package policy
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/config"
+	"github.com/DataDog/datadog-agent/pkg/security/probe"
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+const testPolicy = `---
+version: 1.0.0
+lists:
+  - id: sensitive_files
+    file: sensitive_files.list
+rules:
+  - id: sensitive_open
+    expression: open.filename in sensitive_files
+`
+
+func writeTestFile(t *testing.T, dir, name, content string) {
+	if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func loadTestPolicies(t *testing.T, dir string) string {
+	ruleSet := rules.NewRuleSet(&probe.Model{}, func() eval.Event { return probe.NewEvent(nil) }, rules.NewOptsWithParams(false, probe.SECLConstants, nil))
+
+	version, err := LoadPolicies(&config.Config{PoliciesDir: dir}, ruleSet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !ruleSet.HasRulesForEventType("open") {
+		t.Fatal("the rule of the policy wasn't loaded")
+	}
+
+	return version
+}
+
+func TestLoadPoliciesVersion(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policies")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	writeTestFile(t, dir, "test.policy", testPolicy)
+	writeTestFile(t, dir, "sensitive_files.list", "/etc/passwd\n")
+
+	version := loadTestPolicies(t, dir)
+	if version == "" {
+		t.Fatal("expected a version")
+	}
+
+	if loadTestPolicies(t, dir) != version {
+		t.Error("the version should only change with the policies")
+	}
+
+	// files other than policies and lists are ignored
+	writeTestFile(t, dir, "README", "policies")
+	if loadTestPolicies(t, dir) != version {
+		t.Error("the version shouldn't change with a file which isn't a policy")
+	}
+
+	writeTestFile(t, dir, "sensitive_files.list", "/etc/passwd\n/etc/shadow\n")
+	listVersion := loadTestPolicies(t, dir)
+	if listVersion == version {
+		t.Error("the version should change with the values of a list file")
+	}
+
+	writeTestFile(t, dir, "test.policy", testPolicy+"  - id: sensitive_unlink\n    expression: unlink.filename in sensitive_files\n")
+	if v := loadTestPolicies(t, dir); v == listVersion || v == version {
+		t.Error("the version should change with the content of a policy")
+	}
+}
@@ -183,6 +183,18 @@ func (rsa *RuleSetApplier) Apply(rs *rules.RuleSet, applier Applier) (*Report, e
 	return rsa.reporter.GetReport(), nil
 }
 
+// ApplyApprovers applies again the filter policies and the approvers of the event types of the ruleset, for
+// instance once the values of a list changed, and returns a report of them. The kprobes are left untouched.
+func (rsa *RuleSetApplier) ApplyApprovers(rs *rules.RuleSet, applier Applier) (*Report, error) {
+	for _, eventType := range rs.GetEventTypes() {
+		if err := rsa.setupKProbe(rs, eventType, applier); err != nil {
+			return nil, err
+		}
+	}
+
+	return rsa.reporter.GetReport(), nil
+}
+
 // NewRuleSetApplier returns a new RuleSetApplier
 func NewRuleSetApplier(cfg *config.Config) *RuleSetApplier {
 	return &RuleSetApplier{
@@ -54,6 +54,12 @@ const (
 	FileSetXAttrEventType
 	// FileRemoveXAttrEventType - Removexattr event
 	FileRemoveXAttrEventType
+	// ExecEventType - Exec event
+	ExecEventType
+	// ForkEventType - Fork event
+	ForkEventType
+	// ExitEventType - Exit event
+	ExitEventType
 	// internalEventType - used internally to get the maximum number of event. Has to be the last one
 	maxEventType
 )
@@ -86,6 +92,12 @@ func (t EventType) String() string {
 		return "setxattr"
 	case FileRemoveXAttrEventType:
 		return "removexattr"
+	case ExecEventType:
+		return "exec"
+	case ForkEventType:
+		return "fork"
+	case ExitEventType:
+		return "exit"
 	}
 	return "unknown"
 }
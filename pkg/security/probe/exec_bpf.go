@@ -11,4 +11,5 @@ package probe
 var execTables = []string{
 	"proc_cache",
 	"pid_cookie",
+	"process_lineage",
 }
@@ -27,6 +27,38 @@ func discardInode(probe *Probe, mountID uint32, inode uint64, tableName string)
 	return true, nil
 }
 
+// flushInodeDiscarders removes all the inode discarders of the given table
+func flushInodeDiscarders(probe *Probe, tableName string) error {
+	return flushTable(probe, tableName, 16)
+}
+
+// flushTable removes all the entries of the given hash table, whose keys are keySize bytes long
+func flushTable(probe *Probe, tableName string, keySize int) error {
+	table := probe.Table(tableName)
+
+	var keys [][]byte
+	key := make([]byte, keySize)
+	for {
+		more, next, _, err := table.GetNext(key)
+		if err != nil {
+			return err
+		}
+		if !more {
+			break
+		}
+		keys = append(keys, next)
+		key = next
+	}
+
+	for _, key := range keys {
+		if err := table.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func discardParentInode(probe *Probe, rs *rules.RuleSet, eventType eval.EventType, filename string, mountID uint32, inode uint64, tableName string) (bool, error) {
 	isDiscarder, err := isParentPathDiscarder(rs, eventType, filename)
 	if !isDiscarder {
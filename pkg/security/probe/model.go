@@ -115,7 +115,7 @@ func (e *BaseEvent) marshalJSON(eventType EventType, resolvers *Resolvers) ([]by
 
 // ResolveMonotonicTimestamp resolves the monolitic kernel timestamp to an absolute time
 func (e *BaseEvent) ResolveMonotonicTimestamp(resolvers *Resolvers) time.Time {
-	if (e.Timestamp.Equal(time.Time{})) {
+	if (e.Timestamp.Equal(time.Time{})) && resolvers != nil {
 		e.Timestamp = resolvers.TimeResolver.ResolveMonotonicTimestamp(e.TimestampRaw)
 	}
 	return e.Timestamp
@@ -138,7 +138,7 @@ type FileEvent struct {
 
 // ResolveInode resolves the inode to a full path
 func (e *FileEvent) ResolveInode(resolvers *Resolvers) string {
-	if len(e.PathnameStr) == 0 {
+	if len(e.PathnameStr) == 0 && resolvers != nil {
 		e.PathnameStr = resolvers.DentryResolver.Resolve(e.MountID, e.Inode)
 		_, mountPath, rootPath, err := resolvers.MountResolver.GetMountPath(e.MountID, e.OverlayNumLower)
 		if err == nil {
@@ -153,7 +153,7 @@ func (e *FileEvent) ResolveInode(resolvers *Resolvers) string {
 
 // ResolveContainerPath resolves the inode to a path relative to the container
 func (e *FileEvent) ResolveContainerPath(resolvers *Resolvers) string {
-	if len(e.ContainerPath) == 0 {
+	if len(e.ContainerPath) == 0 && resolvers != nil {
 		containerPath, _, _, err := resolvers.MountResolver.GetMountPath(e.MountID, e.OverlayNumLower)
 		if err == nil {
 			e.ContainerPath = containerPath
@@ -168,7 +168,7 @@ func (e *FileEvent) ResolveContainerPath(resolvers *Resolvers) string {
 
 // ResolveBasename resolves the inode to a filename
 func (e *FileEvent) ResolveBasename(resolvers *Resolvers) string {
-	if len(e.BasenameStr) == 0 {
+	if len(e.BasenameStr) == 0 && resolvers != nil {
 		e.BasenameStr = resolvers.DentryResolver.GetName(e.MountID, e.Inode)
 	}
 	return e.BasenameStr
@@ -613,7 +613,7 @@ func (e *MountEvent) UnmarshalBinary(data []byte) (int, error) {
 
 // ResolveMountPoint resolves the mountpoint to a full path
 func (e *MountEvent) ResolveMountPoint(resolvers *Resolvers) string {
-	if len(e.MountPointStr) == 0 {
+	if len(e.MountPointStr) == 0 && resolvers != nil {
 		e.MountPointStr = resolvers.DentryResolver.Resolve(e.ParentMountID, e.ParentInode)
 	}
 	return e.MountPointStr
@@ -621,7 +621,7 @@ func (e *MountEvent) ResolveMountPoint(resolvers *Resolvers) string {
 
 // ResolveRoot resolves the mountpoint to a full path
 func (e *MountEvent) ResolveRoot(resolvers *Resolvers) string {
-	if len(e.RootStr) == 0 {
+	if len(e.RootStr) == 0 && resolvers != nil {
 		e.RootStr = resolvers.DentryResolver.Resolve(e.RootMountID, e.RootInode)
 	}
 	return e.RootStr
@@ -666,6 +666,62 @@ func (e *UmountEvent) UnmarshalBinary(data []byte) (int, error) {
 	return 4, nil
 }
 
+// ExecEvent represents an exec event
+type ExecEvent struct {
+	BaseEvent
+	FileEvent
+}
+
+func (e *ExecEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
+	return e.FileEvent.marshalJSON(resolvers)
+}
+
+// UnmarshalBinary unmarshals a binary representation of itself
+func (e *ExecEvent) UnmarshalBinary(data []byte) (int, error) {
+	return unmarshalBinary(data, &e.BaseEvent, &e.FileEvent)
+}
+
+// ForkEvent represents a fork event, sent from the context of the parent
+type ForkEvent struct {
+	BaseEvent
+	Pid uint32
+}
+
+func (e *ForkEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteRune('{')
+	fmt.Fprintf(&buf, `"pid":%d`, e.Pid)
+	buf.WriteRune('}')
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary unmarshals a binary representation of itself
+func (e *ForkEvent) UnmarshalBinary(data []byte) (int, error) {
+	n, err := unmarshalBinary(data, &e.BaseEvent)
+	if err != nil {
+		return n, err
+	}
+
+	data = data[n:]
+	if len(data) < 8 {
+		return n, ErrNotEnoughData
+	}
+
+	e.Pid = byteOrder.Uint32(data[0:4])
+	return n + 8, nil
+}
+
+// ExitEvent represents the exit of a process or a thread
+type ExitEvent struct {
+	BaseEvent
+}
+
+// UnmarshalBinary unmarshals a binary representation of itself
+func (e *ExitEvent) UnmarshalBinary(data []byte) (int, error) {
+	return unmarshalBinary(data, &e.BaseEvent)
+}
+
 // ContainerEvent holds the container context of an event
 type ContainerEvent struct {
 	ID string `field:"id" handler:"ResolveContainerID,string"`
@@ -724,8 +780,14 @@ type ProcessEvent struct {
 	User    string `field:"user" handler:"ResolveUser,string"`
 	Group   string `field:"group" handler:"ResolveGroup,string"`
 
-	CommRaw    [16]byte `field:"-"`
-	TTYNameRaw [64]byte `field:"-"`
+	AncestorsNames     []string `field:"ancestors.name" handler:"ResolveAncestorsNames,[]string"`
+	AncestorsFilenames []string `field:"ancestors.filename" handler:"ResolveAncestorsFilenames,[]string"`
+	AncestorsPids      []int    `field:"ancestors.pid" handler:"ResolveAncestorsPids,[]int"`
+	AncestorsUIDs      []int    `field:"ancestors.uid" handler:"ResolveAncestorsUIDs,[]int"`
+
+	CommRaw    [16]byte             `field:"-"`
+	TTYNameRaw [64]byte             `field:"-"`
+	Ancestors  []*ProcessCacheEntry `field:"-"`
 }
 
 func (p *ProcessEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
@@ -733,18 +795,93 @@ func (p *ProcessEvent) marshalJSON(resolvers *Resolvers) ([]byte, error) {
 	buf.WriteRune('{')
 	fmt.Fprintf(&buf, `"pidns":%d,`, p.Pidns)
 	fmt.Fprintf(&buf, `"name":"%s",`, p.GetComm())
+	if p.Inode != 0 || p.PathnameStr != "" {
+		fmt.Fprintf(&buf, `"filename":"%s",`, p.ResolveInode(resolvers))
+		fmt.Fprintf(&buf, `"container_path":"%s",`, p.ResolveContainerPath(resolvers))
+		fmt.Fprintf(&buf, `"inode":%d,`, p.Inode)
+		fmt.Fprintf(&buf, `"mount_id":%d,`, p.MountID)
+		fmt.Fprintf(&buf, `"overlay_numlower":%d,`, p.OverlayNumLower)
+	}
 	if tty := p.GetTTY(); tty != "" {
 		fmt.Fprintf(&buf, `"tty_name":"%s",`, tty)
 	}
 	fmt.Fprintf(&buf, `"pid":%d,`, p.Pid)
 	fmt.Fprintf(&buf, `"tid":%d,`, p.Tid)
+	if user := p.ResolveUser(resolvers); user != "" {
+		fmt.Fprintf(&buf, `"user":"%s",`, user)
+	}
+	if group := p.ResolveGroup(resolvers); group != "" {
+		fmt.Fprintf(&buf, `"group":"%s",`, group)
+	}
 	fmt.Fprintf(&buf, `"uid":%d,`, p.UID)
 	fmt.Fprintf(&buf, `"gid":%d`, p.GID)
+	if ancestors := p.ResolveAncestors(resolvers); len(ancestors) > 0 {
+		buf.WriteString(`,"ancestors":[`)
+		for i, ancestor := range ancestors {
+			if i > 0 {
+				buf.WriteRune(',')
+			}
+			fmt.Fprintf(&buf, `{"pid":%d,"ppid":%d,"name":"%s",`, ancestor.Pid, ancestor.PPid, ancestor.Comm)
+			if ancestor.Filename != "" {
+				fmt.Fprintf(&buf, `"filename":"%s",`, ancestor.Filename)
+			}
+			fmt.Fprintf(&buf, `"uid":%d,"gid":%d}`, ancestor.UID, ancestor.GID)
+		}
+		buf.WriteRune(']')
+	}
 	buf.WriteRune('}')
 
 	return buf.Bytes(), nil
 }
 
+// ResolveAncestors resolves the ancestors of the process, from its parent to the oldest known ancestor
+func (p *ProcessEvent) ResolveAncestors(resolvers *Resolvers) []*ProcessCacheEntry {
+	if p.Ancestors == nil && resolvers != nil && resolvers.ProcessResolver != nil {
+		p.Ancestors = resolvers.ProcessResolver.GetAncestors(p.Pid)
+	}
+	return p.Ancestors
+}
+
+// ResolveAncestorsNames resolves the names of the ancestors of the process
+func (p *ProcessEvent) ResolveAncestorsNames(resolvers *Resolvers) []string {
+	if p.AncestorsNames == nil {
+		for _, ancestor := range p.ResolveAncestors(resolvers) {
+			p.AncestorsNames = append(p.AncestorsNames, ancestor.Comm)
+		}
+	}
+	return p.AncestorsNames
+}
+
+// ResolveAncestorsFilenames resolves the paths of the binaries of the ancestors of the process
+func (p *ProcessEvent) ResolveAncestorsFilenames(resolvers *Resolvers) []string {
+	if p.AncestorsFilenames == nil {
+		for _, ancestor := range p.ResolveAncestors(resolvers) {
+			p.AncestorsFilenames = append(p.AncestorsFilenames, ancestor.Filename)
+		}
+	}
+	return p.AncestorsFilenames
+}
+
+// ResolveAncestorsPids resolves the pids of the ancestors of the process
+func (p *ProcessEvent) ResolveAncestorsPids(resolvers *Resolvers) []int {
+	if p.AncestorsPids == nil {
+		for _, ancestor := range p.ResolveAncestors(resolvers) {
+			p.AncestorsPids = append(p.AncestorsPids, int(ancestor.Pid))
+		}
+	}
+	return p.AncestorsPids
+}
+
+// ResolveAncestorsUIDs resolves the user ids of the ancestors of the process
+func (p *ProcessEvent) ResolveAncestorsUIDs(resolvers *Resolvers) []int {
+	if p.AncestorsUIDs == nil {
+		for _, ancestor := range p.ResolveAncestors(resolvers) {
+			p.AncestorsUIDs = append(p.AncestorsUIDs, int(ancestor.UID))
+		}
+	}
+	return p.AncestorsUIDs
+}
+
 // ResolveTTY resolves the name of the process tty
 func (p *ProcessEvent) ResolveTTY(resolvers *Resolvers) string {
 	return p.GetTTY()
@@ -773,18 +910,20 @@ func (p *ProcessEvent) GetComm() string {
 
 // ResolveUser resolves the user id of the process to a username
 func (p *ProcessEvent) ResolveUser(resolvers *Resolvers) string {
-	u, err := user.LookupId(strconv.Itoa(int(p.UID)))
-	if err == nil {
-		p.User = u.Username
+	if len(p.User) == 0 {
+		if u, err := user.LookupId(strconv.Itoa(int(p.UID))); err == nil {
+			p.User = u.Username
+		}
 	}
 	return p.User
 }
 
 // ResolveGroup resolves the group id of the process to a group name
 func (p *ProcessEvent) ResolveGroup(resolvers *Resolvers) string {
-	g, err := user.LookupGroupId(strconv.Itoa(int(p.GID)))
-	if err == nil {
-		p.Group = g.Name
+	if len(p.Group) == 0 {
+		if g, err := user.LookupGroupId(strconv.Itoa(int(p.GID))); err == nil {
+			p.Group = g.Name
+		}
 	}
 	return p.Group
 }
@@ -834,6 +973,9 @@ type Event struct {
 	RemoveXAttr SetXAttrEvent  `yaml:"removexattr" field:"removexattr" event:"removexattr"`
 	Mount       MountEvent     `yaml:"mount" field:"-"`
 	Umount      UmountEvent    `yaml:"umount" field:"-"`
+	Exec        ExecEvent      `yaml:"exec" field:"-"`
+	Fork        ForkEvent      `yaml:"fork" field:"-"`
+	Exit        ExitEvent      `yaml:"exit" field:"-"`
 
 	resolvers *Resolvers `field:"-"`
 }
@@ -1020,6 +1162,32 @@ func (e *Event) MarshalJSON() ([]byte, error) {
 				field:      "file",
 				marshalFnc: e.RemoveXAttr.marshalJSON,
 			})
+	case ExecEventType:
+		entries = append(entries,
+			eventMarshaler{
+				field:      "syscall",
+				marshalFnc: eventMarshalJSON(&e.Exec.BaseEvent),
+			},
+			eventMarshaler{
+				field:      "file",
+				marshalFnc: e.Exec.marshalJSON,
+			})
+	case ForkEventType:
+		entries = append(entries,
+			eventMarshaler{
+				field:      "syscall",
+				marshalFnc: eventMarshalJSON(&e.Fork.BaseEvent),
+			},
+			eventMarshaler{
+				field:      "child",
+				marshalFnc: e.Fork.marshalJSON,
+			})
+	case ExitEventType:
+		entries = append(entries,
+			eventMarshaler{
+				field:      "syscall",
+				marshalFnc: eventMarshalJSON(&e.Exit.BaseEvent),
+			})
 	}
 
 	var prev bool
@@ -387,6 +387,46 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 			Field: field,
 		}, nil
 
+	case "process.ancestors.filename":
+
+		return &eval.StringArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []string {
+				return (*Event)(ctx.Object).Process.ResolveAncestorsFilenames((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "process.ancestors.name":
+
+		return &eval.StringArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []string {
+				return (*Event)(ctx.Object).Process.ResolveAncestorsNames((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "process.ancestors.pid":
+
+		return &eval.IntArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []int {
+				return (*Event)(ctx.Object).Process.ResolveAncestorsPids((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
+	case "process.ancestors.uid":
+
+		return &eval.IntArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []int {
+				return (*Event)(ctx.Object).Process.ResolveAncestorsUIDs((*Event)(ctx.Object).resolvers)
+			},
+
+			Field: field,
+		}, nil
+
 	case "process.basename":
 
 		return &eval.StringEvaluator{
@@ -1107,6 +1147,22 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 
 		return int(e.Open.Retval), nil
 
+	case "process.ancestors.filename":
+
+		return e.Process.ResolveAncestorsFilenames(e.resolvers), nil
+
+	case "process.ancestors.name":
+
+		return e.Process.ResolveAncestorsNames(e.resolvers), nil
+
+	case "process.ancestors.pid":
+
+		return e.Process.ResolveAncestorsPids(e.resolvers), nil
+
+	case "process.ancestors.uid":
+
+		return e.Process.ResolveAncestorsUIDs(e.resolvers), nil
+
 	case "process.basename":
 
 		return e.Process.ResolveBasename(e.resolvers), nil
@@ -1481,6 +1537,18 @@ func (e *Event) GetFieldEventType(field eval.Field) (eval.EventType, error) {
 	case "open.retval":
 		return "open", nil
 
+	case "process.ancestors.filename":
+		return "*", nil
+
+	case "process.ancestors.name":
+		return "*", nil
+
+	case "process.ancestors.pid":
+		return "*", nil
+
+	case "process.ancestors.uid":
+		return "*", nil
+
 	case "process.basename":
 		return "*", nil
 
@@ -1837,6 +1905,22 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 
 		return reflect.Int, nil
 
+	case "process.ancestors.filename":
+
+		return reflect.String, nil
+
+	case "process.ancestors.name":
+
+		return reflect.String, nil
+
+	case "process.ancestors.pid":
+
+		return reflect.Int, nil
+
+	case "process.ancestors.uid":
+
+		return reflect.Int, nil
+
 	case "process.basename":
 
 		return reflect.String, nil
@@ -2426,6 +2510,42 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 		e.Open.Retval = int64(v)
 		return nil
 
+	case "process.ancestors.filename":
+
+		v, ok := value.(string)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.AncestorsFilenames"}
+		}
+		e.Process.AncestorsFilenames = []string{v}
+		return nil
+
+	case "process.ancestors.name":
+
+		v, ok := value.(string)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.AncestorsNames"}
+		}
+		e.Process.AncestorsNames = []string{v}
+		return nil
+
+	case "process.ancestors.pid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.AncestorsPids"}
+		}
+		e.Process.AncestorsPids = []int{v}
+		return nil
+
+	case "process.ancestors.uid":
+
+		v, ok := value.(int)
+		if !ok {
+			return &eval.ErrValueTypeMismatch{Field: "Process.AncestorsUIDs"}
+		}
+		e.Process.AncestorsUIDs = []int{v}
+		return nil
+
 	case "process.basename":
 
 		if e.Process.BasenameStr, ok = value.(string); !ok {
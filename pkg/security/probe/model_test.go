@@ -10,7 +10,10 @@ package probe
 import (
 	"bytes"
 	"encoding/json"
+	"reflect"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestMkdirJSON(t *testing.T) {
@@ -50,3 +53,127 @@ func TestMkdirJSON(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestOpenJSONRoundTrip(t *testing.T) {
+	e := NewEvent(nil)
+	e.Type = uint64(FileOpenEventType)
+	e.Process = ProcessEvent{
+		FileEvent: FileEvent{
+			Inode:         44,
+			PathnameStr:   "/usr/bin/cat",
+			ContainerPath: "/",
+		},
+		Comm:  "cat",
+		Pid:   123,
+		UID:   1000,
+		User:  "alice",
+		Group: "staff",
+	}
+	e.Container = ContainerEvent{ID: "0123456789012345678901234567890123456789012345678901234567890123"}
+	e.Open = OpenEvent{
+		BaseEvent: BaseEvent{
+			Timestamp: time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC),
+			Retval:    3,
+		},
+		FileEvent: FileEvent{
+			Inode:         33,
+			PathnameStr:   "/etc/shadow",
+			ContainerPath: "/",
+		},
+		Flags: uint32(syscall.O_CREAT | syscall.O_WRONLY),
+		Mode:  0600,
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := NewEvent(nil)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	if decoded.GetType() != "open" {
+		t.Errorf("expected an open event, got %s", decoded.GetType())
+	}
+
+	for field, expected := range map[string]interface{}{
+		"process.name":     "cat",
+		"process.pid":      123,
+		"process.uid":      1000,
+		"process.filename": "/usr/bin/cat",
+		"process.basename": "cat",
+		"process.inode":    44,
+		"process.user":     "alice",
+		"process.group":    "staff",
+		"container.id":     e.Container.ID,
+		"open.filename":    "/etc/shadow",
+		"open.basename":    "shadow",
+		"open.inode":       33,
+		"open.flags":       syscall.O_CREAT | syscall.O_WRONLY,
+		"open.mode":        0600,
+		"open.retval":      3,
+	} {
+		value, err := decoded.GetFieldValue(field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if value != expected {
+			t.Errorf("expected %v for %s, got %v", expected, field, value)
+		}
+	}
+
+	if !decoded.Open.Timestamp.Equal(e.Open.Timestamp) {
+		t.Errorf("expected timestamp %s, got %s", e.Open.Timestamp, decoded.Open.Timestamp)
+	}
+}
+
+func TestUnmarshalUnknownEventType(t *testing.T) {
+	if err := json.Unmarshal([]byte(`{"syscall":{"type":"ptrace"}}`), NewEvent(nil)); err == nil {
+		t.Error("an unknown event type should be rejected")
+	}
+}
+
+func TestAncestorsJSONRoundTrip(t *testing.T) {
+	e := NewEvent(nil)
+	e.Type = uint64(FileMkdirEventType)
+	e.Process = ProcessEvent{
+		Comm: "mkdir",
+		Pid:  300,
+		Ancestors: []*ProcessCacheEntry{
+			{Pid: 200, PPid: 1, Comm: "bash", Filename: "/usr/bin/bash", UID: 1000, GID: 1000},
+			{Pid: 1, Comm: "systemd", Filename: "/usr/lib/systemd/systemd"},
+		},
+	}
+	e.Mkdir = MkdirEvent{
+		FileEvent: FileEvent{
+			PathnameStr: "/tmp/test",
+		},
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	decoded := NewEvent(nil)
+	if err := json.Unmarshal(data, decoded); err != nil {
+		t.Fatal(err)
+	}
+
+	for field, expected := range map[string]interface{}{
+		"process.ancestors.name":     []string{"bash", "systemd"},
+		"process.ancestors.filename": []string{"/usr/bin/bash", "/usr/lib/systemd/systemd"},
+		"process.ancestors.pid":      []int{200, 1},
+		"process.ancestors.uid":      []int{1000, 0},
+	} {
+		value, err := decoded.GetFieldValue(field)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(value, expected) {
+			t.Errorf("expected %v for %s, got %v", expected, field, value)
+		}
+	}
+}
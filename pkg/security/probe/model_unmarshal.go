@@ -0,0 +1,279 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// timeLayout is the layout of the timestamps of the JSON representation of an event
+const timeLayout = "2006-01-02 15:04:05.999999999 -0700 MST"
+
+// ParseEventType returns the event type of the given name
+func ParseEventType(name string) EventType {
+	for eventType := UnknownEventType + 1; eventType < maxEventType; eventType++ {
+		if eventType.String() == name {
+			return eventType
+		}
+	}
+	return UnknownEventType
+}
+
+// parseBitmask parses the string representation of a bitmask, as returned by bitmaskToString
+func parseBitmask(s string, constants map[string]int) (int, error) {
+	var bitmask int
+	for _, str := range strings.Split(s, "|") {
+		str = strings.TrimSpace(str)
+		if str == "" {
+			continue
+		}
+
+		if value, found := constants[str]; found {
+			bitmask |= value
+			continue
+		}
+
+		value, err := strconv.Atoi(str)
+		if err != nil {
+			return 0, fmt.Errorf("unknown value `%s`", str)
+		}
+		bitmask |= value
+	}
+	return bitmask, nil
+}
+
+type baseEventJSON struct {
+	Type      string `json:"type"`
+	Timestamp string `json:"timestamp"`
+	Retval    int64  `json:"retval"`
+}
+
+func (b *baseEventJSON) unmarshal(e *BaseEvent) {
+	e.Retval = b.Retval
+	if timestamp, err := time.Parse(timeLayout, b.Timestamp); err == nil {
+		e.Timestamp = timestamp
+	}
+}
+
+type fileEventJSON struct {
+	Filename           string `json:"filename"`
+	ContainerPath      string `json:"container_path"`
+	Inode              uint64 `json:"inode"`
+	MountID            uint32 `json:"mount_id"`
+	OverlayNumLower    int32  `json:"overlay_numlower"`
+	Mode               uint32 `json:"mode"`
+	Flags              string `json:"flags"`
+	UID                int32  `json:"uid"`
+	GID                int32  `json:"gid"`
+	AttributeName      string `json:"attribute_name"`
+	AttributeNamespace string `json:"attribute_namespace"`
+}
+
+func (f *fileEventJSON) unmarshal(e *FileEvent) {
+	e.PathnameStr = f.Filename
+	e.ContainerPath = f.ContainerPath
+	e.Inode = f.Inode
+	e.MountID = f.MountID
+	e.OverlayNumLower = f.OverlayNumLower
+	if f.Filename != "" {
+		e.BasenameStr = path.Base(f.Filename)
+	}
+}
+
+type processEventJSON struct {
+	Pidns           uint64 `json:"pidns"`
+	Name            string `json:"name"`
+	Filename        string `json:"filename"`
+	ContainerPath   string `json:"container_path"`
+	Inode           uint64 `json:"inode"`
+	MountID         uint32 `json:"mount_id"`
+	OverlayNumLower int32  `json:"overlay_numlower"`
+	TTYName         string `json:"tty_name"`
+	Pid             uint32 `json:"pid"`
+	Tid             uint32 `json:"tid"`
+	User            string `json:"user"`
+	Group           string `json:"group"`
+	UID             uint32 `json:"uid"`
+	GID             uint32 `json:"gid"`
+
+	Ancestors []processAncestorJSON `json:"ancestors"`
+}
+
+type processAncestorJSON struct {
+	Pid      uint32 `json:"pid"`
+	PPid     uint32 `json:"ppid"`
+	Name     string `json:"name"`
+	Filename string `json:"filename"`
+	UID      uint32 `json:"uid"`
+	GID      uint32 `json:"gid"`
+}
+
+type mountEventJSON struct {
+	MountPoint    string `json:"mount_point"`
+	ParentMountID uint32 `json:"parent_mount_id"`
+	ParentInode   uint64 `json:"parent_inode"`
+	RootInode     uint64 `json:"root_inode"`
+	RootMountID   uint32 `json:"root_mount_id"`
+	Root          string `json:"root"`
+	NewMountID    uint32 `json:"new_mount_id"`
+	NewGroupID    uint32 `json:"new_group_id"`
+	NewDevice     uint32 `json:"new_device"`
+	FSType        string `json:"fstype"`
+}
+
+type eventJSON struct {
+	ID        string           `json:"id"`
+	Process   processEventJSON `json:"process"`
+	Container struct {
+		ID string `json:"container_id"`
+	} `json:"container"`
+	Syscall baseEventJSON  `json:"syscall"`
+	File    fileEventJSON  `json:"file"`
+	Old     fileEventJSON  `json:"old"`
+	New     fileEventJSON  `json:"new"`
+	Source  fileEventJSON  `json:"source"`
+	Target  fileEventJSON  `json:"target"`
+	Mount   mountEventJSON `json:"mount"`
+	Umount  struct {
+		MountID uint32 `json:"mount_id"`
+	} `json:"umount"`
+	Child struct {
+		Pid uint32 `json:"pid"`
+	} `json:"child"`
+}
+
+// UnmarshalJSON decodes an event from the JSON representation returned by MarshalJSON. The decoded
+// event holds the resolved values of its fields, so that it can be evaluated without resolvers.
+func (e *Event) UnmarshalJSON(data []byte) error {
+	var ej eventJSON
+	if err := json.Unmarshal(data, &ej); err != nil {
+		return err
+	}
+
+	eventType := ParseEventType(ej.Syscall.Type)
+	if eventType == UnknownEventType {
+		return fmt.Errorf("unknown event type `%s`", ej.Syscall.Type)
+	}
+
+	e.ID = ej.ID
+	e.Type = uint64(eventType)
+
+	e.Process = ProcessEvent{
+		Pidns:   ej.Process.Pidns,
+		Comm:    ej.Process.Name,
+		TTYName: ej.Process.TTYName,
+		Pid:     ej.Process.Pid,
+		Tid:     ej.Process.Tid,
+		UID:     ej.Process.UID,
+		GID:     ej.Process.GID,
+		User:    ej.Process.User,
+		Group:   ej.Process.Group,
+	}
+	processFile := fileEventJSON{
+		Filename:        ej.Process.Filename,
+		ContainerPath:   ej.Process.ContainerPath,
+		Inode:           ej.Process.Inode,
+		MountID:         ej.Process.MountID,
+		OverlayNumLower: ej.Process.OverlayNumLower,
+	}
+	processFile.unmarshal(&e.Process.FileEvent)
+	for _, ancestor := range ej.Process.Ancestors {
+		e.Process.Ancestors = append(e.Process.Ancestors, &ProcessCacheEntry{
+			Pid:      ancestor.Pid,
+			PPid:     ancestor.PPid,
+			Comm:     ancestor.Name,
+			Filename: ancestor.Filename,
+			UID:      ancestor.UID,
+			GID:      ancestor.GID,
+		})
+	}
+	e.Container = ContainerEvent{ID: ej.Container.ID}
+
+	switch eventType {
+	case FileOpenEventType:
+		ej.Syscall.unmarshal(&e.Open.BaseEvent)
+		ej.File.unmarshal(&e.Open.FileEvent)
+		flags, err := parseBitmask(ej.File.Flags, openFlagsConstants)
+		if err != nil {
+			return errors.Wrap(err, "invalid open flags")
+		}
+		e.Open.Flags = uint32(flags)
+		e.Open.Mode = ej.File.Mode
+	case FileMkdirEventType:
+		ej.Syscall.unmarshal(&e.Mkdir.BaseEvent)
+		ej.File.unmarshal(&e.Mkdir.FileEvent)
+		e.Mkdir.Mode = int32(ej.File.Mode)
+	case FileRmdirEventType:
+		ej.Syscall.unmarshal(&e.Rmdir.BaseEvent)
+		ej.File.unmarshal(&e.Rmdir.FileEvent)
+	case FileUnlinkEventType:
+		ej.Syscall.unmarshal(&e.Unlink.BaseEvent)
+		ej.File.unmarshal(&e.Unlink.FileEvent)
+	case FileChmodEventType:
+		ej.Syscall.unmarshal(&e.Chmod.BaseEvent)
+		ej.File.unmarshal(&e.Chmod.FileEvent)
+		e.Chmod.Mode = ej.File.Mode
+	case FileChownEventType:
+		ej.Syscall.unmarshal(&e.Chown.BaseEvent)
+		ej.File.unmarshal(&e.Chown.FileEvent)
+		e.Chown.UID = ej.File.UID
+		e.Chown.GID = ej.File.GID
+	case FileUtimeEventType:
+		ej.Syscall.unmarshal(&e.Utimes.BaseEvent)
+		ej.File.unmarshal(&e.Utimes.FileEvent)
+	case FileRenameEventType:
+		ej.Syscall.unmarshal(&e.Rename.BaseEvent)
+		ej.Old.unmarshal(&e.Rename.Old)
+		ej.New.unmarshal(&e.Rename.New)
+	case FileLinkEventType:
+		ej.Syscall.unmarshal(&e.Link.BaseEvent)
+		ej.Source.unmarshal(&e.Link.Source)
+		ej.Target.unmarshal(&e.Link.Target)
+	case FileSetXAttrEventType, FileRemoveXAttrEventType:
+		xattr := &e.SetXAttr
+		if eventType == FileRemoveXAttrEventType {
+			xattr = &e.RemoveXAttr
+		}
+		ej.Syscall.unmarshal(&xattr.BaseEvent)
+		ej.File.unmarshal(&xattr.FileEvent)
+		xattr.Name = ej.File.AttributeName
+		xattr.Namespace = ej.File.AttributeNamespace
+	case FileMountEventType:
+		ej.Syscall.unmarshal(&e.Mount.BaseEvent)
+		e.Mount.MountPointStr = ej.Mount.MountPoint
+		e.Mount.ParentMountID = ej.Mount.ParentMountID
+		e.Mount.ParentInode = ej.Mount.ParentInode
+		e.Mount.RootInode = ej.Mount.RootInode
+		e.Mount.RootMountID = ej.Mount.RootMountID
+		e.Mount.RootStr = ej.Mount.Root
+		e.Mount.NewMountID = ej.Mount.NewMountID
+		e.Mount.NewGroupID = ej.Mount.NewGroupID
+		e.Mount.NewDevice = ej.Mount.NewDevice
+		e.Mount.FSType = ej.Mount.FSType
+	case FileUmountEventType:
+		ej.Syscall.unmarshal(&e.Umount.BaseEvent)
+		e.Umount.MountID = ej.Umount.MountID
+	case ExecEventType:
+		ej.Syscall.unmarshal(&e.Exec.BaseEvent)
+		ej.File.unmarshal(&e.Exec.FileEvent)
+	case ForkEventType:
+		ej.Syscall.unmarshal(&e.Fork.BaseEvent)
+		e.Fork.Pid = ej.Child.Pid
+	case ExitEventType:
+		ej.Syscall.unmarshal(&e.Exit.BaseEvent)
+	}
+
+	return nil
+}
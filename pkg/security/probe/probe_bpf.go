@@ -12,6 +12,7 @@ import (
 	"strings"
 
 	"github.com/DataDog/datadog-go/statsd"
+	"github.com/pkg/errors"
 
 	"github.com/DataDog/datadog-agent/pkg/ebpf/bytecode"
 	"github.com/DataDog/datadog-agent/pkg/security/config"
@@ -59,6 +60,11 @@ type Probe struct {
 	kernelVersion    uint32
 	_                uint32 // padding for goarch=386
 	eventsStats      EventsStats
+	// the kprobes and the tracepoints already registered, as a ruleset can be applied more than once
+	kprobes     map[*ebpf.KProbe]bool
+	tracepoints map[string]bool
+	// the kprobes required by the ruleset being applied
+	requiredKProbes map[*ebpf.KProbe]bool
 }
 
 func (p *Probe) getTableNames() []string {
@@ -134,6 +140,13 @@ func (p *Probe) Start() error {
 		return err
 	}
 
+	// the process cache must be maintained before it is seeded by the resolvers
+	if p.config.ProcessLineage {
+		if err := p.enableProcessLineage(); err != nil {
+			return err
+		}
+	}
+
 	if err := p.resolvers.Start(); err != nil {
 		return err
 	}
@@ -342,11 +355,36 @@ func (p *Probe) handleEvent(data []byte) {
 			log.Errorf("failed to decode removexattr event: %s (offset %d, len %d)", err, offset, len(data))
 			return
 		}
+	case ExecEventType:
+		if _, err := event.Exec.UnmarshalBinary(data[offset:]); err != nil {
+			log.Errorf("failed to decode exec event: %s (offset %d, len %d)", err, offset, len(data))
+			return
+		}
+		p.resolvers.ProcessResolver.AddExecEntry(event, p.resolvers)
+	case ForkEventType:
+		if _, err := event.Fork.UnmarshalBinary(data[offset:]); err != nil {
+			log.Errorf("failed to decode fork event: %s (offset %d, len %d)", err, offset, len(data))
+			return
+		}
+		p.resolvers.ProcessResolver.AddForkEntry(event, p.resolvers)
+	case ExitEventType:
+		if _, err := event.Exit.UnmarshalBinary(data[offset:]); err != nil {
+			log.Errorf("failed to decode exit event: %s (offset %d, len %d)", err, offset, len(data))
+			return
+		}
+		// resolve the lineage of the exiting process before its entry is removed from the cache
+		event.Process.ResolveAncestors(p.resolvers)
+		p.resolvers.ProcessResolver.DeleteEntry(event.Process.Tid)
 	default:
 		log.Errorf("unsupported event type %d", eventType)
 		return
 	}
 
+	if eventType != ExecEventType && eventType != ForkEventType && eventType != ExitEventType {
+		// refresh the process cache with the context of the event, such as the credentials of the process
+		p.resolvers.ProcessResolver.UpdateEntry(&event.Process)
+	}
+
 	p.eventsStats.CountEventType(eventType, 1)
 
 	log.Tracef("Dispatching event %+v\n", event)
@@ -386,6 +424,21 @@ func (p *Probe) OnNewDiscarder(rs *rules.RuleSet, event *Event, field eval.Field
 	return nil
 }
 
+// FlushDiscarders removes all the discarders pushed to the kernel, as they may no longer be valid once the
+// rules changed
+func (p *Probe) FlushDiscarders() error {
+	log.Debugf("Flushing discarders")
+
+	for _, tableName := range []string{"open_path_inode_discarders", "unlink_path_inode_discarders"} {
+		if err := flushInodeDiscarders(p, tableName); err != nil {
+			return errors.Wrapf(err, "unable to flush the discarders of `%s`", tableName)
+		}
+	}
+
+	table := p.Table("open_flags_discarders")
+	return table.Set(ebpf.ZeroUint32TableItem, ebpf.ZeroUint32TableItem)
+}
+
 // Init initialises the probe
 func (p *Probe) Init() error {
 	if !p.config.EnableKernelFilters {
@@ -427,8 +480,14 @@ func (p *Probe) ApplyApprovers(eventType eval.EventType, approvers rules.Approve
 
 // RegisterKProbe register the given kprobe
 func (p *Probe) RegisterKProbe(kprobe *ebpf.KProbe) error {
+	p.requiredKProbes[kprobe] = true
+	if p.kprobes[kprobe] {
+		return nil
+	}
+
 	err := p.Module.RegisterKprobe(kprobe)
 	if err == nil {
+		p.kprobes[kprobe] = true
 		log.Infof("kProbe `%s` registered", kprobe.Name)
 	} else {
 		log.Errorf("failed to register kProbe `%s`", kprobe.Name)
@@ -439,8 +498,13 @@ func (p *Probe) RegisterKProbe(kprobe *ebpf.KProbe) error {
 
 // RegisterTracepoint registers the given tracepoint
 func (p *Probe) RegisterTracepoint(tracepoint string) error {
+	if p.tracepoints[tracepoint] {
+		return nil
+	}
+
 	err := p.Module.RegisterTracepoint(tracepoint)
 	if err == nil {
+		p.tracepoints[tracepoint] = true
 		log.Infof("tracepoint `%s` registered", tracepoint)
 	} else {
 		log.Errorf("failed to register tracepoint `%s`", tracepoint)
@@ -448,6 +512,82 @@ func (p *Probe) RegisterTracepoint(tracepoint string) error {
 	return err
 }
 
+// FlushApprovers removes the approvers of all the event types
+func (p *Probe) FlushApprovers() error {
+	log.Debugf("Flushing approvers")
+
+	for _, table := range []struct {
+		name    string
+		keySize int
+	}{
+		{name: "open_basename_approvers", keySize: BasenameFilterSize},
+		{name: "open_process_inode_approvers", keySize: 8},
+	} {
+		if err := flushTable(p, table.name, table.keySize); err != nil {
+			return errors.Wrapf(err, "unable to flush the approvers of `%s`", table.name)
+		}
+	}
+
+	table := p.Table("open_flags_approvers")
+	return table.Set(ebpf.ZeroUint32TableItem, ebpf.ZeroUint32TableItem)
+}
+
+// enableProcessLineage enables the exec, fork and exit events used to maintain the process cache resolving the
+// lineage of the processes
+func (p *Probe) enableProcessLineage() error {
+	table := p.Table("process_lineage")
+	if err := table.Set(ebpf.ZeroUint32TableItem, ebpf.Uint32TableItem(1)); err != nil {
+		return errors.Wrap(err, "unable to enable the process lineage tracking")
+	}
+	return nil
+}
+
+// ApplyRuleSet setups the filters, the approvers and the kprobes required by the given ruleset, and returns a
+// report of them. The approvers and the discarders of a previous ruleset are flushed, as they may no longer be
+// valid. The kprobes only required by the event types of a previous ruleset are unregistered, and the in-kernel
+// filters of these event types discard all their events, as they can share kprobes with the other event types.
+func (p *Probe) ApplyRuleSet(rs *rules.RuleSet) (*Report, error) {
+	if err := p.FlushApprovers(); err != nil {
+		return nil, err
+	}
+
+	p.requiredKProbes = make(map[*ebpf.KProbe]bool)
+	report, err := NewRuleSetApplier(p.config).Apply(rs, p)
+	if err != nil {
+		return nil, err
+	}
+
+	if !p.config.ProcessLineage && usesProcessLineage(rs) {
+		log.Warn("The rules using the `process.ancestors` fields won't match, as the lineage of the processes isn't tracked")
+	}
+
+	for eventType, tableName := range allPolicyTables {
+		if !rs.HasRulesForEventType(eventType) {
+			if err := p.ApplyFilterPolicy(eventType, tableName, PolicyModeDeny, 0); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for kprobe := range p.kprobes {
+		if p.requiredKProbes[kprobe] {
+			continue
+		}
+		if err := p.Module.UnregisterKprobe(kprobe); err != nil {
+			log.Errorf("failed to unregister kProbe `%s`: %s", kprobe.Name, err)
+			continue
+		}
+		delete(p.kprobes, kprobe)
+		log.Infof("kProbe `%s` unregistered", kprobe.Name)
+	}
+
+	if err := p.FlushDiscarders(); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
+
 // Snapshot runs the different snapshot functions of the resolvers that
 // require to sync with the current state of the system
 func (p *Probe) Snapshot() error {
@@ -460,6 +600,9 @@ func NewProbe(config *config.Config) (*Probe, error) {
 		config:           config,
 		onDiscardersFncs: make(map[eval.EventType][]onDiscarderFnc),
 		tables:           make(map[string]*ebpf.Table),
+		kprobes:          make(map[*ebpf.KProbe]bool),
+		tracepoints:      make(map[string]bool),
+		requiredKProbes:  make(map[*ebpf.KProbe]bool),
 	}
 
 	p.Probe = &ebpf.Probe{
@@ -0,0 +1,185 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/DataDog/gopsutil/process"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+)
+
+// maxProcessAncestors is the maximum number of ancestors resolved for a process
+const maxProcessAncestors = 64
+
+// ProcessCacheEntry holds the context of a process kept in the user space process cache
+type ProcessCacheEntry struct {
+	Pid      uint32
+	PPid     uint32
+	Comm     string
+	Filename string
+	UID      uint32
+	GID      uint32
+
+	// Parent is kept once the parent exited, so that the lineage of its children can still be resolved
+	Parent *ProcessCacheEntry
+}
+
+// usesProcessLineage returns whether a rule of the ruleset uses the ancestors of the processes
+func usesProcessLineage(rs *rules.RuleSet) bool {
+	for _, field := range rs.GetFields() {
+		if strings.HasPrefix(field, "process.ancestors.") {
+			return true
+		}
+	}
+	return false
+}
+
+// ProcessResolver resolves the ancestors of the processes from a cache seeded from /proc
+// and maintained from the fork, exec and exit events
+type ProcessResolver struct {
+	sync.RWMutex
+	entries map[uint32]*ProcessCacheEntry
+}
+
+// AddForkEntry adds the entry of a process forked by the process of the given event. The child
+// runs the binary of its parent until it executes a new one.
+func (pr *ProcessResolver) AddForkEntry(event *Event, resolvers *Resolvers) {
+	pr.Lock()
+	defer pr.Unlock()
+
+	parent := pr.getOrCreateEntry(&event.Process, resolvers)
+
+	pr.entries[event.Fork.Pid] = &ProcessCacheEntry{
+		Pid:      event.Fork.Pid,
+		PPid:     parent.Pid,
+		Comm:     parent.Comm,
+		Filename: parent.Filename,
+		UID:      parent.UID,
+		GID:      parent.GID,
+		Parent:   parent,
+	}
+}
+
+// AddExecEntry updates the entry of a process that executed a new binary
+func (pr *ProcessResolver) AddExecEntry(event *Event, resolvers *Resolvers) {
+	pr.Lock()
+	defer pr.Unlock()
+
+	entry := pr.getOrCreateEntry(&event.Process, resolvers)
+	entry.Filename = event.Exec.ResolveInode(resolvers)
+	entry.UID = event.Process.UID
+	entry.GID = event.Process.GID
+
+	// the comm of the process is only updated later during the exec, the
+	// kernel sets it to the basename of the binary truncated to 15 characters
+	if entry.Filename != "" {
+		comm := path.Base(entry.Filename)
+		if len(comm) > 15 {
+			comm = comm[:15]
+		}
+		entry.Comm = comm
+	}
+}
+
+// UpdateEntry refreshes the context of the cached process of an event, such as its
+// credentials, that may have changed since it was executed
+func (pr *ProcessResolver) UpdateEntry(p *ProcessEvent) {
+	pr.Lock()
+	defer pr.Unlock()
+
+	if entry, found := pr.entries[p.Pid]; found {
+		entry.Comm = p.GetComm()
+		entry.UID = p.UID
+		entry.GID = p.GID
+	}
+}
+
+// DeleteEntry removes the entry of an exited process or thread
+func (pr *ProcessResolver) DeleteEntry(pid uint32) {
+	pr.Lock()
+	defer pr.Unlock()
+
+	delete(pr.entries, pid)
+}
+
+// GetAncestors returns the ancestors of a process, from its parent to the oldest known ancestor
+func (pr *ProcessResolver) GetAncestors(pid uint32) []*ProcessCacheEntry {
+	pr.RLock()
+	defer pr.RUnlock()
+
+	entry, found := pr.entries[pid]
+	if !found {
+		return nil
+	}
+
+	var ancestors []*ProcessCacheEntry
+	for ancestor := entry.Parent; ancestor != nil && len(ancestors) < maxProcessAncestors; ancestor = ancestor.Parent {
+		ancestors = append(ancestors, ancestor)
+	}
+	return ancestors
+}
+
+// SyncCache adds the processes listed from /proc that are not already in the cache and links them to their parent
+func (pr *ProcessResolver) SyncCache(processes map[int32]*process.FilledProcess) {
+	pr.Lock()
+	defer pr.Unlock()
+
+	for _, p := range processes {
+		pid := uint32(p.Pid)
+		if _, found := pr.entries[pid]; found {
+			continue
+		}
+
+		entry := &ProcessCacheEntry{
+			Pid:      pid,
+			PPid:     uint32(p.Ppid),
+			Comm:     p.Name,
+			Filename: p.Exe,
+		}
+		if len(p.Uids) > 0 {
+			entry.UID = uint32(p.Uids[0])
+		}
+		if len(p.Gids) > 0 {
+			entry.GID = uint32(p.Gids[0])
+		}
+		pr.entries[pid] = entry
+	}
+
+	for _, entry := range pr.entries {
+		if entry.Parent == nil && entry.PPid != 0 {
+			entry.Parent = pr.entries[entry.PPid]
+		}
+	}
+}
+
+// getOrCreateEntry returns the entry of the process of an event, created from the context of the event when missing
+func (pr *ProcessResolver) getOrCreateEntry(p *ProcessEvent, resolvers *Resolvers) *ProcessCacheEntry {
+	entry, found := pr.entries[p.Pid]
+	if !found {
+		entry = &ProcessCacheEntry{
+			Pid:      p.Pid,
+			Comm:     p.GetComm(),
+			Filename: p.ResolveInode(resolvers),
+			UID:      p.UID,
+			GID:      p.GID,
+		}
+		pr.entries[p.Pid] = entry
+	}
+	return entry
+}
+
+// NewProcessResolver returns a new process resolver
+func NewProcessResolver() *ProcessResolver {
+	return &ProcessResolver{
+		entries: make(map[uint32]*ProcessCacheEntry),
+	}
+}
@@ -0,0 +1,104 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// +build linux
+
+package probe
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/DataDog/gopsutil/process"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/DataDog/datadog-agent/pkg/security/rules"
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+func ancestorsPids(ancestors []*ProcessCacheEntry) []uint32 {
+	var pids []uint32
+	for _, ancestor := range ancestors {
+		pids = append(pids, ancestor.Pid)
+	}
+	return pids
+}
+
+func TestProcessResolverLineage(t *testing.T) {
+	pr := NewProcessResolver()
+
+	pr.SyncCache(map[int32]*process.FilledProcess{
+		1:   {Pid: 1, Name: "systemd", Exe: "/usr/lib/systemd/systemd"},
+		100: {Pid: 100, Ppid: 1, Name: "sshd", Exe: "/usr/sbin/sshd"},
+		200: {Pid: 200, Ppid: 100, Name: "bash", Exe: "/usr/bin/bash", Uids: []int32{1000}},
+	})
+
+	assert.Equal(t, []uint32{100, 1}, ancestorsPids(pr.GetAncestors(200)))
+
+	// bash forks a child that executes a new binary
+	event := NewEvent(nil)
+	event.Process = ProcessEvent{Pid: 200, Comm: "bash", UID: 1000}
+	event.Fork.Pid = 300
+	pr.AddForkEntry(event, nil)
+
+	event = NewEvent(nil)
+	event.Process = ProcessEvent{Pid: 300, Comm: "bash", UID: 1000}
+	event.Exec.PathnameStr = "/usr/bin/a-binary-with-a-long-name"
+	pr.AddExecEntry(event, nil)
+
+	assert.Equal(t, []uint32{200, 100, 1}, ancestorsPids(pr.GetAncestors(300)))
+
+	pr.RLock()
+	child := pr.entries[300]
+	pr.RUnlock()
+	assert.Equal(t, uint32(200), child.PPid)
+	assert.Equal(t, "/usr/bin/a-binary-with-a-long-name", child.Filename)
+	assert.Equal(t, "a-binary-with-a", child.Comm)
+	assert.Equal(t, uint32(1000), child.UID)
+
+	// the lineage of the child is kept once its parent exited
+	pr.DeleteEntry(200)
+	assert.Equal(t, []uint32{200, 100, 1}, ancestorsPids(pr.GetAncestors(300)))
+
+	pr.DeleteEntry(300)
+	assert.Empty(t, pr.GetAncestors(300))
+}
+
+func TestProcessResolverUnknownParent(t *testing.T) {
+	pr := NewProcessResolver()
+
+	// the parent was not in the cache, it is created from the context of the fork event
+	event := NewEvent(nil)
+	event.Process = ProcessEvent{Pid: 400, Comm: "cron", UID: 0}
+	event.Fork.Pid = 401
+	pr.AddForkEntry(event, nil)
+
+	ancestors := pr.GetAncestors(401)
+	if assert.Len(t, ancestors, 1) {
+		assert.Equal(t, uint32(400), ancestors[0].Pid)
+		assert.Equal(t, "cron", ancestors[0].Comm)
+	}
+
+	assert.Empty(t, pr.GetAncestors(400))
+	assert.Nil(t, pr.GetAncestors(999))
+}
+
+func TestUsesProcessLineage(t *testing.T) {
+	newRuleSet := func(expressions ...string) *rules.RuleSet {
+		rs := rules.NewRuleSet(&Model{}, func() eval.Event { return NewEvent(nil) }, rules.NewOptsWithParams(false, SECLConstants, nil))
+		for i, expression := range expressions {
+			if _, err := rs.AddRule(&rules.RuleDefinition{ID: fmt.Sprintf("rule_%d", i), Expression: expression}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return rs
+	}
+
+	assert.False(t, usesProcessLineage(newRuleSet(`open.filename == "/etc/shadow" && process.name == "cat"`)))
+	assert.True(t, usesProcessLineage(newRuleSet(
+		`open.filename == "/etc/shadow"`,
+		`open.filename == "/etc/passwd" && process.ancestors.name == "nginx"`,
+	)))
+}
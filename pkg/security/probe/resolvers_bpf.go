@@ -60,6 +60,7 @@ type Resolvers struct {
 	MountResolver     *MountResolver
 	ContainerResolver *ContainerResolver
 	TimeResolver      *TimeResolver
+	ProcessResolver   *ProcessResolver
 }
 
 // Start the resolvers
@@ -131,6 +132,11 @@ func (r *Resolvers) snapshot(retry int) error {
 		return err
 	}
 
+	// Seed the user space process cache used to resolve the ancestors of the processes
+	if r.probe.config.ProcessLineage {
+		r.ProcessResolver.SyncCache(processes)
+	}
+
 	cacheModified := false
 
 	for _, p := range processes {
@@ -0,0 +1,147 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// ListID represents the ID of a list
+type ListID = string
+
+// ListDefinition holds the definition of a list. The values of the list are either declared
+// inline or loaded from a file, one value per line.
+type ListDefinition struct {
+	ID     ListID        `yaml:"id"`
+	Values []interface{} `yaml:"values"`
+	File   string        `yaml:"file"`
+}
+
+// AddLists adds the lists to the ruleset
+func (rs *RuleSet) AddLists(lists []*ListDefinition) error {
+	var result *multierror.Error
+
+	for _, listDef := range lists {
+		if _, err := rs.AddList(listDef); err != nil {
+			result = multierror.Append(result, err)
+		}
+	}
+
+	return result.ErrorOrNil()
+}
+
+// AddList adds a list to the ruleset, so that macros and rules can reference it by its ID
+func (rs *RuleSet) AddList(listDef *ListDefinition) (*eval.List, error) {
+	if _, exists := rs.opts.Lists[listDef.ID]; exists {
+		return nil, fmt.Errorf("found multiple definition of the list '%s'", listDef.ID)
+	}
+	if _, exists := rs.opts.Macros[listDef.ID]; exists {
+		return nil, fmt.Errorf("found a macro with the same ID as the list '%s'", listDef.ID)
+	}
+
+	list, err := eval.NewList(listDef.ID, listDef.Values)
+	if err != nil {
+		return nil, err
+	}
+
+	rs.opts.Lists[list.ID] = list
+	rs.listDefinitions[list.ID] = listDef
+
+	return list, nil
+}
+
+// GetListDefinitions returns the definitions of the lists of the ruleset
+func (rs *RuleSet) GetListDefinitions() []*ListDefinition {
+	var lists []*ListDefinition
+	for _, listDef := range rs.listDefinitions {
+		lists = append(lists, listDef)
+	}
+	return lists
+}
+
+// UpdateList replaces the values of a list. The evaluators of the macros and the rules referencing the
+// list are generated again, along with their partials, so that the approvers of the ruleset reflect the
+// new values. It returns whether the values of the list changed.
+func (rs *RuleSet) UpdateList(id ListID, values []interface{}) (bool, error) {
+	list, exists := rs.opts.Lists[id]
+	if !exists {
+		return false, fmt.Errorf("list '%s' not found", id)
+	}
+
+	updated, err := eval.NewList(id, values)
+	if err != nil {
+		return false, err
+	}
+
+	previous := list.GetValues()
+	if reflect.DeepEqual(previous, updated.GetValues()) {
+		return false, nil
+	}
+
+	if err := list.SetValues(values); err != nil {
+		return false, err
+	}
+
+	if err := rs.regenerateListEvaluators(id); err != nil {
+		// restore the previous values so that the ruleset stays consistent
+		if restoreErr := list.SetValues(previous); restoreErr == nil {
+			if restoreErr = rs.regenerateListEvaluators(id); restoreErr != nil {
+				err = multierror.Append(err, restoreErr)
+			}
+		}
+		return false, errors.Wrapf(err, "couldn't update the list %s", id)
+	}
+
+	rs.listDefinitions[id].Values = values
+
+	return true, nil
+}
+
+// regenerateListEvaluators generates again the evaluators of the macros and the rules referencing the given list
+func (rs *RuleSet) regenerateListEvaluators(id ListID) error {
+	// macros are generated in the order they were added, as a macro can reference a previous one
+	for _, macroID := range rs.macroIDs {
+		macro := rs.opts.Macros[macroID]
+		if !containsList(macro.GetEvaluator().Lists, id) {
+			continue
+		}
+
+		if err := macro.GenEvaluator(rs.model, &rs.opts.Opts); err != nil {
+			return errors.Wrapf(err, "couldn't generate an evaluation of the macro %s", macroID)
+		}
+	}
+
+	for _, rule := range rs.rules {
+		if !containsList(rule.GetEvaluator().Lists, id) {
+			continue
+		}
+
+		if err := rule.GenEvaluator(rs.model, &rs.opts.Opts); err != nil {
+			return errors.Wrapf(err, "couldn't generate an evaluation of the rule %s", rule.ID)
+		}
+
+		if err := rule.GenPartials(); err != nil {
+			return errors.Wrapf(err, "couldn't generate the partials of the rule %s", rule.ID)
+		}
+	}
+
+	return nil
+}
+
+func containsList(lists []ListID, id ListID) bool {
+	for _, list := range lists {
+		if list == id {
+			return true
+		}
+	}
+	return false
+}
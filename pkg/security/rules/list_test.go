@@ -0,0 +1,136 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+func newTestListRuleSet(t *testing.T) *RuleSet {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(true, testConstants, nil))
+
+	if err := rs.AddLists([]*ListDefinition{
+		{ID: "sensitive_files", Values: []interface{}{"/etc/passwd", "/etc/shadow"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rs.AddMacros([]*MacroDefinition{
+		{ID: "sensitive_open", Expression: `open.filename in sensitive_files`},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	addRuleExpr(t, rs,
+		`open.filename in sensitive_files && process.uid == 0`,
+		`sensitive_open && process.gid == 0`,
+	)
+
+	return rs
+}
+
+func TestListApprovers(t *testing.T) {
+	rs := newTestListRuleSet(t)
+
+	caps := FieldCapabilities{
+		{
+			Field: "open.filename",
+			Types: eval.ScalarValueType,
+		},
+	}
+
+	approvers, err := rs.GetApprovers("open", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := approvers["open.filename"]; len(values) != 2 {
+		t.Fatalf("expected approvers not found: %v", values)
+	}
+
+	updated, err := rs.UpdateList("sensitive_files", []interface{}{"/etc/shadow", "/etc/passwd", "/etc/gshadow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !updated {
+		t.Fatal("the list should have been updated")
+	}
+
+	approvers, err = rs.GetApprovers("open", caps)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := approvers["open.filename"]; len(values) != 3 {
+		t.Fatalf("expected the approvers to reflect the new values of the list: %v", values)
+	}
+
+	// same values in a different order
+	updated, err = rs.UpdateList("sensitive_files", []interface{}{"/etc/gshadow", "/etc/passwd", "/etc/shadow"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated {
+		t.Error("the list shouldn't have been updated")
+	}
+}
+
+func TestListEvaluate(t *testing.T) {
+	rs := newTestListRuleSet(t)
+
+	handler := &testSequenceHandler{}
+	rs.AddListener(handler)
+
+	event := &testEvent{
+		kind: "open",
+		process: testProcess{
+			uid: 1,
+		},
+		open: testOpen{
+			filename: "/etc/gshadow",
+		},
+	}
+
+	rs.Evaluate(event)
+	if len(handler.rules) != 0 {
+		t.Fatalf("unexpected match: %v", handler.rules)
+	}
+
+	if _, err := rs.UpdateList("sensitive_files", []interface{}{"/etc/gshadow"}); err != nil {
+		t.Fatal(err)
+	}
+
+	rs.Evaluate(event)
+	if len(handler.rules) != 1 {
+		t.Fatalf("expected a match of the macro with the new values of the list, got %v", handler.rules)
+	}
+}
+
+func TestListInvalid(t *testing.T) {
+	rs := newTestListRuleSet(t)
+
+	if _, err := rs.AddList(&ListDefinition{ID: "sensitive_files"}); err == nil {
+		t.Error("a list shouldn't be defined twice")
+	}
+
+	if _, err := rs.AddList(&ListDefinition{ID: "sensitive_open"}); err == nil {
+		t.Error("a list shouldn't have the ID of a macro")
+	}
+
+	if _, err := rs.UpdateList("unknown", []interface{}{"/etc/passwd"}); err == nil {
+		t.Error("an unknown list shouldn't be updated")
+	}
+
+	// the rules compare the list to string fields
+	if _, err := rs.UpdateList("sensitive_files", []interface{}{0, 1}); err == nil {
+		t.Error("the type of the values of a list shouldn't change")
+	}
+
+	values := rs.opts.Lists["sensitive_files"].GetValues()
+	if len(values) != 2 || values[0] != "/etc/passwd" {
+		t.Errorf("expected the previous values to be restored: %v", values)
+	}
+}
@@ -7,6 +7,7 @@ package rules
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
@@ -36,8 +37,12 @@ type RuleDefinition struct {
 
 // GetTags returns the tags associated to a rule
 func (rd *RuleDefinition) GetTags() []string {
+	return tagsFromMap(rd.Tags)
+}
+
+func tagsFromMap(m map[string]string) []string {
 	tags := []string{}
-	for k, v := range rd.Tags {
+	for k, v := range m {
 		tags = append(
 			tags,
 			fmt.Sprintf("%s:%s", k, v))
@@ -84,6 +89,7 @@ func NewOptsWithParams(debug bool, constants map[string]interface{}, invalidDisc
 			Debug:     debug,
 			Constants: constants,
 			Macros:    make(map[eval.MacroID]*eval.Macro),
+			Lists:     make(map[eval.ListID]*eval.List),
 		},
 		InvalidDiscarders: invalidDiscarders,
 	}
@@ -101,13 +107,26 @@ type RuleSet struct {
 	// fields holds the list of event field queries (like "process.uid") used by the entire set of rules
 	fields            []string
 	invalidDiscarders map[eval.Field]map[interface{}]bool
+	// sequences holds the sequences of the ruleset, sequenceSteps the sequence step of each rule generated for them
+	sequences     map[eval.RuleID]*Sequence
+	sequenceSteps map[eval.RuleID]*sequenceStep
+	// now returns the time at which an event is evaluated, used for the sequence windows
+	now func() time.Time
+	// macroIDs holds the IDs of the macros in the order they were added, listDefinitions the definitions of the lists
+	macroIDs        []eval.MacroID
+	listDefinitions map[ListID]*ListDefinition
 }
 
-// ListRuleIDs returns the list of RuleIDs from the ruleset
+// ListRuleIDs returns the list of RuleIDs from the ruleset, including the sequences but not their steps
 func (rs *RuleSet) ListRuleIDs() []string {
 	var ids []string
 	for ruleID := range rs.rules {
-		ids = append(ids, ruleID)
+		if _, isStep := rs.sequenceSteps[ruleID]; !isStep {
+			ids = append(ids, ruleID)
+		}
+	}
+	for seqID := range rs.sequences {
+		ids = append(ids, seqID)
 	}
 	return ids
 }
@@ -123,7 +142,7 @@ func (rs *RuleSet) AddMacros(macros []*MacroDefinition) error {
 		}
 	}
 
-	return result
+	return result.ErrorOrNil()
 }
 
 // AddMacro parses the macro AST and adds it to the list of macros of the ruleset
@@ -131,6 +150,9 @@ func (rs *RuleSet) AddMacro(macroDef *MacroDefinition) (*eval.Macro, error) {
 	if _, exists := rs.opts.Macros[macroDef.ID]; exists {
 		return nil, fmt.Errorf("found multiple definition of the macro '%s'", macroDef.ID)
 	}
+	if _, exists := rs.opts.Lists[macroDef.ID]; exists {
+		return nil, fmt.Errorf("found a list with the same ID as the macro '%s'", macroDef.ID)
+	}
 
 	macro := &eval.Macro{
 		ID:         macroDef.ID,
@@ -146,6 +168,7 @@ func (rs *RuleSet) AddMacro(macroDef *MacroDefinition) (*eval.Macro, error) {
 	}
 
 	rs.opts.Macros[macro.ID] = macro
+	rs.macroIDs = append(rs.macroIDs, macro.ID)
 
 	return macro, nil
 }
@@ -172,6 +195,9 @@ func (rs *RuleSet) AddRule(ruleDef *RuleDefinition) (*eval.Rule, error) {
 	if _, exists := rs.rules[ruleDef.ID]; exists {
 		return nil, fmt.Errorf("found multiple definition of the rule '%s'", ruleDef.ID)
 	}
+	if _, exists := rs.sequences[ruleDef.ID]; exists {
+		return nil, fmt.Errorf("found a sequence with the same ID as the rule '%s'", ruleDef.ID)
+	}
 
 	rule := &eval.Rule{
 		ID:         ruleDef.ID,
@@ -186,7 +212,7 @@ func (rs *RuleSet) AddRule(ruleDef *RuleDefinition) (*eval.Rule, error) {
 		return nil, err
 	}
 
-	for _, event := range rule.GetEvaluator().EventTypes {
+	for _, event := range rule.GetEventTypes() {
 		bucket, exists := rs.eventRuleBuckets[event]
 		if !exists {
 			bucket = &RuleBucket{}
@@ -321,12 +347,38 @@ func (rs *RuleSet) Evaluate(event eval.Event) bool {
 	}
 	log.Tracef("Evaluating event of type `%s` against set of %d rules", eventType, len(bucket.rules))
 
+	var matchedSteps map[*Sequence]map[int]bool
 	for _, rule := range bucket.rules {
 		if rule.GetEvaluator().Eval(ctx) {
+			result = true
+
+			if step, isStep := rs.sequenceSteps[rule.ID]; isStep {
+				log.Tracef("Step %d of sequence `%s` matches with event `%s`", step.index, step.sequence.rule.ID, event)
+
+				if matchedSteps == nil {
+					matchedSteps = make(map[*Sequence]map[int]bool)
+				}
+				if matchedSteps[step.sequence] == nil {
+					matchedSteps[step.sequence] = make(map[int]bool)
+				}
+				matchedSteps[step.sequence][step.index] = true
+				continue
+			}
+
 			log.Infof("Rule `%s` matches with event `%s`\n", rule.ID, event)
 
 			rs.NotifyRuleMatch(rule, event)
-			result = true
+		}
+	}
+
+	if len(matchedSteps) > 0 {
+		now := rs.now()
+		for sequence, steps := range matchedSteps {
+			if seqEvent := sequence.process(ctx, event, steps, now); seqEvent != nil {
+				log.Infof("Sequence `%s` matches with key `%s`", sequence.rule.ID, seqEvent.Key)
+
+				rs.NotifyRuleMatch(sequence.rule, seqEvent)
+			}
 		}
 	}
 
@@ -374,6 +426,11 @@ func (rs *RuleSet) GetEventTypes() []eval.EventType {
 	return eventTypes
 }
 
+// GetFields returns the fields used by the rules of the ruleset
+func (rs *RuleSet) GetFields() []eval.Field {
+	return rs.fields
+}
+
 // AddFields merges the provided set of fields with the existing set of fields of the ruleset
 func (rs *RuleSet) AddFields(fields []eval.EventType) {
 NewFields:
@@ -411,5 +468,9 @@ func NewRuleSet(model eval.Model, eventCtor func() eval.Event, opts *Opts) *Rule
 		eventRuleBuckets:  make(map[eval.EventType]*RuleBucket),
 		rules:             make(map[eval.RuleID]*eval.Rule),
 		invalidDiscarders: opts.getInvalidDiscarders(),
+		sequences:         make(map[eval.RuleID]*Sequence),
+		sequenceSteps:     make(map[eval.RuleID]*sequenceStep),
+		now:               time.Now,
+		listDefinitions:   make(map[ListID]*ListDefinition),
 	}
 }
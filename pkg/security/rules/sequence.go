@@ -0,0 +1,222 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/golang-lru/simplelru"
+	"github.com/pkg/errors"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+// DefaultSequenceMaxKeys is the default number of correlation keys for which a sequence keeps a partial match
+const DefaultSequenceMaxKeys = 1000
+
+// SequenceStepDefinition holds the definition of a step of a sequence
+type SequenceStepDefinition struct {
+	Expression string `yaml:"expression"`
+}
+
+// SequenceDefinition holds the definition of a sequence. A sequence matches when events matching
+// each of its steps, in order, share the same value of the key field, within the time window
+type SequenceDefinition struct {
+	ID      RuleID                    `yaml:"id"`
+	Steps   []*SequenceStepDefinition `yaml:"steps"`
+	Key     eval.Field                `yaml:"key"`
+	Window  time.Duration             `yaml:"window"`
+	MaxKeys int                       `yaml:"max_keys"`
+	Tags    map[string]string         `yaml:"tags"`
+}
+
+// GetTags returns the tags associated to a sequence
+func (sd *SequenceDefinition) GetTags() []string {
+	return tagsFromMap(sd.Tags)
+}
+
+// SequenceEvent is the event sent to the ruleset listeners when a sequence matches. It behaves
+// like the event of the last step, and holds the events of all the steps
+type SequenceEvent struct {
+	eval.Event
+	Key    string
+	Events []eval.Event
+}
+
+// MarshalJSON returns the JSON encoding of the events of the sequence
+func (se *SequenceEvent) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Key    string       `json:"key"`
+		Events []eval.Event `json:"events"`
+	}{
+		Key:    se.Key,
+		Events: se.Events,
+	})
+}
+
+// sequenceState holds the events of a partial match of a sequence
+type sequenceState struct {
+	start  time.Time
+	events []eval.Event
+}
+
+// Sequence holds the steps of a sequence and the partial matches of each of its keys
+type Sequence struct {
+	rule    *eval.Rule
+	steps   []*eval.Rule
+	keyEval eval.Evaluator
+	window  time.Duration
+	states  *simplelru.LRU
+}
+
+// GetRule returns the rule notified to the listeners when the sequence matches
+func (s *Sequence) GetRule() *eval.Rule {
+	return s.rule
+}
+
+func newSequence(seqDef *SequenceDefinition, model eval.Model) (*Sequence, error) {
+	if len(seqDef.Steps) < 2 {
+		return nil, errors.New("a sequence requires at least 2 steps")
+	}
+
+	if seqDef.Window <= 0 {
+		return nil, errors.New("a sequence requires a positive window")
+	}
+
+	keyEval, err := model.GetEvaluator(seqDef.Key)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid sequence key `%s`", seqDef.Key)
+	}
+
+	maxKeys := seqDef.MaxKeys
+	if maxKeys <= 0 {
+		maxKeys = DefaultSequenceMaxKeys
+	}
+
+	states, err := simplelru.NewLRU(maxKeys, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var expressions []string
+	for _, step := range seqDef.Steps {
+		expressions = append(expressions, step.Expression)
+	}
+
+	return &Sequence{
+		rule: &eval.Rule{
+			ID:         seqDef.ID,
+			Expression: strings.Join(expressions, " ; "),
+			Tags:       seqDef.GetTags(),
+		},
+		keyEval: keyEval,
+		window:  seqDef.Window,
+		states:  states,
+	}, nil
+}
+
+// process updates the partial match of the key of the event with the steps it matched. It returns
+// the event to notify when the event completes the sequence.
+func (s *Sequence) process(ctx *eval.Context, event eval.Event, matched map[int]bool, now time.Time) *SequenceEvent {
+	key := fmt.Sprintf("%v", s.keyEval.Eval(ctx))
+
+	var state *sequenceState
+	if entry, found := s.states.Get(key); found {
+		state = entry.(*sequenceState)
+		if now.Sub(state.start) > s.window {
+			s.states.Remove(key)
+			state = nil
+		}
+	}
+
+	if state == nil {
+		if matched[0] {
+			s.states.Add(key, &sequenceState{start: now, events: []eval.Event{event}})
+		}
+		return nil
+	}
+
+	if !matched[len(state.events)] {
+		return nil
+	}
+
+	state.events = append(state.events, event)
+	if len(state.events) < len(s.steps) {
+		return nil
+	}
+
+	s.states.Remove(key)
+
+	return &SequenceEvent{
+		Event:  event,
+		Key:    key,
+		Events: state.events,
+	}
+}
+
+// sequenceStep identifies the step of a sequence a rule was generated for
+type sequenceStep struct {
+	sequence *Sequence
+	index    int
+}
+
+func sequenceStepID(seqID RuleID, index int) RuleID {
+	return fmt.Sprintf("%s_step%d", seqID, index)
+}
+
+// AddSequences adds sequences to the ruleset and generate the partials of their steps
+func (rs *RuleSet) AddSequences(sequences []*SequenceDefinition) error {
+	var result *multierror.Error
+
+	for _, seqDef := range sequences {
+		if _, err := rs.AddSequence(seqDef); err != nil {
+			result = multierror.Append(result, errors.Wrapf(err, "couldn't add sequence %s to the ruleset", seqDef.ID))
+		}
+	}
+
+	if err := rs.generatePartials(); err != nil {
+		result = multierror.Append(result, errors.Wrap(err, "couldn't generate partials"))
+	}
+
+	return result.ErrorOrNil()
+}
+
+// AddSequence adds the steps of a sequence to the buckets of their events. A step never notifies
+// the listeners itself, the sequence does once all its steps matched
+func (rs *RuleSet) AddSequence(seqDef *SequenceDefinition) (*Sequence, error) {
+	if _, exists := rs.sequences[seqDef.ID]; exists {
+		return nil, fmt.Errorf("found multiple definition of the sequence '%s'", seqDef.ID)
+	}
+	if _, exists := rs.rules[seqDef.ID]; exists {
+		return nil, fmt.Errorf("found a rule with the same ID as the sequence '%s'", seqDef.ID)
+	}
+
+	sequence, err := newSequence(seqDef, rs.model)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, stepDef := range seqDef.Steps {
+		rule, err := rs.AddRule(&RuleDefinition{
+			ID:         sequenceStepID(seqDef.ID, i),
+			Expression: stepDef.Expression,
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid step %d", i)
+		}
+
+		sequence.steps = append(sequence.steps, rule)
+		rs.sequenceSteps[rule.ID] = &sequenceStep{sequence: sequence, index: i}
+	}
+
+	rs.sequences[seqDef.ID] = sequence
+
+	return sequence, nil
+}
@@ -0,0 +1,165 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/eval"
+)
+
+type testSequenceHandler struct {
+	matches []*SequenceEvent
+	rules   []*eval.Rule
+}
+
+func (h *testSequenceHandler) RuleMatch(rule *eval.Rule, event eval.Event) {
+	h.rules = append(h.rules, rule)
+	if seqEvent, ok := event.(*SequenceEvent); ok {
+		h.matches = append(h.matches, seqEvent)
+	}
+}
+
+func (h *testSequenceHandler) EventDiscarderFound(rs *RuleSet, event eval.Event, field string) {
+}
+
+func newTestSequenceRuleSet(t *testing.T, maxKeys int) (*RuleSet, *testSequenceHandler, *time.Time) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(true, testConstants, nil))
+
+	now := time.Now()
+	rs.now = func() time.Time { return now }
+
+	handler := &testSequenceHandler{}
+	rs.AddListener(handler)
+
+	seqDef := &SequenceDefinition{
+		ID: "shadow_then_mkdir",
+		Steps: []*SequenceStepDefinition{
+			{Expression: `open.filename == "/etc/shadow"`},
+			{Expression: `mkdir.filename == "/tmp/.hidden"`},
+		},
+		Key:     "process.name",
+		Window:  10 * time.Second,
+		MaxKeys: maxKeys,
+		Tags:    map[string]string{"severity": "high"},
+	}
+
+	if err := rs.AddSequences([]*SequenceDefinition{seqDef}); err != nil {
+		t.Fatal(err)
+	}
+
+	return rs, handler, &now
+}
+
+func newOpenEvent(process, filename string) *testEvent {
+	return &testEvent{
+		kind:    "open",
+		process: testProcess{name: process},
+		open:    testOpen{filename: filename},
+	}
+}
+
+func newMkdirEvent(process, filename string) *testEvent {
+	return &testEvent{
+		kind:    "mkdir",
+		process: testProcess{name: process},
+		mkdir:   testMkdir{filename: filename},
+	}
+}
+
+func TestSequenceMatch(t *testing.T) {
+	rs, handler, _ := newTestSequenceRuleSet(t, 0)
+
+	// out of order
+	rs.Evaluate(newMkdirEvent("bash", "/tmp/.hidden"))
+	if len(handler.rules) != 0 {
+		t.Fatalf("a step shouldn't match on its own: %v", handler.rules)
+	}
+
+	open := newOpenEvent("bash", "/etc/shadow")
+	mkdir := newMkdirEvent("bash", "/tmp/.hidden")
+
+	rs.Evaluate(open)
+	rs.Evaluate(newMkdirEvent("zsh", "/tmp/.hidden"))
+	if len(handler.matches) != 0 {
+		t.Fatal("a sequence shouldn't match events of different keys")
+	}
+
+	rs.Evaluate(mkdir)
+	if len(handler.matches) != 1 {
+		t.Fatalf("expected a sequence match, got %d", len(handler.matches))
+	}
+
+	if rule := handler.rules[0]; rule.ID != "shadow_then_mkdir" || len(rule.Tags) != 1 || rule.Tags[0] != "severity:high" {
+		t.Errorf("unexpected rule: %+v", rule)
+	}
+
+	match := handler.matches[0]
+	if match.Key != "bash" || len(match.Events) != 2 || match.Events[0] != open || match.Events[1] != mkdir {
+		t.Errorf("unexpected sequence event: %+v", match)
+	}
+	if match.GetType() != "mkdir" {
+		t.Errorf("expected the type of the last step, got %s", match.GetType())
+	}
+
+	// the state of the key is reset once the sequence matched
+	rs.Evaluate(newMkdirEvent("bash", "/tmp/.hidden"))
+	if len(handler.matches) != 1 {
+		t.Error("a sequence shouldn't match twice on the same events")
+	}
+
+	var hasStep bool
+	for _, id := range rs.ListRuleIDs() {
+		if id != "shadow_then_mkdir" {
+			hasStep = true
+		}
+	}
+	if hasStep {
+		t.Errorf("steps shouldn't be listed as rules: %v", rs.ListRuleIDs())
+	}
+}
+
+func TestSequenceWindow(t *testing.T) {
+	rs, handler, now := newTestSequenceRuleSet(t, 0)
+
+	rs.Evaluate(newOpenEvent("bash", "/etc/shadow"))
+	*now = now.Add(11 * time.Second)
+	rs.Evaluate(newMkdirEvent("bash", "/tmp/.hidden"))
+
+	if len(handler.matches) != 0 {
+		t.Fatal("a sequence shouldn't match outside of its window")
+	}
+}
+
+func TestSequenceMaxKeys(t *testing.T) {
+	rs, handler, _ := newTestSequenceRuleSet(t, 1)
+
+	rs.Evaluate(newOpenEvent("bash", "/etc/shadow"))
+	rs.Evaluate(newOpenEvent("zsh", "/etc/shadow"))
+
+	// the state of bash was evicted by the one of zsh
+	rs.Evaluate(newMkdirEvent("bash", "/tmp/.hidden"))
+	rs.Evaluate(newMkdirEvent("zsh", "/tmp/.hidden"))
+
+	if len(handler.matches) != 1 || handler.matches[0].Key != "zsh" {
+		t.Fatalf("expected a single match for zsh, got %+v", handler.matches)
+	}
+}
+
+func TestSequenceInvalid(t *testing.T) {
+	rs := NewRuleSet(&testModel{}, func() eval.Event { return &testEvent{} }, NewOptsWithParams(true, testConstants, nil))
+
+	for _, seqDef := range []*SequenceDefinition{
+		{ID: "one_step", Key: "process.name", Window: time.Second, Steps: []*SequenceStepDefinition{{Expression: `open.filename == "/etc/shadow"`}}},
+		{ID: "no_window", Key: "process.name", Steps: []*SequenceStepDefinition{{Expression: `open.filename == "/etc/shadow"`}, {Expression: `mkdir.mode == 0`}}},
+		{ID: "bad_key", Key: "process.unknown", Window: time.Second, Steps: []*SequenceStepDefinition{{Expression: `open.filename == "/etc/shadow"`}, {Expression: `mkdir.mode == 0`}}},
+	} {
+		if _, err := rs.AddSequence(seqDef); err == nil {
+			t.Errorf("sequence %s should be invalid", seqDef.ID)
+		}
+	}
+}
@@ -98,6 +98,8 @@ func (d *Marshaler) getID(n interface{}) (string, error) {
 		return fmt.Sprintf("Unary%d", n.Pos.Offset), nil
 	case *ast.Primary:
 		return fmt.Sprintf("Primary%d", n.Pos.Offset), nil
+	case *ast.Function:
+		return fmt.Sprintf("Function%d", n.Pos.Offset), nil
 	case *node:
 		return n.id, nil
 	default:
@@ -188,6 +190,9 @@ func (d *Marshaler) getChildren(n interface{}) ([]interface{}, error) {
 		}
 		return children, nil
 	case *ast.Primary:
+		if n.Function != nil {
+			return []interface{}{n.Function}, nil
+		}
 		if n.Ident != nil {
 			return []interface{}{newNode(fmt.Sprintf("Ident%p", n.Ident), fmt.Sprintf("Ident\\n%s", *n.Ident))}, nil
 		}
@@ -201,6 +206,12 @@ func (d *Marshaler) getChildren(n interface{}) ([]interface{}, error) {
 			return []interface{}{n.SubExpression}, nil
 		}
 		return nil, fmt.Errorf("empty ast.Primary")
+	case *ast.Function:
+		children := []interface{}{newNode(fmt.Sprintf("Name%p", n), fmt.Sprintf("Name\\n%s", n.Name))}
+		for _, arg := range n.Args {
+			children = append(children, arg)
+		}
+		return children, nil
 	case *node:
 		return nil, nil
 	default:
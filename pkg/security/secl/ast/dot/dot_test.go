@@ -37,3 +37,16 @@ func TestDotWriterInArray(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestDotWriterFunction(t *testing.T) {
+	rule, err := ast.ParseRule(`has_prefix(lower(process.name), "sh")`)
+	if err != nil {
+		t.Error(err)
+	}
+
+	dotMarshaller := NewMarshaler(os.Stdout)
+
+	if err := dotMarshaller.MarshalRule(rule); err != nil {
+		t.Error(err)
+	}
+}
@@ -148,12 +148,21 @@ type Unary struct {
 type Primary struct {
 	Pos lexer.Position
 
-	Ident         *string     `parser:"@Ident"`
+	Function      *Function   `parser:"@@"`
+	Ident         *string     `parser:"| @Ident"`
 	Number        *int        `parser:"| @Int"`
 	String        *string     `parser:"| @String"`
 	SubExpression *Expression `parser:"| \"(\" @@ \")\""`
 }
 
+// Function describes a call to a builtin function, like lower(process.name)
+type Function struct {
+	Pos lexer.Position
+
+	Name string        `parser:"@Ident \"(\""`
+	Args []*Expression `parser:"[ @@ { \",\" @@ } ] \")\""`
+}
+
 // Array describes an array of values
 type Array struct {
 	Pos lexer.Position
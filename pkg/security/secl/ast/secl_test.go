@@ -124,3 +124,21 @@ func TestMacroExpression(t *testing.T) {
 
 	print(t, macro)
 }
+
+func TestFunction(t *testing.T) {
+	rule, err := ParseRule(`has_prefix(lower(process.name), "sh") && length(open.filename) > 3`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	print(t, rule)
+
+	primary := rule.BooleanExpression.Expression.Comparison.BitOperation.Unary.Primary
+	if primary.Function == nil || primary.Function.Name != "has_prefix" || len(primary.Function.Args) != 2 {
+		t.Fatalf("expected a call to has_prefix with 2 arguments")
+	}
+
+	if _, err := ParseRule(`lower(process.name`); err == nil {
+		t.Error("an unterminated function call should not be valid")
+	}
+}
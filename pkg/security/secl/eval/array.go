@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"reflect"
+
+	"github.com/alecthomas/participle/lexer"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/ast"
+)
+
+// StringArrayEvaluator returns an array of strings as result of the evaluation
+type StringArrayEvaluator struct {
+	EvalFnc func(ctx *Context) []string
+	Field   Field
+
+	isPartial bool
+}
+
+// Eval returns the result of the evaluation
+func (s *StringArrayEvaluator) Eval(ctx *Context) interface{} {
+	return s.EvalFnc(ctx)
+}
+
+// IntArrayEvaluator returns an array of ints as result of the evaluation
+type IntArrayEvaluator struct {
+	EvalFnc func(ctx *Context) []int
+	Field   Field
+
+	isPartial bool
+}
+
+// Eval returns the result of the evaluation
+func (i *IntArrayEvaluator) Eval(ctx *Context) interface{} {
+	return i.EvalFnc(ctx)
+}
+
+// positiveOperator returns the operator negated by the given one, along with whether it was negated
+func positiveOperator(op string) (string, bool) {
+	switch op {
+	case "!=":
+		return "==", true
+	case "!~":
+		return "=~", true
+	case "notin":
+		return "in", true
+	}
+	return op, false
+}
+
+// anyValue generates the evaluator of a comparison of the values of an array field. The comparison is generated
+// once, with an evaluator returning the value being iterated, selected by `set`, and is true if it is true for at
+// least one value. The result is inverted for negated operators, so that `!=` is true if no value is equal.
+func anyValue(cmp *BoolEvaluator, load func(ctx *Context) int, set func(i int), not bool, state *state) *BoolEvaluator {
+	ec := cmp.EvalFnc
+	if ec == nil {
+		value := cmp.Value
+		ec = func(ctx *Context) bool {
+			return value
+		}
+	}
+
+	evalFnc := func(ctx *Context) bool {
+		for i, n := 0, load(ctx); i < n; i++ {
+			set(i)
+			if ec(ctx) {
+				return !not
+			}
+		}
+		return not
+	}
+
+	if state.field != "" && cmp.isPartial {
+		evalFnc = func(ctx *Context) bool {
+			return true
+		}
+	}
+
+	return &BoolEvaluator{
+		EvalFnc:   evalFnc,
+		isPartial: cmp.isPartial,
+	}
+}
+
+// stringArrayComparison generates the evaluator of the comparison of a string array field
+func stringArrayComparison(a *StringArrayEvaluator, op string, next interface{}, pos lexer.Position, opts *Opts, state *state) (*BoolEvaluator, error) {
+	// the comparison is evaluated for one value of the array at a time, the events
+	// of a ruleset being evaluated sequentially
+	var values []string
+	var current string
+
+	value := &StringEvaluator{
+		EvalFnc: func(ctx *Context) string {
+			return current
+		},
+		Field:     a.Field,
+		isPartial: a.isPartial,
+	}
+
+	op, not := positiveOperator(op)
+
+	var cmp *BoolEvaluator
+	var err error
+
+	switch op {
+	case "==", "=~":
+		nextString, ok := next.(*StringEvaluator)
+		if !ok {
+			return nil, NewTypeError(pos, reflect.String)
+		}
+
+		if op == "==" {
+			cmp, err = StringEquals(value, nextString, opts, state)
+		} else if cmp, err = StringMatches(value, nextString, false, opts, state); err != nil {
+			return nil, NewOpError(pos, op, err)
+		}
+	case "in":
+		nextStringArray, ok := next.(*StringArray)
+		if !ok {
+			return nil, NewTypeError(pos, reflect.Array)
+		}
+
+		cmp, err = StringArrayContains(value, nextStringArray, false, opts, state)
+	default:
+		return nil, NewOpUnknownError(pos, op)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	ea := a.EvalFnc
+
+	return anyValue(cmp, func(ctx *Context) int {
+		values = ea(ctx)
+		return len(values)
+	}, func(i int) {
+		current = values[i]
+	}, not, state), nil
+}
+
+// intArrayComparison generates the evaluator of the comparison of an int array field
+func intArrayComparison(a *IntArrayEvaluator, op string, next interface{}, pos lexer.Position, opts *Opts, state *state) (*BoolEvaluator, error) {
+	var values []int
+	var current int
+
+	value := &IntEvaluator{
+		EvalFnc: func(ctx *Context) int {
+			return current
+		},
+		Field:     a.Field,
+		isPartial: a.isPartial,
+	}
+
+	op, not := positiveOperator(op)
+
+	var cmp *BoolEvaluator
+	var err error
+
+	if op == "in" {
+		nextIntArray, ok := next.(*IntArray)
+		if !ok {
+			return nil, NewTypeError(pos, reflect.Array)
+		}
+
+		cmp, err = IntArrayContains(value, nextIntArray, false, opts, state)
+	} else {
+		nextInt, ok := next.(*IntEvaluator)
+		if !ok {
+			return nil, NewTypeError(pos, reflect.Int)
+		}
+
+		switch op {
+		case "==":
+			cmp, err = IntEquals(value, nextInt, opts, state)
+		case "<":
+			cmp, err = LesserThan(value, nextInt, opts, state)
+		case "<=":
+			cmp, err = LesserOrEqualThan(value, nextInt, opts, state)
+		case ">":
+			cmp, err = GreaterThan(value, nextInt, opts, state)
+		case ">=":
+			cmp, err = GreaterOrEqualThan(value, nextInt, opts, state)
+		default:
+			return nil, NewOpUnknownError(pos, op)
+		}
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	ea := a.EvalFnc
+
+	return anyValue(cmp, func(ctx *Context) int {
+		values = ea(ctx)
+		return len(values)
+	}, func(i int) {
+		current = values[i]
+	}, not, state), nil
+}
+
+// arrayFieldComparison generates the evaluator of a comparison whose left operand is an array field
+func arrayFieldComparison(obj *ast.Comparison, unary interface{}, opts *Opts, state *state) (interface{}, interface{}, lexer.Position, error) {
+	var op string
+	var next interface{}
+	var pos lexer.Position
+	var err error
+
+	switch {
+	case obj.ArrayComparison != nil:
+		op = *obj.ArrayComparison.Op
+		next, _, pos, err = nodeToEvaluator(obj.ArrayComparison, opts, state)
+	case obj.ScalarComparison != nil:
+		op = *obj.ScalarComparison.Op
+		next, _, pos, err = nodeToEvaluator(obj.ScalarComparison, opts, state)
+	default:
+		return nil, nil, obj.Pos, NewTypeError(obj.Pos, reflect.Bool)
+	}
+
+	if err != nil {
+		return nil, nil, pos, err
+	}
+
+	var boolEvaluator *BoolEvaluator
+	switch unary := unary.(type) {
+	case *StringArrayEvaluator:
+		boolEvaluator, err = stringArrayComparison(unary, op, next, obj.Pos, opts, state)
+	case *IntArrayEvaluator:
+		boolEvaluator, err = intArrayComparison(unary, op, next, obj.Pos, opts, state)
+	}
+
+	if err != nil {
+		return nil, nil, obj.Pos, err
+	}
+	return boolEvaluator, nil, obj.Pos, nil
+}
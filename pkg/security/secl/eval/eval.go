@@ -46,6 +46,7 @@ type Opts struct {
 	Debug     bool
 	Constants map[string]interface{}
 	Macros    map[MacroID]*Macro
+	Lists     map[ListID]*List
 }
 
 // NewOptsWithParams initializes a new Opts instance with Debug and Constants parameters
@@ -54,6 +55,7 @@ func NewOptsWithParams(debug bool, constants map[string]interface{}) *Opts {
 		Debug:     debug,
 		Constants: constants,
 		Macros:    make(map[MacroID]*Macro),
+		Lists:     make(map[ListID]*List),
 	}
 }
 
@@ -209,6 +211,11 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 			return nil, nil, pos, err
 		}
 
+		switch unary.(type) {
+		case *StringArrayEvaluator, *IntArrayEvaluator:
+			return arrayFieldComparison(obj, unary, opts, state)
+		}
+
 		if obj.ArrayComparison != nil {
 			next, _, pos, err := nodeToEvaluator(obj.ArrayComparison, opts, state)
 			if err != nil {
@@ -388,6 +395,8 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 		return nodeToEvaluator(obj.Primary, opts, state)
 	case *ast.Primary:
 		switch {
+		case obj.Function != nil:
+			return functionToEvaluator(obj.Function, opts, state)
 		case obj.Ident != nil:
 			if accessor, ok := opts.Constants[*obj.Ident]; ok {
 				return accessor, nil, obj.Pos, nil
@@ -395,6 +404,7 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 
 			if state.macros != nil {
 				if macro, ok := state.macros[*obj.Ident]; ok {
+					state.UpdateMacros(*obj.Ident, macro)
 					return macro.Value, nil, obj.Pos, nil
 				}
 			}
@@ -432,9 +442,15 @@ func nodeToEvaluator(obj interface{}, opts *Opts, state *state) (interface{}, in
 		} else if obj.Ident != nil {
 			if state.macros != nil {
 				if macro, ok := state.macros[*obj.Ident]; ok {
+					state.UpdateMacros(*obj.Ident, macro)
 					return macro.Value, nil, obj.Pos, nil
 				}
 			}
+
+			if list, ok := opts.Lists[*obj.Ident]; ok {
+				state.UpdateLists(list.ID)
+				return list.value, nil, obj.Pos, nil
+			}
 		}
 	}
 
@@ -7,6 +7,7 @@ package eval
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 	"syscall"
 	"testing"
@@ -337,6 +338,80 @@ func TestInArray(t *testing.T) {
 	}
 }
 
+func TestArrayField(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name:           "sh",
+			ancestorsNames: []string{"bash", "nginx", "systemd"},
+			ancestorsUIDs:  []int{33, 0, 0},
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `process.ancestors.name == "nginx"`, Expected: true},
+		{Expr: `process.ancestors.name == "apache2"`, Expected: false},
+		{Expr: `process.ancestors.name != "nginx"`, Expected: false},
+		{Expr: `process.ancestors.name != "apache2"`, Expected: true},
+		{Expr: `process.ancestors.name == process.name`, Expected: false},
+		{Expr: `process.ancestors.name =~ "ngi*"`, Expected: true},
+		{Expr: `process.ancestors.name !~ "ngi*"`, Expected: false},
+		{Expr: `process.ancestors.name in [ "apache2", "nginx" ]`, Expected: true},
+		{Expr: `process.ancestors.name not in [ "apache2", "nginx" ]`, Expected: false},
+		{Expr: `process.ancestors.name not in [ "apache2", "lighttpd" ]`, Expected: true},
+		{Expr: `process.ancestors.uid == 33`, Expected: true},
+		{Expr: `process.ancestors.uid > 33`, Expected: false},
+		{Expr: `process.ancestors.uid != 1000`, Expected: true},
+		{Expr: `process.ancestors.uid in [ 1000, 33 ]`, Expected: true},
+		{Expr: `process.name == "sh" && process.ancestors.name == "nginx" && process.ancestors.uid == 0`, Expected: true},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s: %s`", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	// no ancestor
+	event.process.ancestorsNames = nil
+
+	for _, test := range []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `process.ancestors.name == "nginx"`, Expected: false},
+		{Expr: `process.ancestors.name != "nginx"`, Expected: true},
+	} {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s: %s`", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+
+	for _, expr := range []string{
+		`process.ancestors.name`,
+		`process.ancestors.name == 1`,
+		`process.ancestors.name > "nginx"`,
+		`process.ancestors.uid =~ "1*"`,
+		`process.ancestors.uid in [ "nginx" ]`,
+	} {
+		if _, _, err := eval(t, event, expr); err == nil {
+			t.Errorf("expected an error for `%s`", expr)
+		}
+	}
+}
+
 func TestComplex(t *testing.T) {
 	event := &testEvent{
 		open: testOpen{
@@ -415,6 +490,9 @@ func TestPartial(t *testing.T) {
 		{Expr: `open.filename == "test1" && process.uid == 123`, Field: "process.uid", IsDiscarder: false},
 		{Expr: `open.filename == "test1" && !process.is_root`, Field: "process.is_root", IsDiscarder: true},
 		{Expr: `open.filename == "test1" && process.is_root`, Field: "process.is_root", IsDiscarder: false},
+		{Expr: `open.filename == "test1" && process.ancestors.name == "nginx"`, Field: "open.filename", IsDiscarder: true},
+		{Expr: `open.filename == "xyz" && process.ancestors.name == "nginx"`, Field: "open.filename", IsDiscarder: false},
+		{Expr: `open.filename == "xyz" && process.ancestors.name != "nginx"`, Field: "open.filename", IsDiscarder: false},
 	}
 
 	ctx := &Context{}
@@ -521,6 +599,10 @@ func TestMacroExpression(t *testing.T) {
 	if !rule.Eval(ctx) {
 		t.Fatalf("should return true")
 	}
+
+	if macros := rule.GetEvaluator().Macros; !reflect.DeepEqual(macros, []MacroID{"is_passwd"}) {
+		t.Fatalf("should reference the is_passwd macro, got %v", macros)
+	}
 }
 
 func TestMacroPartial(t *testing.T) {
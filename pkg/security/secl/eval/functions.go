@@ -0,0 +1,281 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"reflect"
+	"strings"
+
+	"github.com/alecthomas/participle/lexer"
+
+	"github.com/DataDog/datadog-agent/pkg/security/secl/ast"
+)
+
+// builtin generates the evaluator of a call to a builtin function from the evaluators of its arguments
+type builtin func(pos lexer.Position, args []interface{}, opts *Opts, state *state) (interface{}, error)
+
+// builtins holds the functions available in SECL expressions
+var builtins = map[string]builtin{
+	"lower":         stringTransform("lower", strings.ToLower),
+	"upper":         stringTransform("upper", strings.ToUpper),
+	"basename":      stringTransform("basename", path.Base),
+	"dirname":       stringTransform("dirname", path.Dir),
+	"length":        stringLength,
+	"has_prefix":    stringPredicate("has_prefix", strings.HasPrefix, prefixPattern),
+	"has_suffix":    stringPredicate("has_suffix", strings.HasSuffix, nil),
+	"cidr_contains": cidrContains,
+}
+
+func functionToEvaluator(obj *ast.Function, opts *Opts, state *state) (interface{}, interface{}, lexer.Position, error) {
+	fnc, exists := builtins[obj.Name]
+	if !exists {
+		return nil, nil, obj.Pos, NewError(obj.Pos, fmt.Sprintf("function `%s` unknown", obj.Name))
+	}
+
+	var args []interface{}
+	for _, arg := range obj.Args {
+		eval, _, pos, err := nodeToEvaluator(arg, opts, state)
+		if err != nil {
+			return nil, nil, pos, err
+		}
+		args = append(args, eval)
+	}
+
+	eval, err := fnc(obj.Pos, args, opts, state)
+	if err != nil {
+		return nil, nil, obj.Pos, err
+	}
+
+	return eval, nil, obj.Pos, nil
+}
+
+// stringArgs checks that the function was called with n string arguments
+func stringArgs(pos lexer.Position, name string, args []interface{}, n int) ([]*StringEvaluator, error) {
+	if len(args) != n {
+		return nil, NewError(pos, fmt.Sprintf("function `%s` expects %d argument(s), got %d", name, n, len(args)))
+	}
+
+	var strs []*StringEvaluator
+	for _, arg := range args {
+		str, ok := arg.(*StringEvaluator)
+		if !ok {
+			return nil, NewTypeError(pos, reflect.String)
+		}
+		strs = append(strs, str)
+	}
+
+	return strs, nil
+}
+
+// isPartialArg returns whether the argument can't be evaluated during the partial evaluation of the current field.
+// The result of a function is not bound to the field of its argument, as the values it is compared to are not values
+// of the field, so that it never generates approvers. It is instead marked as partial when its argument is.
+func isPartialArg(a *StringEvaluator, state *state) bool {
+	return a.isPartial || (a.Field != "" && state.field != "" && a.Field != state.field)
+}
+
+// stringTransform returns a function transforming a string into another string
+func stringTransform(name string, fnc func(string) string) builtin {
+	return func(pos lexer.Position, args []interface{}, opts *Opts, state *state) (interface{}, error) {
+		strs, err := stringArgs(pos, name, args, 1)
+		if err != nil {
+			return nil, err
+		}
+		a := strs[0]
+
+		if a.EvalFnc == nil {
+			return &StringEvaluator{
+				Value:     fnc(a.Value),
+				isPartial: isPartialArg(a, state),
+			}, nil
+		}
+
+		ea := a.EvalFnc
+
+		var evalFnc func(ctx *Context) string
+		if opts.Debug {
+			evalFnc = func(ctx *Context) string {
+				ctx.evalDepth++
+				op := ea(ctx)
+				result := fnc(op)
+				ctx.Logf("Evaluating %s(%s) => %s", name, op, result)
+				ctx.evalDepth--
+				return result
+			}
+		} else {
+			evalFnc = func(ctx *Context) string {
+				return fnc(ea(ctx))
+			}
+		}
+
+		return &StringEvaluator{
+			EvalFnc:   evalFnc,
+			isPartial: isPartialArg(a, state),
+		}, nil
+	}
+}
+
+// stringLength - length(string) function
+func stringLength(pos lexer.Position, args []interface{}, opts *Opts, state *state) (interface{}, error) {
+	strs, err := stringArgs(pos, "length", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	a := strs[0]
+
+	if a.EvalFnc == nil {
+		return &IntEvaluator{
+			Value:     len(a.Value),
+			isPartial: isPartialArg(a, state),
+		}, nil
+	}
+
+	ea := a.EvalFnc
+
+	var evalFnc func(ctx *Context) int
+	if opts.Debug {
+		evalFnc = func(ctx *Context) int {
+			ctx.evalDepth++
+			op := ea(ctx)
+			result := len(op)
+			ctx.Logf("Evaluating length(%s) => %d", op, result)
+			ctx.evalDepth--
+			return result
+		}
+	} else {
+		evalFnc = func(ctx *Context) int {
+			return len(ea(ctx))
+		}
+	}
+
+	return &IntEvaluator{
+		EvalFnc:   evalFnc,
+		isPartial: isPartialArg(a, state),
+	}, nil
+}
+
+// prefixPattern registers the prefix of a field as a pattern value, as `field =~ "prefix*"` would,
+// so that approvers can still be derived from has_prefix
+func prefixPattern(a *StringEvaluator, prefix string, state *state) error {
+	if strings.Contains(prefix, "*") {
+		return nil
+	}
+	return state.UpdateFieldValues(a.Field, FieldValue{Value: prefix + "*", Type: PatternValueType})
+}
+
+// stringPredicate returns a function testing a string against another one. When the first argument is a field
+// and the second one a constant, fieldValue is called to register the values of the field it implies, if any.
+func stringPredicate(name string, fnc func(string, string) bool, fieldValue func(a *StringEvaluator, value string, state *state) error) builtin {
+	return func(pos lexer.Position, args []interface{}, opts *Opts, state *state) (interface{}, error) {
+		strs, err := stringArgs(pos, name, args, 2)
+		if err != nil {
+			return nil, err
+		}
+		a, b := strs[0], strs[1]
+
+		isPartial := isPartialArg(a, state) || isPartialArg(b, state)
+
+		if a.EvalFnc == nil && b.EvalFnc == nil {
+			return &BoolEvaluator{
+				Value:     fnc(a.Value, b.Value),
+				isPartial: isPartial,
+			}, nil
+		}
+
+		if fieldValue != nil && a.Field != "" && b.EvalFnc == nil {
+			if err := fieldValue(a, b.Value, state); err != nil {
+				return nil, NewError(pos, err.Error())
+			}
+		}
+
+		ea, eb := a.EvalFnc, b.EvalFnc
+		if ea == nil {
+			value := a.Value
+			ea = func(ctx *Context) string { return value }
+		}
+		if eb == nil {
+			value := b.Value
+			eb = func(ctx *Context) string { return value }
+		}
+
+		var evalFnc func(ctx *Context) bool
+		if opts.Debug {
+			evalFnc = func(ctx *Context) bool {
+				ctx.evalDepth++
+				op1, op2 := ea(ctx), eb(ctx)
+				result := fnc(op1, op2)
+				ctx.Logf("Evaluating %s(%s, %s) => %v", name, op1, op2, result)
+				ctx.evalDepth--
+				return result
+			}
+		} else {
+			evalFnc = func(ctx *Context) bool {
+				return fnc(ea(ctx), eb(ctx))
+			}
+		}
+
+		return &BoolEvaluator{
+			EvalFnc:   evalFnc,
+			isPartial: isPartial,
+		}, nil
+	}
+}
+
+// cidrContains - cidr_contains(cidr, ip) function. The CIDR has to be a constant
+func cidrContains(pos lexer.Position, args []interface{}, opts *Opts, state *state) (interface{}, error) {
+	strs, err := stringArgs(pos, "cidr_contains", args, 2)
+	if err != nil {
+		return nil, err
+	}
+	a, b := strs[0], strs[1]
+
+	if a.EvalFnc != nil {
+		return nil, NewError(pos, "cidr_contains expects a constant CIDR")
+	}
+
+	_, network, err := net.ParseCIDR(a.Value)
+	if err != nil {
+		return nil, NewError(pos, fmt.Sprintf("invalid CIDR `%s`", a.Value))
+	}
+
+	contains := func(s string) bool {
+		ip := net.ParseIP(s)
+		return ip != nil && network.Contains(ip)
+	}
+
+	if b.EvalFnc == nil {
+		return &BoolEvaluator{
+			Value:     contains(b.Value),
+			isPartial: isPartialArg(b, state),
+		}, nil
+	}
+
+	eb := b.EvalFnc
+
+	var evalFnc func(ctx *Context) bool
+	if opts.Debug {
+		evalFnc = func(ctx *Context) bool {
+			ctx.evalDepth++
+			op := eb(ctx)
+			result := contains(op)
+			ctx.Logf("Evaluating cidr_contains(%s, %s) => %v", network, op, result)
+			ctx.evalDepth--
+			return result
+		}
+	} else {
+		evalFnc = func(ctx *Context) bool {
+			return contains(eb(ctx))
+		}
+	}
+
+	return &BoolEvaluator{
+		EvalFnc:   evalFnc,
+		isPartial: isPartialArg(b, state),
+	}, nil
+}
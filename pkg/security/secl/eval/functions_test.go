@@ -0,0 +1,144 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"testing"
+	"unsafe"
+)
+
+func TestFunctions(t *testing.T) {
+	event := &testEvent{
+		process: testProcess{
+			name: "/usr/bin/CAT",
+			uid:  1,
+		},
+		open: testOpen{
+			filename: "10.1.2.3",
+		},
+	}
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+	}{
+		{Expr: `lower(process.name) == "/usr/bin/cat"`, Expected: true},
+		{Expr: `upper(process.name) == "/USR/BIN/CAT"`, Expected: true},
+		{Expr: `process.name == lower("/usr/bin/CAT")`, Expected: false},
+		{Expr: `basename(process.name) == "CAT"`, Expected: true},
+		{Expr: `dirname(process.name) == "/usr/bin"`, Expected: true},
+		{Expr: `lower(basename(process.name)) in [ "cat", "dog" ]`, Expected: true},
+		{Expr: `length(process.name) == 12`, Expected: true},
+		{Expr: `length(basename(process.name)) > 3`, Expected: false},
+		{Expr: `has_prefix(process.name, "/usr/")`, Expected: true},
+		{Expr: `has_prefix(process.name, "/etc/")`, Expected: false},
+		{Expr: `has_suffix(lower(process.name), "cat") && process.uid == 1`, Expected: true},
+		{Expr: `!has_suffix(process.name, "cat")`, Expected: true},
+		{Expr: `has_prefix("abc", "ab")`, Expected: true},
+		{Expr: `cidr_contains("10.0.0.0/8", open.filename)`, Expected: true},
+		{Expr: `cidr_contains("192.168.0.0/16", open.filename)`, Expected: false},
+		{Expr: `cidr_contains("10.0.0.0/8", process.name)`, Expected: false},
+	}
+
+	for _, test := range tests {
+		result, _, err := eval(t, event, test.Expr)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+	}
+}
+
+func TestFunctionErrors(t *testing.T) {
+	event := &testEvent{}
+
+	for _, expr := range []string{
+		`unknown(process.name) == "cat"`,
+		`lower(process.uid) == "cat"`,
+		`lower(process.name, "a") == "cat"`,
+		`length(process.name) == "cat"`,
+		`has_prefix(process.name) == "cat"`,
+		`cidr_contains(process.name, open.filename)`,
+		`cidr_contains("10.0.0.0", open.filename)`,
+	} {
+		if _, _, err := eval(t, event, expr); err == nil {
+			t.Errorf("expected an error for `%s`", expr)
+		}
+	}
+}
+
+func TestFunctionsPartial(t *testing.T) {
+	event := testEvent{
+		process: testProcess{
+			name: "ABC",
+		},
+		open: testOpen{
+			filename: "/etc/passwd",
+		},
+	}
+
+	tests := []struct {
+		Expr        string
+		Field       string
+		IsDiscarder bool
+	}{
+		{Expr: `lower(process.name) == "abc"`, Field: "process.name", IsDiscarder: false},
+		{Expr: `lower(process.name) == "xyz"`, Field: "process.name", IsDiscarder: true},
+		{Expr: `lower(open.filename) == "xyz" && process.name == "ABC"`, Field: "process.name", IsDiscarder: false},
+		{Expr: `lower(open.filename) == "xyz" && process.name == "XYZ"`, Field: "process.name", IsDiscarder: true},
+		{Expr: `length(open.filename) > 100 && process.name == "ABC"`, Field: "process.name", IsDiscarder: false},
+		{Expr: `length(open.filename) > 100 && process.name == "ABC"`, Field: "open.filename", IsDiscarder: true},
+		{Expr: `has_prefix(open.filename, "/etc/") && process.name == "XYZ"`, Field: "open.filename", IsDiscarder: false},
+		{Expr: `has_prefix(open.filename, "/usr/") && process.name == "XYZ"`, Field: "open.filename", IsDiscarder: true},
+		{Expr: `!has_prefix(open.filename, "/usr/") && process.name == "ABC"`, Field: "process.name", IsDiscarder: false},
+	}
+
+	ctx := &Context{}
+	ctx.SetObject(unsafe.Pointer(&event))
+
+	for _, test := range tests {
+		model := &testModel{}
+		opts := &Opts{Constants: testConstants}
+		rule, err := parseRule(test.Expr, model, opts)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+		if err := rule.GenPartials(); err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		result, err := rule.PartialEval(ctx, test.Field)
+		if err != nil {
+			t.Fatalf("error while partial evaluating `%s` for `%s`: %s", test.Expr, test.Field, err)
+		}
+
+		if !result != test.IsDiscarder {
+			t.Errorf("expected result `%t` for `%s`, got `%t`\n%s", test.IsDiscarder, test.Field, result, test.Expr)
+		}
+	}
+}
+
+func TestFunctionsFieldValues(t *testing.T) {
+	model := &testModel{}
+
+	rule, err := parseRule(`lower(process.name) == "abc" && has_prefix(open.filename, "/etc/")`, model, &Opts{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the values compared to the result of a function are not values of its field
+	if values, exists := rule.GetEvaluator().FieldValues["process.name"]; !exists || len(values) != 0 {
+		t.Errorf("expected process.name to be a field of the rule, without values: %v", values)
+	}
+
+	values := rule.GetEvaluator().FieldValues["open.filename"]
+	if len(values) != 1 || values[0].Value != "/etc/*" || values[0].Type != PatternValueType {
+		t.Errorf("expected has_prefix to register a pattern value: %v", values)
+	}
+}
@@ -0,0 +1,86 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"fmt"
+	"sort"
+)
+
+// ListID - ID of a List
+type ListID = string
+
+// List - named list of values, referenced by its `ID` as the array of an `in` comparison
+type List struct {
+	ID ListID
+
+	value interface{}
+}
+
+// NewList returns a new list holding the given values
+func NewList(id ListID, values []interface{}) (*List, error) {
+	list := &List{ID: id}
+	if err := list.SetValues(values); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// SetValues replaces the values of the list. The values have to be either all strings or all integers.
+// The evaluators of the rules referencing the list have to be generated again to use the new values.
+func (l *List) SetValues(values []interface{}) error {
+	value, err := listToArray(values)
+	if err != nil {
+		return fmt.Errorf("invalid list `%s`: %s", l.ID, err)
+	}
+	l.value = value
+	return nil
+}
+
+// GetValues returns the sorted values of the list
+func (l *List) GetValues() []interface{} {
+	var values []interface{}
+
+	switch array := l.value.(type) {
+	case *StringArray:
+		for _, value := range array.Values {
+			values = append(values, value)
+		}
+	case *IntArray:
+		for _, value := range array.Values {
+			values = append(values, value)
+		}
+	}
+
+	return values
+}
+
+func listToArray(values []interface{}) (interface{}, error) {
+	var strs []string
+	var ints []int
+
+	for _, value := range values {
+		switch value := value.(type) {
+		case string:
+			strs = append(strs, value)
+		case int:
+			ints = append(ints, value)
+		default:
+			return nil, fmt.Errorf("unsupported value `%v` of type %T", value, value)
+		}
+	}
+
+	switch {
+	case len(strs) != 0 && len(ints) != 0:
+		return nil, fmt.Errorf("mixed string and integer values")
+	case len(ints) != 0:
+		sort.Ints(ints)
+		return &IntArray{Values: ints}, nil
+	default:
+		sort.Strings(strs)
+		return &StringArray{Values: strs}, nil
+	}
+}
@@ -0,0 +1,123 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package eval
+
+import (
+	"reflect"
+	"testing"
+	"unsafe"
+)
+
+func TestList(t *testing.T) {
+	shells, err := NewList("shells", []interface{}{"zsh", "bash", "sh"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	uids, err := NewList("uids", []interface{}{0, 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	model := &testModel{}
+
+	macro := &Macro{
+		ID:         "is_shell",
+		Expression: `process.name in shells`,
+	}
+	if err := macro.Parse(); err != nil {
+		t.Fatalf("%s\n%s", err, macro.Expression)
+	}
+
+	opts := NewOptsWithParams(false, make(map[string]interface{}))
+	opts.Lists = map[string]*List{
+		"shells": shells,
+		"uids":   uids,
+	}
+	if err := macro.GenEvaluator(model, opts); err != nil {
+		t.Fatalf("%s\n%s", err, macro.Expression)
+	}
+	opts.Macros["is_shell"] = macro
+
+	event := &testEvent{
+		process: testProcess{
+			name: "bash",
+			uid:  1000,
+		},
+	}
+
+	ctx := &Context{}
+	ctx.SetObject(unsafe.Pointer(event))
+
+	tests := []struct {
+		Expr     string
+		Expected bool
+		Lists    []ListID
+	}{
+		{Expr: `process.name in shells`, Expected: true, Lists: []ListID{"shells"}},
+		{Expr: `process.name not in shells`, Expected: false, Lists: []ListID{"shells"}},
+		{Expr: `process.uid in uids`, Expected: true, Lists: []ListID{"uids"}},
+		{Expr: `is_shell && process.uid not in uids`, Expected: false, Lists: []ListID{"shells", "uids"}},
+	}
+
+	for _, test := range tests {
+		rule, err := parseRule(test.Expr, model, opts)
+		if err != nil {
+			t.Fatalf("error while evaluating `%s`: %s", test.Expr, err)
+		}
+
+		if result := rule.Eval(ctx); result != test.Expected {
+			t.Errorf("expected result `%t` not found, got `%t`\n%s", test.Expected, result, test.Expr)
+		}
+
+		if lists := rule.GetEvaluator().Lists; !reflect.DeepEqual(lists, test.Lists) {
+			t.Errorf("expected lists %v, got %v\n%s", test.Lists, lists, test.Expr)
+		}
+	}
+
+	rule, err := parseRule(`process.name in shells`, model, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := rule.GetEvaluator().FieldValues["process.name"]
+	if len(values) != 3 {
+		t.Errorf("expected the values of the list to be values of the field: %v", values)
+	}
+
+	// the evaluator has to be generated again to use the new values of the list
+	if err := shells.SetValues([]interface{}{"zsh"}); err != nil {
+		t.Fatal(err)
+	}
+	if !rule.Eval(ctx) {
+		t.Error("the evaluator shouldn't be affected by the new values of the list")
+	}
+
+	if err := rule.GenEvaluator(model, opts); err != nil {
+		t.Fatal(err)
+	}
+	if rule.Eval(ctx) {
+		t.Error("the evaluator should use the new values of the list")
+	}
+}
+
+func TestListInvalid(t *testing.T) {
+	if _, err := NewList("mixed", []interface{}{"bash", 0}); err == nil {
+		t.Error("a list shouldn't mix strings and integers")
+	}
+
+	if _, err := NewList("float", []interface{}{1.5}); err == nil {
+		t.Error("a list shouldn't accept floats")
+	}
+
+	list, err := NewList("empty", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values := list.GetValues(); len(values) != 0 {
+		t.Errorf("expected an empty list, got %v", values)
+	}
+}
@@ -28,6 +28,10 @@ type MacroEvaluator struct {
 	Value       interface{}
 	EventTypes  []EventType
 	FieldValues map[Field][]FieldValue
+	// Macros holds the IDs of the macros referenced by the macro, directly or through another macro
+	Macros []MacroID
+	// Lists holds the IDs of the lists referenced by the macro, directly or through another macro
+	Lists []ListID
 }
 
 // GetEvaluator - Returns the MacroEvaluator of the Macro corresponding to the SECL `Expression`
@@ -82,6 +86,8 @@ func macroToEvaluator(macro *ast.Macro, model Model, opts *Opts, field Field) (*
 		Value:       eval,
 		EventTypes:  events,
 		FieldValues: state.fieldValues,
+		Macros:      state.Macros(),
+		Lists:       state.Lists(),
 	}, nil
 }
 
@@ -105,8 +111,10 @@ func (m *Macro) GenEvaluator(model Model, opts *Opts) error {
 func (m *Macro) GetEventTypes() []EventType {
 	eventTypes := m.evaluator.EventTypes
 
-	for _, macro := range m.Opts.Macros {
-		eventTypes = append(eventTypes, macro.evaluator.EventTypes...)
+	for _, id := range m.evaluator.Macros {
+		if macro, exists := m.Opts.Macros[id]; exists {
+			eventTypes = append(eventTypes, macro.evaluator.EventTypes...)
+		}
 	}
 
 	return eventTypes
@@ -116,8 +124,10 @@ func (m *Macro) GetEventTypes() []EventType {
 func (m *Macro) GetFields() []Field {
 	fields := m.evaluator.GetFields()
 
-	for _, macro := range m.Opts.Macros {
-		fields = append(fields, macro.evaluator.GetFields()...)
+	for _, id := range m.evaluator.Macros {
+		if macro, exists := m.Opts.Macros[id]; exists {
+			fields = append(fields, macro.evaluator.GetFields()...)
+		}
 	}
 
 	return fields
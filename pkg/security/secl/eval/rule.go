@@ -32,6 +32,10 @@ type RuleEvaluator struct {
 	Eval        func(ctx *Context) bool
 	EventTypes  []EventType
 	FieldValues map[Field][]FieldValue
+	// Macros holds the IDs of the macros referenced by the rule
+	Macros []MacroID
+	// Lists holds the IDs of the lists referenced by the rule, directly or through a macro
+	Lists []ListID
 
 	partialEvals map[Field]func(ctx *Context) bool
 }
@@ -88,8 +92,10 @@ func (r *Rule) GetPartialEval(field Field) func(ctx *Context) bool {
 func (r *Rule) GetFields() []Field {
 	fields := r.evaluator.GetFields()
 
-	for _, macro := range r.Opts.Macros {
-		fields = append(fields, macro.GetFields()...)
+	for _, id := range r.evaluator.Macros {
+		if macro, exists := r.Opts.Macros[id]; exists {
+			fields = append(fields, macro.evaluator.GetFields()...)
+		}
 	}
 
 	return fields
@@ -104,11 +110,23 @@ func (r *Rule) GetEvaluator() *RuleEvaluator {
 func (r *Rule) GetEventTypes() []EventType {
 	eventTypes := r.evaluator.EventTypes
 
-	for _, macro := range r.Opts.Macros {
-		eventTypes = append(eventTypes, macro.GetEventTypes()...)
+	for _, id := range r.evaluator.Macros {
+		if macro, exists := r.Opts.Macros[id]; exists {
+			eventTypes = append(eventTypes, macro.evaluator.EventTypes...)
+		}
+	}
+
+	// the rule and its macros can handle the same event types
+	seen := make(map[EventType]bool)
+	var uniq []EventType
+	for _, eventType := range eventTypes {
+		if !seen[eventType] {
+			seen[eventType] = true
+			uniq = append(uniq, eventType)
+		}
 	}
 
-	return eventTypes
+	return uniq
 }
 
 // GetAst - Returns the representation of the SECL `Expression`
@@ -156,6 +174,8 @@ func ruleToEvaluator(rule *ast.Rule, model Model, opts *Opts) (*RuleEvaluator, e
 			},
 			EventTypes:  events,
 			FieldValues: state.fieldValues,
+			Macros:      state.Macros(),
+			Lists:       state.Lists(),
 		}, nil
 	}
 
@@ -163,6 +183,8 @@ func ruleToEvaluator(rule *ast.Rule, model Model, opts *Opts) (*RuleEvaluator, e
 		Eval:        evalBool.EvalFnc,
 		EventTypes:  events,
 		FieldValues: state.fieldValues,
+		Macros:      state.Macros(),
+		Lists:       state.Lists(),
 	}, nil
 }
 
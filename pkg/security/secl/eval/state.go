@@ -13,6 +13,8 @@ type state struct {
 	events      map[EventType]bool
 	fieldValues map[Field][]FieldValue
 	macros      map[MacroID]*MacroEvaluator
+	macrosUsed  map[MacroID]bool
+	listsUsed   map[ListID]bool
 }
 
 //
@@ -32,6 +34,52 @@ func (s *state) UpdateFieldValues(field Field, value FieldValue) error {
 	return s.model.ValidateField(field, value)
 }
 
+// UpdateMacros records that the expression references the given macro, along with the macros and the lists
+// the macro references. The fields and values of the macro become fields and values of the expression.
+func (s *state) UpdateMacros(id MacroID, macro *MacroEvaluator) {
+	s.macrosUsed[id] = true
+	for _, id := range macro.Macros {
+		s.macrosUsed[id] = true
+	}
+	s.UpdateLists(macro.Lists...)
+
+	for field, values := range macro.FieldValues {
+		s.UpdateFields(field)
+		s.fieldValues[field] = append(s.fieldValues[field], values...)
+	}
+}
+
+// UpdateLists records that the expression references the given lists
+func (s *state) UpdateLists(ids ...ListID) {
+	for _, id := range ids {
+		s.listsUsed[id] = true
+	}
+}
+
+// Macros returns the sorted list of the macros referenced by the expression, directly or through another macro
+func (s *state) Macros() []MacroID {
+	var macros []MacroID
+
+	for id := range s.macrosUsed {
+		macros = append(macros, id)
+	}
+	sort.Strings(macros)
+
+	return macros
+}
+
+// Lists returns the sorted list of the lists referenced by the expression, directly or through a macro
+func (s *state) Lists() []ListID {
+	var lists []ListID
+
+	for id := range s.listsUsed {
+		lists = append(lists, id)
+	}
+	sort.Strings(lists)
+
+	return lists
+}
+
 func (s *state) Events() []EventType {
 	var events []EventType
 
@@ -53,5 +101,7 @@ func newState(model Model, field Field, macros map[MacroID]*MacroEvaluator) *sta
 		model:       model,
 		events:      make(map[EventType]bool),
 		fieldValues: make(map[Field][]FieldValue),
+		macrosUsed:  make(map[MacroID]bool),
+		listsUsed:   make(map[ListID]bool),
 	}
 }
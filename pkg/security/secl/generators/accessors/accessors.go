@@ -177,17 +177,27 @@ func handleSpec(astFile *ast.File, spec interface{}, prefix, aliasPrefix, event
 								fieldAlias = aliasPrefix + "." + fieldAlias
 							}
 
-							fieldType, ok := field.Type.(*ast.Ident)
-							if ok {
+							var origType string
+							switch fieldType := field.Type.(type) {
+							case *ast.Ident:
+								origType = fieldType.Name
+							case *ast.ArrayType:
+								// array fields, compared value by value
+								if elt, ok := fieldType.Elt.(*ast.Ident); ok {
+									origType = "[]" + elt.Name
+								}
+							}
 
+							if origType != "" {
 								module.Fields[fieldAlias] = &structField{
 									Name:       fmt.Sprintf("%s.%s", prefix, fieldName),
-									BasicType:  origTypeToBasicType(fieldType.Name),
+									BasicType:  origTypeToBasicType(origType),
 									Handler:    fmt.Sprintf("%s.%s", prefix, fnc),
 									ReturnType: kind,
+									IsArray:    strings.HasPrefix(origType, "[]"),
 									Public:     true,
 									Event:      event,
-									OrigType:   fieldType.Name,
+									OrigType:   origType,
 								}
 							}
 							continue
@@ -340,6 +350,12 @@ func (m *Model) GetEvaluator(field eval.Field) (eval.Evaluator, error) {
 	{{else if eq $Field.ReturnType "bool"}}
 		return &eval.BoolEvaluator{
 			EvalFnc: func(ctx *eval.Context) bool { return {{$Return}} },
+	{{else if eq $Field.ReturnType "[]string"}}
+		return &eval.StringArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []string { return {{$Return}} },
+	{{else if eq $Field.ReturnType "[]int"}}
+		return &eval.IntArrayEvaluator{
+			EvalFnc: func(ctx *eval.Context) []int { return {{$Return}} },
 	{{end}}
 			Field: field,
 		}, nil
@@ -364,6 +380,8 @@ func (e *Event) GetFieldValue(field eval.Field) (interface{}, error) {
 			return int({{$Return}}), nil
 		{{else if eq $Field.ReturnType "bool"}}
 			return {{$Return}}, nil
+		{{else if $Field.IsArray}}
+			return {{$Return}}, nil
 		{{end}}
 		{{end}}
 		}
@@ -387,9 +405,9 @@ func (e *Event) GetFieldType(field eval.Field) (reflect.Kind, error) {
 		{{range $Name, $Field := .Fields}}
 
 		case "{{$Name}}":
-		{{if eq $Field.ReturnType "string"}}
+		{{if or (eq $Field.ReturnType "string") (eq $Field.ReturnType "[]string")}}
 			return reflect.String, nil
-		{{else if eq $Field.ReturnType "int"}}
+		{{else if or (eq $Field.ReturnType "int") (eq $Field.ReturnType "[]int")}}
 			return reflect.Int, nil
 		{{else if eq $Field.ReturnType "bool"}}
 			return reflect.Bool, nil
@@ -411,6 +429,20 @@ func (e *Event) SetFieldValue(field eval.Field, value interface{}) error {
 				return &eval.ErrValueTypeMismatch{Field: "{{$Field.Name}}"}
 			}
 			return nil
+		{{else if eq $Field.OrigType "[]string"}}
+			v, ok := value.(string)
+			if !ok {
+				return &eval.ErrValueTypeMismatch{Field: "{{$Field.Name}}"}
+			}
+			{{$FieldName}} = []string{v}
+			return nil
+		{{else if eq $Field.OrigType "[]int"}}
+			v, ok := value.(int)
+			if !ok {
+				return &eval.ErrValueTypeMismatch{Field: "{{$Field.Name}}"}
+			}
+			{{$FieldName}} = []int{v}
+			return nil
 		{{else if eq $Field.BasicType "int"}}
 			v, ok := value.(int)
 			if !ok {
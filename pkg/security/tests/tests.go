@@ -298,7 +298,7 @@ func newTestProbe(macrosDef []*rules.MacroDefinition, rulesDef []*rules.RuleDefi
 
 	ruleSet := probe.NewRuleSet(rules.NewOptsWithParams(false, sprobe.SECLConstants, sprobe.InvalidDiscarders))
 
-	if err := policy.LoadPolicies(config, ruleSet); err != nil {
+	if _, err := policy.LoadPolicies(config, ruleSet); err != nil {
 		return nil, err
 	}
 
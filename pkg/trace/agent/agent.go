@@ -20,7 +20,9 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/obfuscate"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/spanmetrics"
 	"github.com/DataDog/datadog-agent/pkg/trace/stats"
+	"github.com/DataDog/datadog-agent/pkg/trace/tap"
 	"github.com/DataDog/datadog-agent/pkg/trace/traceutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/writer"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
@@ -43,6 +45,7 @@ type Agent struct {
 	ExceptionSampler   *sampler.ExceptionSampler
 	PrioritySampler    *Sampler
 	EventProcessor     *event.Processor
+	SpanMetrics        *spanmetrics.Aggregator
 	TraceWriter        *writer.TraceWriter
 	StatsWriter        *writer.StatsWriter
 
@@ -53,6 +56,10 @@ type Agent struct {
 	In  chan *api.Payload
 	Out chan *writer.SampledSpans
 
+	// clock returns the time at which traces are sampled. When nil, the
+	// wall clock is used.
+	clock func() time.Time
+
 	// config
 	conf *config.AgentConfig
 
@@ -70,7 +77,7 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 
 	return &Agent{
 		Receiver:           api.NewHTTPReceiver(conf, dynConf, in),
-		Concentrator:       stats.NewConcentrator(conf.ExtraAggregators, conf.BucketInterval.Nanoseconds(), statsChan),
+		Concentrator:       stats.NewConcentrator(conf.ExtraAggregators, statsDimensions(conf), conf.BucketInterval.Nanoseconds(), statsChan),
 		Blacklister:        filters.NewBlacklister(conf.Ignore["resource"]),
 		Replacer:           filters.NewReplacer(conf.ReplaceTags),
 		ScoreSampler:       NewScoreSampler(conf),
@@ -78,6 +85,7 @@ func NewAgent(ctx context.Context, conf *config.AgentConfig) *Agent {
 		ErrorsScoreSampler: NewErrorsSampler(conf),
 		PrioritySampler:    NewPrioritySampler(conf, dynConf),
 		EventProcessor:     newEventProcessor(conf),
+		SpanMetrics:        spanmetrics.New(conf.SpanMetrics),
 		TraceWriter:        writer.NewTraceWriter(conf, out),
 		StatsWriter:        writer.NewStatsWriter(conf, statsChan),
 		obfuscator:         obfuscate.NewObfuscator(conf.Obfuscation),
@@ -97,6 +105,7 @@ func (a *Agent) Run() {
 		a.ErrorsScoreSampler,
 		a.PrioritySampler,
 		a.EventProcessor,
+		a.SpanMetrics,
 	} {
 		starter.Start()
 	}
@@ -141,6 +150,7 @@ func (a *Agent) loop() {
 			a.ErrorsScoreSampler.Stop()
 			a.PrioritySampler.Stop()
 			a.EventProcessor.Stop()
+			a.SpanMetrics.Stop()
 			a.obfuscator.Stop()
 			return
 		}
@@ -221,7 +231,11 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 			Sublayers:     make(map[*pb.Span][]stats.SublayerValue),
 		}
 
-		events, keep := a.sample(ts, pt)
+		// span metrics account for all the traffic, so they are computed before sampling.
+		a.SpanMetrics.Add(env, t)
+
+		events, decision := a.sample(ts, pt)
+		keep := decision.keep
 
 		subtraces := stats.ExtractSubtraces(t, root)
 		for _, subtrace := range subtraces {
@@ -231,6 +245,19 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 				stats.SetSublayersOnSpan(subtrace.Root, subtraceSublayers)
 			}
 		}
+		if a.Receiver.Tap.Active() {
+			a.Receiver.Tap.Publish(&tap.Event{
+				Time:     time.Now(),
+				Env:      pt.Env,
+				Service:  root.Service,
+				Resource: root.Resource,
+				TraceID:  root.TraceID,
+				Sampled:  decision.keep,
+				Rate:     decision.rate,
+				Sampler:  decision.sampler,
+				Spans:    t,
+			})
+		}
 		sinputs = append(sinputs, &stats.Input{
 			Trace:     pt.WeightedTrace,
 			Sublayers: pt.Sublayers,
@@ -259,9 +286,16 @@ func (a *Agent) Process(p *api.Payload, sublayerCalculator *stats.SublayerCalcul
 	}
 }
 
+// samplingDecision holds the outcome of sampling a trace.
+type samplingDecision struct {
+	keep    bool    // whether the trace is kept
+	rate    float64 // the sampling rate applied
+	sampler string  // the name of the sampler which made the decision
+}
+
 // sample decides whether the trace will be kept and extracts any APM events
 // from it.
-func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span, keep bool) {
+func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span, d samplingDecision) {
 	priority, hasPriority := sampler.GetSamplingPriority(pt.Root)
 
 	// Depending on the sampling priority, count that trace differently.
@@ -280,10 +314,10 @@ func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span,
 	atomic.AddInt64(stat, 1)
 
 	if priority < 0 {
-		return nil, false
+		return nil, samplingDecision{sampler: samplerPriority}
 	}
 
-	sampled, rate := a.runSamplers(pt, hasPriority)
+	sampled, rate, name := a.runSamplers(pt, hasPriority)
 	if sampled {
 		sampler.AddGlobalRate(pt.Root, rate)
 	}
@@ -293,40 +327,62 @@ func (a *Agent) sample(ts *info.TagStats, pt ProcessedTrace) (events []*pb.Span,
 	atomic.AddInt64(&ts.EventsExtracted, int64(numExtracted))
 	atomic.AddInt64(&ts.EventsSampled, int64(len(events)))
 
-	return events, sampled
+	return events, samplingDecision{keep: sampled, rate: rate, sampler: name}
 }
 
+// Names of the samplers, as reported by runSamplers.
+const (
+	samplerPriority  = "priority"
+	samplerErrors    = "errors"
+	samplerException = "exception"
+	samplerScore     = "score"
+)
+
 // runSamplers runs all the agent's samplers on pt and returns the sampling decision
-// along with the sampling rate.
-func (a *Agent) runSamplers(pt ProcessedTrace, hasPriority bool) (bool, float64) {
+// along with the sampling rate and the name of the sampler which made the decision.
+func (a *Agent) runSamplers(pt ProcessedTrace, hasPriority bool) (bool, float64, string) {
 	if hasPriority {
 		return a.samplePriorityTrace(pt)
 	}
 	return a.sampleNoPriorityTrace(pt)
 }
 
+// now returns the time at which traces are sampled.
+func (a *Agent) now() time.Time {
+	if a.clock != nil {
+		return a.clock()
+	}
+	return time.Now()
+}
+
 // samplePriorityTrace samples traces with priority set on them. PrioritySampler and
 // ErrorSampler are run in parallel. The ExceptionSampler catches traces with rare top-level
 // or measured spans that are not caught by PrioritySampler and ErrorSampler.
-func (a *Agent) samplePriorityTrace(pt ProcessedTrace) (sampled bool, rate float64) {
+func (a *Agent) samplePriorityTrace(pt ProcessedTrace) (sampled bool, rate float64, name string) {
 	sampledPriority, ratePriority := a.PrioritySampler.Add(pt)
 	if traceContainsError(pt.Trace) {
 		sampledError, rateError := a.ErrorsScoreSampler.Add(pt)
-		return sampledError || sampledPriority, sampler.CombineRates(ratePriority, rateError)
+		name = samplerPriority
+		if sampledError && !sampledPriority {
+			name = samplerErrors
+		}
+		return sampledError || sampledPriority, sampler.CombineRates(ratePriority, rateError), name
 	}
-	if sampled := a.ExceptionSampler.Add(pt.Env, pt.Root, pt.Trace); sampled {
-		return sampled, 1
+	if sampled := a.ExceptionSampler.AddAt(a.now(), pt.Env, pt.Root, pt.Trace); sampled {
+		return sampled, 1, samplerException
 	}
-	return sampledPriority, ratePriority
+	return sampledPriority, ratePriority, samplerPriority
 }
 
 // sampleNoPriorityTrace samples traces with no priority set on them. The traces
 // get sampled by either the score sampler or the error sampler if they have an error.
-func (a *Agent) sampleNoPriorityTrace(pt ProcessedTrace) (sampled bool, rate float64) {
+func (a *Agent) sampleNoPriorityTrace(pt ProcessedTrace) (sampled bool, rate float64, name string) {
 	if traceContainsError(pt.Trace) {
-		return a.ErrorsScoreSampler.Add(pt)
+		sampled, rate = a.ErrorsScoreSampler.Add(pt)
+		return sampled, rate, samplerErrors
 	}
-	return a.ScoreSampler.Add(pt)
+	sampled, rate = a.ScoreSampler.Add(pt)
+	return sampled, rate, samplerScore
 }
 
 func traceContainsError(trace pb.Trace) bool {
@@ -338,6 +394,18 @@ func traceContainsError(trace pb.Trace) bool {
 	return false
 }
 
+// statsDimensions returns the optional stats dimensions enabled in conf.
+func statsDimensions(conf *config.AgentConfig) stats.Dimensions {
+	if conf.StatsDimensions == nil {
+		return stats.Dimensions{}
+	}
+	return stats.Dimensions{
+		StatusClass:        conf.StatsDimensions.HTTPStatusClass,
+		PeerService:        conf.StatsDimensions.PeerService,
+		MaxPeersPerService: conf.StatsDimensions.MaxPeersPerService,
+	}
+}
+
 func newEventProcessor(conf *config.AgentConfig) *event.Processor {
 	extractors := []event.Extractor{
 		event.NewMetricBasedExtractor(),
@@ -412,13 +412,15 @@ func TestSampling(t *testing.T) {
 		// scoreSampled, scoreErrorSampled, prioritySampled are the sample decisions of the mock samplers
 		scoreSampled, scoreErrorSampled, prioritySampled bool
 
-		// wantRate and wantSampled are the expected result
+		// wantRate, wantSampled and wantSampler are the expected result
 		wantRate    float64
 		wantSampled bool
+		wantSampler string
 	}{
 		"score-rate": {
-			scoreRate: 0.5,
-			wantRate:  0.5,
+			scoreRate:   0.5,
+			wantRate:    0.5,
+			wantSampler: samplerScore,
 		},
 		"error-priority": {
 			hasErrors:      true,
@@ -426,47 +428,56 @@ func TestSampling(t *testing.T) {
 			scoreErrorRate: 0.8,
 			priorityRate:   0.2,
 			wantRate:       sampler.CombineRates(0.8, 0.2),
+			wantSampler:    samplerPriority,
 		},
 		"score-unsampled": {
 			scoreSampled: false,
 			wantSampled:  false,
+			wantSampler:  samplerScore,
 		},
 		"score-sampled": {
 			scoreSampled: true,
 			wantSampled:  true,
+			wantSampler:  samplerScore,
 		},
 		"prio-unsampled": {
 			hasPriority:     true,
 			scoreSampled:    true,
 			prioritySampled: false,
 			wantSampled:     false,
+			wantSampler:     samplerPriority,
 		},
 		"prio-sampled": {
 			hasPriority:     true,
 			prioritySampled: true,
 			wantSampled:     true,
+			wantSampler:     samplerPriority,
 		},
 		"score-prio-sampled": {
 			hasPriority:     true,
 			scoreSampled:    true,
 			prioritySampled: true,
 			wantSampled:     true,
+			wantSampler:     samplerPriority,
 		},
 		"score-prio-unsampled": {
 			hasPriority:     true,
 			scoreSampled:    false,
 			prioritySampled: false,
 			wantSampled:     false,
+			wantSampler:     samplerPriority,
 		},
 		"error-unsampled": {
 			hasErrors:         true,
 			scoreErrorSampled: false,
 			wantSampled:       false,
+			wantSampler:       samplerErrors,
 		},
 		"error-sampled": {
 			hasErrors:         true,
 			scoreErrorSampled: true,
 			wantSampled:       true,
+			wantSampler:       samplerErrors,
 		},
 		"error-sampled-prio-unsampled": {
 			hasErrors:         true,
@@ -474,6 +485,7 @@ func TestSampling(t *testing.T) {
 			scoreErrorSampled: true,
 			prioritySampled:   false,
 			wantSampled:       true,
+			wantSampler:       samplerErrors,
 		},
 		"error-unsampled-prio-sampled": {
 			hasErrors:         true,
@@ -481,6 +493,7 @@ func TestSampling(t *testing.T) {
 			scoreErrorSampled: false,
 			prioritySampled:   true,
 			wantSampled:       true,
+			wantSampler:       samplerPriority,
 		},
 		"error-prio-sampled": {
 			hasErrors:         true,
@@ -488,6 +501,7 @@ func TestSampling(t *testing.T) {
 			scoreErrorSampled: true,
 			prioritySampled:   true,
 			wantSampled:       true,
+			wantSampler:       samplerPriority,
 		},
 		"error-prio-unsampled": {
 			hasErrors:         true,
@@ -495,6 +509,7 @@ func TestSampling(t *testing.T) {
 			scoreErrorSampled: false,
 			prioritySampled:   false,
 			wantSampled:       false,
+			wantSampler:       samplerPriority,
 		},
 	} {
 		t.Run(name, func(t *testing.T) {
@@ -518,9 +533,10 @@ func TestSampling(t *testing.T) {
 				sampler.SetSamplingPriority(pt.Root, 1)
 			}
 
-			sampled, rate := a.runSamplers(pt, tt.hasPriority)
+			sampled, rate, name := a.runSamplers(pt, tt.hasPriority)
 			assert.EqualValues(t, tt.wantRate, rate)
 			assert.EqualValues(t, tt.wantSampled, sampled)
+			assert.Equal(t, tt.wantSampler, name)
 		})
 	}
 }
@@ -0,0 +1,82 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/tap"
+)
+
+// runCommand runs the sub-command named by args[0] against the given
+// configuration. It reports false if there is no such command.
+func runCommand(ctx context.Context, cfg *config.AgentConfig, args []string) (bool, error) {
+	if len(args) == 0 {
+		return false, nil
+	}
+	switch args[0] {
+	case "tap":
+		return true, runTap(ctx, cfg, args[1:])
+	case "replay":
+		return true, runReplay(ctx, cfg, args[1:])
+	}
+	return false, nil
+}
+
+// runTap streams the traces processed by the running trace-agent to stdout.
+func runTap(ctx context.Context, cfg *config.AgentConfig, args []string) error {
+	fs := flag.NewFlagSet("tap", flag.ExitOnError)
+	service := fs.String("service", "", "Only show traces having this root service")
+	rate := fs.Float64("rate", tap.DefaultRate, fmt.Sprintf("Maximum number of traces per second (at most %d)", tap.MaxRate))
+	asJSON := fs.Bool("json", false, "Print the full traces as JSON lines")
+	fs.Parse(args)
+
+	if err := apiutil.SetAuthToken(); err != nil {
+		return fmt.Errorf("unable to read the auth token: %v", err)
+	}
+	addr := fmt.Sprintf("http://%s:%d/debug/traces/tap", cfg.ReceiverHost, cfg.ReceiverPort)
+	fmt.Fprintf(os.Stderr, "Streaming traces from %s, press Ctrl-C to stop.\n", addr)
+	opts := tap.Options{Service: *service, Rate: *rate}
+	return tap.Stream(ctx, addr, apiutil.GetAuthToken(), opts, func(e *tap.Event) error {
+		if *asJSON {
+			return json.NewEncoder(os.Stdout).Encode(e)
+		}
+		return printTapEvent(os.Stdout, e)
+	})
+}
+
+// runReplay replays a capture file through the agent and prints what would have
+// been sent to the intake.
+func runReplay(ctx context.Context, cfg *config.AgentConfig, args []string) error {
+	fs := flag.NewFlagSet("replay", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print the full payloads as JSON lines")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: trace-agent replay [-json] <capture file>")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("a capture file is required")
+	}
+	return replay(ctx, cfg, fs.Arg(0), os.Stdout, *asJSON)
+}
+
+// printTapEvent writes a one line summary of e to w.
+func printTapEvent(w io.Writer, e *tap.Event) error {
+	_, err := fmt.Fprintf(w, "%s env:%s service:%s trace_id:%d spans:%d sampled:%t rate:%g sampler:%s resource:%q\n",
+		e.Time.Format(time.RFC3339Nano), e.Env, e.Service, e.TraceID, len(e.Spans), e.Sampled, e.Rate, e.Sampler, e.Resource)
+	return err
+}
@@ -0,0 +1,265 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/stats"
+)
+
+// replay feeds the payloads of the capture file at path through the agent's
+// processing pipeline and writes to w what would have been sent to the intake.
+// When asJSON is true, full payloads are written as JSON lines instead of a
+// summary.
+func replay(ctx context.Context, cfg *config.AgentConfig, path string, w io.Writer, asJSON bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	intake, err := newReplayIntake(w, asJSON)
+	if err != nil {
+		return err
+	}
+	defer intake.Close()
+
+	// send everything to the local intake only
+	c := *cfg
+	c.Endpoints = []*config.Endpoint{{Host: intake.URL, APIKey: "replay"}}
+	a := NewAgent(ctx, &c)
+	// samplers are not started: they are driven by the timestamps of the
+	// replayed spans instead of the wall clock, so that replaying a capture
+	// always gives the same output.
+	var now time.Time
+	a.clock = func() time.Time { return now }
+	tickers := []interface{ Tick(time.Time) }{
+		a.ScoreSampler,
+		a.ErrorsScoreSampler,
+		a.PrioritySampler,
+		a.EventProcessor,
+	}
+	a.SpanMetrics.Start()
+	go a.TraceWriter.Run()
+	go a.StatsWriter.Run()
+
+	// the concentrator is fed synchronously, so that all stats can be flushed
+	// once the capture has been processed.
+	concentrated := make(chan struct{})
+	go func() {
+		defer close(concentrated)
+		for inputs := range a.Concentrator.In {
+			a.Concentrator.Add(inputs)
+		}
+	}()
+
+	var (
+		n, failed int
+		readErr   error
+	)
+	rd := api.NewCaptureReader(f)
+	sublayerCalculator := stats.NewSublayerCalculator()
+	for ctx.Err() == nil {
+		rec, err := rd.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			readErr = fmt.Errorf("error reading %s: %v", path, err)
+			break
+		}
+		n++
+		p, err := rec.Payload()
+		if err != nil {
+			failed++
+			fmt.Fprintf(w, "# payload %d (%s, received at %s) can not be decoded: %v\n", n, rec.Version, rec.Time, err)
+			continue
+		}
+		if t := payloadTime(p, rec.Time); t.After(now) {
+			now = t
+		}
+		for _, ticker := range tickers {
+			ticker.Tick(now)
+		}
+		a.Process(p, sublayerCalculator)
+	}
+
+	close(a.Concentrator.In)
+	<-concentrated
+	a.Concentrator.Out <- a.Concentrator.FlushAll()
+	a.TraceWriter.Stop()
+	a.StatsWriter.Stop()
+	a.ExceptionSampler.Stop()
+	a.SpanMetrics.Stop()
+	a.obfuscator.Stop()
+
+	fmt.Fprintf(w, "# replayed %d payloads (%d could not be decoded)\n", n, failed)
+	if readErr != nil {
+		return readErr
+	}
+	return ctx.Err()
+}
+
+// payloadTime returns the time at which the last span of p ended, or received
+// when p holds no spans.
+func payloadTime(p *api.Payload, received time.Time) time.Time {
+	var end int64
+	for _, t := range p.Traces {
+		for _, s := range t {
+			if e := s.Start + s.Duration; e > end {
+				end = e
+			}
+		}
+	}
+	if end == 0 {
+		return received
+	}
+	return time.Unix(0, end)
+}
+
+// replayIntake is a local HTTP server acting as the Datadog intake, which
+// writes the payloads it receives.
+type replayIntake struct {
+	URL string
+
+	srv    *http.Server
+	asJSON bool
+
+	mu sync.Mutex // guards w
+	w  io.Writer
+}
+
+// newReplayIntake starts a new intake on a random local port.
+func newReplayIntake(w io.Writer, asJSON bool) (*replayIntake, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	ri := &replayIntake{
+		URL:    "http://" + ln.Addr().String(),
+		asJSON: asJSON,
+		w:      w,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v0.2/traces", ri.handleTraces)
+	mux.HandleFunc("/api/v0.2/stats", ri.handleStats)
+	ri.srv = &http.Server{Handler: mux}
+	go ri.srv.Serve(ln)
+	return ri, nil
+}
+
+// Close stops the intake.
+func (ri *replayIntake) Close() error { return ri.srv.Close() }
+
+func (ri *replayIntake) handleTraces(w http.ResponseWriter, req *http.Request) {
+	var p pb.TracePayload
+	body, err := readGzipBody(req)
+	if err == nil {
+		err = p.Unmarshal(body)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		ri.printf("# invalid trace payload: %v\n", err)
+		return
+	}
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	if ri.asJSON {
+		ri.writeJSON("traces", &p)
+		return
+	}
+	fmt.Fprintf(ri.w, "traces payload: env:%s traces:%d events:%d\n", p.Env, len(p.Traces), len(p.Transactions))
+	for _, t := range p.Traces {
+		root := &pb.Span{}
+		if len(t.Spans) > 0 {
+			root = t.Spans[0]
+			for _, s := range t.Spans {
+				if s.ParentID == 0 {
+					root = s
+					break
+				}
+			}
+		}
+		fmt.Fprintf(ri.w, "  trace_id:%d service:%s spans:%d resource:%q\n", t.TraceID, root.Service, len(t.Spans), root.Resource)
+	}
+	for _, s := range p.Transactions {
+		fmt.Fprintf(ri.w, "  event trace_id:%d span_id:%d service:%s name:%s resource:%q\n", s.TraceID, s.SpanID, s.Service, s.Name, s.Resource)
+	}
+}
+
+func (ri *replayIntake) handleStats(w http.ResponseWriter, req *http.Request) {
+	var p stats.Payload
+	body, err := readGzipBody(req)
+	if err == nil {
+		err = json.Unmarshal(body, &p)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		ri.printf("# invalid stats payload: %v\n", err)
+		return
+	}
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	if ri.asJSON {
+		ri.writeJSON("stats", &p)
+		return
+	}
+	fmt.Fprintf(ri.w, "stats payload: env:%s buckets:%d\n", p.Env, len(p.Stats))
+	for _, b := range p.Stats {
+		keys := make([]string, 0, len(b.Counts))
+		for k := range b.Counts {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(ri.w, "  %s %g\n", k, b.Counts[k].Value)
+		}
+	}
+}
+
+func (ri *replayIntake) printf(format string, a ...interface{}) {
+	ri.mu.Lock()
+	defer ri.mu.Unlock()
+	fmt.Fprintf(ri.w, format, a...)
+}
+
+// writeJSON writes the payload p sent to the given endpoint as a JSON line.
+// Callers must guard!
+func (ri *replayIntake) writeJSON(endpoint string, p interface{}) {
+	json.NewEncoder(ri.w).Encode(struct {
+		Endpoint string      `json:"endpoint"`
+		Payload  interface{} `json:"payload"`
+	}{endpoint, p})
+}
+
+// readGzipBody returns the gunzipped body of req.
+func readGzipBody(req *http.Request) ([]byte, error) {
+	if req.Header.Get("Content-Encoding") != "gzip" {
+		return nil, errors.New("payload is not gzipped")
+	}
+	gz, err := gzip.NewReader(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	defer gz.Close()
+	return ioutil.ReadAll(gz)
+}
@@ -0,0 +1,146 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/api"
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplay(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.capture")
+
+	now := time.Now()
+	root := &pb.Span{
+		Service:  "web",
+		Name:     "http.request",
+		Resource: "SELECT * FROM users WHERE id = 42",
+		Type:     "sql",
+		TraceID:  1,
+		SpanID:   1,
+		Start:    now.UnixNano(),
+		Duration: int64(time.Millisecond),
+		Metrics:  map[string]float64{sampler.KeySamplingPriority: 2},
+	}
+	body, err := json.Marshal(pb.Traces{{root}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var capture bytes.Buffer
+	enc := json.NewEncoder(&capture)
+	enc.Encode(&api.CaptureRecord{
+		Time:    now,
+		Version: "v0.4",
+		Header:  http.Header{"Content-Type": []string{"application/json"}},
+		Body:    body,
+	})
+	enc.Encode(&api.CaptureRecord{
+		Time:    now,
+		Version: "v0.4",
+		Header:  http.Header{"Content-Type": []string{"application/json"}},
+		Body:    []byte("{invalid"),
+	})
+	if err := ioutil.WriteFile(path, capture.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.New()
+	cfg.Endpoints[0].APIKey = "test"
+	var out bytes.Buffer
+	assert.NoError(t, replay(context.Background(), cfg, path, &out, false))
+
+	got := out.String()
+	assert.Contains(t, got, "# payload 2 (v0.4")
+	assert.Contains(t, got, `trace_id:1 service:web spans:1 resource:"SELECT * FROM users WHERE id = ?"`)
+	assert.Contains(t, got, "http.request|hits|env:none,resource:SELECT * FROM users WHERE id = ?,service:web 1")
+	assert.Contains(t, got, "# replayed 2 payloads (1 could not be decoded)")
+	// the original configuration is left untouched
+	assert.Equal(t, "test", cfg.Endpoints[0].APIKey)
+}
+
+func TestReplayDeterministic(t *testing.T) {
+	dir, err := ioutil.TempDir("", "replay")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.capture")
+
+	// a minute worth of traces, spread over many sampler decay periods
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	var capture bytes.Buffer
+	enc := json.NewEncoder(&capture)
+	for i := 0; i < 60; i++ {
+		var traces pb.Traces
+		for j := 0; j < 50; j++ {
+			id := uint64(i*50 + j + 1)
+			traces = append(traces, pb.Trace{{
+				Service:  "web",
+				Name:     "http.request",
+				Resource: "GET /users",
+				TraceID:  id,
+				SpanID:   id,
+				Start:    start.Add(time.Duration(i)*time.Second + time.Duration(j)*time.Millisecond).UnixNano(),
+				Duration: int64(time.Millisecond),
+			}})
+		}
+		body, err := json.Marshal(traces)
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc.Encode(&api.CaptureRecord{
+			Time:    start.Add(time.Duration(i) * time.Second),
+			Version: "v0.4",
+			Header:  http.Header{"Content-Type": []string{"application/json"}},
+			Body:    body,
+		})
+	}
+	if err := ioutil.WriteFile(path, capture.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	sampled := func() []string {
+		cfg := config.New()
+		cfg.Endpoints[0].APIKey = "test"
+		var out bytes.Buffer
+		assert.NoError(t, replay(context.Background(), cfg, path, &out, false))
+		var ids []string
+		for _, line := range strings.Split(out.String(), "\n") {
+			if strings.HasPrefix(line, "  trace_id:") {
+				ids = append(ids, line)
+			}
+		}
+		sort.Strings(ids)
+		return ids
+	}
+	first := sampled()
+	assert.NotEmpty(t, first)
+	assert.Less(t, len(first), 3000, "score sampler should drop some of the traces")
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, first, sampled())
+	}
+}
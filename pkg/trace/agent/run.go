@@ -7,6 +7,7 @@ package agent
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"math/rand"
 	"os"
@@ -67,6 +68,13 @@ func Run(ctx context.Context) {
 		return
 	}
 
+	if ok, err := runCommand(ctx, cfg, flag.Args()); ok {
+		if err != nil {
+			osutil.Exitf("%v", err)
+		}
+		return
+	}
+
 	if err := coreconfig.SetupLogger(
 		coreconfig.LoggerName("TRACE"),
 		cfg.LogLevel,
@@ -81,6 +81,12 @@ func (s *Sampler) Add(t ProcessedTrace) (sampled bool, rate float64) {
 	return sampled, rate
 }
 
+// Tick runs the periodic work of the sampling engine due at the given time. It is used
+// in place of Start to drive the sampler from the timestamps of the traces it receives.
+func (s *Sampler) Tick(now time.Time) {
+	s.engine.Tick(now)
+}
+
 // Stop stops the sampler
 func (s *Sampler) Stop() {
 	s.exit <- struct{}{}
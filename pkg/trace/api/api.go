@@ -6,6 +6,7 @@
 package api
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"expvar"
@@ -29,6 +30,7 @@ import (
 
 	"github.com/tinylib/msgp/msgp"
 
+	apiutil "github.com/DataDog/datadog-agent/pkg/api/util"
 	mainconfig "github.com/DataDog/datadog-agent/pkg/config"
 	"github.com/DataDog/datadog-agent/pkg/tagger"
 	"github.com/DataDog/datadog-agent/pkg/tagger/collectors"
@@ -39,6 +41,7 @@ import (
 	"github.com/DataDog/datadog-agent/pkg/trace/osutil"
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
+	"github.com/DataDog/datadog-agent/pkg/trace/tap"
 	"github.com/DataDog/datadog-agent/pkg/trace/watchdog"
 	"github.com/DataDog/datadog-agent/pkg/util/log"
 )
@@ -48,11 +51,13 @@ import (
 type HTTPReceiver struct {
 	Stats       *info.ReceiverStats
 	RateLimiter *rateLimiter
+	Tap         *tap.Tap
 
 	out     chan *Payload
 	conf    *config.AgentConfig
 	dynConf *sampler.DynamicConfig
 	server  *http.Server
+	rec     *recorder // records incoming payloads, if enabled
 
 	debug               bool
 	rateLimiterResponse int // HTTP status code when refusing
@@ -70,6 +75,7 @@ func NewHTTPReceiver(conf *config.AgentConfig, dynConf *sampler.DynamicConfig, o
 	return &HTTPReceiver{
 		Stats:       info.NewReceiverStats(),
 		RateLimiter: newRateLimiter(),
+		Tap:         tap.New(),
 		out:         out,
 
 		conf:    conf,
@@ -136,6 +142,16 @@ func (r *HTTPReceiver) Start() {
 		log.Infof("Listening for traces at unix://%s", path)
 	}
 
+	if c := r.conf.Capture; c != nil && c.Path != "" {
+		rec, err := newRecorder(c)
+		if err != nil {
+			log.Errorf("Unable to record payloads to %s: %v", c.Path, err)
+		} else {
+			r.rec = rec
+			log.Infof("Recording incoming payloads to %s", c.Path)
+		}
+	}
+
 	go r.RateLimiter.Run()
 
 	go func() {
@@ -179,6 +195,30 @@ func (r *HTTPReceiver) attachDebugHandlers(mux *http.ServeMux) {
 		w.Header().Set("Access-Control-Allow-Origin", "http://127.0.0.1:"+mainconfig.Datadog.GetString("GUI_port"))
 		expvar.Handler().ServeHTTP(w, req)
 	}))
+
+	if err := apiutil.SetAuthToken(); err != nil {
+		log.Debugf("Unable to read the auth token, %s will be unavailable: %v", tapPath, err)
+	}
+	mux.Handle(tapPath, withAuth(r.Tap))
+}
+
+// tapPath is the path of the endpoint streaming processed traces.
+const tapPath = "/debug/traces/tap"
+
+// withAuth wraps h so that it is only served to requests authenticated with the
+// agent's auth token.
+func withAuth(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if apiutil.GetAuthToken() == "" {
+			http.Error(w, "auth token not available", http.StatusServiceUnavailable)
+			return
+		}
+		if err := apiutil.Validate(w, req); err != nil {
+			log.Debugf("Rejected unauthenticated request to %s: %v", req.URL.Path, err)
+			return
+		}
+		h.ServeHTTP(w, req)
+	})
 }
 
 // listenUnix returns a net.Listener listening on the given "unix" socket path.
@@ -235,6 +275,9 @@ func (r *HTTPReceiver) Stop() error {
 	}
 	r.wg.Wait()
 	close(r.out)
+	if r.rec != nil {
+		return r.rec.Close()
+	}
 	return nil
 }
 
@@ -299,14 +342,20 @@ const (
 )
 
 func (r *HTTPReceiver) tagStats(v Version, req *http.Request) *info.TagStats {
-	return r.Stats.GetTagStats(info.Tags{
-		Lang:            req.Header.Get(headerLang),
-		LangVersion:     req.Header.Get(headerLangVersion),
-		Interpreter:     req.Header.Get(headerLangInterpreter),
-		LangVendor:      req.Header.Get(headerLangInterpreterVendor),
-		TracerVersion:   req.Header.Get(headerTracerVersion),
+	return r.Stats.GetTagStats(tagsFromHeader(v, req.Header))
+}
+
+// tagsFromHeader returns the tags identifying the source of a payload received
+// at the given endpoint version with the given headers.
+func tagsFromHeader(v Version, h http.Header) info.Tags {
+	return info.Tags{
+		Lang:            h.Get(headerLang),
+		LangVersion:     h.Get(headerLangVersion),
+		Interpreter:     h.Get(headerLangInterpreter),
+		LangVendor:      h.Get(headerLangInterpreterVendor),
+		TracerVersion:   h.Get(headerTracerVersion),
 		EndpointVersion: string(v),
-	})
+	}
 }
 
 func decodeTraces(v Version, req *http.Request) (pb.Traces, error) {
@@ -366,7 +415,14 @@ func (r *HTTPReceiver) handleTraces(v Version, w http.ResponseWriter, req *http.
 		return
 	}
 
+	var raw *bytes.Buffer
+	if r.rec != nil {
+		raw = r.rec.tee(req)
+	}
 	traces, err := decodeTraces(v, req)
+	if raw != nil {
+		r.rec.record(v, req.Header, raw.Bytes())
+	}
 	if err != nil {
 		httpDecodingError(err, []string{"handler:traces", fmt.Sprintf("v:%s", v)}, w)
 		switch err {
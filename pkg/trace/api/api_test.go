@@ -789,6 +789,20 @@ func TestExpvar(t *testing.T) {
 	}
 }
 
+func TestTapRequiresAuth(t *testing.T) {
+	called := false
+	h := withAuth(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		called = true
+	}))
+	req := httptest.NewRequest(http.MethodGet, tapPath, nil)
+	req.Header.Set("Authorization", "Bearer ")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	// no auth token could be read in tests, so the endpoint is unavailable
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.False(t, called)
+}
+
 func TestWatchdog(t *testing.T) {
 	t.Run("rate-limit", func(t *testing.T) {
 		if testing.Short() {
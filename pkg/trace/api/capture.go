@@ -0,0 +1,241 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/info"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// defaultCaptureMaxFileSize is the default size above which a capture file is rotated.
+	defaultCaptureMaxFileSize = 100 * 1024 * 1024 // 100MB
+	// defaultCaptureMaxFiles is the default number of rotated capture files kept.
+	defaultCaptureMaxFiles = 5
+	// captureQueueSize is the number of records waiting to be written above which
+	// new records are dropped.
+	captureQueueSize = 1000
+)
+
+// CaptureRecord is a raw trace payload, as received by the API. Capture files
+// are made of JSON encoded records, one per line.
+type CaptureRecord struct {
+	// Time is the time at which the payload was received.
+	Time time.Time `json:"time"`
+	// Version is the version of the endpoint which received the payload (e.g. "v0.4").
+	Version Version `json:"version"`
+	// Header holds the HTTP headers of the request.
+	Header http.Header `json:"header"`
+	// Body holds the raw body of the request.
+	Body []byte `json:"body"`
+}
+
+// Payload decodes the record into a Payload, as the API would have done upon
+// receiving it. Container tags are not resolved.
+func (rec *CaptureRecord) Payload() (*Payload, error) {
+	req, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(rec.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = rec.Header
+	traces, err := decodeTraces(rec.Version, req)
+	if err != nil {
+		return nil, err
+	}
+	return &Payload{
+		Source: info.NewReceiverStats().GetTagStats(tagsFromHeader(rec.Version, rec.Header)),
+		Traces: traces,
+	}, nil
+}
+
+// CaptureReader reads records from a capture file.
+type CaptureReader struct {
+	dec *json.Decoder
+}
+
+// NewCaptureReader returns a new CaptureReader reading from r.
+func NewCaptureReader(r io.Reader) *CaptureReader {
+	return &CaptureReader{dec: json.NewDecoder(bufio.NewReader(r))}
+}
+
+// Next returns the next record. It returns io.EOF when there are no more records.
+func (r *CaptureReader) Next() (*CaptureRecord, error) {
+	var rec CaptureRecord
+	if err := r.dec.Decode(&rec); err != nil {
+		return nil, err
+	}
+	return &rec, nil
+}
+
+// recorder writes the raw payloads received by the API to a capture file. The
+// file is rotated when it grows above maxSize, keeping at most maxFiles old
+// files, suffixed with ".1" (the newest) to ".<maxFiles>" (the oldest).
+//
+// Records are written by a separate goroutine so that the API never waits on
+// the disk. When it falls behind by more than captureQueueSize records, new
+// records are dropped.
+type recorder struct {
+	path     string
+	maxSize  int64
+	maxFiles int
+
+	in   chan *CaptureRecord
+	done chan struct{}
+
+	mu     sync.RWMutex // guards closed
+	closed bool
+
+	// only accessed by the writing goroutine
+	f    *os.File
+	size int64
+}
+
+// newRecorder returns a new recorder for the given configuration.
+func newRecorder(conf *config.CaptureConfig) (*recorder, error) {
+	rec := &recorder{
+		path:     conf.Path,
+		maxSize:  conf.MaxFileSize,
+		maxFiles: conf.MaxFiles,
+		in:       make(chan *CaptureRecord, captureQueueSize),
+		done:     make(chan struct{}),
+	}
+	if rec.maxSize <= 0 {
+		rec.maxSize = defaultCaptureMaxFileSize
+	}
+	if rec.maxFiles <= 0 {
+		rec.maxFiles = defaultCaptureMaxFiles
+	}
+	if err := rec.open(); err != nil {
+		return nil, err
+	}
+	go rec.run()
+	return rec, nil
+}
+
+// open opens the capture file for appending.
+func (rec *recorder) open() error {
+	f, err := os.OpenFile(rec.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	rec.f = f
+	rec.size = fi.Size()
+	return nil
+}
+
+// rotate closes the current capture file, shifts the older ones and opens a new one.
+func (rec *recorder) rotate() error {
+	if err := rec.f.Close(); err != nil {
+		log.Debugf("Error closing capture file: %v", err)
+	}
+	for i := rec.maxFiles - 1; i > 0; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", rec.path, i), fmt.Sprintf("%s.%d", rec.path, i+1))
+	}
+	if err := os.Rename(rec.path, rec.path+".1"); err != nil {
+		return err
+	}
+	return rec.open()
+}
+
+// record queues the given payload to be written to the capture file.
+func (rec *recorder) record(v Version, header http.Header, body []byte) {
+	rec.mu.RLock()
+	defer rec.mu.RUnlock()
+	if rec.closed {
+		return
+	}
+	select {
+	case rec.in <- &CaptureRecord{Time: time.Now(), Version: v, Header: header, Body: body}:
+	default:
+		metrics.Count("datadog.trace_agent.receiver.capture_dropped", 1, nil, 1)
+		log.Debug("Capture queue is full, dropping payload.")
+	}
+}
+
+// run writes the queued records to the capture file until the recorder is closed.
+func (rec *recorder) run() {
+	defer close(rec.done)
+	for r := range rec.in {
+		rec.write(r)
+	}
+}
+
+// write writes the given record to the capture file, rotating it if needed.
+func (rec *recorder) write(r *CaptureRecord) {
+	if rec.f == nil {
+		return
+	}
+	data, err := json.Marshal(r)
+	if err != nil {
+		log.Errorf("Error encoding capture record: %v", err)
+		return
+	}
+	data = append(data, '\n')
+	if rec.size > 0 && rec.size+int64(len(data)) > rec.maxSize {
+		if err := rec.rotate(); err != nil {
+			log.Errorf("Error rotating capture file, capture stopped: %v", err)
+			rec.f = nil
+			return
+		}
+	}
+	n, err := rec.f.Write(data)
+	rec.size += int64(n)
+	if err != nil {
+		log.Errorf("Error writing capture file: %v", err)
+	}
+}
+
+// tee makes the body of req also be written to the returned buffer as it is read.
+func (rec *recorder) tee(req *http.Request) *bytes.Buffer {
+	var buf bytes.Buffer
+	if lr, ok := req.Body.(*LimitedReader); ok {
+		lr.r = teeReadCloser{Reader: io.TeeReader(lr.r, &buf), Closer: lr.r}
+	}
+	return &buf
+}
+
+// Close writes the queued records and closes the capture file.
+func (rec *recorder) Close() error {
+	rec.mu.Lock()
+	if rec.closed {
+		rec.mu.Unlock()
+		return nil
+	}
+	rec.closed = true
+	close(rec.in)
+	rec.mu.Unlock()
+
+	<-rec.done
+	if rec.f == nil {
+		return nil
+	}
+	err := rec.f.Close()
+	rec.f = nil
+	return err
+}
+
+// teeReadCloser is an io.ReadCloser reading from a tee.
+type teeReadCloser struct {
+	io.Reader
+	io.Closer
+}
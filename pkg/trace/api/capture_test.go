@@ -0,0 +1,151 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/test/testutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.capture")
+
+	conf := newTestReceiverConfig()
+	conf.Capture = &config.CaptureConfig{Path: path}
+	r := newTestReceiverFromConfig(conf)
+	r.rec, err = newRecorder(conf.Capture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	traces := pb.Traces{testutil.RandomTrace(3, 2)}
+	body := msgpTraces(t, traces)
+	req := httptest.NewRequest(http.MethodPost, "/v0.4/traces", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/msgpack")
+	req.Header.Set(headerLang, "go")
+	rec := httptest.NewRecorder()
+	r.handleWithVersion(v04, r.handleTraces)(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	<-r.out
+	assert.NoError(t, r.rec.Close())
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rd := NewCaptureReader(f)
+	cr, err := rd.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, v04, cr.Version)
+	assert.Equal(t, "go", cr.Header.Get(headerLang))
+	assert.Equal(t, body, cr.Body)
+	assert.False(t, cr.Time.IsZero())
+
+	p, err := cr.Payload()
+	assert.NoError(t, err)
+	if assert.Len(t, p.Traces, 1) && assert.Len(t, p.Traces[0], len(traces[0])) {
+		for i, s := range p.Traces[0] {
+			assert.Equal(t, traces[0][i].SpanID, s.SpanID)
+			assert.Equal(t, traces[0][i].Resource, s.Resource)
+		}
+	}
+	assert.Equal(t, "go", p.Source.Lang)
+	assert.Equal(t, "v0.4", p.Source.EndpointVersion)
+
+	_, err = rd.Next()
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestRecorderRotate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.capture")
+
+	rec, err := newRecorder(&config.CaptureConfig{Path: path, MaxFileSize: 100, MaxFiles: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		// each record is larger than half the maximum size
+		rec.record(v04, http.Header{}, bytes.Repeat([]byte{byte(i)}, 30))
+	}
+	assert.NoError(t, rec.Close())
+
+	// the newest records are kept, one per file
+	for i, name := range []string{path, path + ".1", path + ".2"} {
+		f, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cr, err := NewCaptureReader(f).Next()
+		f.Close()
+		if assert.NoError(t, err, name) {
+			assert.Equal(t, bytes.Repeat([]byte{byte(4 - i)}, 30), cr.Body, name)
+		}
+	}
+	_, err = os.Stat(path + ".3")
+	assert.True(t, os.IsNotExist(err), fmt.Sprintf("%s.3 should not exist", path))
+}
+
+func TestRecorderClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "capture")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "traces.capture")
+
+	rec, err := newRecorder(&config.CaptureConfig{Path: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		rec.record(v04, http.Header{}, []byte{byte(i)})
+	}
+	// queued records are written upon closing, later ones are ignored
+	assert.NoError(t, rec.Close())
+	rec.record(v04, http.Header{}, []byte{3})
+	assert.NoError(t, rec.Close())
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rd := NewCaptureReader(f)
+	for i := 0; i < 3; i++ {
+		cr, err := rd.Next()
+		if assert.NoError(t, err) {
+			assert.Equal(t, []byte{byte(i)}, cr.Body)
+		}
+	}
+	_, err = rd.Next()
+	assert.Equal(t, io.EOF, err)
+}
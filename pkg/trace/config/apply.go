@@ -45,6 +45,90 @@ type ObfuscationConfig struct {
 	// Memcached holds the configuration for obfuscating the "memcached.command" tag
 	// for spans of type "memcached".
 	Memcached Enablable `mapstructure:"memcached"`
+
+	// GraphQL holds the configuration for obfuscating the literal values of the
+	// "graphql.query" tag for spans of type "graphql".
+	GraphQL Enablable `mapstructure:"graphql"`
+
+	// CreditCards holds the configuration for obfuscating credit card numbers
+	// found in span tags.
+	CreditCards CreditCardsConfig `mapstructure:"credit_cards"`
+}
+
+// CreditCardsConfig holds the configuration for obfuscating credit card numbers.
+type CreditCardsConfig struct {
+	// Enabled specifies whether tag values holding credit card numbers are obfuscated.
+	Enabled bool `mapstructure:"enabled"`
+
+	// KeepValues specifies tags which are never obfuscated.
+	KeepValues []string `mapstructure:"keep_values"`
+}
+
+// StatsDimensionsConfig holds the configuration for the optional grain
+// dimensions computed by the concentrator.
+type StatsDimensionsConfig struct {
+	// HTTPStatusClass enables aggregating stats by HTTP status code class (e.g. "5xx").
+	// When enabled, it replaces the "http.status_code" extra aggregator.
+	HTTPStatusClass bool `mapstructure:"http_status_class"`
+
+	// PeerService enables aggregating stats by downstream peer, as found in the
+	// "peer.service", "out.host" or "db.instance" tags.
+	PeerService bool `mapstructure:"peer_service"`
+
+	// MaxPeersPerService caps the number of distinct peers tracked per service
+	// in a stats bucket. Additional peers are aggregated together.
+	MaxPeersPerService int `mapstructure:"max_peers_per_service"`
+}
+
+// CaptureConfig holds the configuration for recording the raw payloads received
+// by the API to a capture file.
+type CaptureConfig struct {
+	// Path is the path of the capture file. Recording is disabled when empty.
+	Path string `mapstructure:"path"`
+
+	// MaxFileSize is the size, in bytes, above which the capture file is rotated.
+	MaxFileSize int64 `mapstructure:"max_file_size"`
+
+	// MaxFiles is the number of rotated capture files to keep.
+	MaxFiles int `mapstructure:"max_files"`
+}
+
+// SpanMetricsConfig holds the configuration of the custom metrics computed
+// from spans, before sampling.
+type SpanMetricsConfig struct {
+	// MaxContextsPerRule caps the number of distinct tag sets reported by a rule
+	// in each flush interval. Additional tag sets are aggregated together.
+	MaxContextsPerRule int `mapstructure:"max_contexts_per_rule"`
+
+	// Rules holds the metric rules.
+	Rules []*SpanMetricRule `mapstructure:"rules"`
+}
+
+// SpanMetricRule describes a metric computed from the spans matching it.
+type SpanMetricRule struct {
+	// Name is the name of the reported metric.
+	Name string `mapstructure:"name"`
+
+	// Type is the type of the metric: "count" (the default) or "distribution".
+	Type string `mapstructure:"type"`
+
+	// Service and Resource are optional patterns which spans must match.
+	Service  string `mapstructure:"service"`
+	Resource string `mapstructure:"resource"`
+
+	// ServiceRe and ResourceRe hold the compiled patterns and are only used internally.
+	ServiceRe  *regexp.Regexp `mapstructure:"-"`
+	ResourceRe *regexp.Regexp `mapstructure:"-"`
+
+	// Value is the value reported by distributions: "duration" (the default,
+	// in seconds) or the name of a span metric.
+	Value string `mapstructure:"value"`
+
+	// GroupBy lists the span tags by which the metric is tagged.
+	GroupBy []string `mapstructure:"group_by"`
+
+	// SampleRate is the rate at which distribution values are sent to DogStatsD.
+	SampleRate float64 `mapstructure:"sample_rate"`
 }
 
 // HTTPObfuscationConfig holds the configuration settings for HTTP obfuscation.
@@ -248,6 +332,33 @@ func (c *AgentConfig) applyDatadogConfig() error {
 		}
 	}
 
+	if k := "apm_config.stats_dimensions"; config.Datadog.IsSet(k) {
+		if err := config.Datadog.UnmarshalKey(k, c.StatsDimensions); err != nil {
+			log.Errorf("Error reading %q: %v", k, err)
+		}
+	}
+
+	if k := "apm_config.capture"; config.Datadog.IsSet(k) {
+		var cc CaptureConfig
+		if err := config.Datadog.UnmarshalKey(k, &cc); err != nil {
+			log.Errorf("Error reading %q: %v", k, err)
+		} else {
+			c.Capture = &cc
+		}
+	}
+
+	if k := "apm_config.span_metrics"; config.Datadog.IsSet(k) {
+		var sm SpanMetricsConfig
+		if err := config.Datadog.UnmarshalKey(k, &sm); err != nil {
+			log.Errorf("Error reading %q: %v", k, err)
+		} else {
+			if err := compileSpanMetricRules(sm.Rules); err != nil {
+				osutil.Exitf("span_metrics: %s", err)
+			}
+			c.SpanMetrics = &sm
+		}
+	}
+
 	// undocumented
 	if config.Datadog.IsSet("apm_config.max_cpu_percent") {
 		c.MaxCPU = config.Datadog.GetFloat64("apm_config.max_cpu_percent") / 100
@@ -384,6 +495,42 @@ func compileReplaceRules(rules []*ReplaceRule) error {
 	return nil
 }
 
+// compileSpanMetricRules validates the given rules and compiles their patterns.
+func compileSpanMetricRules(rules []*SpanMetricRule) error {
+	seen := make(map[string]bool, len(rules))
+	for _, r := range rules {
+		if r.Name == "" {
+			return errors.New(`all rules must have a "name"`)
+		}
+		if seen[r.Name] {
+			return fmt.Errorf("duplicate rule %q", r.Name)
+		}
+		seen[r.Name] = true
+		switch r.Type {
+		case "":
+			r.Type = "count"
+		case "count", "distribution":
+		default:
+			return fmt.Errorf("rule %q: unknown type %q (should be \"count\" or \"distribution\")", r.Name, r.Type)
+		}
+		if r.SampleRate < 0 || r.SampleRate > 1 {
+			return fmt.Errorf("rule %q: sample_rate must be between 0 and 1", r.Name)
+		}
+		var err error
+		if r.Service != "" {
+			if r.ServiceRe, err = regexp.Compile(r.Service); err != nil {
+				return fmt.Errorf("rule %q: service: %s", r.Name, err)
+			}
+		}
+		if r.Resource != "" {
+			if r.ResourceRe, err = regexp.Compile(r.Resource); err != nil {
+				return fmt.Errorf("rule %q: resource: %s", r.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
 // getDuration returns the duration of the provided value in seconds
 func getDuration(seconds int) time.Duration {
 	return time.Duration(seconds) * time.Second
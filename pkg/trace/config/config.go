@@ -63,12 +63,16 @@ type AgentConfig struct {
 	// Concentrator
 	BucketInterval   time.Duration // the size of our pre-aggregation per bucket
 	ExtraAggregators []string
+	StatsDimensions  *StatsDimensionsConfig // optional extra grain dimensions
 
 	// Sampler configuration
 	ExtraSampleRate float64
 	MaxTPS          float64
 	MaxEPS          float64
 
+	// SpanMetrics, if set, holds the rules of the metrics computed from spans.
+	SpanMetrics *SpanMetricsConfig
+
 	// Receiver
 	ReceiverHost    string
 	ReceiverPort    int
@@ -77,6 +81,9 @@ type AgentConfig struct {
 	ReceiverTimeout int
 	MaxRequestBytes int64 // specifies the maximum allowed request size for incoming trace payloads
 
+	// Capture, if set, records the raw payloads received by the receiver.
+	Capture *CaptureConfig
+
 	// Writers
 	StatsWriter             *WriterConfig
 	TraceWriter             *WriterConfig
@@ -127,6 +134,7 @@ func New() *AgentConfig {
 
 		BucketInterval:   time.Duration(10) * time.Second,
 		ExtraAggregators: []string{"http.status_code", "version", "_dd.hostname"},
+		StatsDimensions:  &StatsDimensionsConfig{MaxPeersPerService: 100},
 
 		ExtraSampleRate: 1.0,
 		MaxTPS:          10,
@@ -165,6 +165,24 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.EqualValues(123.4, c.MaxMemory)
 	assert.Equal("0.0.0.0", c.ReceiverHost)
 	assert.True(c.LogThrottling)
+	assert.Equal(&StatsDimensionsConfig{
+		HTTPStatusClass:    true,
+		PeerService:        true,
+		MaxPeersPerService: 20,
+	}, c.StatsDimensions)
+	if assert.NotNil(c.SpanMetrics) && assert.Len(c.SpanMetrics.Rules, 2) {
+		assert.Equal(50, c.SpanMetrics.MaxContextsPerRule)
+		r := c.SpanMetrics.Rules[0]
+		assert.Equal("checkout.requests", r.Name)
+		assert.Equal("count", r.Type)
+		assert.Equal([]string{"http.status_code"}, r.GroupBy)
+		assert.True(r.ServiceRe.MatchString("web"))
+		assert.True(r.ResourceRe.MatchString("POST /checkout/cart"))
+		r = c.SpanMetrics.Rules[1]
+		assert.Equal("distribution", r.Type)
+		assert.Equal(0.5, r.SampleRate)
+		assert.Nil(r.ResourceRe)
+	}
 
 	noProxy := true
 	if _, ok := os.LookupEnv("NO_PROXY"); ok {
@@ -217,6 +235,9 @@ func TestFullYamlConfig(t *testing.T) {
 	assert.True(o.RemoveStackTraces)
 	assert.True(c.Obfuscation.Redis.Enabled)
 	assert.True(c.Obfuscation.Memcached.Enabled)
+	assert.True(c.Obfuscation.GraphQL.Enabled)
+	assert.True(c.Obfuscation.CreditCards.Enabled)
+	assert.Equal([]string{"order.id"}, c.Obfuscation.CreditCards.KeepValues)
 }
 
 func TestUndocumentedYamlConfig(t *testing.T) {
@@ -6,6 +6,8 @@
 package event
 
 import (
+	"time"
+
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 )
@@ -48,6 +50,11 @@ func (p *Processor) Stop() {
 	p.maxEPSSampler.Stop()
 }
 
+// Tick runs the periodic work of the processor due at the given time, in place of Start.
+func (p *Processor) Tick(now time.Time) {
+	p.maxEPSSampler.Tick(now)
+}
+
 // Process takes a processed trace, extracts events from it and samples them, returning a collection of
 // sampled events along with the total count of extracted events.
 func (p *Processor) Process(root *pb.Span, t pb.Trace) (events []*pb.Span, numExtracted int64) {
@@ -112,5 +119,6 @@ func (p *Processor) maxEPSSample(event *pb.Span, priority sampler.SamplingPriori
 type eventSampler interface {
 	Start()
 	Sample(event *pb.Span) (sampled bool, rate float64)
+	Tick(now time.Time)
 	Stop()
 }
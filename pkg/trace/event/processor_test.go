@@ -8,6 +8,7 @@ package event
 import (
 	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
@@ -132,6 +133,8 @@ func (s *MockEventSampler) Stop() {
 	s.StopCalls++
 }
 
+func (s *MockEventSampler) Tick(_ time.Time) {}
+
 func (s *MockEventSampler) Sample(event *pb.Span) (bool, float64) {
 	s.SampleCalls++
 
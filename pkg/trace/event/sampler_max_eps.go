@@ -67,6 +67,11 @@ func (s *maxEPSSampler) Stop() {
 	s.rateCounter.Stop()
 }
 
+// Tick decays the underlying rate counter as due at the given time, in place of Start.
+func (s *maxEPSSampler) Tick(now time.Time) {
+	s.rateCounter.Tick(now)
+}
+
 // Sample determines whether or not we should sample the provided event in order to ensure no more than maxEPS events
 // are sampled every second.
 func (s *maxEPSSampler) Sample(event *pb.Span) (sampled bool, rate float64) {
@@ -116,6 +121,7 @@ type rateCounter interface {
 	Start()
 	Count()
 	GetRate() float64
+	Tick(now time.Time)
 	Stop()
 }
 
@@ -142,6 +148,11 @@ func (sb *samplerBackendRateCounter) Stop() {
 	sb.backend.Stop()
 }
 
+// Tick applies the decays of the backend rate counter due at the given time.
+func (sb *samplerBackendRateCounter) Tick(now time.Time) {
+	sb.backend.Tick(now)
+}
+
 // Count adds an event to the rate computation.
 func (sb *samplerBackendRateCounter) Count() {
 	sb.backend.CountSample()
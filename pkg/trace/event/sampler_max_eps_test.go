@@ -7,6 +7,7 @@ package event
 
 import (
 	"testing"
+	"time"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/test/testutil"
@@ -66,8 +67,9 @@ type MockRateCounter struct {
 	GetRateResult float64
 }
 
-func (mc *MockRateCounter) Start() {}
-func (mc *MockRateCounter) Stop()  {}
+func (mc *MockRateCounter) Start()           {}
+func (mc *MockRateCounter) Stop()            {}
+func (mc *MockRateCounter) Tick(_ time.Time) {}
 
 func (mc *MockRateCounter) Count() {
 	mc.CountCalls++
@@ -58,6 +58,11 @@ func (c *captureClient) Histogram(name string, value float64, tags []string, rat
 	return c.write("histogram", name, formatFloat(value), tags)
 }
 
+// Distribution implements Client.
+func (c *captureClient) Distribution(name string, value float64, tags []string, rate float64) error {
+	return c.write("distribution", name, formatFloat(value), tags)
+}
+
 // Timing implements Client.
 func (c *captureClient) Timing(name string, value time.Duration, tags []string, rate float64) error {
 	return c.write("timing", name, strconv.FormatInt(int64(value), 10), tags)
@@ -0,0 +1,85 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// ccKeepTags holds the tags which never hold credit card numbers, in
+// addition to the internal ones (prefixed with "_").
+var ccKeepTags = map[string]bool{
+	"env":              true,
+	"version":          true,
+	"http.status_code": true,
+	"error.stack":      true,
+}
+
+// obfuscateCreditCards replaces the values of the span's tags which are credit card
+// numbers with "?".
+func (o *Obfuscator) obfuscateCreditCards(span *pb.Span) {
+	for k, v := range span.Meta {
+		if ccKeepTags[k] || strings.HasPrefix(k, "_") || o.ccKeep[k] {
+			continue
+		}
+		if isCardNumber(v) {
+			span.Meta[k] = "?"
+		}
+	}
+}
+
+// isCardNumber reports whether v is a payment card number: 12 to 19 digits, optionally
+// grouped by spaces or dashes, starting with a major industry identifier of the
+// banking industry (2 to 6) and passing the Luhn checksum.
+func isCardNumber(v string) bool {
+	const minDigits, maxDigits = 12, 19
+	if len(v) < minDigits || len(v) > 2*maxDigits {
+		return false
+	}
+	var (
+		digits [maxDigits]byte
+		n      int
+	)
+	for i := 0; i < len(v); i++ {
+		switch c := v[i]; {
+		case '0' <= c && c <= '9':
+			if n == maxDigits {
+				return false
+			}
+			digits[n] = c - '0'
+			n++
+		case c == ' ' || c == '-':
+			if i == 0 || i == len(v)-1 || v[i-1] == ' ' || v[i-1] == '-' {
+				// separators only appear between groups of digits
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	if n < minDigits || digits[0] < 2 || digits[0] > 6 {
+		return false
+	}
+	return luhnValid(digits[:n])
+}
+
+// luhnValid reports whether the given digits pass the Luhn checksum.
+func luhnValid(digits []byte) bool {
+	var sum int
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i])
+		if (len(digits)-i)%2 == 0 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,49 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateCreditCards(t *testing.T) {
+	suite, err := loadXMLTests("./testdata/credit_card_tests.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range suite {
+		t.Run(strconv.Itoa(i+1), func(t *testing.T) {
+			o := NewObfuscator(&config.ObfuscationConfig{
+				CreditCards: config.CreditCardsConfig{Enabled: true, KeepValues: tt.KeepValues},
+			})
+			span := &pb.Span{Type: "web", Meta: map[string]string{tt.Tag: tt.In}}
+			o.Obfuscate(span)
+			assert.Equal(t, tt.Out, span.Meta[tt.Tag])
+		})
+	}
+}
+
+func TestObfuscateCreditCardsDisabled(t *testing.T) {
+	span := &pb.Span{Meta: map[string]string{"card": "4111111111111111"}}
+	NewObfuscator(nil).Obfuscate(span)
+	assert.Equal(t, "4111111111111111", span.Meta["card"])
+}
+
+func BenchmarkIsCardNumber(b *testing.B) {
+	for _, v := range []string{"4111 1111 1111 1111", "4111111111111112", "GET /users/42"} {
+		b.Run(v, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				isCardNumber(v)
+			}
+		})
+	}
+}
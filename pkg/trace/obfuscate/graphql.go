@@ -0,0 +1,97 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"strings"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+// obfuscateGraphQL obfuscates the literal values of the query found in the span's
+// "graphql.query" tag, as well as in its resource when it holds the same query.
+func (*Obfuscator) obfuscateGraphQL(span *pb.Span) {
+	const k = "graphql.query"
+	if span.Meta == nil || span.Meta[k] == "" {
+		return
+	}
+	in := span.Meta[k]
+	out := obfuscateGraphQLQuery(in)
+	span.Meta[k] = out
+	if span.Resource == in {
+		span.Resource = out
+	}
+}
+
+// obfuscateGraphQLQuery replaces the string (including block strings) and number
+// literals of the given GraphQL document with "?" and removes its comments.
+// Names, variables, booleans, null and enum values are kept.
+func obfuscateGraphQLQuery(in string) string {
+	var out strings.Builder
+	out.Grow(len(in))
+	for i := 0; i < len(in); {
+		switch c := in[i]; {
+		case c == '#':
+			// comment, until the end of the line
+			for i < len(in) && in[i] != '\n' && in[i] != '\r' {
+				i++
+			}
+		case c == '"':
+			i = skipGraphQLString(in, i)
+			out.WriteByte('?')
+		case c == '$' || c == '_' || isGraphQLLetter(c):
+			// name or variable
+			j := i + 1
+			for j < len(in) && (in[j] == '_' || isGraphQLLetter(in[j]) || isGraphQLDigit(in[j])) {
+				j++
+			}
+			out.WriteString(in[i:j])
+			i = j
+		case isGraphQLDigit(c) || c == '-' && i+1 < len(in) && isGraphQLDigit(in[i+1]):
+			i++
+			for i < len(in) && (isGraphQLDigit(in[i]) || strings.IndexByte(".eE+-", in[i]) != -1) {
+				i++
+			}
+			out.WriteByte('?')
+		default:
+			out.WriteByte(c)
+			i++
+		}
+	}
+	return out.String()
+}
+
+// skipGraphQLString returns the position following the string or block string
+// starting at position i of the query.
+func skipGraphQLString(in string, i int) int {
+	if strings.HasPrefix(in[i:], `"""`) {
+		// block string; only `\"""` is escaped
+		for i += 3; i < len(in); i++ {
+			if strings.HasPrefix(in[i:], `\"""`) {
+				i += 3
+				continue
+			}
+			if strings.HasPrefix(in[i:], `"""`) {
+				return i + 3
+			}
+		}
+		return len(in)
+	}
+	for i++; i < len(in); i++ {
+		switch in[i] {
+		case '\\':
+			i++
+		case '"', '\n':
+			// strings can not span multiple lines
+			return i + 1
+		}
+	}
+	return len(in)
+}
+
+func isGraphQLLetter(c byte) bool { return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' }
+
+func isGraphQLDigit(c byte) bool { return '0' <= c && c <= '9' }
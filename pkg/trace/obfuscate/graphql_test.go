@@ -0,0 +1,58 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package obfuscate
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObfuscateGraphQLQuery(t *testing.T) {
+	suite, err := loadXMLTests("./testdata/graphql_tests.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range suite {
+		t.Run(strconv.Itoa(i+1), func(t *testing.T) {
+			assert.Equal(t, tt.Out, obfuscateGraphQLQuery(tt.In))
+		})
+	}
+}
+
+func TestObfuscateGraphQL(t *testing.T) {
+	const q = `query { user(id: 42) { name } }`
+	newSpan := func() *pb.Span {
+		return &pb.Span{
+			Type:     "graphql",
+			Resource: q,
+			Meta:     map[string]string{"graphql.query": q},
+		}
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		span := newSpan()
+		NewObfuscator(nil).Obfuscate(span)
+		assert.Equal(t, q, span.Meta["graphql.query"])
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		span := newSpan()
+		NewObfuscator(&config.ObfuscationConfig{GraphQL: config.Enablable{Enabled: true}}).Obfuscate(span)
+		assert.Equal(t, `query { user(id: ?) { name } }`, span.Meta["graphql.query"])
+		assert.Equal(t, `query { user(id: ?) { name } }`, span.Resource)
+	})
+
+	t.Run("resource", func(t *testing.T) {
+		span := newSpan()
+		span.Resource = "user"
+		NewObfuscator(&config.ObfuscationConfig{GraphQL: config.Enablable{Enabled: true}}).Obfuscate(span)
+		assert.Equal(t, "user", span.Resource)
+	})
+}
@@ -8,6 +8,7 @@ package obfuscate
 import (
 	"encoding/json"
 	"encoding/xml"
+	"fmt"
 	"log"
 	"os"
 	"path/filepath"
@@ -28,35 +29,48 @@ type xmlObfuscateTests struct {
 
 type xmlObfuscateTest struct {
 	Tag           string
+	DBType        string
 	DontNormalize bool // this test contains invalid JSON
 	In            string
 	Out           string
 	KeepValues    []string `xml:"KeepValues>key"`
 }
 
-// loadTests loads all XML tests from ./testdata/obfuscate.xml
-func loadTests() ([]*xmlObfuscateTest, error) {
-	path, err := filepath.Abs(obfuscateTestFile)
+// loadXMLTests loads all XML tests from the given file.
+func loadXMLTests(file string) ([]*xmlObfuscateTest, error) {
+	path, err := filepath.Abs(file)
 	if err != nil {
 		return nil, err
 	}
 	f, err := os.Open(path)
-	defer f.Close()
 	if err != nil {
 		return nil, err
 	}
+	defer f.Close()
 	var suite xmlObfuscateTests
 	if err := xml.NewDecoder(f).Decode(&suite); err != nil {
 		return nil, err
 	}
-	for _, test := range suite.Tests {
+	if len(suite.Tests) == 0 {
+		return nil, fmt.Errorf("no tests in %s", file)
+	}
+	return suite.Tests, nil
+}
+
+// loadTests loads all XML tests from ./testdata/json_tests.xml
+func loadTests() ([]*xmlObfuscateTest, error) {
+	tests, err := loadXMLTests(obfuscateTestFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, test := range tests {
 		// normalize JSON output
 		if !test.DontNormalize {
 			test.Out = normalize(test.Out)
 			test.In = normalize(test.In)
 		}
 	}
-	return suite.Tests, err
+	return tests, nil
 }
 
 // normalize normalizes JSON input. This allows us to write "pretty" JSON
@@ -21,6 +21,8 @@ type Obfuscator struct {
 	opts  *config.ObfuscationConfig
 	es    *jsonObfuscator // nil if disabled
 	mongo *jsonObfuscator // nil if disabled
+	// ccKeep holds the tags which are not checked for credit card numbers.
+	ccKeep map[string]bool
 	// sqlLiteralEscapes reports whether we should treat escape characters literally or as escape characters.
 	// A non-zero value means 'yes'. Different SQL engines behave in different ways and the tokenizer needs
 	// to be generic.
@@ -59,6 +61,12 @@ func NewObfuscator(cfg *config.ObfuscationConfig) *Obfuscator {
 	if cfg.Mongo.Enabled {
 		o.mongo = newJSONObfuscator(&cfg.Mongo)
 	}
+	if cfg.CreditCards.Enabled {
+		o.ccKeep = make(map[string]bool, len(cfg.CreditCards.KeepValues))
+		for _, k := range cfg.CreditCards.KeepValues {
+			o.ccKeep[k] = true
+		}
+	}
 	return &o
 }
 
@@ -86,6 +94,13 @@ func (o *Obfuscator) Obfuscate(span *pb.Span) {
 		o.obfuscateJSON(span, "mongodb.query", o.mongo)
 	case "elasticsearch":
 		o.obfuscateJSON(span, "elasticsearch.body", o.es)
+	case "graphql":
+		if o.opts.GraphQL.Enabled {
+			o.obfuscateGraphQL(span)
+		}
+	}
+	if o.opts.CreditCards.Enabled {
+		o.obfuscateCreditCards(span)
 	}
 }
 
@@ -9,6 +9,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"unicode"
 	"unicode/utf8"
@@ -20,6 +21,7 @@ import (
 )
 
 const sqlQueryTag = "sql.query"
+const dbTypeTag = "db.type"
 const nonParsableResource = "Non-parsable SQL query"
 
 // tokenFilter is a generic interface that a sqlObfuscator expects. It defines
@@ -94,6 +96,15 @@ func (f *replaceFilter) Filter(token, lastToken TokenKind, buffer []byte) (token
 	switch lastToken {
 	case Savepoint:
 		return FilteredGroupable, []byte("?"), nil
+	case JSONPathOp:
+		switch token {
+		case String:
+			// JSON keys and paths are part of the query structure and are kept
+			return token, quoteString(buffer), nil
+		case Number:
+			// array index
+			return token, buffer, nil
+		}
 	case '=':
 		switch token {
 		case DoubleQuotedString:
@@ -112,6 +123,14 @@ func (f *replaceFilter) Filter(token, lastToken TokenKind, buffer []byte) (token
 // Reset implements tokenFilter.
 func (f *replaceFilter) Reset() {}
 
+// quoteString returns the SQL string literal having the given value.
+func quoteString(value []byte) []byte {
+	out := make([]byte, 0, len(value)+2)
+	out = append(out, '\'')
+	out = append(out, bytes.Replace(value, []byte("'"), []byte("''"), -1)...)
+	return append(out, '\'')
+}
+
 // groupingFilter is a token filter which groups together items replaced by the replaceFilter. It is meant
 // to run immediately after it.
 type groupingFilter struct {
@@ -165,25 +184,40 @@ func (f *groupingFilter) Reset() {
 // some elements such as comments and aliases and obfuscation attempts to hide sensitive information
 // in strings and numbers by redacting them.
 func (o *Obfuscator) ObfuscateSQLString(in string) (*ObfuscatedQuery, error) {
-	if v, ok := o.queryCache.Get(in); ok {
+	return o.obfuscateSQLStringWithDialect(in, dialectGeneric)
+}
+
+// ObfuscateSQLStringForDBType works like ObfuscateSQLString, using the tokenization rules of
+// the SQL dialect of the given database type, as found in the "db.type" tag (e.g. "postgresql").
+func (o *Obfuscator) ObfuscateSQLStringForDBType(in, dbType string) (*ObfuscatedQuery, error) {
+	return o.obfuscateSQLStringWithDialect(in, dialectFromDBType(dbType))
+}
+
+func (o *Obfuscator) obfuscateSQLStringWithDialect(in string, d sqlDialect) (*ObfuscatedQuery, error) {
+	key := in
+	if d != dialectGeneric {
+		// the same query may be obfuscated differently depending on the dialect
+		key = strconv.Itoa(int(d)) + "|" + in
+	}
+	if v, ok := o.queryCache.Get(key); ok {
 		return v.(*ObfuscatedQuery), nil
 	}
-	oq, err := o.obfuscateSQLString(in)
+	oq, err := o.obfuscateSQLString(in, d)
 	if err != nil {
 		return oq, err
 	}
-	o.queryCache.Set(in, oq, oq.Cost())
+	o.queryCache.Set(key, oq, oq.Cost())
 	return oq, nil
 }
 
-func (o *Obfuscator) obfuscateSQLString(in string) (*ObfuscatedQuery, error) {
+func (o *Obfuscator) obfuscateSQLString(in string, d sqlDialect) (*ObfuscatedQuery, error) {
 	lesc := o.SQLLiteralEscapes()
-	tok := NewSQLTokenizer(in, lesc)
+	tok := newSQLTokenizerWithDialect(in, lesc, d)
 	out, err := attemptObfuscation(tok)
 	if err != nil && tok.SeenEscape() {
 		// If the tokenizer failed, but saw an escape character in the process,
 		// try again treating escapes differently
-		tok = NewSQLTokenizer(in, !lesc)
+		tok = newSQLTokenizerWithDialect(in, !lesc, d)
 		if out, err2 := attemptObfuscation(tok); err2 == nil {
 			// If the second attempt succeeded, change the default behavior so that
 			// on the next run we get it right in the first run.
@@ -209,7 +243,7 @@ func (f *tableFinderFilter) Filter(token, lastToken TokenKind, buffer []byte) (T
 	case From:
 		// SELECT ... FROM [tableName]
 		// DELETE FROM [tableName]
-		if r, _ := utf8.DecodeRune(buffer); !unicode.IsLetter(r) {
+		if r, _ := utf8.DecodeRune(buffer); !unicode.IsLetter(r) && r != '[' {
 			// first character in buffer is not a letter; we might have a nested
 			// query like SELECT * FROM (SELECT ...)
 			break
@@ -327,7 +361,7 @@ func (o *Obfuscator) obfuscateSQL(span *pb.Span) {
 	if span.Resource == "" {
 		return
 	}
-	oq, err := o.ObfuscateSQLString(span.Resource)
+	oq, err := o.ObfuscateSQLStringForDBType(span.Resource, span.Meta[dbTypeTag])
 	if err != nil {
 		// we have an error, discard the SQL to avoid polluting user resources.
 		log.Debugf("Error parsing SQL query: %v. Resource: %q", err, span.Resource)
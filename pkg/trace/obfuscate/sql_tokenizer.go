@@ -59,6 +59,8 @@ const (
 	Into
 	Join
 	ColonCast
+	JSONOp
+	JSONPathOp
 
 	// FilteredGroupable specifies that the given token has been discarded by one of the
 	// token filters and that it is groupable together with consecutive FilteredGroupable
@@ -77,6 +79,33 @@ const (
 
 const escapeCharacter = '\\'
 
+// sqlDialect specifies the SQL dialect of a query. Dialects enable additional
+// tokenization rules which would be ambiguous in other dialects.
+type sqlDialect int
+
+const (
+	// dialectGeneric is the default dialect, used when the database is unknown.
+	dialectGeneric sqlDialect = iota
+	// dialectPostgres enables dollar-quoted strings, E'' escape strings and
+	// JSON operators.
+	dialectPostgres
+	// dialectMSSQL enables bracketed identifiers, N'' strings and temporary
+	// table names.
+	dialectMSSQL
+)
+
+// dialectFromDBType returns the SQL dialect for the given value of the "db.type" tag.
+func dialectFromDBType(dbType string) sqlDialect {
+	switch strings.ToLower(dbType) {
+	case "postgres", "postgresql":
+		return dialectPostgres
+	case "mssql", "sqlserver":
+		return dialectMSSQL
+	default:
+		return dialectGeneric
+	}
+}
+
 // SQLTokenizer is the struct used to generate SQL
 // tokens for the parser.
 type SQLTokenizer struct {
@@ -85,8 +114,9 @@ type SQLTokenizer struct {
 	lastChar rune            // last read rune
 	err      error           // any error occurred while reading
 
-	literalEscapes bool // indicates we should not treat backslashes as escape characters
-	seenEscape     bool // indicates whether this tokenizer has seen an escape character within a string
+	literalEscapes bool       // indicates we should not treat backslashes as escape characters
+	seenEscape     bool       // indicates whether this tokenizer has seen an escape character within a string
+	dialect        sqlDialect // the SQL dialect of the query
 }
 
 // NewSQLTokenizer creates a new SQLTokenizer for the given SQL string. The literalEscapes argument specifies
@@ -98,6 +128,14 @@ func NewSQLTokenizer(sql string, literalEscapes bool) *SQLTokenizer {
 	}
 }
 
+// newSQLTokenizerWithDialect creates a new SQLTokenizer for the given SQL string, using the
+// tokenization rules of dialect d.
+func newSQLTokenizerWithDialect(sql string, literalEscapes bool, d sqlDialect) *SQLTokenizer {
+	tkn := NewSQLTokenizer(sql, literalEscapes)
+	tkn.dialect = d
+	return tkn
+}
+
 // Reset the underlying buffer and positions
 func (tkn *SQLTokenizer) Reset(in string) {
 	tkn.rd.Reset(in)
@@ -140,7 +178,12 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 	tkn.skipBlank()
 
 	switch ch := tkn.lastChar; {
-	case isLeadingLetter(ch):
+	case ch == '@' && tkn.dialect == dialectPostgres:
+		// Postgres operators (e.g. '@>'); '@' does not start identifiers
+		tkn.next()
+		return tkn.scanPostgresOperator(ch)
+	case isLeadingLetter(ch), ch == '#' && tkn.dialect == dialectMSSQL:
+		// MSSQL temporary tables start with '#' (e.g. '#tmp' or '##global')
 		return tkn.scanIdentifier()
 	case isDigit(ch):
 		return tkn.scanNumber(false)
@@ -158,7 +201,17 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 				return tkn.scanBindVar()
 			}
 			fallthrough
-		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', '~', '[', ']', '?':
+		case '[':
+			if tkn.dialect == dialectMSSQL {
+				return tkn.scanBracketedIdentifier()
+			}
+			return TokenKind(ch), runeBytes(ch)
+		case '?':
+			if tkn.dialect == dialectPostgres && (tkn.lastChar == '|' || tkn.lastChar == '&') {
+				return tkn.scanPostgresOperator(ch)
+			}
+			return TokenKind(ch), runeBytes(ch)
+		case '=', ',', ';', '(', ')', '+', '*', '&', '|', '^', '~', ']':
 			return TokenKind(ch), runeBytes(ch)
 		case '.':
 			if isDigit(tkn.lastChar) {
@@ -181,11 +234,21 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 				tkn.next()
 				return tkn.scanCommentType1("--")
 			}
+			if tkn.dialect == dialectPostgres && tkn.lastChar == '>' {
+				return tkn.scanPostgresOperator(ch)
+			}
 			return TokenKind(ch), runeBytes(ch)
 		case '#':
+			if tkn.dialect == dialectPostgres {
+				// Postgres has no '#' comments, only operators (e.g. '#>')
+				return tkn.scanPostgresOperator(ch)
+			}
 			tkn.next()
 			return tkn.scanCommentType1("#")
 		case '<':
+			if tkn.dialect == dialectPostgres && tkn.lastChar == '@' {
+				return tkn.scanPostgresOperator(ch)
+			}
 			switch tkn.lastChar {
 			case '>':
 				tkn.next()
@@ -216,9 +279,9 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			tkn.setErr(`expected "=" after "!", got "%c" (%d)`, tkn.lastChar, tkn.lastChar)
 			return LexError, []byte("!")
 		case '\'':
-			return tkn.scanString(ch, String)
+			return tkn.scanString(ch, String, tkn.literalEscapes)
 		case '"':
-			return tkn.scanString(ch, DoubleQuotedString)
+			return tkn.scanString(ch, DoubleQuotedString, tkn.literalEscapes)
 		case '`':
 			return tkn.scanLiteralIdentifier('`')
 		case '%':
@@ -232,6 +295,9 @@ func (tkn *SQLTokenizer) Scan() (TokenKind, []byte) {
 			// modulo operator (e.g. 'id % 8')
 			return TokenKind(ch), runeBytes(ch)
 		case '$':
+			if tkn.dialect == dialectPostgres && !isDigit(tkn.lastChar) {
+				return tkn.scanDollarQuotedString()
+			}
 			return tkn.scanPreparedStatement('$')
 		case '{':
 			return tkn.scanEscapeSequence('{')
@@ -250,13 +316,37 @@ func (tkn *SQLTokenizer) skipBlank() {
 
 func (tkn *SQLTokenizer) scanIdentifier() (TokenKind, []byte) {
 	buffer := &bytes.Buffer{}
-	buffer.WriteRune(tkn.lastChar)
+	first := tkn.lastChar
+	buffer.WriteRune(first)
 	tkn.next()
 
+	if tkn.lastChar == '\'' {
+		switch {
+		case tkn.dialect == dialectPostgres && (first == 'E' || first == 'e'):
+			// Postgres escape string constant (e.g. E'foo\nbar')
+			tkn.next()
+			return tkn.scanString('\'', String, false)
+		case tkn.dialect == dialectMSSQL && (first == 'N' || first == 'n'):
+			// MSSQL unicode string (e.g. N'foo')
+			tkn.next()
+			return tkn.scanString('\'', String, tkn.literalEscapes)
+		}
+	}
 	for isLetter(tkn.lastChar) || isDigit(tkn.lastChar) || tkn.lastChar == '.' || tkn.lastChar == '*' {
+		if tkn.lastChar == '#' && tkn.dialect == dialectPostgres {
+			// Postgres operator (e.g. data#>'{a,b}')
+			break
+		}
 		buffer.WriteRune(tkn.lastChar)
 		tkn.next()
 	}
+	if tkn.dialect == dialectMSSQL && tkn.lastChar == '[' && bytes.HasSuffix(buffer.Bytes(), []byte(".")) {
+		// multi-part name ending with a bracketed identifier (e.g. dbo.[users])
+		tkn.next()
+		kind, rest := tkn.scanBracketedIdentifier()
+		buffer.Write(rest)
+		return kind, buffer.Bytes()
+	}
 	upper := bytes.ToUpper(buffer.Bytes())
 	if keywordID, found := keywords[string(upper)]; found {
 		return keywordID, buffer.Bytes()
@@ -283,6 +373,112 @@ func (tkn *SQLTokenizer) scanLiteralIdentifier(quote rune) (TokenKind, []byte) {
 	return ID, buffer.Bytes()
 }
 
+// scanBracketedIdentifier scans an MSSQL bracketed identifier (e.g. [user id]), including
+// any following parts of a multi-part name (e.g. [dbo].[users]). The opening bracket
+// has already been consumed.
+func (tkn *SQLTokenizer) scanBracketedIdentifier() (TokenKind, []byte) {
+	buffer := &bytes.Buffer{}
+	buffer.WriteByte('[')
+	for {
+		ch := tkn.lastChar
+		if ch == EOFChar {
+			tkn.setErr("unexpected EOF in bracketed identifier")
+			return LexError, buffer.Bytes()
+		}
+		tkn.next()
+		buffer.WriteRune(ch)
+		if ch != ']' {
+			continue
+		}
+		if tkn.lastChar != ']' {
+			break
+		}
+		// a doubled closing bracket is part of the identifier
+		tkn.consumeNext(buffer)
+	}
+	if buffer.Len() == 2 {
+		tkn.setErr("empty bracketed identifier")
+		return LexError, buffer.Bytes()
+	}
+	if tkn.lastChar != '.' {
+		return ID, buffer.Bytes()
+	}
+	tkn.consumeNext(buffer)
+	var (
+		kind TokenKind
+		rest []byte
+	)
+	switch {
+	case tkn.lastChar == '[':
+		tkn.next()
+		kind, rest = tkn.scanBracketedIdentifier()
+	case isLeadingLetter(tkn.lastChar):
+		kind, rest = tkn.scanIdentifier()
+		kind = ID
+	default:
+		kind = ID
+	}
+	buffer.Write(rest)
+	return kind, buffer.Bytes()
+}
+
+// scanPostgresOperator scans the Postgres operators starting with prefix, which has
+// already been consumed. The JSON operators selecting a path (->, ->>, #>, #>> and #-)
+// are returned as JSONPathOp, others (e.g. @>, <@, ?| or @@) as JSONOp.
+func (tkn *SQLTokenizer) scanPostgresOperator(prefix rune) (TokenKind, []byte) {
+	buffer := &bytes.Buffer{}
+	buffer.WriteRune(prefix)
+	switch prefix {
+	case '-', '#':
+		switch tkn.lastChar {
+		case '>':
+			tkn.consumeNext(buffer)
+			if tkn.lastChar == '>' {
+				tkn.consumeNext(buffer)
+			}
+			return JSONPathOp, buffer.Bytes()
+		case '-':
+			if prefix == '#' {
+				tkn.consumeNext(buffer)
+				return JSONPathOp, buffer.Bytes()
+			}
+		}
+	case '@':
+		if tkn.lastChar == '>' || tkn.lastChar == '@' {
+			tkn.consumeNext(buffer)
+		}
+	case '<', '?':
+		tkn.consumeNext(buffer)
+	}
+	return JSONOp, buffer.Bytes()
+}
+
+// scanDollarQuotedString scans a Postgres dollar-quoted string (e.g. $$foo$$ or
+// $tag$foo$tag$). The leading '$' has already been consumed.
+func (tkn *SQLTokenizer) scanDollarQuotedString() (TokenKind, []byte) {
+	delim := &bytes.Buffer{}
+	delim.WriteByte('$')
+	for tkn.lastChar != '$' {
+		if !isLeadingLetter(tkn.lastChar) && !isDigit(tkn.lastChar) || tkn.lastChar == '@' {
+			tkn.setErr(`unexpected character "%c" (%d) in dollar-quoted string tag`, tkn.lastChar, tkn.lastChar)
+			return LexError, delim.Bytes()
+		}
+		tkn.consumeNext(delim)
+	}
+	tkn.consumeNext(delim)
+
+	buffer := &bytes.Buffer{}
+	for !bytes.HasSuffix(buffer.Bytes(), delim.Bytes()) {
+		if tkn.lastChar == EOFChar {
+			tkn.setErr("unexpected EOF in dollar-quoted string")
+			return LexError, buffer.Bytes()
+		}
+		tkn.consumeNext(buffer)
+	}
+	buffer.Truncate(buffer.Len() - delim.Len())
+	return String, buffer.Bytes()
+}
+
 func (tkn *SQLTokenizer) scanVariableIdentifier(prefix rune) (TokenKind, []byte) {
 	buffer := &bytes.Buffer{}
 	buffer.WriteRune(prefix)
@@ -440,7 +636,9 @@ exit:
 	return Number, buffer.Bytes()
 }
 
-func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind) (TokenKind, []byte) {
+// scanString scans a string enclosed by delim. When literalEscapes is false, backslashes
+// are treated as escape characters.
+func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind, literalEscapes bool) (TokenKind, []byte) {
 	buffer := &bytes.Buffer{}
 	for {
 		ch := tkn.lastChar
@@ -456,7 +654,7 @@ func (tkn *SQLTokenizer) scanString(delim rune, kind TokenKind) (TokenKind, []by
 		} else if ch == escapeCharacter {
 			tkn.seenEscape = true
 
-			if !tkn.literalEscapes {
+			if !literalEscapes {
 				// treat as an escape character
 				ch = tkn.lastChar
 				tkn.next()
@@ -5,6 +5,8 @@
 
 package sampler
 
+import "time"
+
 // Backend stores and counts traces and signatures ingested by a sampler.
 type Backend interface {
 	// Run runs the blocking execution of the backend main loop.
@@ -13,6 +15,9 @@ type Backend interface {
 	// Stop stops the backend main loop.
 	Stop()
 
+	// Tick runs the work of the main loop due at the given time, in place of Run.
+	Tick(now time.Time)
+
 	// CountSample counts that 1 trace is going through the sampler.
 	CountSample()
 
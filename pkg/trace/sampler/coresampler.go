@@ -47,6 +47,8 @@ type Engine interface {
 	Run()
 	// Stop the sampler.
 	Stop()
+	// Tick runs the periodic work of the sampler due at the given time, in place of Run.
+	Tick(now time.Time)
 	// Sample a trace.
 	Sample(trace pb.Trace, root *pb.Span, env string) (sampled bool, samplingRate float64)
 	// GetState returns information about the sampler.
@@ -75,6 +77,9 @@ type Sampler struct {
 	// signatureScoreFactor = math.Pow(signatureScoreSlope, math.Log10(scoreSamplingOffset))
 	signatureScoreFactor *atomic.Float64
 
+	// lastAdjust is the time of the last scoring adjustment run by Tick.
+	lastAdjust time.Time
+
 	exit chan struct{}
 }
 
@@ -144,6 +149,21 @@ func (s *Sampler) RunAdjustScoring() {
 	}
 }
 
+// Tick runs the decays and scoring adjustments due at the given time, since the first
+// call to Tick. It is used in place of Run to drive the sampler from the timestamps of
+// the traces it receives, e.g. when replaying captured payloads.
+func (s *Sampler) Tick(now time.Time) {
+	s.Backend.Tick(now)
+	if s.lastAdjust.IsZero() {
+		s.lastAdjust = now
+		return
+	}
+	for !now.Before(s.lastAdjust.Add(adjustPeriod)) {
+		s.AdjustScoring()
+		s.lastAdjust = s.lastAdjust.Add(adjustPeriod)
+	}
+}
+
 // GetSampleRate returns the sample rate to apply to a trace.
 func (s *Sampler) GetSampleRate(trace pb.Trace, root *pb.Span, signature Signature) float64 {
 	return s.loadRate(s.GetSignatureSampleRate(signature) * s.extraRate)
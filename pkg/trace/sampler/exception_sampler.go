@@ -66,6 +66,12 @@ func (e *ExceptionSampler) Add(env string, root *pb.Span, t pb.Trace) (sampled b
 	return e.add(time.Now(), env, root, t)
 }
 
+// AddAt samples a trace received at the given time and returns true if trace was sampled
+// (should be kept). It is used in place of Add when replaying captured traces.
+func (e *ExceptionSampler) AddAt(now time.Time, env string, root *pb.Span, t pb.Trace) (sampled bool) {
+	return e.add(now, env, root, t)
+}
+
 func (e *ExceptionSampler) add(now time.Time, env string, root *pb.Span, t pb.Trace) (sampled bool) {
 	if priority, ok := GetSamplingPriority(root); priority > 0 && ok {
 		e.handlePriorityTrace(now, env, t)
@@ -121,7 +127,7 @@ func (e *ExceptionSampler) sampleSpan(now time.Time, env string, s *pb.Span) boo
 	sig := ss.sign(s)
 	expire, ok := ss.getExpire(sig)
 	if now.After(expire) || !ok {
-		sampled = e.limiter.Allow()
+		sampled = e.limiter.AllowN(now, 1)
 		if sampled {
 			ss.add(now.Add(defaultTTL), s)
 			atomic.AddInt64(&e.hits, 1)
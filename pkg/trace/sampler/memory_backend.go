@@ -45,6 +45,9 @@ type MemoryBackend struct {
 	// increased by N / countScaleFactor.
 	countScaleFactor float64
 
+	// lastDecay is the time of the last decay run by Tick.
+	lastDecay time.Time
+
 	// exit is the channel to close to stop the run loop.
 	exit chan struct{}
 }
@@ -75,6 +78,20 @@ func (b *MemoryBackend) Run() {
 	}
 }
 
+// Tick applies the decays due at the given time, since the first call to Tick. It is used
+// in place of Run to drive the backend from the timestamps of the traces rather than from
+// the wall clock.
+func (b *MemoryBackend) Tick(now time.Time) {
+	if b.lastDecay.IsZero() {
+		b.lastDecay = now
+		return
+	}
+	for !now.Before(b.lastDecay.Add(b.decayPeriod)) {
+		b.decayScore()
+		b.lastDecay = b.lastDecay.Add(b.decayPeriod)
+	}
+}
+
 // Stop stops the main Run loop.
 func (b *MemoryBackend) Stop() {
 	close(b.exit)
@@ -42,6 +42,7 @@ type PriorityEngine struct {
 
 	rateByService *RateByService
 	catalog       *serviceKeyCatalog
+	lastSync      time.Time
 	exit          chan struct{}
 }
 
@@ -92,6 +93,19 @@ func (s *PriorityEngine) Stop() {
 	close(s.exit)
 }
 
+// Tick runs the work of the main loop due at the given time, in place of Run
+func (s *PriorityEngine) Tick(now time.Time) {
+	s.Sampler.Tick(now)
+	if s.lastSync.IsZero() {
+		s.lastSync = now
+		return
+	}
+	for !now.Before(s.lastSync.Add(syncPeriod)) {
+		s.rateByService.SetAll(s.ratesByService())
+		s.lastSync = s.lastSync.Add(syncPeriod)
+	}
+}
+
 // Sample counts an incoming trace and returns the trace sampling decision and the applied sampling rate
 func (s *PriorityEngine) Sample(trace pb.Trace, root *pb.Span, env string) (sampled bool, rate float64) {
 	// Extra safety, just in case one trace is empty
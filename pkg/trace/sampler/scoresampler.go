@@ -5,7 +5,11 @@
 
 package sampler
 
-import "github.com/DataDog/datadog-agent/pkg/trace/pb"
+import (
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
 
 const (
 	// errorSamplingRateThresholdTo1 defines the maximum allowed sampling rate below 1.
@@ -53,6 +57,11 @@ func (s *ScoreEngine) Stop() {
 	s.Sampler.Stop()
 }
 
+// Tick runs the work of the main loop due at the given time, in place of Run
+func (s *ScoreEngine) Tick(now time.Time) {
+	s.Sampler.Tick(now)
+}
+
 func applySampleRate(root *pb.Span, rate float64) bool {
 	initialRate := GetGlobalRate(root)
 	newRate := initialRate * rate
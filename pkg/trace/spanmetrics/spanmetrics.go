@@ -0,0 +1,254 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package spanmetrics computes user defined metrics from spans. Metrics are
+// computed before sampling, so that they account for all the traffic received
+// by the agent, and are sent through DogStatsD.
+package spanmetrics
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+)
+
+const (
+	// flushInterval is the interval at which counts and telemetry are sent.
+	flushInterval = 10 * time.Second
+
+	// defaultMaxContextsPerRule is the default maximum number of distinct
+	// tag sets reported by a rule in a flush interval.
+	defaultMaxContextsPerRule = 1000
+
+	// overflowValue is the value given to the service and group by tags of
+	// spans which exceed the maximum number of contexts of a rule.
+	overflowValue = "_other"
+
+	// valueDuration is the value of a rule reporting the span duration.
+	valueDuration = "duration"
+)
+
+// Aggregator computes the metrics of a set of rules.
+type Aggregator struct {
+	rules []*rule
+
+	exit chan struct{}
+	done chan struct{}
+}
+
+// New returns a new Aggregator for the rules found in conf, which may be nil.
+func New(conf *config.SpanMetricsConfig) *Aggregator {
+	a := &Aggregator{
+		exit: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	if conf == nil {
+		return a
+	}
+	max := conf.MaxContextsPerRule
+	if max <= 0 {
+		max = defaultMaxContextsPerRule
+	}
+	for _, r := range conf.Rules {
+		a.rules = append(a.rules, newRule(r, max))
+	}
+	return a
+}
+
+// Active reports whether the aggregator has any rule.
+func (a *Aggregator) Active() bool {
+	return len(a.rules) > 0
+}
+
+// Start starts flushing metrics periodically.
+func (a *Aggregator) Start() {
+	go func() {
+		defer close(a.done)
+		if !a.Active() {
+			<-a.exit
+			return
+		}
+		tick := time.NewTicker(flushInterval)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				a.flush()
+			case <-a.exit:
+				a.flush()
+				return
+			}
+		}
+	}()
+}
+
+// Stop flushes the pending metrics and stops the aggregator.
+func (a *Aggregator) Stop() {
+	close(a.exit)
+	<-a.done
+}
+
+// Add computes the metrics of the spans of the trace t, belonging to env.
+// It is safe for concurrent use.
+func (a *Aggregator) Add(env string, t pb.Trace) {
+	for _, r := range a.rules {
+		for _, s := range t {
+			if r.match(s) {
+				r.add(env, s)
+			}
+		}
+	}
+}
+
+func (a *Aggregator) flush() {
+	for _, r := range a.rules {
+		r.flush()
+	}
+}
+
+// rule computes the metric of a single rule.
+type rule struct {
+	conf         *config.SpanMetricRule
+	distribution bool
+	rate         float64
+	maxContexts  int
+	telemetry    []string // tags of the rule's telemetry
+
+	mu       sync.Mutex // guards below
+	contexts map[string]*metricContext
+	matched  int64 // spans matched since the last flush
+	overflow int64 // spans aggregated under overflowValue since the last flush
+	missing  int64 // spans without a value since the last flush
+}
+
+// metricContext is a distinct set of tags reported by a rule.
+type metricContext struct {
+	tags  []string
+	count int64
+}
+
+func newRule(conf *config.SpanMetricRule, maxContexts int) *rule {
+	r := &rule{
+		conf:         conf,
+		distribution: conf.Type == "distribution",
+		rate:         conf.SampleRate,
+		maxContexts:  maxContexts,
+		telemetry:    []string{"rule:" + conf.Name},
+		contexts:     make(map[string]*metricContext),
+	}
+	if r.rate == 0 {
+		r.rate = 1
+	}
+	return r
+}
+
+// match reports whether the span s matches the rule.
+func (r *rule) match(s *pb.Span) bool {
+	if r.conf.ServiceRe != nil && !r.conf.ServiceRe.MatchString(s.Service) {
+		return false
+	}
+	if r.conf.ResourceRe != nil && !r.conf.ResourceRe.MatchString(s.Resource) {
+		return false
+	}
+	return true
+}
+
+// value returns the value reported by a distribution for the span s.
+func (r *rule) value(s *pb.Span) (float64, bool) {
+	if r.conf.Value == "" || r.conf.Value == valueDuration {
+		return float64(s.Duration) / float64(time.Second), true
+	}
+	v, ok := s.Metrics[r.conf.Value]
+	return v, ok
+}
+
+// tags returns the tags of the metric for span s. When overflow is true, the
+// service and the values of the group by tags are replaced with overflowValue,
+// so that spans exceeding the maximum number of contexts add at most one
+// context per env.
+func (r *rule) tags(env string, s *pb.Span, overflow bool) []string {
+	tags := make([]string, 0, 2+len(r.conf.GroupBy))
+	if env != "" {
+		tags = append(tags, "env:"+env)
+	}
+	service := s.Service
+	if overflow {
+		service = overflowValue
+	}
+	tags = append(tags, "service:"+service)
+	for _, k := range r.conf.GroupBy {
+		v, ok := s.Meta[k]
+		if !ok {
+			continue
+		}
+		if overflow {
+			v = overflowValue
+		}
+		tags = append(tags, k+":"+v)
+	}
+	return tags
+}
+
+// add reports the span s, belonging to env.
+func (r *rule) add(env string, s *pb.Span) {
+	var value float64
+	if r.distribution {
+		v, ok := r.value(s)
+		if !ok {
+			r.mu.Lock()
+			r.missing++
+			r.mu.Unlock()
+			return
+		}
+		value = v
+	}
+	tags := r.tags(env, s, false)
+	key := strings.Join(tags, ",")
+
+	r.mu.Lock()
+	r.matched++
+	c, ok := r.contexts[key]
+	if !ok && len(r.contexts) >= r.maxContexts {
+		r.overflow++
+		tags = r.tags(env, s, true)
+		key = strings.Join(tags, ",")
+		c, ok = r.contexts[key]
+	}
+	if !ok {
+		c = &metricContext{tags: tags}
+		r.contexts[key] = c
+	}
+	c.count++
+	tags = c.tags
+	r.mu.Unlock()
+
+	if r.distribution {
+		metrics.Distribution(r.conf.Name, value, tags, r.rate)
+	}
+}
+
+// flush sends the counts and the telemetry of the rule and resets its contexts.
+func (r *rule) flush() {
+	r.mu.Lock()
+	contexts := r.contexts
+	matched, overflow, missing := r.matched, r.overflow, r.missing
+	r.contexts = make(map[string]*metricContext, len(contexts))
+	r.matched, r.overflow, r.missing = 0, 0, 0
+	r.mu.Unlock()
+
+	if !r.distribution {
+		for _, c := range contexts {
+			metrics.Count(r.conf.Name, c.count, c.tags, 1)
+		}
+	}
+	metrics.Count("datadog.trace_agent.span_metrics.matched", matched, r.telemetry, 1)
+	metrics.Count("datadog.trace_agent.span_metrics.overflow", overflow, r.telemetry, 1)
+	metrics.Count("datadog.trace_agent.span_metrics.missing_value", missing, r.telemetry, 1)
+	metrics.Gauge("datadog.trace_agent.span_metrics.contexts", float64(len(contexts)), r.telemetry, 1)
+}
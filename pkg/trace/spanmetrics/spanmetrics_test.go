@@ -0,0 +1,177 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package spanmetrics
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/config"
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/trace/test/testutil"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInactive(t *testing.T) {
+	a := New(nil)
+	assert.False(t, a.Active())
+	a.Start()
+	a.Add("prod", pb.Trace{{Service: "web"}}) // no-op
+	a.Stop()
+}
+
+func TestCount(t *testing.T) {
+	defer func(old metrics.StatsClient) { metrics.Client = old }(metrics.Client)
+	stats := &testutil.TestStatsClient{}
+	metrics.Client = stats
+	a := New(&config.SpanMetricsConfig{
+		Rules: []*config.SpanMetricRule{{
+			Name:       "checkout.requests",
+			Type:       "count",
+			ServiceRe:  regexp.MustCompile("^web$"),
+			ResourceRe: regexp.MustCompile("^POST /checkout"),
+			GroupBy:    []string{"http.status_code"},
+		}},
+	})
+	assert.True(t, a.Active())
+	a.Add("prod", pb.Trace{
+		{Service: "web", Resource: "POST /checkout", Meta: map[string]string{"http.status_code": "200"}},
+		{Service: "web", Resource: "POST /checkout", Meta: map[string]string{"http.status_code": "200"}},
+		{Service: "web", Resource: "POST /checkout", Meta: map[string]string{"http.status_code": "500"}},
+		{Service: "web", Resource: "GET /checkout"},
+		{Service: "db", Resource: "POST /checkout"},
+	})
+	a.flush()
+
+	counts := stats.GetCountSummaries()
+	if assert.Contains(t, counts, "checkout.requests") {
+		got := make(map[string]float64)
+		for _, c := range counts["checkout.requests"].Calls {
+			got[c.Tags[len(c.Tags)-1]] = c.Value
+			assert.Equal(t, []string{"env:prod", "service:web"}, c.Tags[:2])
+		}
+		assert.Equal(t, map[string]float64{
+			"http.status_code:200": 2,
+			"http.status_code:500": 1,
+		}, got)
+	}
+	assert.EqualValues(t, 3, counts["datadog.trace_agent.span_metrics.matched"].Sum)
+	assert.Equal(t, []string{"rule:checkout.requests"}, counts["datadog.trace_agent.span_metrics.matched"].Calls[0].Tags)
+	assert.Empty(t, stats.DistributionCalls)
+
+	// contexts are reset after each flush
+	stats.Reset()
+	a.flush()
+	assert.NotContains(t, stats.GetCountSummaries(), "checkout.requests")
+}
+
+func TestDistribution(t *testing.T) {
+	defer func(old metrics.StatsClient) { metrics.Client = old }(metrics.Client)
+	stats := &testutil.TestStatsClient{}
+	metrics.Client = stats
+	a := New(&config.SpanMetricsConfig{
+		Rules: []*config.SpanMetricRule{
+			{Name: "web.duration", Type: "distribution", SampleRate: 0.5},
+			{Name: "web.bytes", Type: "distribution", Value: "response.size"},
+		},
+	})
+	a.Add("", pb.Trace{
+		{Service: "web", Duration: int64(1500 * time.Millisecond), Metrics: map[string]float64{"response.size": 42}},
+		{Service: "web", Duration: int64(time.Second)},
+	})
+	a.flush()
+
+	var durations, sizes []metricsCall
+	for _, c := range stats.DistributionCalls {
+		assert.Equal(t, []string{"service:web"}, c.Tags)
+		switch c.Name {
+		case "web.duration":
+			durations = append(durations, metricsCall{c.Value, c.Rate})
+		case "web.bytes":
+			sizes = append(sizes, metricsCall{c.Value, c.Rate})
+		}
+	}
+	assert.Equal(t, []metricsCall{{1.5, 0.5}, {1, 0.5}}, durations)
+	assert.Equal(t, []metricsCall{{42, 1}}, sizes)
+
+	counts := stats.GetCountSummaries()
+	assert.NotContains(t, counts, "web.duration")
+	assert.EqualValues(t, 1, missing(counts, "web.bytes"))
+}
+
+type metricsCall struct {
+	value, rate float64
+}
+
+func missing(counts map[string]*testutil.CountSummary, rule string) float64 {
+	for _, c := range counts["datadog.trace_agent.span_metrics.missing_value"].Calls {
+		if c.Tags[0] == "rule:"+rule {
+			return c.Value
+		}
+	}
+	return 0
+}
+
+func TestMaxContexts(t *testing.T) {
+	defer func(old metrics.StatsClient) { metrics.Client = old }(metrics.Client)
+	stats := &testutil.TestStatsClient{}
+	metrics.Client = stats
+	a := New(&config.SpanMetricsConfig{
+		MaxContextsPerRule: 2,
+		Rules:              []*config.SpanMetricRule{{Name: "hits", GroupBy: []string{"customer"}}},
+	})
+	for _, customer := range []string{"a", "b", "c", "d", "a"} {
+		a.Add("prod", pb.Trace{{Service: "web", Meta: map[string]string{"customer": customer}}})
+	}
+	a.flush()
+
+	counts := stats.GetCountSummaries()
+	got := make(map[string]float64)
+	for _, c := range counts["hits"].Calls {
+		got[c.Tags[2]] = c.Value
+	}
+	assert.Equal(t, map[string]float64{
+		"customer:a":      2,
+		"customer:b":      1,
+		"customer:_other": 2,
+	}, got)
+	assert.EqualValues(t, 2, counts["datadog.trace_agent.span_metrics.overflow"].Sum)
+	assert.EqualValues(t, 3, stats.GetGaugeSummaries()["datadog.trace_agent.span_metrics.contexts"].Last)
+}
+
+func TestMaxContextsServices(t *testing.T) {
+	defer func(old metrics.StatsClient) { metrics.Client = old }(metrics.Client)
+	stats := &testutil.TestStatsClient{}
+	metrics.Client = stats
+	a := New(&config.SpanMetricsConfig{
+		MaxContextsPerRule: 10,
+		Rules:              []*config.SpanMetricRule{{Name: "hits", GroupBy: []string{"customer"}}},
+	})
+	for i := 0; i < 100; i++ {
+		a.Add("prod", pb.Trace{{
+			Service: fmt.Sprintf("service-%d", i),
+			Meta:    map[string]string{"customer": "a"},
+		}})
+	}
+	a.flush()
+
+	counts := stats.GetCountSummaries()
+	assert.Len(t, counts["hits"].Calls, 11)
+	var other float64
+	for _, c := range counts["hits"].Calls {
+		if c.Tags[1] == "service:_other" {
+			assert.Equal(t, []string{"env:prod", "service:_other", "customer:_other"}, c.Tags)
+			other += c.Value
+		}
+	}
+	assert.EqualValues(t, 90, other)
+	assert.EqualValues(t, 90, counts["datadog.trace_agent.span_metrics.overflow"].Sum)
+	assert.EqualValues(t, 11, stats.GetGaugeSummaries()["datadog.trace_agent.span_metrics.contexts"].Last)
+}
@@ -26,6 +26,8 @@ const defaultBufferLen = 2
 type Concentrator struct {
 	// list of attributes to use for extra aggregation
 	aggregators []string
+	// optional dimensions to aggregate on
+	dims Dimensions
 	// bucket duration in nanoseconds
 	bsize int64
 	// Timestamp of the oldest time bucket for which we allow data.
@@ -48,9 +50,10 @@ type Concentrator struct {
 }
 
 // NewConcentrator initializes a new concentrator ready to be started
-func NewConcentrator(aggregators []string, bsize int64, out chan []Bucket) *Concentrator {
+func NewConcentrator(aggregators []string, dims Dimensions, bsize int64, out chan []Bucket) *Concentrator {
 	c := Concentrator{
 		aggregators: aggregators,
+		dims:        dims,
 		bsize:       bsize,
 		buckets:     make(map[int64]*RawBucket),
 		// At start, only allow stats for the current time bucket. Ensure we don't
@@ -151,7 +154,7 @@ func (c *Concentrator) addNow(i *Input) {
 
 		b, ok := c.buckets[btime]
 		if !ok {
-			b = NewRawBucket(btime, c.bsize)
+			b = newRawBucketWithDimensions(btime, c.bsize, c.dims)
 			c.buckets[btime] = b
 		}
 
@@ -165,6 +168,19 @@ func (c *Concentrator) Flush() []Bucket {
 	return c.flushNow(time.Now().UnixNano())
 }
 
+// FlushAll deletes and returns all statistic buckets, including the ones which
+// may still receive data. It should only be used when no more data is expected.
+func (c *Concentrator) FlushAll() []Bucket {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	sb := make([]Bucket, 0, len(c.buckets))
+	for ts, srb := range c.buckets {
+		sb = append(sb, srb.Export())
+		delete(c.buckets, ts)
+	}
+	return sb
+}
+
 func (c *Concentrator) flushNow(now int64) []Bucket {
 	var sb []Bucket
 
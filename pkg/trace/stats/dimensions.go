@@ -0,0 +1,72 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package stats
+
+import "strconv"
+
+const (
+	// tagStatusCode is the span meta key holding the HTTP status code.
+	tagStatusCode = "http.status_code"
+	// tagStatusClass is the grain dimension holding the HTTP status code class (e.g. "2xx").
+	tagStatusClass = "http.status_class"
+	// tagPeerService is the grain dimension holding the downstream peer of a span.
+	tagPeerService = "peer.service"
+	// tagPeerSource is the grain dimension holding the meta key the downstream peer
+	// was read from, so that hostnames and database instances are not mistaken for
+	// service names.
+	tagPeerSource = "peer.source"
+
+	// peerOverflow is the value reported in place of any peer exceeding the
+	// per-service cap within a bucket.
+	peerOverflow = "_other"
+
+	// defaultMaxPeersPerService is the default number of distinct peers tracked
+	// for a given service within a bucket.
+	defaultMaxPeersPerService = 100
+)
+
+// peerTags lists, by order of precedence, the span meta keys used to identify
+// the downstream peer of a span.
+var peerTags = []string{"peer.service", "out.host", "db.instance"}
+
+// Dimensions specifies optional grain dimensions which the concentrator
+// aggregates on in addition to env, service, name, resource and aggregators.
+type Dimensions struct {
+	// StatusClass enables aggregation by HTTP status code class ("2xx", "4xx", ...).
+	// When enabled, it replaces any "http.status_code" aggregator.
+	StatusClass bool
+
+	// PeerService enables aggregation by downstream peer, as found in the
+	// "peer.service", "out.host" or "db.instance" meta tags. The meta tag the
+	// peer was found in is reported in the "peer.source" dimension.
+	PeerService bool
+
+	// MaxPeersPerService is the maximum number of distinct peers tracked per
+	// service within a bucket. Further peers are collapsed into "_other".
+	// Defaults to 100 when zero.
+	MaxPeersPerService int
+}
+
+// statusClass returns the class of the given HTTP status code, e.g. "5xx" for "503".
+// It returns false if the code is not a valid HTTP status code.
+func statusClass(code string) (string, bool) {
+	n, err := strconv.Atoi(code)
+	if err != nil || n < 100 || n > 599 {
+		return "", false
+	}
+	return string(rune('0'+n/100)) + "xx", true
+}
+
+// peer returns the downstream peer of the given span and the meta key it was
+// read from, if any.
+func peer(meta map[string]string) (value, source string, ok bool) {
+	for _, k := range peerTags {
+		if v := meta[k]; v != "" {
+			return v, k, true
+		}
+	}
+	return "", "", false
+}
@@ -73,6 +73,11 @@ type RawBucket struct {
 	data         map[statsKey]groupedStats
 	sublayerData map[statsSubKey]sublayerStats
 
+	// dims holds the optional grain dimensions and peers tracks, per service,
+	// the distinct peers seen in this bucket to bound their cardinality.
+	dims  Dimensions
+	peers map[string]map[string]struct{}
+
 	// internal buffer for aggregate strings - not threadsafe
 	keyBuf bytes.Buffer
 }
@@ -88,6 +93,20 @@ func NewRawBucket(ts, d int64) *RawBucket {
 	}
 }
 
+// newRawBucketWithDimensions opens a new calculation bucket which additionally
+// aggregates on the given dimensions.
+func newRawBucketWithDimensions(ts, d int64, dims Dimensions) *RawBucket {
+	sb := NewRawBucket(ts, d)
+	sb.dims = dims
+	if dims.MaxPeersPerService <= 0 {
+		sb.dims.MaxPeersPerService = defaultMaxPeersPerService
+	}
+	if dims.PeerService {
+		sb.peers = make(map[string]map[string]struct{})
+	}
+	return sb
+}
+
 // Export transforms a RawBucket into a Bucket, typically used
 // before communicating data to the API, as RawBucket is the internal
 // type while Bucket is the public, shared one.
@@ -197,12 +216,27 @@ func (sb *RawBucket) HandleSpan(s *WeightedSpan, env string, aggregators []strin
 	m := make(map[string]string)
 
 	for _, agg := range aggregators {
+		if agg == tagStatusCode && sb.dims.StatusClass {
+			// collapsed into its class below
+			continue
+		}
 		if agg != "env" && agg != "resource" && agg != "service" {
 			if v, ok := s.Meta[agg]; ok {
 				m[agg] = v
 			}
 		}
 	}
+	if sb.dims.StatusClass {
+		if class, ok := statusClass(s.Meta[tagStatusCode]); ok {
+			m[tagStatusClass] = class
+		}
+	}
+	if sb.dims.PeerService {
+		if p, source, ok := peer(s.Meta); ok {
+			m[tagPeerService] = sb.cappedPeer(s.Service, p)
+			m[tagPeerSource] = source
+		}
+	}
 
 	grain, tags := assembleGrain(&sb.keyBuf, env, s.Resource, s.Service, m)
 	sb.add(s, grain, tags)
@@ -212,6 +246,24 @@ func (sb *RawBucket) HandleSpan(s *WeightedSpan, env string, aggregators []strin
 	}
 }
 
+// cappedPeer returns the given peer, or peerOverflow if the service already
+// reached its maximum number of distinct peers in this bucket.
+func (sb *RawBucket) cappedPeer(service, peer string) string {
+	seen, ok := sb.peers[service]
+	if !ok {
+		seen = make(map[string]struct{})
+		sb.peers[service] = seen
+	}
+	if _, ok := seen[peer]; ok {
+		return peer
+	}
+	if len(seen) >= sb.dims.MaxPeersPerService {
+		return peerOverflow
+	}
+	seen[peer] = struct{}{}
+	return peer
+}
+
 func (sb *RawBucket) add(s *WeightedSpan, aggr string, tags TagSet) {
 	var gs groupedStats
 	var ok bool
@@ -6,6 +6,7 @@
 package stats
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
@@ -278,3 +279,73 @@ var benchTrace = pb.Trace{
 		Type:     "lamar",
 	},
 }
+
+func TestStatusClass(t *testing.T) {
+	for in, out := range map[string]string{
+		"200": "2xx",
+		"302": "3xx",
+		"404": "4xx",
+		"503": "5xx",
+		"100": "1xx",
+	} {
+		class, ok := statusClass(in)
+		assert.True(t, ok, in)
+		assert.Equal(t, out, class)
+	}
+	for _, in := range []string{"", "abc", "99", "600", "2xx"} {
+		_, ok := statusClass(in)
+		assert.False(t, ok, in)
+	}
+}
+
+func TestHandleSpanDimensions(t *testing.T) {
+	newSpan := func(service string, meta map[string]string) *WeightedSpan {
+		return &WeightedSpan{
+			Span:     &pb.Span{Service: service, Name: "http.request", Resource: "GET /", Meta: meta},
+			Weight:   1,
+			TopLevel: true,
+		}
+	}
+	grains := func(sb Bucket) []string {
+		var keys []string
+		for k := range sb.Counts {
+			if strings.HasPrefix(k, "http.request|hits|") {
+				keys = append(keys, strings.TrimPrefix(k, "http.request|hits|"))
+			}
+		}
+		return keys
+	}
+
+	t.Run("status-class", func(t *testing.T) {
+		srb := newRawBucketWithDimensions(0, 1e9, Dimensions{StatusClass: true})
+		for _, code := range []string{"200", "201", "500", "bad"} {
+			srb.HandleSpan(newSpan("web", map[string]string{"http.status_code": code}), "dev", []string{"http.status_code"}, nil)
+		}
+		assert.ElementsMatch(t, []string{
+			"env:dev,resource:GET /,service:web,http.status_class:2xx",
+			"env:dev,resource:GET /,service:web,http.status_class:5xx",
+			"env:dev,resource:GET /,service:web",
+		}, grains(srb.Export()))
+	})
+
+	t.Run("peer", func(t *testing.T) {
+		srb := newRawBucketWithDimensions(0, 1e9, Dimensions{PeerService: true, MaxPeersPerService: 2})
+		for _, meta := range []map[string]string{
+			{"peer.service": "users-db", "out.host": "10.0.0.1"},
+			{"out.host": "cache"},
+			{"db.instance": "orders"},
+			{"out.host": "cache"},
+			{},
+		} {
+			srb.HandleSpan(newSpan("web", meta), "dev", nil, nil)
+		}
+		srb.HandleSpan(newSpan("other", map[string]string{"db.instance": "orders"}), "dev", nil, nil)
+		assert.ElementsMatch(t, []string{
+			"env:dev,resource:GET /,service:web,peer.service:users-db,peer.source:peer.service",
+			"env:dev,resource:GET /,service:web,peer.service:cache,peer.source:out.host",
+			"env:dev,resource:GET /,service:web,peer.service:_other,peer.source:db.instance",
+			"env:dev,resource:GET /,service:web",
+			"env:dev,resource:GET /,service:other,peer.service:orders,peer.source:db.instance",
+		}, grains(srb.Export()))
+	})
+}
@@ -0,0 +1,74 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tap
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Options specifies the events requested from a tap.
+type Options struct {
+	// Service only streams traces with this root service, if non-empty.
+	Service string
+	// Rate is the maximum number of traces per second. Zero uses DefaultRate.
+	Rate float64
+}
+
+// Stream connects to the tap endpoint at addr, authenticating with token, and
+// calls fn for each received event. It returns when ctx is done, when the
+// connection is closed or when fn returns an error.
+func Stream(ctx context.Context, addr, token string, opts Options, fn func(*Event) error) error {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	if opts.Service != "" {
+		q.Set("service", opts.Service)
+	}
+	if opts.Rate > 0 {
+		q.Set("rate", strconv.FormatFloat(opts.Rate, 'f', -1, 64))
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, msg)
+	}
+
+	sc := bufio.NewScanner(resp.Body)
+	sc.Buffer(nil, 10*1024*1024) // traces may be large
+	for sc.Scan() {
+		var e Event
+		if err := json.Unmarshal(sc.Bytes(), &e); err != nil {
+			return fmt.Errorf("invalid event: %v", err)
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return sc.Err()
+}
@@ -0,0 +1,205 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+// Package tap implements a live stream of the traces processed by the trace-agent,
+// for debugging purposes.
+package tap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/metrics"
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+	"github.com/DataDog/datadog-agent/pkg/util/log"
+)
+
+const (
+	// DefaultRate is the default maximum number of traces per second sent to a subscriber.
+	DefaultRate = 5
+	// MaxRate is the maximum number of traces per second a subscriber may request.
+	MaxRate = 50
+
+	// subscriberBuffer is the number of encoded events buffered for each subscriber.
+	// Events are dropped when the buffer is full.
+	subscriberBuffer = 100
+)
+
+// Event describes a trace, as seen after it was processed by the agent.
+type Event struct {
+	// Time is the time at which the trace was processed.
+	Time time.Time `json:"time"`
+	// Env is the environment of the trace.
+	Env string `json:"env"`
+	// Service is the service of the root span.
+	Service string `json:"service"`
+	// Resource is the obfuscated resource of the root span.
+	Resource string `json:"resource"`
+	// TraceID is the ID of the trace.
+	TraceID uint64 `json:"trace_id"`
+	// Sampled reports whether the trace was kept.
+	Sampled bool `json:"sampled"`
+	// Rate is the sampling rate applied to the trace.
+	Rate float64 `json:"rate"`
+	// Sampler is the name of the sampler which made the decision.
+	Sampler string `json:"sampler"`
+	// Spans holds the normalized and obfuscated spans of the trace.
+	Spans pb.Trace `json:"spans"`
+}
+
+// Tap dispatches events to its subscribers. The zero value is not usable, use New.
+type Tap struct {
+	mu   sync.RWMutex
+	subs map[*subscriber]struct{}
+	n    int32 // atomic; number of subscribers
+}
+
+// subscriber is a single client of the tap.
+type subscriber struct {
+	service string // only send traces of this service, if non-empty
+	limiter *rate.Limiter
+	out     chan []byte
+	dropped int64 // atomic
+}
+
+// New returns a new Tap.
+func New() *Tap {
+	return &Tap{subs: make(map[*subscriber]struct{})}
+}
+
+// Active reports whether the tap has any subscriber. Callers should use it to
+// avoid creating events needlessly.
+func (t *Tap) Active() bool {
+	return atomic.LoadInt32(&t.n) > 0
+}
+
+// Publish sends the event to all matching subscribers. It encodes the event
+// synchronously, so the caller may modify it after Publish returns.
+func (t *Tap) Publish(e *Event) {
+	if !t.Active() {
+		return
+	}
+	var data []byte
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for s := range t.subs {
+		if s.service != "" && s.service != e.Service {
+			continue
+		}
+		if !s.limiter.Allow() {
+			continue
+		}
+		if data == nil {
+			var err error
+			if data, err = json.Marshal(e); err != nil {
+				log.Debugf("Error encoding tap event: %v", err)
+				return
+			}
+			data = append(data, '\n')
+		}
+		select {
+		case s.out <- data:
+		default:
+			atomic.AddInt64(&s.dropped, 1)
+		}
+	}
+}
+
+func (t *Tap) subscribe(s *subscriber) {
+	t.mu.Lock()
+	t.subs[s] = struct{}{}
+	atomic.StoreInt32(&t.n, int32(len(t.subs)))
+	t.mu.Unlock()
+}
+
+func (t *Tap) unsubscribe(s *subscriber) {
+	t.mu.Lock()
+	delete(t.subs, s)
+	atomic.StoreInt32(&t.n, int32(len(t.subs)))
+	t.mu.Unlock()
+}
+
+// ServeHTTP streams events as JSON lines until the client goes away. The
+// "service" query parameter filters traces by root service and the "rate"
+// parameter sets the maximum number of traces per second (at most MaxRate).
+//
+// The connection is hijacked so that the stream outlives the server's write
+// timeout; ServeHTTP does not authenticate the request.
+func (t *Tap) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	limit := float64(DefaultRate)
+	if v := req.URL.Query().Get("rate"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 {
+			http.Error(w, "rate must be a positive number", http.StatusBadRequest)
+			return
+		}
+		limit = f
+	}
+	if limit > MaxRate {
+		limit = MaxRate
+	}
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+	conn, buf, err := hj.Hijack()
+	if err != nil {
+		log.Debugf("Error hijacking tap connection: %v", err)
+		return
+	}
+	defer conn.Close()
+	// clear the deadlines set by the server
+	conn.SetDeadline(time.Time{})
+
+	s := &subscriber{
+		service: req.URL.Query().Get("service"),
+		limiter: rate.NewLimiter(rate.Limit(limit), 1),
+		out:     make(chan []byte, subscriberBuffer),
+	}
+	t.subscribe(s)
+	defer t.unsubscribe(s)
+	log.Infof("Tap subscriber connected from %s (service=%q, rate=%.2f)", req.RemoteAddr, s.service, limit)
+	defer func() {
+		log.Infof("Tap subscriber %s disconnected (%d events dropped)", req.RemoteAddr, atomic.LoadInt64(&s.dropped))
+	}()
+	metrics.Count("datadog.trace_agent.tap.subscribers", 1, nil, 1)
+
+	fmt.Fprint(buf, "HTTP/1.1 200 OK\r\nContent-Type: application/x-ndjson\r\nConnection: close\r\n\r\n")
+	if err := buf.Flush(); err != nil {
+		return
+	}
+	// the client is not expected to send anything else; a read returning
+	// means that it went away.
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		buf.ReadByte()
+	}()
+	for {
+		select {
+		case data := <-s.out:
+			if _, err := buf.Write(data); err != nil {
+				return
+			}
+			if err := buf.Flush(); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
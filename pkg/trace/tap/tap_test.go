@@ -0,0 +1,125 @@
+// Unless explicitly stated otherwise all files in this repository are licensed
+// under the Apache License Version 2.0.
+// This product includes software developed at Datadog (https://www.datadoghq.com/).
+// Copyright 2016-2020 Datadog, Inc.
+
+package tap
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DataDog/datadog-agent/pkg/trace/pb"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/time/rate"
+)
+
+func TestPublish(t *testing.T) {
+	t.Run("inactive", func(t *testing.T) {
+		tp := New()
+		assert.False(t, tp.Active())
+		tp.Publish(&Event{Service: "a"}) // does not block nor panic
+	})
+
+	t.Run("filter", func(t *testing.T) {
+		tp := New()
+		all := &subscriber{limiter: rate.NewLimiter(rate.Inf, 1), out: make(chan []byte, 10)}
+		onlyA := &subscriber{service: "a", limiter: rate.NewLimiter(rate.Inf, 1), out: make(chan []byte, 10)}
+		tp.subscribe(all)
+		tp.subscribe(onlyA)
+		assert.True(t, tp.Active())
+
+		tp.Publish(&Event{Service: "a"})
+		tp.Publish(&Event{Service: "b"})
+		assert.Len(t, all.out, 2)
+		assert.Len(t, onlyA.out, 1)
+
+		tp.unsubscribe(all)
+		tp.unsubscribe(onlyA)
+		assert.False(t, tp.Active())
+	})
+
+	t.Run("rate", func(t *testing.T) {
+		tp := New()
+		s := &subscriber{limiter: rate.NewLimiter(rate.Every(time.Hour), 1), out: make(chan []byte, 10)}
+		tp.subscribe(s)
+		for i := 0; i < 5; i++ {
+			tp.Publish(&Event{Service: "a"})
+		}
+		assert.Len(t, s.out, 1)
+	})
+
+	t.Run("full", func(t *testing.T) {
+		tp := New()
+		s := &subscriber{limiter: rate.NewLimiter(rate.Inf, 1), out: make(chan []byte, 1)}
+		tp.subscribe(s)
+		tp.Publish(&Event{Service: "a"})
+		tp.Publish(&Event{Service: "a"})
+		assert.Len(t, s.out, 1)
+		assert.EqualValues(t, 1, s.dropped)
+	})
+}
+
+func TestStream(t *testing.T) {
+	tp := New()
+	srv := httptest.NewServer(tp)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	go func() {
+		// publish until the subscriber connects
+		for !tp.Active() {
+			time.Sleep(time.Millisecond)
+		}
+		tp.Publish(&Event{Service: "other"})
+		tp.Publish(&Event{
+			Service:  "web",
+			Resource: "GET /users/?",
+			TraceID:  42,
+			Sampled:  true,
+			Rate:     0.5,
+			Sampler:  "priority",
+			Spans:    pb.Trace{{Service: "web", TraceID: 42, SpanID: 1}},
+		})
+	}()
+
+	errDone := errors.New("done")
+	var got *Event
+	err := Stream(ctx, srv.URL, "token", Options{Service: "web", Rate: 1000}, func(e *Event) error {
+		got = e
+		return errDone
+	})
+	assert.Equal(t, errDone, err)
+	if assert.NotNil(t, got) {
+		assert.Equal(t, "web", got.Service)
+		assert.Equal(t, "GET /users/?", got.Resource)
+		assert.EqualValues(t, 42, got.TraceID)
+		assert.True(t, got.Sampled)
+		assert.Equal(t, 0.5, got.Rate)
+		assert.Equal(t, "priority", got.Sampler)
+		assert.Len(t, got.Spans, 1)
+	}
+}
+
+func TestServeHTTPBadRequest(t *testing.T) {
+	tp := New()
+	for _, tt := range []struct {
+		method, url string
+		code        int
+	}{
+		{http.MethodPost, "/", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/?rate=abc", http.StatusBadRequest},
+		{http.MethodGet, "/?rate=-1", http.StatusBadRequest},
+	} {
+		rec := httptest.NewRecorder()
+		tp.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.url, nil))
+		assert.Equal(t, tt.code, rec.Code, tt.url)
+	}
+}
@@ -6,6 +6,8 @@
 package testutil
 
 import (
+	"time"
+
 	"github.com/DataDog/datadog-agent/pkg/trace/pb"
 	"github.com/DataDog/datadog-agent/pkg/trace/sampler"
 )
@@ -36,6 +38,11 @@ func (e *MockEngine) Stop() {
 	return
 }
 
+// Tick mocks Engine.Tick()
+func (e *MockEngine) Tick(_ time.Time) {
+	return
+}
+
 // GetState mocks Engine.GetState()
 func (e *MockEngine) GetState() interface{} {
 	return nil